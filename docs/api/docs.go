@@ -26,40 +26,90 @@ const docTemplate = `{
                 "tags": [
                     "Metrics"
                 ],
-                "summary": "Get HTML page with full list of stored metrics",
+                "summary": "Get HTML page with list of stored metrics",
                 "operationId": "metrics_list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Select only metrics which names start with the prefix.",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Select only metrics which names match glob (e.g. ` + "`" + `Heap*` + "`" + `).",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Select only metrics which names match regular expression.",
+                        "name": "regex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Select only metrics of specified type (e.g. ` + "`" + `counter` + "`" + `, ` + "`" + `gauge` + "`" + `).",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximal count of metrics on single page (100 by default).",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Position in the list returned by previous request.",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK"
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "501": {
+                        "description": "Metric type is not supported",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/ping": {
+        "/alerts": {
             "get": {
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Healthcheck"
+                    "Alerts"
                 ],
-                "summary": "Verify connection to the database",
-                "operationId": "health_info",
+                "summary": "List pending and firing alerts",
+                "operationId": "alerts_list",
                 "responses": {
                     "200": {
-                        "description": "OK"
-                    },
-                    "500": {
-                        "description": "Connection is broken",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/metrics.AlertsResponse"
                         }
                     },
-                    "501": {
-                        "description": "Server is not configured to use database",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "string"
                         }
@@ -67,33 +117,38 @@ const docTemplate = `{
                 }
             }
         },
-        "/update": {
+        "/api/v1/write": {
             "post": {
+                "description": "Each series is stored as gauge holding the latest sample.\nName of the gauge is built from the series name followed by names and values of other labels\nordered by label names and hash of the labels, e.g. ` + "`" + `up{job=\"api\"}` + "`" + ` becomes ` + "`" + `UpJobApibe913344` + "`" + `.\nSeries which can't be converted are skipped.",
                 "consumes": [
-                    "application/json"
+                    "application/x-protobuf"
                 ],
                 "tags": [
                     "Metrics"
                 ],
-                "summary": "Push metric data as JSON",
-                "operationId": "metrics_json_update",
+                "summary": "Push samples using Prometheus remote write protocol",
+                "operationId": "metrics_remote_write",
                 "parameters": [
                     {
-                        "description": "Request parameters.",
+                        "type": "string",
+                        "description": "Must be ` + "`" + `snappy` + "`" + `.",
+                        "name": "Content-Encoding",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Snappy compressed protobuf WriteRequest.",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/metrics.MetricReq"
+                            "type": "string"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/metrics.MetricReq"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -101,14 +156,26 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Access to metric denied",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests or series quota exceeded",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "501": {
-                        "description": "Metric type is not supported",
+                    "503": {
+                        "description": "Server is in maintenance mode",
                         "schema": {
                             "type": "string"
                         }
@@ -116,48 +183,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/update/{type}/{name}/{value}": {
+        "/api/v2/write": {
             "post": {
-                "produces": [
+                "description": "Compatible with InfluxDB v2 write API, e.g. Telegraf ` + "`" + `outputs.influxdb_v2` + "`" + ` plugin.\nInteger, unsigned, float and boolean fields are recorded as gauges,\nstring fields are ignored. Name of the metric is built from the measurement and the field key\nfollowed by keys and values of tags ordered by tag keys and hash of the tags,\ne.g. ` + "`" + `cpu,host=web1 usage_idle=90` + "`" + ` becomes gauge ` + "`" + `CpuUsageIdleHostWeb1` + "`" + ` followed by 8 hex digits.\nTimestamps are ignored.\nQuery parameters ` + "`" + `org` + "`" + `, ` + "`" + `bucket` + "`" + ` and ` + "`" + `precision` + "`" + ` are accepted but ignored.\nThe whole request is rejected if any line is malformed.",
+                "consumes": [
                     "text/plain"
                 ],
                 "tags": [
                     "Metrics"
                 ],
-                "summary": "Push metric data.",
-                "operationId": "metrics_update",
+                "summary": "Push metrics using InfluxDB line protocol",
+                "operationId": "metrics_influx_write",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Metrics type (e.g. ` + "`" + `counter` + "`" + `, ` + "`" + `gauge` + "`" + `).",
-                        "name": "type",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Metrics name.",
-                        "name": "name",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Metrics value, must be convertable to ` + "`" + `int64` + "`" + ` or ` + "`" + `float64` + "`" + `.",
-                        "name": "value",
-                        "in": "path",
-                        "required": true
+                        "description": "Metrics in InfluxDB line protocol.",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Access to metric denied",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Decompressed request is larger than 32 MiB",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests or series quota exceeded",
                         "schema": {
                             "type": "string"
                         }
@@ -168,8 +239,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "501": {
-                        "description": "Metric type is not supported",
+                    "503": {
+                        "description": "Server is in maintenance mode",
                         "schema": {
                             "type": "string"
                         }
@@ -177,38 +248,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/updates": {
-            "post": {
-                "consumes": [
+        "/cardinality": {
+            "get": {
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Metrics"
                 ],
-                "summary": "Push list of metrics data as JSON",
-                "operationId": "metrics_json_update_list",
+                "summary": "Get count of stored series and clients created most of them",
+                "operationId": "cardinality_report",
                 "parameters": [
                     {
-                        "description": "List of metrics to update.",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/metrics.MetricReq"
-                            }
-                        }
+                        "type": "integer",
+                        "description": "Count of top series creators, 10 by default.",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/metrics.MetricReq"
-                            }
+                            "$ref": "#/definitions/metrics.CardinalityResponse"
                         }
                     },
                     "400": {
@@ -222,6 +284,49 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/live": {
+            "get": {
+                "description": "Each message is JSON encoded metrics.MetricReq.\nUpdates could be dropped, if the client doesn't keep up with the stream.",
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Stream updates of metrics over WebSocket",
+                "operationId": "metrics_live",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Names of metrics to watch for.",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Types of metrics to watch for (e.g. ` + "`" + `counter` + "`" + `, ` + "`" + `gauge` + "`" + `).",
+                        "name": "kind",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "501": {
                         "description": "Metric type is not supported",
@@ -232,8 +337,32 @@ const docTemplate = `{
                 }
             }
         },
-        "/value": {
-            "post": {
+        "/maintenance": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Maintenance"
+                ],
+                "summary": "Get current maintenance mode of the server",
+                "operationId": "maintenance_info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/httpbackend.maintenanceState"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
                 "consumes": [
                     "application/json"
                 ],
@@ -241,18 +370,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Metrics"
+                    "Maintenance"
                 ],
-                "summary": "Get metrics value as JSON",
-                "operationId": "metrics_json_info",
+                "summary": "Enable or disable maintenance (read-only) mode",
+                "operationId": "maintenance_update",
                 "parameters": [
                     {
-                        "description": "Request parameters: ` + "`" + `id` + "`" + ` and ` + "`" + `type` + "`" + ` are required.",
+                        "description": "Requested mode.",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/metrics.MetricReq"
+                            "$ref": "#/definitions/httpbackend.maintenanceState"
                         }
                     }
                 ],
@@ -260,7 +389,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/metrics.MetricReq"
+                            "$ref": "#/definitions/httpbackend.maintenanceState"
                         }
                     },
                     "400": {
@@ -269,20 +398,34 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Metric not found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/ping": {
+            "get": {
+                "tags": [
+                    "Healthcheck"
+                ],
+                "summary": "Verify connection to the database",
+                "operationId": "health_info",
+                "responses": {
+                    "200": {
+                        "description": "OK"
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Connection is broken",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "501": {
-                        "description": "Metric type is not supported",
+                        "description": "Server is not configured to use database",
                         "schema": {
                             "type": "string"
                         }
@@ -290,29 +433,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/value/{type}/{name}": {
+        "/query": {
             "get": {
+                "description": "Supported functions are ` + "`" + `sum` + "`" + `, ` + "`" + `avg` + "`" + `, ` + "`" + `min` + "`" + `, ` + "`" + `max` + "`" + `, ` + "`" + `count` + "`" + ` and ` + "`" + `rate` + "`" + `,\ne.g. ` + "`" + `sum(PollCount*)` + "`" + `, ` + "`" + `max(HeapInuse[10m])` + "`" + ` or ` + "`" + `rate(PollCount:counter[5m]) * 60` + "`" + `.",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
                     "Metrics"
                 ],
-                "summary": "Get metrics value as string",
-                "operationId": "metrics_info",
+                "summary": "Evaluate expression over stored metrics",
+                "operationId": "metrics_query",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Metrics type (e.g. ` + "`" + `counter` + "`" + `, ` + "`" + `gauge` + "`" + `).",
-                        "name": "type",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Metrics name.",
-                        "name": "name",
-                        "in": "path",
+                        "description": "Expression to evaluate.",
+                        "name": "expr",
+                        "in": "query",
                         "required": true
                     }
                 ],
@@ -320,7 +457,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/metrics.QueryResponse"
                         }
                     },
                     "400": {
@@ -330,7 +467,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Metric not found",
+                        "description": "No metrics match the expression",
                         "schema": {
                             "type": "string"
                         }
@@ -340,19 +477,902 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/silences": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "List silences including expired ones",
+                "operationId": "silences_list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.SilencesResponse"
+                        }
                     },
-                    "501": {
-                        "description": "Metric type is not supported",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "metrics.MetricReq": {
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Mute notifications about alerts matching all matchers during the time window",
+                "operationId": "silences_create",
+                "parameters": [
+                    {
+                        "description": "Silence to create.",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/metrics.Silence"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.Silence"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/silences/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Get silence",
+                "operationId": "silences_info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the silence.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.Silence"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Replace silence, e.g. to prolong or expire it",
+                "operationId": "silences_update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the silence.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New state of the silence.",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/metrics.Silence"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.Silence"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Remove silence",
+                "operationId": "silences_delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the silence.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/update": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Push metric data as JSON",
+                "operationId": "metrics_json_update",
+                "parameters": [
+                    {
+                        "description": "Request parameters.",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/metrics.MetricReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.MetricReq"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Access to metric denied",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests or series quota exceeded",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "Metric type is not supported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in maintenance mode",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/update/{type}/{name}/{value}": {
+            "post": {
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Push metric data.",
+                "operationId": "metrics_update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Metrics type (e.g. ` + "`" + `counter` + "`" + `, ` + "`" + `gauge` + "`" + `).",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Metrics name.",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Metrics value, must be convertable to ` + "`" + `int64` + "`" + ` or ` + "`" + `float64` + "`" + `.",
+                        "name": "value",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Access to metric denied",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests or series quota exceeded",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "Metric type is not supported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in maintenance mode",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/updates": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Push list of metrics data as JSON",
+                "operationId": "metrics_json_update_list",
+                "parameters": [
+                    {
+                        "description": "List of metrics to update.",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/metrics.MetricReq"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/metrics.MetricReq"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Access to metric denied",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests or series quota exceeded",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "Metric type is not supported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in maintenance mode",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/metrics": {
+            "post": {
+                "description": "Gauges and cumulative sums are stored as gauges holding the latest value, delta sums as counters.\nHistograms are stored as gauge holding the mean value and ` + "`" + `\u003cname\u003eCount` + "`" + ` holding count of events.\nName of the metric is built from the metric name followed by keys and values of data point\nand resource attributes ordered by keys and by a hash of the attributes.\nResource attributes clashing with data point attributes are prefixed with ` + "`" + `resource.` + "`" + `.\nData points which can't be converted are skipped and reported as partial success.",
+                "consumes": [
+                    "application/x-protobuf"
+                ],
+                "produces": [
+                    "application/x-protobuf"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Push metrics using OpenTelemetry protocol (OTLP/HTTP)",
+                "operationId": "metrics_otlp_write",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Either empty or ` + "`" + `gzip` + "`" + `.",
+                        "name": "Content-Encoding",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Protobuf ExportMetricsServiceRequest.",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Protobuf ExportMetricsServiceResponse.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Access to metric denied",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests or series quota exceeded",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in maintenance mode",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/value": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Get metrics value as JSON",
+                "operationId": "metrics_json_info",
+                "parameters": [
+                    {
+                        "description": "Request parameters: ` + "`" + `id` + "`" + ` and ` + "`" + `type` + "`" + ` are required.",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/metrics.MetricReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.MetricReq"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Access to metric denied",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Metric not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "Metric type is not supported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/value/{type}/{name}": {
+            "get": {
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Get metrics value as string",
+                "operationId": "metrics_info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Metrics type (e.g. ` + "`" + `counter` + "`" + `, ` + "`" + `gauge` + "`" + `).",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Metrics name.",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Access to metric denied",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Metric not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "Metric type is not supported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Delete stored metric",
+                "operationId": "metrics_delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Metrics type (e.g. ` + "`" + `counter` + "`" + `, ` + "`" + `gauge` + "`" + `).",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Metrics name.",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Access to metric denied",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Metric not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "Metric type is not supported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is in maintenance mode",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/values": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Get single page of stored metrics as JSON",
+                "operationId": "metrics_json_list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Select only metrics which names start with the prefix.",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Select only metrics which names match glob (e.g. ` + "`" + `Heap*` + "`" + `).",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Select only metrics which names match regular expression.",
+                        "name": "regex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Select only metrics of specified type (e.g. ` + "`" + `counter` + "`" + `, ` + "`" + `gauge` + "`" + `).",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximal count of metrics on single page (100 by default).",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Position in the list returned by previous request.",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "Metric type is not supported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "httpbackend.maintenanceState": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "description": "Whether the service is in read-only mode.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "metrics.Alert": {
+            "type": "object",
+            "properties": {
+                "active_since": {
+                    "description": "Moment since which the condition holds.",
+                    "type": "string"
+                },
+                "condition": {
+                    "description": "Condition of the rule, e.g. \"CPUutilization* \u003e 90\".",
+                    "type": "string"
+                },
+                "fired_at": {
+                    "description": "Moment when the alert started firing, omitted for pending alerts.",
+                    "type": "string"
+                },
+                "inhibited_by": {
+                    "description": "Names of firing alerts inhibiting the alert.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "labels": {
+                    "description": "Additional labels defined by the rule, e.g. {\"host\": \"web1\"}.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "metric": {
+                    "description": "Name of the metric, omitted if condition of the rule evaluates to single number.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name of the alert rule.",
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "description": "Moment when the alert was resolved, omitted for active alerts.",
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                },
+                "silenced_by": {
+                    "description": "IDs of silences muting the alert.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "state": {
+                    "description": "One of pending, firing or resolved.",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Last value of the condition's expression.",
+                    "type": "number"
+                }
+            }
+        },
+        "metrics.AlertsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.Alert"
+                    }
+                }
+            }
+        },
+        "metrics.CardinalityResponse": {
+            "type": "object",
+            "properties": {
+                "client_limit": {
+                    "type": "integer"
+                },
+                "global_limit": {
+                    "type": "integer"
+                },
+                "series": {
+                    "description": "Count of series of all tenants.",
+                    "type": "integer"
+                },
+                "tenant_limit": {
+                    "description": "Limits of count of series, zero value means no limit.",
+                    "type": "integer"
+                },
+                "top_creators": {
+                    "description": "Clients created most series, ordered by count of series.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.SeriesCreator"
+                    }
+                }
+            }
+        },
+        "metrics.ListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.MetricReq"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Cursor pointing to the next page, empty if there are no more metrics.",
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.MetricReq": {
             "type": "object",
             "properties": {
                 "delta": {
@@ -376,6 +1396,133 @@ const docTemplate = `{
                     "type": "number"
                 }
             }
+        },
+        "metrics.QueryPoint": {
+            "type": "object",
+            "properties": {
+                "timestamp": {
+                    "description": "Unix time in milliseconds.",
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "metrics.QueryResponse": {
+            "type": "object",
+            "properties": {
+                "matrix": {
+                    "description": "Set if type of the result is matrix.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.QuerySeries"
+                    }
+                },
+                "scalar": {
+                    "description": "Set if type of the result is scalar.",
+                    "type": "number"
+                },
+                "type": {
+                    "description": "Type of the result: scalar, vector or matrix.",
+                    "type": "string"
+                },
+                "vector": {
+                    "description": "Set if type of the result is vector.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.QuerySample"
+                    }
+                }
+            }
+        },
+        "metrics.QuerySample": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "metrics.QuerySeries": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.QueryPoint"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.SeriesCreator": {
+            "type": "object",
+            "properties": {
+                "client": {
+                    "description": "Name of the client, e.g. \"identity:agent\" for authorized clients or \"ip:10.0.0.1\" for others.",
+                    "type": "string"
+                },
+                "series": {
+                    "description": "Count of series created by the client since start of the server.",
+                    "type": "integer"
+                }
+            }
+        },
+        "metrics.Silence": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Generated by the server, ignored in requests.",
+                    "type": "string"
+                },
+                "matchers": {
+                    "description": "Matchers in the \u003clabel\u003e=\u003cglob\u003e form, e.g. \"alertname=HighCpu*\".\nSupported labels are alertname, metric, severity and labels defined by alert rules.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "starts_at": {
+                    "description": "Start of the silence, if omitted in request the silence starts immediately.",
+                    "type": "string"
+                },
+                "state": {
+                    "description": "One of pending, active or expired, ignored in requests.",
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.SilencesResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.Silence"
+                    }
+                }
+            }
         }
     },
     "tags": [
@@ -383,9 +1530,17 @@ const docTemplate = `{
             "description": "\"Metrics API\"",
             "name": "Metrics"
         },
+        {
+            "description": "\"API to inspect active alerts\"",
+            "name": "Alerts"
+        },
         {
             "description": "\"API to inspect service health state\"",
             "name": "Healthcheck"
+        },
+        {
+            "description": "\"API to switch service to read-only mode\"",
+            "name": "Maintenance"
         }
     ]
 }`
@@ -8,6 +8,8 @@ require (
 	github.com/go-chi/chi v1.5.4
 	github.com/go-chi/chi/v5 v5.0.7
 	github.com/golang-migrate/migrate/v4 v4.15.2
+	github.com/golang/snappy v0.0.4
+	github.com/gorilla/websocket v1.5.0
 	github.com/jackc/pgx/v5 v5.2.0
 	github.com/kisielk/errcheck v1.6.3
 	github.com/maratori/testpackage v1.1.1
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/alkurbatov/metrics-collector/internal/config"
+	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/alkurbatov/metrics-collector/internal/exporter"
 	"github.com/alkurbatov/metrics-collector/internal/monitoring"
 	"github.com/alkurbatov/metrics-collector/internal/recovery"
@@ -75,6 +76,8 @@ func sendMetrics(
 		return err
 	}
 
+	// Send succeeds only if the server has recorded the metrics,
+	// otherwise the polled count is kept and sent again on the next report.
 	stats.PollCount -= snapshot.PollCount
 
 	return nil
@@ -158,10 +161,19 @@ func (app *Agent) poll(ctx context.Context) {
 
 // Report sends metrics to the server.
 func (app *Agent) report(ctx context.Context) {
-	ticker := time.NewTicker(app.config.ReportInterval)
+	interval := app.config.ReportInterval
+
+	// gRPC exporter pushes metrics over stream one by one as they are added,
+	// thus metrics are reported as soon as they are polled.
+	if app.config.Transport == entity.TransportGRPC {
+		interval = app.config.PollInterval
+	}
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	exp := exporter.New(app.config.Transport, app.config.Address, app.config.Secret, app.publicKey)
+	exp := exporter.New(app.config.Transport, app.config.Address, app.config.Secret, app.config.Token, app.publicKey)
+	delay := NewBackoff(interval)
 
 	for {
 		select {
@@ -170,6 +182,11 @@ func (app *Agent) report(ctx context.Context) {
 				defer recovery.TryRecover()
 				defer exp.Reset()
 
+				if delay.Active() {
+					log.Info().Msg("Server is unavailable, postpone sending of application metrics")
+					return
+				}
+
 				log.Info().Msg("Sending application metrics")
 
 				// NB (alkurbatov): We have to complete sending data even if shutdown was requested.
@@ -184,11 +201,17 @@ func (app *Agent) report(ctx context.Context) {
 					return
 				}
 
+				if errors.Is(err, entity.ErrServiceUnavailable) {
+					log.Warn().Err(err).Dur("retry_in", delay.Next(exp.RetryAfter())).Msg("")
+					return
+				}
+
 				if err != nil {
 					log.Error().Err(err).Msg("")
 					return
 				}
 
+				delay.Reset()
 				log.Info().Msg("Metrics successfully sent")
 			}()
 
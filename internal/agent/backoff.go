@@ -0,0 +1,60 @@
+package agent
+
+import "time"
+
+// Maximal delay before next attempt to export metrics to unavailable server.
+const _maxReportBackoff = 5 * time.Minute
+
+// Backoff tracks delay of metrics export while the server is unavailable,
+// e.g. due to maintenance. The delay doubles after each failed attempt,
+// but is never shorter than the delay requested by the server.
+type Backoff struct {
+	// Initial delay.
+	base time.Duration
+
+	// Current delay, zero value means that the server is available.
+	delay time.Duration
+
+	// Moment before which export should not be attempted.
+	resumeAt time.Time
+}
+
+func NewBackoff(base time.Duration) *Backoff {
+	return &Backoff{base: base}
+}
+
+// Active reports whether export should be skipped now.
+func (b *Backoff) Active() bool {
+	return time.Now().Before(b.resumeAt)
+}
+
+// Next increases the delay and returns it.
+// The retryAfter is the delay requested by the server (e.g. in the Retry-After header), zero if not requested.
+// The delay never exceeds _maxReportBackoff.
+func (b *Backoff) Next(retryAfter time.Duration) time.Duration {
+	switch {
+	case b.delay == 0:
+		b.delay = b.base
+
+	case b.delay < _maxReportBackoff:
+		b.delay *= 2
+	}
+
+	if b.delay < retryAfter {
+		b.delay = retryAfter
+	}
+
+	if b.delay > _maxReportBackoff {
+		b.delay = _maxReportBackoff
+	}
+
+	b.resumeAt = time.Now().Add(b.delay)
+
+	return b.delay
+}
+
+// Reset drops the delay after successful export.
+func (b *Backoff) Reset() {
+	b.delay = 0
+	b.resumeAt = time.Time{}
+}
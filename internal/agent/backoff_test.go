@@ -0,0 +1,79 @@
+package agent_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/agent"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffSchedule(t *testing.T) {
+	tt := []struct {
+		name       string
+		retryAfter []time.Duration
+		expected   []time.Duration
+	}{
+		{
+			name:       "Should double delay after each failure",
+			retryAfter: []time.Duration{0, 0, 0, 0},
+			expected:   []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second},
+		},
+		{
+			name:       "Should not exceed maximal delay",
+			retryAfter: []time.Duration{0, 0, 0, 0, 0, 0, 0},
+			expected: []time.Duration{
+				10 * time.Second,
+				20 * time.Second,
+				40 * time.Second,
+				80 * time.Second,
+				160 * time.Second,
+				5 * time.Minute,
+				5 * time.Minute,
+			},
+		},
+		{
+			name:       "Should wait as long as requested by server",
+			retryAfter: []time.Duration{60 * time.Second, 0, 60 * time.Second},
+			expected:   []time.Duration{60 * time.Second, 120 * time.Second, 240 * time.Second},
+		},
+		{
+			name:       "Should ignore request of server shorter than current delay",
+			retryAfter: []time.Duration{0, 0, 5 * time.Second},
+			expected:   []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second},
+		},
+		{
+			name:       "Should limit delay requested by server",
+			retryAfter: []time.Duration{time.Hour},
+			expected:   []time.Duration{5 * time.Minute},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			b := agent.NewBackoff(10 * time.Second)
+
+			delays := make([]time.Duration, 0, len(tc.retryAfter))
+			for _, retryAfter := range tc.retryAfter {
+				delays = append(delays, b.Next(retryAfter))
+			}
+
+			require.Equal(t, tc.expected, delays)
+		})
+	}
+}
+
+func TestBackoffReset(t *testing.T) {
+	require := require.New(t)
+
+	b := agent.NewBackoff(10 * time.Second)
+	require.False(b.Active())
+
+	b.Next(60 * time.Second)
+	b.Next(0)
+	require.True(b.Active())
+
+	b.Reset()
+	require.False(b.Active())
+	require.Equal(10*time.Second, b.Next(0))
+}
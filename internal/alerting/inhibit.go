@@ -0,0 +1,108 @@
+package alerting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+)
+
+// An InhibitRule mutes alerts matching target matchers while there is a firing alert
+// matching source matchers, e.g. "alertname=HostDown => alertname=HighCpu equal host".
+type InhibitRule struct {
+	Source []Matcher
+	Target []Matcher
+
+	// Labels which must have the same values in source and target alerts.
+	Equal []string
+}
+
+func invalidInhibitRuleError(def, reason string) error {
+	return fmt.Errorf("%w: %q, %s", entity.ErrInvalidInhibitRule, def, reason)
+}
+
+// ParseInhibitRules parses list of inhibition rules in the following form:
+//
+//	<source matcher>... => <target matcher>... [equal <label>...]
+//
+// Matchers are separated by spaces and have the <label>=<glob> form.
+func ParseInhibitRules(defs []string) ([]InhibitRule, error) {
+	rv := make([]InhibitRule, 0, len(defs))
+
+	for _, def := range defs {
+		rule, err := parseInhibitRule(def)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, rule)
+	}
+
+	return rv, nil
+}
+
+func parseInhibitRule(def string) (InhibitRule, error) {
+	source, target, found := strings.Cut(def, "=>")
+	if !found {
+		return InhibitRule{}, invalidInhibitRuleError(def, "expected <source> => <target>")
+	}
+
+	var (
+		rule InhibitRule
+		err  error
+	)
+
+	rule.Source, err = ParseMatchers(strings.Fields(source))
+	if err != nil {
+		return InhibitRule{}, invalidInhibitRuleError(def, err.Error())
+	}
+
+	fields := strings.Fields(target)
+
+	for i, field := range fields {
+		if field != "equal" {
+			continue
+		}
+
+		rule.Equal = fields[i+1:]
+		fields = fields[:i]
+
+		if len(rule.Equal) == 0 {
+			return InhibitRule{}, invalidInhibitRuleError(def, "labels after equal not set")
+		}
+
+		break
+	}
+
+	rule.Target, err = ParseMatchers(fields)
+	if err != nil {
+		return InhibitRule{}, invalidInhibitRuleError(def, err.Error())
+	}
+
+	if len(rule.Source) == 0 || len(rule.Target) == 0 {
+		return InhibitRule{}, invalidInhibitRuleError(def, "source and target matchers must be set")
+	}
+
+	for _, label := range rule.Equal {
+		if !_labelName.MatchString(label) {
+			return InhibitRule{}, invalidInhibitRuleError(def, fmt.Sprintf("invalid label %q", label))
+		}
+	}
+
+	return rule, nil
+}
+
+// inhibits reports whether the source alert mutes the target alert.
+func (r InhibitRule) inhibits(source, target Alert) bool {
+	if source.State != StateFiring || !matchAll(r.Source, source) || !matchAll(r.Target, target) {
+		return false
+	}
+
+	for _, label := range r.Equal {
+		if source.Label(label) != target.Label(label) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,72 @@
+package alerting_test
+
+import (
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInhibitRules(t *testing.T) {
+	require := require.New(t)
+
+	rules, err := alerting.ParseInhibitRules([]string{
+		"alertname=HostDown severity=critical => alertname=HighCpu* equal host dc",
+		"alertname=Maintenance=>severity=warning",
+	})
+
+	require.NoError(err)
+	require.Len(rules, 2)
+
+	require.Len(rules[0].Source, 2)
+	require.Equal("alertname=HostDown", rules[0].Source[0].String())
+	require.Equal("severity=critical", rules[0].Source[1].String())
+	require.Len(rules[0].Target, 1)
+	require.Equal("alertname=HighCpu*", rules[0].Target[0].String())
+	require.Equal([]string{"host", "dc"}, rules[0].Equal)
+
+	require.Len(rules[1].Source, 1)
+	require.Len(rules[1].Target, 1)
+	require.Empty(rules[1].Equal)
+}
+
+func TestParseInhibitRulesFails(t *testing.T) {
+	tt := []struct {
+		name string
+		rule string
+	}{
+		{
+			name: "Should fail without target",
+			rule: "alertname=HostDown",
+		},
+		{
+			name: "Should fail on empty source",
+			rule: "=> alertname=HighCpu",
+		},
+		{
+			name: "Should fail on empty target",
+			rule: "alertname=HostDown => equal host",
+		},
+		{
+			name: "Should fail on invalid matcher",
+			rule: "alertname=HostDown => HighCpu",
+		},
+		{
+			name: "Should fail without equal labels",
+			rule: "alertname=HostDown => alertname=HighCpu equal",
+		},
+		{
+			name: "Should fail on invalid equal label",
+			rule: "alertname=HostDown => alertname=HighCpu equal Host",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := alerting.ParseInhibitRules([]string{tc.rule})
+
+			require.ErrorIs(t, err, entity.ErrInvalidInhibitRule)
+		})
+	}
+}
@@ -0,0 +1,335 @@
+// Package alerting implements evaluation of alert rules and notifications about alerts.
+package alerting
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/query"
+	"github.com/rs/zerolog/log"
+)
+
+// States of alerts.
+const (
+	// Condition holds, but not long enough.
+	StatePending = "pending"
+
+	// Condition holds longer than required by rule.
+	StateFiring = "firing"
+
+	// Condition of firing alert doesn't hold anymore.
+	StateResolved = "resolved"
+)
+
+// An Alert is state of alert rule for single metric.
+type Alert struct {
+	// Name of the rule.
+	Name string
+
+	// Name of the metric, empty if expression of the rule evaluates to single number.
+	Metric string
+
+	Severity  string
+	Condition string
+	State     string
+
+	// Last value of the expression.
+	Value float64
+
+	// Moment since which the condition holds.
+	ActiveSince time.Time
+
+	// Moment when the alert started firing, zero if it is pending.
+	FiredAt time.Time
+
+	// Moment when the alert was resolved, zero if it is still active.
+	ResolvedAt time.Time
+
+	// Additional labels defined by the rule.
+	Labels map[string]string
+
+	// IDs of silences muting the alert.
+	SilencedBy []string
+
+	// Names of firing alerts inhibiting the alert.
+	InhibitedBy []string
+
+	// Whether notification about firing alert was sent.
+	notified bool
+}
+
+// Label returns value of the label, empty string if the alert doesn't have such label.
+func (a Alert) Label(name string) string {
+	switch name {
+	case LabelAlertName:
+		return a.Name
+
+	case LabelMetric:
+		return a.Metric
+
+	case LabelSeverity:
+		return a.Severity
+
+	default:
+		return a.Labels[name]
+	}
+}
+
+// Suppressed reports whether notifications about the alert are muted.
+func (a Alert) Suppressed() bool {
+	return len(a.SilencedBy) != 0 || len(a.InhibitedBy) != 0
+}
+
+// A Notifier delivers notifications about firing and resolved alerts.
+type Notifier interface {
+	Notify(alert Alert)
+}
+
+// Manager periodically evaluates alert rules and tracks state of alerts.
+type Manager struct {
+	engine      *query.Engine
+	rules       []Rule
+	inhibitions []InhibitRule
+	silences    *Silences
+	notifier    Notifier
+
+	mu sync.RWMutex
+
+	// Pending and firing alerts.
+	active map[string]*Alert
+}
+
+// NewManager creates new instance of Manager.
+// Notifications about alerts muted by silences or inhibition rules are not sent.
+func NewManager(
+	engine *query.Engine,
+	rules []Rule,
+	inhibitions []InhibitRule,
+	silences *Silences,
+	notifier Notifier,
+) *Manager {
+	return &Manager{
+		engine:      engine,
+		rules:       rules,
+		inhibitions: inhibitions,
+		silences:    silences,
+		notifier:    notifier,
+		active:      make(map[string]*Alert),
+	}
+}
+
+func alertKey(rule, metric string) string {
+	return rule + "/" + metric
+}
+
+// values returns values of the rule's expression per metric.
+// Empty name corresponds to expression evaluated to single number.
+func (m *Manager) values(ctx context.Context, rule Rule) (map[string]float64, error) {
+	result, err := m.engine.Evaluate(ctx, rule.Expr)
+	if err != nil {
+		// Aggregates of missing metrics are treated as absence of data,
+		// thus related alerts are resolved.
+		if errors.Is(err, entity.ErrMetricNotFound) {
+			return map[string]float64{}, nil
+		}
+
+		return nil, err
+	}
+
+	switch result.Type {
+	case query.ResultScalar:
+		return map[string]float64{"": result.Scalar}, nil
+
+	case query.ResultVector:
+		rv := make(map[string]float64, len(result.Vector))
+		for _, s := range result.Vector {
+			rv[s.Name] = s.Value
+		}
+
+		return rv, nil
+	}
+
+	return nil, entity.InvalidQueryError("condition of alert must evaluate to number or list of metrics")
+}
+
+// Evaluate checks conditions of all rules at the moment, updates state of alerts
+// and sends notifications. If evaluation of a rule fails, state of its alerts is kept.
+func (m *Manager) Evaluate(ctx context.Context, now time.Time) {
+	resolved := make([]Alert, 0)
+
+	for _, rule := range m.rules {
+		values, err := m.values(ctx, rule)
+		if err != nil {
+			log.Warn().Err(err).Str("alert", rule.Name).Msg("Failed to evaluate alert rule")
+			continue
+		}
+
+		resolved = append(resolved, m.update(rule, values, now)...)
+	}
+
+	m.notify(resolved, now)
+}
+
+// update changes state of alerts of the rule according to the values of its expression.
+// Returns resolved alerts which were notified as firing.
+func (m *Manager) update(rule Rule, values map[string]float64, now time.Time) []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	matched := make(map[string]bool, len(values))
+
+	for metric, value := range values {
+		if !rule.matches(value) {
+			continue
+		}
+
+		key := alertKey(rule.Name, metric)
+		matched[key] = true
+
+		alert, ok := m.active[key]
+		if !ok {
+			alert = &Alert{
+				Name:        rule.Name,
+				Metric:      metric,
+				Severity:    rule.Severity,
+				Condition:   rule.Condition(),
+				State:       StatePending,
+				ActiveSince: now,
+				Labels:      rule.Labels,
+			}
+			m.active[key] = alert
+		}
+
+		alert.Value = value
+
+		if alert.State == StatePending && now.Sub(alert.ActiveSince) >= rule.For {
+			alert.State = StateFiring
+			alert.FiredAt = now
+		}
+	}
+
+	var resolved []Alert
+
+	for key, alert := range m.active {
+		if alert.Name != rule.Name || matched[key] {
+			continue
+		}
+
+		delete(m.active, key)
+
+		// Nobody was told about the alert, thus there is nothing to resolve.
+		if !alert.notified {
+			continue
+		}
+
+		alert.State = StateResolved
+		alert.ResolvedAt = now
+
+		if value, ok := values[alert.Metric]; ok {
+			alert.Value = value
+		}
+
+		resolved = append(resolved, *alert)
+	}
+
+	return resolved
+}
+
+// notify marks alerts muted by silences and inhibition rules
+// and sends notifications about resolved and newly fired alerts.
+func (m *Manager) notify(resolved []Alert, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, alert := range m.active {
+		alert.SilencedBy = m.silences.silencedBy(*alert, now)
+		alert.InhibitedBy = m.inhibitedBy(*alert)
+	}
+
+	for _, alert := range resolved {
+		m.notifier.Notify(alert)
+	}
+
+	keys := make([]string, 0, len(m.active))
+	for key := range m.active {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		alert := m.active[key]
+		if alert.State != StateFiring || alert.notified || alert.Suppressed() {
+			continue
+		}
+
+		alert.notified = true
+		m.notifier.Notify(*alert)
+	}
+}
+
+// inhibitedBy returns names of firing alerts muting the alert.
+func (m *Manager) inhibitedBy(alert Alert) []string {
+	var rv []string
+
+	for _, source := range m.active {
+		if source.Name == alert.Name && source.Metric == alert.Metric {
+			continue
+		}
+
+		for _, rule := range m.inhibitions {
+			if rule.inhibits(*source, alert) {
+				rv = append(rv, source.Name)
+				break
+			}
+		}
+	}
+
+	sort.Strings(rv)
+
+	// Several alerts of the same rule could inhibit the alert.
+	uniq := rv[:0]
+
+	for i, name := range rv {
+		if i == 0 || name != rv[i-1] {
+			uniq = append(uniq, name)
+		}
+	}
+
+	return uniq
+}
+
+// Alerts returns list of pending and firing alerts ordered by name and metric.
+func (m *Manager) Alerts() []Alert {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rv := make([]Alert, 0, len(m.active))
+	for _, alert := range m.active {
+		rv = append(rv, *alert)
+	}
+
+	sort.Slice(rv, func(i, j int) bool {
+		if rv[i].Name != rv[j].Name {
+			return rv[i].Name < rv[j].Name
+		}
+
+		return rv[i].Metric < rv[j].Metric
+	})
+
+	return rv
+}
+
+// AlertsOf returns alerts visible to the tenant of the request.
+// Rules are evaluated over metrics of the default tenant only, so other tenants have no alerts.
+func (m *Manager) AlertsOf(ctx context.Context) []Alert {
+	if entity.TenantFromContext(ctx) != entity.DefaultTenant {
+		return make([]Alert, 0)
+	}
+
+	return m.Alerts()
+}
@@ -0,0 +1,274 @@
+package alerting_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/query"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+type notifierStub struct {
+	alerts []alerting.Alert
+}
+
+func (n *notifierStub) Notify(alert alerting.Alert) {
+	n.alerts = append(n.alerts, alert)
+}
+
+func newTestManager(t *testing.T, defs ...string) (*alerting.Manager, services.Recorder, *notifierStub) {
+	t.Helper()
+
+	return newTestManagerWithSilences(t, nil, nil, defs...)
+}
+
+func newTestManagerWithSilences(
+	t *testing.T,
+	inhibitRules []string,
+	silences *alerting.Silences,
+	defs ...string,
+) (*alerting.Manager, services.Recorder, *notifierStub) {
+	t.Helper()
+
+	rules, err := alerting.ParseRules(defs)
+	require.NoError(t, err)
+
+	inhibitions, err := alerting.ParseInhibitRules(inhibitRules)
+	require.NoError(t, err)
+
+	recorder := services.NewMetricsRecorder(storage.NewMemStorage())
+	engine := query.NewEngine(recorder, query.NewHistory(recorder, 0))
+	notifier := new(notifierStub)
+
+	return alerting.NewManager(engine, rules, inhibitions, silences, notifier), recorder, notifier
+}
+
+func setGauge(t *testing.T, recorder services.Recorder, name string, value float64) {
+	t.Helper()
+
+	_, err := recorder.Push(context.Background(), storage.Record{Name: name, Value: metrics.Gauge(value)})
+	require.NoError(t, err)
+}
+
+func TestManagerFiresAfterDuration(t *testing.T) {
+	require := require.New(t)
+
+	manager, recorder, notifier := newTestManager(t, "HighCpu = CPUutilization* > 90 for 1m severity critical")
+	start := time.Unix(1000, 0)
+
+	setGauge(t, recorder, "CPUutilization1", 95)
+	setGauge(t, recorder, "CPUutilization2", 10)
+
+	manager.Evaluate(context.Background(), start)
+
+	alerts := manager.Alerts()
+	require.Len(alerts, 1)
+	require.Equal("CPUutilization1", alerts[0].Metric)
+	require.Equal(alerting.StatePending, alerts[0].State)
+	require.Equal(start, alerts[0].ActiveSince)
+	require.Empty(notifier.alerts)
+
+	setGauge(t, recorder, "CPUutilization1", 97)
+	manager.Evaluate(context.Background(), start.Add(time.Minute))
+
+	alerts = manager.Alerts()
+	require.Len(alerts, 1)
+	require.Equal(alerting.StateFiring, alerts[0].State)
+	require.Equal(float64(97), alerts[0].Value)
+	require.Equal(start.Add(time.Minute), alerts[0].FiredAt)
+
+	require.Len(notifier.alerts, 1)
+	require.Equal(alerting.StateFiring, notifier.alerts[0].State)
+	require.Equal("HighCpu", notifier.alerts[0].Name)
+	require.Equal("critical", notifier.alerts[0].Severity)
+	require.Equal("CPUutilization* > 90", notifier.alerts[0].Condition)
+
+	// Firing alerts are reported only once.
+	manager.Evaluate(context.Background(), start.Add(2*time.Minute))
+	require.Len(notifier.alerts, 1)
+}
+
+func TestManagerResolvesFiringAlert(t *testing.T) {
+	require := require.New(t)
+
+	manager, recorder, notifier := newTestManager(t, "HighMemUsage = MemUsedPct > 90")
+	start := time.Unix(1000, 0)
+
+	setGauge(t, recorder, "MemUsedPct", 95)
+	manager.Evaluate(context.Background(), start)
+
+	require.Len(notifier.alerts, 1)
+	require.Equal(alerting.StateFiring, notifier.alerts[0].State)
+	require.Equal("MemUsedPct", notifier.alerts[0].Metric)
+
+	setGauge(t, recorder, "MemUsedPct", 50)
+	manager.Evaluate(context.Background(), start.Add(time.Minute))
+
+	require.Empty(manager.Alerts())
+	require.Len(notifier.alerts, 2)
+	require.Equal(alerting.StateResolved, notifier.alerts[1].State)
+	require.Equal(float64(50), notifier.alerts[1].Value)
+	require.Equal(start, notifier.alerts[1].FiredAt)
+	require.Equal(start.Add(time.Minute), notifier.alerts[1].ResolvedAt)
+}
+
+func TestManagerDropsPendingAlertSilently(t *testing.T) {
+	require := require.New(t)
+
+	manager, recorder, notifier := newTestManager(t, "HighMemUsage = MemUsedPct > 90 for 5m")
+	start := time.Unix(1000, 0)
+
+	setGauge(t, recorder, "MemUsedPct", 95)
+	manager.Evaluate(context.Background(), start)
+	require.Len(manager.Alerts(), 1)
+
+	setGauge(t, recorder, "MemUsedPct", 50)
+	manager.Evaluate(context.Background(), start.Add(time.Minute))
+
+	require.Empty(manager.Alerts())
+	require.Empty(notifier.alerts)
+}
+
+func TestManagerTreatsMissingMetricsAsResolved(t *testing.T) {
+	require := require.New(t)
+
+	manager, recorder, notifier := newTestManager(t, "HighHeap = max(Heap*) > 100")
+	start := time.Unix(1000, 0)
+
+	manager.Evaluate(context.Background(), start)
+	require.Empty(manager.Alerts())
+
+	setGauge(t, recorder, "HeapInuse", 200)
+	manager.Evaluate(context.Background(), start.Add(time.Minute))
+	require.Len(manager.Alerts(), 1)
+	require.Len(notifier.alerts, 1)
+}
+
+func TestManagerKeepsStateOnEvaluationFailure(t *testing.T) {
+	require := require.New(t)
+
+	manager, recorder, notifier := newTestManager(t, "HighRatio = HeapInuse / HeapAlloc > 2")
+	start := time.Unix(1000, 0)
+
+	setGauge(t, recorder, "HeapInuse", 300)
+	setGauge(t, recorder, "HeapAlloc", 100)
+	manager.Evaluate(context.Background(), start)
+	require.Len(manager.Alerts(), 1)
+
+	// Division by zero.
+	setGauge(t, recorder, "HeapAlloc", 0)
+	manager.Evaluate(context.Background(), start.Add(time.Minute))
+
+	require.Len(manager.Alerts(), 1)
+	require.Len(notifier.alerts, 1)
+}
+
+func TestManagerMutesSilencedAlerts(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	start := time.Unix(1000, 0)
+
+	silences := alerting.NewSilences(storage.NewMemStorage())
+	silence, err := silences.Create(ctx, storage.Silence{
+		Matchers: []string{"alertname=HighCpu", "metric=CPUutilization1"},
+		EndsAt:   start.Add(time.Minute),
+	}, start)
+	require.NoError(err)
+
+	manager, recorder, notifier := newTestManagerWithSilences(t, nil, silences, "HighCpu = CPUutilization* > 90")
+
+	setGauge(t, recorder, "CPUutilization1", 95)
+	setGauge(t, recorder, "CPUutilization2", 95)
+
+	manager.Evaluate(ctx, start)
+
+	alerts := manager.Alerts()
+	require.Len(alerts, 2)
+	require.Equal([]string{silence.ID}, alerts[0].SilencedBy)
+	require.Empty(alerts[1].SilencedBy)
+
+	require.Len(notifier.alerts, 1)
+	require.Equal("CPUutilization2", notifier.alerts[0].Metric)
+
+	// Notification is sent as soon as the silence expires.
+	manager.Evaluate(ctx, start.Add(time.Minute))
+
+	require.Empty(manager.Alerts()[0].SilencedBy)
+	require.Len(notifier.alerts, 2)
+	require.Equal("CPUutilization1", notifier.alerts[1].Metric)
+	require.Equal(alerting.StateFiring, notifier.alerts[1].State)
+}
+
+func TestManagerDoesntResolveMutedAlerts(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	start := time.Unix(1000, 0)
+
+	silences := alerting.NewSilences(storage.NewMemStorage())
+	_, err := silences.Create(ctx, storage.Silence{
+		Matchers: []string{"alertname=HighMemUsage"},
+		EndsAt:   start.Add(time.Hour),
+	}, start)
+	require.NoError(err)
+
+	manager, recorder, notifier := newTestManagerWithSilences(t, nil, silences, "HighMemUsage = MemUsedPct > 90")
+
+	setGauge(t, recorder, "MemUsedPct", 95)
+	manager.Evaluate(ctx, start)
+
+	setGauge(t, recorder, "MemUsedPct", 50)
+	manager.Evaluate(ctx, start.Add(time.Minute))
+
+	require.Empty(manager.Alerts())
+	require.Empty(notifier.alerts)
+}
+
+func TestManagerInhibitsAlerts(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	start := time.Unix(1000, 0)
+
+	manager, recorder, notifier := newTestManagerWithSilences(
+		t,
+		[]string{"alertname=HostDown* => alertname=HighCpu* equal host"},
+		nil,
+		"HostDownWeb1 = UptimeWeb1 == 0 label host=web1",
+		"HighCpuWeb1 = CPUutilizationWeb1 > 90 label host=web1",
+		"HighCpuWeb2 = CPUutilizationWeb2 > 90 label host=web2",
+	)
+
+	setGauge(t, recorder, "UptimeWeb1", 0)
+	setGauge(t, recorder, "CPUutilizationWeb1", 95)
+	setGauge(t, recorder, "CPUutilizationWeb2", 95)
+
+	manager.Evaluate(ctx, start)
+
+	alerts := manager.Alerts()
+	require.Len(alerts, 3)
+	require.Equal("HighCpuWeb1", alerts[0].Name)
+	require.Equal([]string{"HostDownWeb1"}, alerts[0].InhibitedBy)
+	require.Equal("HighCpuWeb2", alerts[1].Name)
+	require.Empty(alerts[1].InhibitedBy)
+	require.Equal("HostDownWeb1", alerts[2].Name)
+	require.Empty(alerts[2].InhibitedBy)
+
+	require.Len(notifier.alerts, 2)
+	require.Equal("HighCpuWeb2", notifier.alerts[0].Name)
+	require.Equal("HostDownWeb1", notifier.alerts[1].Name)
+
+	// The host is back, but CPU usage is still high.
+	setGauge(t, recorder, "UptimeWeb1", 100)
+	manager.Evaluate(ctx, start.Add(time.Minute))
+
+	require.Len(notifier.alerts, 4)
+	require.Equal("HostDownWeb1", notifier.alerts[2].Name)
+	require.Equal(alerting.StateResolved, notifier.alerts[2].State)
+	require.Equal("HighCpuWeb1", notifier.alerts[3].Name)
+	require.Equal(alerting.StateFiring, notifier.alerts[3].State)
+	require.Equal(map[string]string{"host": "web1"}, notifier.alerts[3].Labels)
+}
@@ -0,0 +1,91 @@
+package alerting
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+)
+
+// Labels set for each alert.
+const (
+	// Name of the alert rule.
+	LabelAlertName = "alertname"
+
+	// Name of the metric, empty if expression of the rule evaluates to single number.
+	LabelMetric = "metric"
+
+	LabelSeverity = "severity"
+)
+
+var _labelName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// A Matcher selects alerts by value of single label, e.g. "alertname=HighCpu*".
+// Alerts without the label are matched as if the label had empty value.
+type Matcher struct {
+	Label string
+	Glob  string
+
+	pattern *regexp.Regexp
+}
+
+// ParseMatcher parses matcher in the <label>=<glob> form.
+// Wildcard '*' matches any sequence of characters, '?' matches single character.
+func ParseMatcher(src string) (Matcher, error) {
+	label, glob, found := strings.Cut(src, "=")
+	if !found || !_labelName.MatchString(label) {
+		return Matcher{}, fmt.Errorf("%w: %q", entity.ErrInvalidMatcher, src)
+	}
+
+	// Empty glob selects alerts without the label.
+	expr := "^$"
+
+	if len(glob) != 0 {
+		var err error
+
+		expr, err = storage.NamePattern(glob, "")
+		if err != nil {
+			return Matcher{}, fmt.Errorf("%w: %q", entity.ErrInvalidMatcher, src)
+		}
+	}
+
+	return Matcher{Label: label, Glob: glob, pattern: regexp.MustCompile(expr)}, nil
+}
+
+// ParseMatchers parses list of matchers in the <label>=<glob> form.
+func ParseMatchers(src []string) ([]Matcher, error) {
+	rv := make([]Matcher, 0, len(src))
+
+	for _, s := range src {
+		m, err := ParseMatcher(s)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, m)
+	}
+
+	return rv, nil
+}
+
+func (m Matcher) String() string {
+	return m.Label + "=" + m.Glob
+}
+
+// Matches reports whether the alert has label with value matching the glob.
+func (m Matcher) Matches(alert Alert) bool {
+	return m.pattern.MatchString(alert.Label(m.Label))
+}
+
+// matchAll reports whether the alert is matched by all matchers.
+func matchAll(matchers []Matcher, alert Alert) bool {
+	for _, m := range matchers {
+		if !m.Matches(alert) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,71 @@
+package alerting_test
+
+import (
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcherMatches(t *testing.T) {
+	alert := alerting.Alert{
+		Name:     "HighCpu",
+		Metric:   "CPUutilization1",
+		Severity: "critical",
+		Labels:   map[string]string{"host": "web1"},
+	}
+
+	tt := []struct {
+		name     string
+		matcher  string
+		expected bool
+	}{
+		{
+			name:     "Should match name of alert",
+			matcher:  "alertname=HighCpu",
+			expected: true,
+		},
+		{
+			name:     "Should match metric by glob",
+			matcher:  "metric=CPUutilization?",
+			expected: true,
+		},
+		{
+			name:     "Should match custom label",
+			matcher:  "host=web*",
+			expected: true,
+		},
+		{
+			name:    "Should require match of whole value",
+			matcher: "severity=crit",
+		},
+		{
+			name:    "Should treat missing label as empty",
+			matcher: "dc=*1",
+		},
+		{
+			name:     "Should match missing label with empty glob",
+			matcher:  "dc=",
+			expected: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := alerting.ParseMatcher(tc.matcher)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.matcher, m.String())
+			require.Equal(t, tc.expected, m.Matches(alert))
+		})
+	}
+}
+
+func TestParseMatcherFails(t *testing.T) {
+	for _, src := range []string{"HighCpu", "=HighCpu", "Alert=HighCpu", "alert-name=HighCpu"} {
+		_, err := alerting.ParseMatcher(src)
+
+		require.ErrorIs(t, err, entity.ErrInvalidMatcher, src)
+	}
+}
@@ -0,0 +1,187 @@
+package alerting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/query"
+	"github.com/alkurbatov/metrics-collector/internal/validators"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+)
+
+// Severity of alerts if not specified by rule.
+const DefaultSeverity = "warning"
+
+// Comparison operators ordered so that two-character operators are matched first.
+var _operators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// A Rule defines condition of an alert, e.g. "HighCpu = CPUutilization* > 90 for 5m severity critical".
+// If expression evaluates to list of metrics, the condition is checked for each metric separately.
+type Rule struct {
+	Name      string
+	Expr      query.Expression
+	Operator  string
+	Threshold float64
+
+	// How long the condition must hold before the alert fires.
+	For time.Duration
+
+	Severity string
+
+	// Additional labels of alerts, e.g. host=web1.
+	Labels map[string]string
+}
+
+// Condition returns text of the condition, e.g. "CPUutilization* > 90".
+func (r Rule) Condition() string {
+	return fmt.Sprintf("%s %s %s", r.Expr, r.Operator, strconv.FormatFloat(r.Threshold, 'f', -1, 64))
+}
+
+// matches reports whether the value satisfies the condition.
+func (r Rule) matches(value float64) bool {
+	switch r.Operator {
+	case ">=":
+		return value >= r.Threshold
+
+	case "<=":
+		return value <= r.Threshold
+
+	case "==":
+		return value == r.Threshold
+
+	case "!=":
+		return value != r.Threshold
+
+	case ">":
+		return value > r.Threshold
+
+	case "<":
+		return value < r.Threshold
+	}
+
+	return false
+}
+
+func (r *Rule) addLabel(src string) error {
+	key, value, found := strings.Cut(src, "=")
+	if !found || len(value) == 0 || !_labelName.MatchString(key) {
+		return fmt.Errorf("expected label in <key>=<value> form, got %q", src)
+	}
+
+	if key == LabelAlertName || key == LabelMetric || key == LabelSeverity {
+		return fmt.Errorf("label %s is reserved", key)
+	}
+
+	if r.Labels == nil {
+		r.Labels = make(map[string]string)
+	}
+
+	r.Labels[key] = value
+
+	return nil
+}
+
+func invalidRuleError(def, reason string) error {
+	return fmt.Errorf("%w: %q, %s", entity.ErrInvalidAlertRule, def, reason)
+}
+
+// ParseRules parses list of alert rules in the following form:
+//
+//	<name> = <expression> <operator> <threshold> [for <duration>] [severity <severity>] [label <key>=<value>]...
+//
+// Supported operators are >, >=, <, <=, == and !=.
+func ParseRules(defs []string) ([]Rule, error) {
+	rv := make([]Rule, 0, len(defs))
+	names := make(map[string]bool, len(defs))
+
+	for _, def := range defs {
+		rule, err := parseRule(def)
+		if err != nil {
+			return nil, err
+		}
+
+		if names[rule.Name] {
+			return nil, invalidRuleError(def, fmt.Sprintf("alert %s is already defined", rule.Name))
+		}
+
+		names[rule.Name] = true
+
+		rv = append(rv, rule)
+	}
+
+	return rv, nil
+}
+
+func parseRule(def string) (Rule, error) {
+	name, condition, found := strings.Cut(def, "=")
+	if !found {
+		return Rule{}, invalidRuleError(def, "expected <name> = <condition>")
+	}
+
+	rule := Rule{Name: strings.TrimSpace(name), Severity: DefaultSeverity}
+
+	// Alert names follow the same rules as names of metrics.
+	if err := validators.ValidateMetricName(rule.Name, metrics.KindGauge); err != nil {
+		return Rule{}, invalidRuleError(def, err.Error())
+	}
+
+	pos := -1
+
+	for _, op := range _operators {
+		if i := strings.Index(condition, op); i != -1 && (pos == -1 || i < pos) {
+			pos = i
+			rule.Operator = op
+		}
+	}
+
+	if pos == -1 {
+		return Rule{}, invalidRuleError(def, "comparison operator not found")
+	}
+
+	expr, err := query.ParseExpression(condition[:pos])
+	if err != nil {
+		return Rule{}, invalidRuleError(def, err.Error())
+	}
+
+	rule.Expr = expr
+
+	fields := strings.Fields(condition[pos+len(rule.Operator):])
+	if len(fields) == 0 {
+		return Rule{}, invalidRuleError(def, "threshold not set")
+	}
+
+	rule.Threshold, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Rule{}, invalidRuleError(def, fmt.Sprintf("invalid threshold %q", fields[0]))
+	}
+
+	for i := 1; i < len(fields); i += 2 {
+		if i+1 == len(fields) {
+			return Rule{}, invalidRuleError(def, fmt.Sprintf("value of %q not set", fields[i]))
+		}
+
+		switch fields[i] {
+		case "for":
+			rule.For, err = time.ParseDuration(fields[i+1])
+			if err != nil || rule.For < 0 {
+				return Rule{}, invalidRuleError(def, fmt.Sprintf("invalid duration %q", fields[i+1]))
+			}
+
+		case "severity":
+			rule.Severity = fields[i+1]
+
+		case "label":
+			if err := rule.addLabel(fields[i+1]); err != nil {
+				return Rule{}, invalidRuleError(def, err.Error())
+			}
+
+		default:
+			return Rule{}, invalidRuleError(def, fmt.Sprintf("unexpected %q", fields[i]))
+		}
+	}
+
+	return rule, nil
+}
@@ -0,0 +1,109 @@
+package alerting_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	require := require.New(t)
+
+	rules, err := alerting.ParseRules([]string{
+		"HighMemUsage = (TotalMemory - FreeMemory) / TotalMemory * 100 >= 90 for 5m severity critical",
+		"NoPolls=rate(PollCount[1m])==0",
+		"HeapGrowth = HeapInuse > 1e6 severity info label host=web1 label dc=eu",
+	})
+
+	require.NoError(err)
+	require.Len(rules, 3)
+
+	require.Equal("HighMemUsage", rules[0].Name)
+	require.Equal("(TotalMemory - FreeMemory) / TotalMemory * 100", rules[0].Expr.String())
+	require.Equal(">=", rules[0].Operator)
+	require.Equal(float64(90), rules[0].Threshold)
+	require.Equal(5*time.Minute, rules[0].For)
+	require.Equal("critical", rules[0].Severity)
+	require.Equal("(TotalMemory - FreeMemory) / TotalMemory * 100 >= 90", rules[0].Condition())
+
+	require.Equal("NoPolls", rules[1].Name)
+	require.Equal("==", rules[1].Operator)
+	require.Equal(time.Duration(0), rules[1].For)
+	require.Equal(alerting.DefaultSeverity, rules[1].Severity)
+
+	require.Equal("HeapGrowth", rules[2].Name)
+	require.Equal(float64(1e6), rules[2].Threshold)
+	require.Equal("info", rules[2].Severity)
+	require.Equal(map[string]string{"host": "web1", "dc": "eu"}, rules[2].Labels)
+}
+
+func TestParseRulesFails(t *testing.T) {
+	tt := []struct {
+		name  string
+		rules []string
+	}{
+		{
+			name:  "Should fail without condition",
+			rules: []string{"HighMemUsage"},
+		},
+		{
+			name:  "Should fail on invalid name",
+			rules: []string{"high_mem = MemUsedPct > 90"},
+		},
+		{
+			name:  "Should fail without operator",
+			rules: []string{"HighMemUsage = MemUsedPct 90"},
+		},
+		{
+			name:  "Should fail on invalid expression",
+			rules: []string{"HighMemUsage = MemUsedPct * > 90"},
+		},
+		{
+			name:  "Should fail without threshold",
+			rules: []string{"HighMemUsage = MemUsedPct >"},
+		},
+		{
+			name:  "Should fail on invalid threshold",
+			rules: []string{"HighMemUsage = MemUsedPct > high"},
+		},
+		{
+			name:  "Should fail on invalid duration",
+			rules: []string{"HighMemUsage = MemUsedPct > 90 for ever"},
+		},
+		{
+			name:  "Should fail on negative duration",
+			rules: []string{"HighMemUsage = MemUsedPct > 90 for -5m"},
+		},
+		{
+			name:  "Should fail without value of option",
+			rules: []string{"HighMemUsage = MemUsedPct > 90 severity"},
+		},
+		{
+			name:  "Should fail on unknown option",
+			rules: []string{"HighMemUsage = MemUsedPct > 90 every 5m"},
+		},
+		{
+			name:  "Should fail on invalid label",
+			rules: []string{"HighMemUsage = MemUsedPct > 90 label host"},
+		},
+		{
+			name:  "Should fail on reserved label",
+			rules: []string{"HighMemUsage = MemUsedPct > 90 label severity=critical"},
+		},
+		{
+			name:  "Should fail on duplicated name",
+			rules: []string{"HighMemUsage = MemUsedPct > 90", "HighMemUsage = MemUsedPct > 95"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := alerting.ParseRules(tc.rules)
+
+			require.ErrorIs(t, err, entity.ErrInvalidAlertRule)
+		})
+	}
+}
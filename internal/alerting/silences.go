@@ -0,0 +1,247 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+)
+
+// States of silences.
+const (
+	// The silence starts in future.
+	SilencePending = "pending"
+
+	// The silence mutes matching alerts.
+	SilenceActive = "active"
+
+	// The silence ended.
+	SilenceExpired = "expired"
+)
+
+// How long expired silences are kept before removal.
+const _expiredSilenceRetention = 24 * time.Hour
+
+type silence struct {
+	storage.Silence
+
+	matchers []Matcher
+}
+
+// SilenceState returns state of the silence at the moment.
+func SilenceState(s storage.Silence, now time.Time) string {
+	switch {
+	case now.Before(s.StartsAt):
+		return SilencePending
+
+	case now.Before(s.EndsAt):
+		return SilenceActive
+
+	default:
+		return SilenceExpired
+	}
+}
+
+func invalidSilenceError(reason string) error {
+	return fmt.Errorf("%w: %s", entity.ErrInvalidSilence, reason)
+}
+
+// Silences mutes notifications about alerts during maintenance, deploys, etc.
+// Silences are kept in the storage, thus survive restarts.
+type Silences struct {
+	store storage.SilenceStorage
+
+	mu       sync.RWMutex
+	silences map[string]silence
+}
+
+// NewSilences creates new instance of Silences keeping silences in the storage.
+func NewSilences(store storage.SilenceStorage) *Silences {
+	return &Silences{
+		store:    store,
+		silences: make(map[string]silence),
+	}
+}
+
+func newSilence(src storage.Silence) (silence, error) {
+	if len(src.Matchers) == 0 {
+		return silence{}, invalidSilenceError("matchers not set")
+	}
+
+	matchers, err := ParseMatchers(src.Matchers)
+	if err != nil {
+		return silence{}, invalidSilenceError(err.Error())
+	}
+
+	if !src.EndsAt.After(src.StartsAt) {
+		return silence{}, invalidSilenceError("end of silence must be after its start")
+	}
+
+	return silence{Silence: src, matchers: matchers}, nil
+}
+
+// Load reads stored silences. Invalid silences are skipped.
+func (s *Silences) Load(ctx context.Context) error {
+	stored, err := s.store.GetSilences(ctx)
+	if err != nil {
+		return fmt.Errorf("Silences - Load - s.store.GetSilences: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, src := range stored {
+		v, err := newSilence(src)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("silence", src.ID).Msg("Skip stored silence")
+			continue
+		}
+
+		s.silences[src.ID] = v
+	}
+
+	return nil
+}
+
+// Create validates and stores new silence. If start of the silence is not set,
+// the silence starts immediately.
+func (s *Silences) Create(ctx context.Context, src storage.Silence, now time.Time) (storage.Silence, error) {
+	src.ID = uuid.NewV4().String()
+
+	if src.StartsAt.IsZero() {
+		src.StartsAt = now
+	}
+
+	return s.push(ctx, src)
+}
+
+// Update replaces existing silence.
+func (s *Silences) Update(ctx context.Context, src storage.Silence) (storage.Silence, error) {
+	prev, err := s.Get(src.ID)
+	if err != nil {
+		return storage.Silence{}, err
+	}
+
+	if src.StartsAt.IsZero() {
+		src.StartsAt = prev.StartsAt
+	}
+
+	return s.push(ctx, src)
+}
+
+func (s *Silences) push(ctx context.Context, src storage.Silence) (storage.Silence, error) {
+	v, err := newSilence(src)
+	if err != nil {
+		return storage.Silence{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.store.PushSilence(ctx, src); err != nil {
+		return storage.Silence{}, fmt.Errorf("Silences - push - s.store.PushSilence: %w", err)
+	}
+
+	s.silences[src.ID] = v
+
+	return src, nil
+}
+
+// Delete removes the silence.
+func (s *Silences) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.silences[id]; !ok {
+		return entity.ErrSilenceNotFound
+	}
+
+	if err := s.store.DeleteSilence(ctx, id); err != nil {
+		return fmt.Errorf("Silences - Delete - s.store.DeleteSilence: %w", err)
+	}
+
+	delete(s.silences, id)
+
+	return nil
+}
+
+// Get returns the silence.
+func (s *Silences) Get(id string) (storage.Silence, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.silences[id]
+	if !ok {
+		return storage.Silence{}, entity.ErrSilenceNotFound
+	}
+
+	return v.Silence, nil
+}
+
+// List returns all silences ordered by start time.
+func (s *Silences) List() []storage.Silence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rv := make([]storage.Silence, 0, len(s.silences))
+	for _, v := range s.silences {
+		rv = append(rv, v.Silence)
+	}
+
+	sort.Slice(rv, func(i, j int) bool {
+		if !rv[i].StartsAt.Equal(rv[j].StartsAt) {
+			return rv[i].StartsAt.Before(rv[j].StartsAt)
+		}
+
+		return rv[i].ID < rv[j].ID
+	})
+
+	return rv
+}
+
+// Cleanup removes silences expired long ago.
+func (s *Silences) Cleanup(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, v := range s.silences {
+		if now.Sub(v.EndsAt) < _expiredSilenceRetention {
+			continue
+		}
+
+		if err := s.store.DeleteSilence(ctx, id); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("silence", id).Msg("Failed to remove expired silence")
+			continue
+		}
+
+		delete(s.silences, id)
+	}
+}
+
+// silencedBy returns IDs of active silences muting the alert.
+func (s *Silences) silencedBy(alert Alert, now time.Time) []string {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var rv []string
+
+	for id, v := range s.silences {
+		if SilenceState(v.Silence, now) == SilenceActive && matchAll(v.matchers, alert) {
+			rv = append(rv, id)
+		}
+	}
+
+	sort.Strings(rv)
+
+	return rv
+}
@@ -0,0 +1,171 @@
+package alerting_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSilencesCreate(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+
+	store := storage.NewMemStorage()
+	silences := alerting.NewSilences(store)
+
+	created, err := silences.Create(ctx, storage.Silence{
+		Matchers:  []string{"alertname=HighCpu*"},
+		EndsAt:    now.Add(time.Hour),
+		CreatedBy: "admin",
+		Comment:   "deploy",
+	}, now)
+
+	require.NoError(err)
+	require.NotEmpty(created.ID)
+	require.Equal(now, created.StartsAt)
+
+	stored, err := silences.Get(created.ID)
+	require.NoError(err)
+	require.Equal(created, stored)
+
+	persisted, err := store.GetSilences(ctx)
+	require.NoError(err)
+	require.Equal([]storage.Silence{created}, persisted)
+}
+
+func TestSilencesCreateFails(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tt := []struct {
+		name    string
+		silence storage.Silence
+	}{
+		{
+			name:    "Should fail without matchers",
+			silence: storage.Silence{EndsAt: now.Add(time.Hour)},
+		},
+		{
+			name:    "Should fail on invalid matcher",
+			silence: storage.Silence{Matchers: []string{"HighCpu"}, EndsAt: now.Add(time.Hour)},
+		},
+		{
+			name:    "Should fail if silence ends before start",
+			silence: storage.Silence{Matchers: []string{"alertname=HighCpu"}, StartsAt: now, EndsAt: now},
+		},
+		{
+			name:    "Should fail without end",
+			silence: storage.Silence{Matchers: []string{"alertname=HighCpu"}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			silences := alerting.NewSilences(storage.NewMemStorage())
+			_, err := silences.Create(context.Background(), tc.silence, now)
+
+			require.ErrorIs(t, err, entity.ErrInvalidSilence)
+			require.Empty(t, silences.List())
+		})
+	}
+}
+
+func TestSilencesUpdateAndDelete(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+
+	silences := alerting.NewSilences(storage.NewMemStorage())
+
+	created, err := silences.Create(ctx, storage.Silence{
+		Matchers: []string{"alertname=HighCpu*"},
+		EndsAt:   now.Add(time.Hour),
+	}, now)
+	require.NoError(err)
+
+	updated, err := silences.Update(ctx, storage.Silence{
+		ID:       created.ID,
+		Matchers: []string{"alertname=HighCpu*"},
+		EndsAt:   now.Add(2 * time.Hour),
+	})
+	require.NoError(err)
+	require.Equal(now, updated.StartsAt)
+	require.Equal(now.Add(2*time.Hour), updated.EndsAt)
+
+	_, err = silences.Update(ctx, storage.Silence{ID: "unknown"})
+	require.ErrorIs(err, entity.ErrSilenceNotFound)
+
+	require.NoError(silences.Delete(ctx, created.ID))
+	require.ErrorIs(silences.Delete(ctx, created.ID), entity.ErrSilenceNotFound)
+
+	_, err = silences.Get(created.ID)
+	require.ErrorIs(err, entity.ErrSilenceNotFound)
+}
+
+func TestSilencesLoad(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+
+	store := storage.NewMemStorage()
+	valid := storage.Silence{
+		ID:       "1",
+		Matchers: []string{"alertname=HighCpu*"},
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	}
+
+	require.NoError(store.PushSilence(ctx, valid))
+	require.NoError(store.PushSilence(ctx, storage.Silence{ID: "2", Matchers: []string{"HighCpu"}}))
+
+	silences := alerting.NewSilences(store)
+	require.NoError(silences.Load(ctx))
+
+	require.Equal([]storage.Silence{valid}, silences.List())
+}
+
+func TestSilencesCleanup(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	now := time.Unix(100000, 0)
+
+	store := storage.NewMemStorage()
+	silences := alerting.NewSilences(store)
+
+	old, err := silences.Create(ctx, storage.Silence{
+		Matchers: []string{"alertname=HighCpu"},
+		StartsAt: now.Add(-48 * time.Hour),
+		EndsAt:   now.Add(-25 * time.Hour),
+	}, now)
+	require.NoError(err)
+
+	recent, err := silences.Create(ctx, storage.Silence{
+		Matchers: []string{"alertname=HighCpu"},
+		StartsAt: now.Add(-2 * time.Hour),
+		EndsAt:   now.Add(-time.Hour),
+	}, now)
+	require.NoError(err)
+
+	silences.Cleanup(ctx, now)
+
+	require.Equal([]storage.Silence{recent}, silences.List())
+
+	persisted, err := store.GetSilences(ctx)
+	require.NoError(err)
+	require.Len(persisted, 1)
+	require.NotEqual(old.ID, persisted[0].ID)
+}
+
+func TestSilenceState(t *testing.T) {
+	now := time.Unix(1000, 0)
+	silence := storage.Silence{StartsAt: now, EndsAt: now.Add(time.Hour)}
+
+	require.Equal(t, alerting.SilencePending, alerting.SilenceState(silence, now.Add(-time.Second)))
+	require.Equal(t, alerting.SilenceActive, alerting.SilenceState(silence, now))
+	require.Equal(t, alerting.SilenceExpired, alerting.SilenceState(silence, now.Add(time.Hour)))
+}
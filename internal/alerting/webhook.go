@@ -0,0 +1,221 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Maximal count of notifications waiting for delivery to single webhook,
+	// new notifications are dropped on overflow.
+	_webhookQueueSize = 1024
+
+	// Count of attempts to deliver notification to single webhook.
+	_webhookAttempts = 5
+
+	// Delay before the second attempt, doubles after each failed attempt.
+	_webhookRetryDelay = time.Second
+
+	_webhookTimeout = 5 * time.Second
+)
+
+var _ Notifier = (*Webhook)(nil)
+
+// A notification is alert serialized for delivery to webhooks.
+type notification struct {
+	alert   string
+	payload []byte
+}
+
+// A webhookTarget is queue of notifications waiting for delivery to single URL.
+type webhookTarget struct {
+	url   string
+	queue chan notification
+}
+
+// Webhook delivers notifications about alerts to HTTP endpoints as JSON.
+// Notifications are sent in background, failed deliveries are retried several times.
+// Each URL has its own queue, so unavailable endpoint doesn't delay delivery to others.
+//
+// In cluster mode alerts are evaluated by each node independently,
+// thus every node sends its own notifications and silences are applied per node.
+type Webhook struct {
+	targets []*webhookTarget
+	client  *http.Client
+
+	// Delay before the second attempt of delivery.
+	retryDelay time.Duration
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewWebhook creates new instance of Webhook sending notifications to the URLs.
+// If list of URLs is empty, the notifications are dropped. Such webhook is no-op.
+func NewWebhook(urls []string) (*Webhook, error) {
+	targets := make([]*webhookTarget, 0, len(urls))
+
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return nil, fmt.Errorf("%w: %q", entity.ErrInvalidWebhook, raw)
+		}
+
+		targets = append(targets, &webhookTarget{url: raw, queue: make(chan notification, _webhookQueueSize)})
+	}
+
+	return &Webhook{
+		targets:    targets,
+		client:     &http.Client{Timeout: _webhookTimeout},
+		retryDelay: _webhookRetryDelay,
+		quit:       make(chan struct{}),
+	}, nil
+}
+
+// WithRetryDelay overrides delay between delivery attempts.
+func (w *Webhook) WithRetryDelay(delay time.Duration) *Webhook {
+	w.retryDelay = delay
+	return w
+}
+
+// Notify schedules delivery of notification about the alert.
+func (w *Webhook) Notify(alert Alert) {
+	if len(w.targets) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(toAlertReq(alert))
+	if err != nil {
+		log.Error().Err(err).Msg("Webhook - Notify - json.Marshal")
+		return
+	}
+
+	n := notification{alert: alert.Name, payload: payload}
+
+	for _, target := range w.targets {
+		select {
+		case target.queue <- n:
+
+		default:
+			log.Warn().
+				Str("webhook", target.url).
+				Str("alert", alert.Name).
+				Str("state", alert.State).
+				Msg("Webhook queue is full, notification dropped")
+		}
+	}
+}
+
+// Start launches delivery of notifications in background.
+func (w *Webhook) Start() {
+	for _, target := range w.targets {
+		w.wg.Add(1)
+
+		go func(target *webhookTarget) {
+			defer w.wg.Done()
+
+			for {
+				select {
+				case n := <-target.queue:
+					w.deliver(target.url, n)
+
+				case <-w.quit:
+					return
+				}
+			}
+		}(target)
+	}
+}
+
+// Shutdown stops delivery of notifications, pending notifications are dropped.
+func (w *Webhook) Shutdown() {
+	close(w.quit)
+	w.wg.Wait()
+}
+
+func toAlertReq(alert Alert) metrics.Alert {
+	rv := metrics.Alert{
+		Name:        alert.Name,
+		Metric:      alert.Metric,
+		Severity:    alert.Severity,
+		Condition:   alert.Condition,
+		State:       alert.State,
+		Value:       alert.Value,
+		ActiveSince: alert.ActiveSince,
+		Labels:      alert.Labels,
+	}
+
+	if !alert.FiredAt.IsZero() {
+		rv.FiredAt = &alert.FiredAt
+	}
+
+	if !alert.ResolvedAt.IsZero() {
+		rv.ResolvedAt = &alert.ResolvedAt
+	}
+
+	return rv
+}
+
+// deliver sends notification to the URL retrying failed attempts.
+func (w *Webhook) deliver(target string, n notification) {
+	delay := w.retryDelay
+
+	for attempt := 1; ; attempt++ {
+		err := w.send(target, n.payload)
+		if err == nil {
+			return
+		}
+
+		if attempt == _webhookAttempts {
+			log.Error().Err(err).Str("webhook", target).Str("alert", n.alert).Msg("Failed to deliver notification")
+			return
+		}
+
+		log.Warn().Err(err).Str("webhook", target).Dur("retry_in", delay).Msg("")
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+func (w *Webhook) send(target string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), _webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("Webhook - send - http.NewRequestWithContext: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Webhook - send - w.client.Do: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(resp.Body)
+		return entity.HTTPError(resp.StatusCode, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package alerting_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhookFailsOnInvalidURL(t *testing.T) {
+	tt := []struct {
+		name string
+		url  string
+	}{
+		{
+			name: "Should fail on relative URL",
+			url:  "/alerts",
+		},
+		{
+			name: "Should fail on unsupported scheme",
+			url:  "ftp://10.0.0.1/alerts",
+		},
+		{
+			name: "Should fail without host",
+			url:  "http:///alerts",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := alerting.NewWebhook([]string{tc.url})
+
+			require.ErrorIs(t, err, entity.ErrInvalidWebhook)
+		})
+	}
+}
+
+func TestWebhookRetriesDelivery(t *testing.T) {
+	require := require.New(t)
+
+	var attempts int32
+
+	received := make(chan metrics.Alert, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var alert metrics.Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- alert
+	}))
+	defer srv.Close()
+
+	webhook, err := alerting.NewWebhook([]string{srv.URL})
+	require.NoError(err)
+
+	webhook.WithRetryDelay(time.Millisecond).Start()
+	defer webhook.Shutdown()
+
+	firedAt := time.Unix(1000, 0).UTC()
+	webhook.Notify(alerting.Alert{
+		Name:        "HighMemUsage",
+		Metric:      "MemUsedPct",
+		Severity:    "critical",
+		Condition:   "MemUsedPct > 90",
+		State:       alerting.StateFiring,
+		Value:       95,
+		ActiveSince: firedAt,
+		FiredAt:     firedAt,
+	})
+
+	select {
+	case alert := <-received:
+		require.Equal("HighMemUsage", alert.Name)
+		require.Equal("MemUsedPct", alert.Metric)
+		require.Equal("critical", alert.Severity)
+		require.Equal(alerting.StateFiring, alert.State)
+		require.Equal(float64(95), alert.Value)
+		require.NotNil(alert.FiredAt)
+		require.True(firedAt.Equal(*alert.FiredAt))
+		require.Nil(alert.ResolvedAt)
+
+	case <-time.After(5 * time.Second):
+		require.Fail("notification not delivered")
+	}
+
+	require.Equal(int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestWebhookUnavailableURLDoesNotBlockOthers(t *testing.T) {
+	require := require.New(t)
+
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	received := make(chan string, 2)
+
+	available := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert metrics.Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- alert.Name
+	}))
+	defer available.Close()
+
+	webhook, err := alerting.NewWebhook([]string{unavailable.URL, available.URL})
+	require.NoError(err)
+
+	// Retries of the unavailable URL last much longer than the test.
+	webhook.WithRetryDelay(time.Hour).Start()
+	defer webhook.Shutdown()
+
+	webhook.Notify(alerting.Alert{Name: "HighMemUsage", State: alerting.StateFiring})
+	webhook.Notify(alerting.Alert{Name: "HighCpuUsage", State: alerting.StateFiring})
+
+	for _, expected := range []string{"HighMemUsage", "HighCpuUsage"} {
+		select {
+		case name := <-received:
+			require.Equal(expected, name)
+
+		case <-time.After(5 * time.Second):
+			require.Fail("notification not delivered")
+		}
+	}
+}
+
+func TestWebhookWithoutURLsIsNoop(t *testing.T) {
+	webhook, err := alerting.NewWebhook(nil)
+	require.NoError(t, err)
+
+	webhook.Start()
+	webhook.Notify(alerting.Alert{Name: "HighMemUsage", State: alerting.StateFiring})
+	webhook.Shutdown()
+}
@@ -0,0 +1,314 @@
+// Package cluster implements sharding of metrics between several servers.
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	_ services.Recorder = (*Recorder)(nil)
+	_ shard             = (*peer)(nil)
+)
+
+// A shard stores part of metrics of the cluster, either locally or on another node.
+type shard interface {
+	PushList(ctx context.Context, records []storage.Record) ([]storage.Record, error)
+	Get(ctx context.Context, kind, name string) (storage.Record, error)
+	Delete(ctx context.Context, kind, name string) error
+}
+
+// Recorder shards metrics between nodes of static cluster.
+// Each series is owned by single node chosen by consistent hashing of its ID,
+// requests for series owned by other nodes are proxied to them over gRPC.
+// Lists are collected from all nodes and merged.
+// Watch is served locally, i.e. reports updates of series owned by this node only.
+type Recorder struct {
+	services.Recorder
+
+	self  entity.NetAddress
+	ring  *Ring
+	peers map[entity.NetAddress]*peer
+
+	// Name of identity authorized by the cluster token.
+	// If empty, requests of other nodes are recognized by their IP addresses.
+	identity string
+
+	// IP addresses of other nodes of the cluster.
+	peerIPs []net.IP
+}
+
+// lookupNodeIPs resolves IP addresses of the node.
+func lookupNodeIPs(node entity.NetAddress) ([]net.IP, error) {
+	host, _, err := net.SplitHostPort(node.String())
+	if err != nil {
+		return nil, fmt.Errorf("cluster - lookupNodeIPs - net.SplitHostPort: %w", err)
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, fmt.Errorf("cluster - lookupNodeIPs - net.LookupIP: %w", err)
+	}
+
+	return ips, nil
+}
+
+// New creates new instance of Recorder wrapping the local recorder.
+// The self address must be one of the nodes and is used as source IP of proxied requests.
+// The token authorizes proxied requests, if set it must grant read and write scopes.
+// The identity is name of the identity authorized by the token, only requests of this identity
+// are accepted as proxied by other nodes. If authorization of requests is disabled, the identity
+// must be empty and proxied requests are accepted from IP addresses of the nodes only.
+// If list of nodes is empty, Recorder just passes requests to the local recorder. Such recorder is no-op.
+func New(
+	self entity.NetAddress,
+	nodes []entity.NetAddress,
+	recorder services.Recorder,
+	signer *security.Signer,
+	token security.Secret,
+	identity string,
+) (*Recorder, error) {
+	if len(nodes) == 0 {
+		return &Recorder{Recorder: recorder}, nil
+	}
+
+	ips, err := lookupNodeIPs(self)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		Recorder: recorder,
+		self:     self,
+		ring:     NewRing(nodes),
+		peers:    make(map[entity.NetAddress]*peer, len(nodes)-1),
+		identity: identity,
+		peerIPs:  make([]net.IP, 0, len(nodes)-1),
+	}
+
+	for _, node := range nodes {
+		if node == self {
+			continue
+		}
+
+		nodeIPs, err := lookupNodeIPs(node)
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+
+		r.peerIPs = append(r.peerIPs, nodeIPs...)
+
+		p, err := newPeer(node, signer, token, ips[0])
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+
+		r.peers[node] = p
+	}
+
+	return r, nil
+}
+
+// forwarded reports whether the request was proxied by another node.
+func (r *Recorder) forwarded(ctx context.Context) bool {
+	if !hasForwardedMark(ctx) {
+		return false
+	}
+
+	if len(r.identity) != 0 {
+		identity, ok := security.IdentityFromContext(ctx)
+
+		return ok && identity.Name == r.identity
+	}
+
+	ip := senderIP(ctx)
+
+	for _, peerIP := range r.peerIPs {
+		if peerIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// local reports whether the request must be served by this node only.
+func (r *Recorder) local(ctx context.Context) bool {
+	return r.ring == nil || r.forwarded(ctx)
+}
+
+// owner returns address of the node owning the series.
+func (r *Recorder) owner(name, kind string) entity.NetAddress {
+	return r.ring.Owner(services.CalculateID(name, kind))
+}
+
+// shard returns shard of the cluster stored on the node.
+func (r *Recorder) shard(node entity.NetAddress) shard {
+	if node == r.self {
+		return r.Recorder
+	}
+
+	return r.peers[node]
+}
+
+// Push records metric data on the node owning the series.
+func (r *Recorder) Push(ctx context.Context, record storage.Record) (storage.Record, error) {
+	if r.local(ctx) {
+		return r.Recorder.Push(ctx, record)
+	}
+
+	node := r.owner(record.Name, record.Value.Kind())
+	if node == r.self {
+		return r.Recorder.Push(ctx, record)
+	}
+
+	rv, err := r.peers[node].PushList(ctx, []storage.Record{record})
+	if err != nil {
+		return storage.Record{}, err
+	}
+
+	if len(rv) == 0 {
+		return storage.Record{}, fmt.Errorf("cluster - Push: %w", entity.ErrUnexpected)
+	}
+
+	return rv[0], nil
+}
+
+// PushList records list of metrics data, each metric is recorded on the node owning the series.
+func (r *Recorder) PushList(ctx context.Context, records []storage.Record) ([]storage.Record, error) {
+	if r.local(ctx) {
+		return r.Recorder.PushList(ctx, records)
+	}
+
+	batches := make(map[entity.NetAddress][]storage.Record)
+
+	for _, record := range records {
+		node := r.owner(record.Name, record.Value.Kind())
+		batches[node] = append(batches[node], record)
+	}
+
+	// Local shard is updated first, as it is the most reliable one.
+	order := make([]entity.NetAddress, 0, len(batches))
+	if _, ok := batches[r.self]; ok {
+		order = append(order, r.self)
+	}
+
+	for node := range batches {
+		if node != r.self {
+			order = append(order, node)
+		}
+	}
+
+	rv := make([]storage.Record, 0, len(records))
+
+	for i, node := range order {
+		pushed, err := r.shard(node).PushList(ctx, batches[node])
+		if err != nil {
+			r.revert(ctx, order[:i], batches)
+			return nil, fmt.Errorf("cluster - PushList - %s: %w", node, err)
+		}
+
+		rv = append(rv, pushed...)
+	}
+
+	sort.Slice(rv, func(i, j int) bool {
+		return rv[i].Name < rv[j].Name
+	})
+
+	return rv, nil
+}
+
+// revert compensates counters already recorded on the nodes, if the list was not recorded completely.
+// Clients retry failed requests, so otherwise the counters are increased twice.
+// Gauges are just overwritten on retry. Counters created by the failed request are left with zero value.
+func (r *Recorder) revert(
+	ctx context.Context,
+	nodes []entity.NetAddress,
+	batches map[entity.NetAddress][]storage.Record,
+) {
+	for _, node := range nodes {
+		deltas := make([]storage.Record, 0, len(batches[node]))
+
+		for _, record := range batches[node] {
+			if delta, ok := record.Value.(metrics.Counter); ok {
+				deltas = append(deltas, storage.Record{Name: record.Name, Value: -delta})
+			}
+		}
+
+		if len(deltas) == 0 {
+			continue
+		}
+
+		if _, err := r.shard(node).PushList(ctx, deltas); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("node", node.String()).Msg("cluster - revert - PushList")
+		}
+	}
+}
+
+// Get returns stored metrics record from the node owning the series.
+func (r *Recorder) Get(ctx context.Context, kind, name string) (storage.Record, error) {
+	if r.local(ctx) {
+		return r.Recorder.Get(ctx, kind, name)
+	}
+
+	return r.shard(r.owner(name, kind)).Get(ctx, kind, name)
+}
+
+// Delete removes stored metrics record from the node owning the series.
+func (r *Recorder) Delete(ctx context.Context, kind, name string) error {
+	if r.local(ctx) {
+		return r.Recorder.Delete(ctx, kind, name)
+	}
+
+	return r.shard(r.owner(name, kind)).Delete(ctx, kind, name)
+}
+
+// List retrieves single page of metrics stored on all nodes of the cluster.
+func (r *Recorder) List(ctx context.Context, opts storage.ListOptions) (storage.Page, error) {
+	if r.local(ctx) {
+		return r.Recorder.List(ctx, opts)
+	}
+
+	page, err := r.Recorder.List(ctx, opts)
+	if err != nil {
+		return storage.Page{}, err
+	}
+
+	pages := make([]storage.Page, 0, len(r.peers)+1)
+	pages = append(pages, page)
+
+	for node, p := range r.peers {
+		page, err := p.List(ctx, opts)
+		if err != nil {
+			return storage.Page{}, fmt.Errorf("cluster - List - %s: %w", node, err)
+		}
+
+		pages = append(pages, page)
+	}
+
+	return storage.MergePages(pages, opts.Limit), nil
+}
+
+// Close closes connections to other nodes of the cluster.
+func (r *Recorder) Close() error {
+	var rv error
+
+	for _, p := range r.peers {
+		if err := p.Close(); err != nil && rv == nil {
+			rv = err
+		}
+	}
+
+	return rv
+}
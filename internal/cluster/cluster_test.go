@@ -0,0 +1,420 @@
+package cluster_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/cluster"
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/grpcbackend"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const _clusterKey = security.Secret("abc")
+
+type node struct {
+	address  entity.NetAddress
+	store    *storage.MemStorage
+	recorder *cluster.Recorder
+	server   *grpc.Server
+}
+
+const (
+	_clusterToken = security.Secret("cluster-secret")
+	_agentToken   = security.Secret("agent-secret")
+)
+
+func createTestTokens(t *testing.T) *security.Tokens {
+	t.Helper()
+	require := require.New(t)
+
+	data := fmt.Sprintf(
+		`[{"name": "cluster", "token": %q, "scopes": ["read", "write"]}, `+
+			`{"name": "agent", "token": %q, "scopes": ["read", "write"]}]`,
+		string(_clusterToken),
+		string(_agentToken),
+	)
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	require.NoError(os.WriteFile(path, []byte(data), 0600))
+
+	tokens, err := security.NewTokens(entity.FilePath(path))
+	require.NoError(err)
+
+	return tokens
+}
+
+// startCluster launches gRPC API of several nodes sharing metrics between each other.
+func startCluster(t *testing.T, size int) []node {
+	t.Helper()
+
+	return startClusterWithTokens(t, size, nil)
+}
+
+// startClusterWithTokens launches cluster requiring authorization of requests,
+// nodes authorize requests to each other with the cluster token.
+func startClusterWithTokens(t *testing.T, size int, tokens *security.Tokens) []node {
+	t.Helper()
+	require := require.New(t)
+
+	var (
+		token    security.Secret
+		identity string
+	)
+
+	if tokens != nil {
+		token = _clusterToken
+		identity = "cluster"
+	}
+
+	listeners := make([]net.Listener, 0, size)
+	addresses := make([]entity.NetAddress, 0, size)
+
+	for i := 0; i < size; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(err)
+
+		listeners = append(listeners, listener)
+		addresses = append(addresses, entity.NetAddress(listener.Addr().String()))
+	}
+
+	signer := security.NewSigner(_clusterKey)
+	nodes := make([]node, 0, size)
+
+	for i, listener := range listeners {
+		store := storage.NewMemStorage()
+
+		local := services.NewMetricsRecorder(store)
+
+		recorder, err := cluster.New(addresses[i], addresses, local, signer, token, identity)
+		require.NoError(err)
+
+		srv := grpcbackend.New(grpcbackend.Deps{
+			Address:     addresses[i],
+			Recorder:    recorder,
+			HealthCheck: &services.HealthCheckMock{},
+			Signer:      signer,
+			Tokens:      tokens,
+		}).Instance()
+
+		go func(listener net.Listener) {
+			require.NoError(srv.Serve(listener))
+		}(listener)
+
+		t.Cleanup(func() {
+			require.NoError(recorder.Close())
+			srv.Stop()
+		})
+
+		nodes = append(nodes, node{address: addresses[i], store: store, recorder: recorder, server: srv})
+	}
+
+	return nodes
+}
+
+func generateRecords(count int) []storage.Record {
+	rv := make([]storage.Record, 0, count)
+
+	for i := 0; i < count; i++ {
+		rv = append(rv, storage.Record{Name: fmt.Sprintf("Metric%d", i), Value: metrics.Gauge(float64(i))})
+	}
+
+	return rv
+}
+
+func TestPushStoresSeriesOnOwner(t *testing.T) {
+	require := require.New(t)
+
+	nodes := startCluster(t, 2)
+	ring := cluster.NewRing([]entity.NetAddress{nodes[0].address, nodes[1].address})
+	records := generateRecords(20)
+
+	_, err := nodes[0].recorder.PushList(context.Background(), records)
+	require.NoError(err)
+
+	for _, record := range records {
+		id := services.CalculateID(record.Name, record.Value.Kind())
+
+		for _, n := range nodes {
+			_, err := n.store.Get(context.Background(), id)
+
+			if ring.Owner(id) == n.address {
+				require.NoError(err, record.Name)
+			} else {
+				require.ErrorIs(err, entity.ErrMetricNotFound, record.Name)
+			}
+		}
+	}
+}
+
+func TestPushAccumulatesCounterOnOwner(t *testing.T) {
+	require := require.New(t)
+
+	nodes := startCluster(t, 2)
+	record := storage.Record{Name: "PollCount", Value: metrics.Counter(10)}
+
+	_, err := nodes[0].recorder.Push(context.Background(), record)
+	require.NoError(err)
+
+	rv, err := nodes[1].recorder.Push(context.Background(), record)
+	require.NoError(err)
+	require.Equal(metrics.Counter(20), rv.Value)
+
+	for _, n := range nodes {
+		rv, err := n.recorder.Get(context.Background(), metrics.KindCounter, "PollCount")
+		require.NoError(err)
+		require.Equal(metrics.Counter(20), rv.Value)
+	}
+}
+
+// ownedRecord returns counter owned by the node.
+func ownedRecord(t *testing.T, nodes []node, owner int) storage.Record {
+	t.Helper()
+
+	addresses := make([]entity.NetAddress, 0, len(nodes))
+	for _, n := range nodes {
+		addresses = append(addresses, n.address)
+	}
+
+	ring := cluster.NewRing(addresses)
+
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("Counter%d", i)
+
+		if ring.Owner(services.CalculateID(name, metrics.KindCounter)) == nodes[owner].address {
+			return storage.Record{Name: name, Value: metrics.Counter(10)}
+		}
+	}
+
+	require.FailNow(t, "no counter owned by the node")
+
+	return storage.Record{}
+}
+
+func TestPushListFailureOnPeerDoesNotCountTwice(t *testing.T) {
+	require := require.New(t)
+
+	nodes := startCluster(t, 2)
+	local := ownedRecord(t, nodes, 0)
+	remote := ownedRecord(t, nodes, 1)
+
+	// Make sure the peer is serving before it is stopped.
+	_, err := nodes[0].recorder.Get(context.Background(), metrics.KindCounter, remote.Name)
+	require.ErrorIs(err, entity.ErrMetricNotFound)
+
+	nodes[1].server.Stop()
+
+	_, err = nodes[0].recorder.PushList(context.Background(), []storage.Record{local, remote})
+	require.ErrorIs(err, entity.ErrServiceUnavailable)
+
+	rv, err := nodes[0].store.Get(context.Background(), services.CalculateID(local.Name, metrics.KindCounter))
+	require.NoError(err)
+	require.Equal(metrics.Counter(0), rv.Value)
+
+	// Bring the peer back and retry the request as clients do.
+	listener, err := net.Listen("tcp", nodes[1].address.String())
+	require.NoError(err)
+
+	srv := grpcbackend.New(grpcbackend.Deps{
+		Address:     nodes[1].address,
+		Recorder:    nodes[1].recorder,
+		HealthCheck: &services.HealthCheckMock{},
+		Signer:      security.NewSigner(_clusterKey),
+	}).Instance()
+	t.Cleanup(srv.Stop)
+
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	require.Eventually(func() bool {
+		_, err := nodes[0].recorder.PushList(context.Background(), []storage.Record{local, remote})
+		return err == nil
+	}, 10*time.Second, 100*time.Millisecond)
+
+	for _, record := range []storage.Record{local, remote} {
+		rv, err := nodes[0].recorder.Get(context.Background(), metrics.KindCounter, record.Name)
+		require.NoError(err)
+		require.Equal(record.Value, rv.Value, record.Name)
+	}
+}
+
+func TestGetFailsOnUnknownMetric(t *testing.T) {
+	nodes := startCluster(t, 2)
+
+	for _, n := range nodes {
+		_, err := n.recorder.Get(context.Background(), metrics.KindGauge, "Unknown")
+
+		require.ErrorIs(t, err, entity.ErrMetricNotFound)
+	}
+}
+
+func TestDeleteRemovesSeriesFromOwner(t *testing.T) {
+	require := require.New(t)
+
+	nodes := startCluster(t, 2)
+	records := generateRecords(10)
+
+	_, err := nodes[0].recorder.PushList(context.Background(), records)
+	require.NoError(err)
+
+	for _, record := range records {
+		err := nodes[1].recorder.Delete(context.Background(), metrics.KindGauge, record.Name)
+		require.NoError(err, record.Name)
+	}
+
+	for _, n := range nodes {
+		require.Empty(n.store.Data)
+
+		err := n.recorder.Delete(context.Background(), metrics.KindGauge, records[0].Name)
+		require.ErrorIs(err, entity.ErrMetricNotFound)
+	}
+}
+
+func TestListMergesSeriesOfAllNodes(t *testing.T) {
+	require := require.New(t)
+
+	nodes := startCluster(t, 3)
+	records := generateRecords(25)
+
+	_, err := nodes[0].recorder.PushList(context.Background(), records)
+	require.NoError(err)
+
+	page, err := nodes[1].recorder.List(context.Background(), storage.ListOptions{})
+	require.NoError(err)
+	require.Len(page.Records, len(records))
+	require.Empty(page.NextCursor)
+
+	names := make([]string, 0, len(records))
+	opts := storage.ListOptions{Limit: 10}
+
+	for {
+		page, err := nodes[2].recorder.List(context.Background(), opts)
+		require.NoError(err)
+		require.LessOrEqual(len(page.Records), opts.Limit)
+
+		for _, record := range page.Records {
+			names = append(names, record.Name)
+		}
+
+		if len(page.NextCursor) == 0 {
+			break
+		}
+
+		opts.Cursor = page.NextCursor
+	}
+
+	require.Len(names, len(records))
+	require.IsIncreasing(names)
+}
+
+func TestForwardedRequestIsServedLocally(t *testing.T) {
+	tt := []struct {
+		name       string
+		withTokens bool
+		token      security.Secret
+		owner      int
+	}{
+		{
+			name:  "Forwarded request of another node is served locally",
+			owner: 0,
+		},
+		{
+			name:       "Forwarded request of cluster identity is served locally",
+			withTokens: true,
+			token:      _clusterToken,
+			owner:      0,
+		},
+		{
+			name:       "Forwarded mark of other clients is ignored",
+			withTokens: true,
+			token:      _agentToken,
+			owner:      1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			var tokens *security.Tokens
+			if tc.withTokens {
+				tokens = createTestTokens(t)
+			}
+
+			nodes := startClusterWithTokens(t, 2, tokens)
+			ring := cluster.NewRing([]entity.NetAddress{nodes[0].address, nodes[1].address})
+
+			// Find series owned by the second node.
+			var record storage.Record
+
+			for _, candidate := range generateRecords(100) {
+				if ring.Owner(services.CalculateID(candidate.Name, candidate.Value.Kind())) == nodes[1].address {
+					record = candidate
+					break
+				}
+			}
+
+			require.NotEmpty(record.Name)
+
+			conn, err := grpc.Dial(nodes[0].address.String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			require.NoError(err)
+
+			defer func() {
+				require.NoError(conn.Close())
+			}()
+
+			req := grpcapi.NewUpdateGaugeReq(record.Name, record.Value.(metrics.Gauge))
+			req.Hash, err = security.NewSigner(_clusterKey).CalculateRecordSignature(record)
+			require.NoError(err)
+
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "x-cluster-forwarded", "true")
+			if len(tc.token) != 0 {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", security.BearerToken(tc.token))
+			}
+
+			_, err = grpcapi.NewMetricsClient(conn).Update(ctx, req)
+			require.NoError(err)
+
+			id := services.CalculateID(record.Name, record.Value.Kind())
+
+			for i, n := range nodes {
+				_, err = n.store.Get(context.Background(), id)
+
+				if i == tc.owner {
+					require.NoError(err)
+				} else {
+					require.ErrorIs(err, entity.ErrMetricNotFound)
+				}
+			}
+		})
+	}
+}
+
+func TestNewWithoutNodes(t *testing.T) {
+	require := require.New(t)
+
+	store := storage.NewMemStorage()
+	recorder, err := cluster.New("", nil, services.NewMetricsRecorder(store), nil, "", "")
+	require.NoError(err)
+
+	_, err = recorder.Push(context.Background(), storage.Record{Name: "Alloc", Value: metrics.Gauge(1)})
+	require.NoError(err)
+
+	_, err = store.Get(context.Background(), services.CalculateID("Alloc", metrics.KindGauge))
+	require.NoError(err)
+	require.NoError(recorder.Close())
+}
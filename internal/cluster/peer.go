@@ -0,0 +1,254 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	grpcpeer "google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata key marking requests proxied by another node of the cluster.
+// Such requests are always served locally to avoid loops.
+const _forwardedKey = "x-cluster-forwarded"
+
+// hasForwardedMark reports whether the request is marked as proxied by another node.
+// The mark can be set by any client, thus the sender must be verified as well.
+func hasForwardedMark(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+
+	return len(md.Get(_forwardedKey)) != 0
+}
+
+// senderIP returns IP address of the client sent incoming gRPC request.
+func senderIP(ctx context.Context) net.IP {
+	p, ok := grpcpeer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return nil
+	}
+
+	return net.ParseIP(host)
+}
+
+// toError converts gRPC status returned by a peer into error of the service.
+func toError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return fmt.Errorf("%w: %s", entity.ErrMetricNotFound, err)
+
+	case codes.Unavailable:
+		return fmt.Errorf("%w: %s", entity.ErrServiceUnavailable, err)
+
+	case codes.ResourceExhausted:
+		return fmt.Errorf("%w: %s", entity.ErrSeriesQuotaExceeded, err)
+	}
+
+	return err
+}
+
+func toRecord(req *grpcapi.MetricReq) (storage.Record, error) {
+	switch req.Mtype {
+	case metrics.KindCounter:
+		return storage.Record{Name: req.Id, Value: metrics.Counter(req.Delta)}, nil
+
+	case metrics.KindGauge:
+		return storage.Record{Name: req.Id, Value: metrics.Gauge(req.Value)}, nil
+	}
+
+	return storage.Record{}, entity.MetricNotImplementedError(req.Mtype)
+}
+
+func toRecordsList(data []*grpcapi.MetricReq) ([]storage.Record, error) {
+	rv := make([]storage.Record, 0, len(data))
+
+	for _, req := range data {
+		record, err := toRecord(req)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, record)
+	}
+
+	return rv, nil
+}
+
+// A peer proxies requests to another node of the cluster over gRPC.
+type peer struct {
+	conn   *grpc.ClientConn
+	client grpcapi.MetricsClient
+
+	// Entity to sign proxied updates, must use the same key as the peer.
+	// If set to nil, requests will not be signed.
+	signer *security.Signer
+
+	// Token to authorize proxied requests.
+	// If empty, requests are sent without authorization.
+	token security.Secret
+
+	// Address of this node reported to the peer to pass trusted subnet filter.
+	clientIP net.IP
+}
+
+func newPeer(
+	address entity.NetAddress,
+	signer *security.Signer,
+	token security.Secret,
+	clientIP net.IP,
+) (*peer, error) {
+	conn, err := grpc.Dial(address.String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("cluster - newPeer - grpc.Dial: %w", err)
+	}
+
+	return &peer{
+		conn:     conn,
+		client:   grpcapi.NewMetricsClient(conn),
+		signer:   signer,
+		token:    token,
+		clientIP: clientIP,
+	}, nil
+}
+
+// outgoing marks the request as proxied by this node.
+func (p *peer) outgoing(ctx context.Context) context.Context {
+	ctx = metadata.AppendToOutgoingContext(ctx, _forwardedKey, "true", "x-real-ip", p.clientIP.String())
+
+	if len(p.token) != 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", security.BearerToken(p.token))
+	}
+
+	if tenant := entity.TenantFromContext(ctx); tenant != entity.DefaultTenant {
+		ctx = metadata.AppendToOutgoingContext(ctx, security.TenantHeader, tenant)
+	}
+
+	return ctx
+}
+
+func (p *peer) toMetricReq(record storage.Record) (*grpcapi.MetricReq, error) {
+	var req *grpcapi.MetricReq
+
+	switch v := record.Value.(type) {
+	case metrics.Counter:
+		req = grpcapi.NewUpdateCounterReq(record.Name, v)
+
+	case metrics.Gauge:
+		req = grpcapi.NewUpdateGaugeReq(record.Name, v)
+
+	default:
+		return nil, entity.MetricNotImplementedError(record.Value.Kind())
+	}
+
+	if p.signer != nil {
+		hash, err := p.signer.CalculateRecordSignature(record)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Hash = hash
+	}
+
+	return req, nil
+}
+
+func (p *peer) PushList(ctx context.Context, records []storage.Record) ([]storage.Record, error) {
+	data := make([]*grpcapi.MetricReq, 0, len(records))
+
+	for _, record := range records {
+		req, err := p.toMetricReq(record)
+		if err != nil {
+			return nil, fmt.Errorf("peer - PushList - p.toMetricReq: %w", err)
+		}
+
+		data = append(data, req)
+	}
+
+	resp, err := p.client.BatchUpdate(p.outgoing(ctx), &grpcapi.BatchUpdateRequest{Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("peer - PushList - p.client.BatchUpdate: %w", toError(err))
+	}
+
+	return toRecordsList(resp.Data)
+}
+
+func (p *peer) Get(ctx context.Context, kind, name string) (storage.Record, error) {
+	resp, err := p.client.Get(p.outgoing(ctx), &grpcapi.GetMetricRequest{Id: name, Mtype: kind})
+	if err != nil {
+		return storage.Record{}, fmt.Errorf("peer - Get - p.client.Get: %w", toError(err))
+	}
+
+	return toRecord(resp)
+}
+
+func (p *peer) Delete(ctx context.Context, kind, name string) error {
+	if _, err := p.client.Delete(p.outgoing(ctx), &grpcapi.GetMetricRequest{Id: name, Mtype: kind}); err != nil {
+		return fmt.Errorf("peer - Delete - p.client.Delete: %w", toError(err))
+	}
+
+	return nil
+}
+
+// List retrieves single page of metrics stored by the peer.
+// If the limit is not set, all pages are retrieved.
+func (p *peer) List(ctx context.Context, opts storage.ListOptions) (storage.Page, error) {
+	req := &grpcapi.ListRequest{
+		Prefix: opts.Prefix,
+		Mtype:  opts.Kind,
+		Limit:  int32(opts.Limit),
+		Cursor: opts.Cursor,
+		Regex:  opts.Match,
+	}
+
+	if opts.Limit == 0 {
+		req.Limit = entity.MaxPageSize
+	}
+
+	rv := storage.Page{Records: make([]storage.Record, 0)}
+
+	for {
+		resp, err := p.client.List(p.outgoing(ctx), req)
+		if err != nil {
+			return storage.Page{}, fmt.Errorf("peer - List - p.client.List: %w", toError(err))
+		}
+
+		records, err := toRecordsList(resp.Data)
+		if err != nil {
+			return storage.Page{}, fmt.Errorf("peer - List - toRecordsList: %w", err)
+		}
+
+		rv.Records = append(rv.Records, records...)
+
+		if opts.Limit != 0 {
+			rv.NextCursor = resp.NextCursor
+			return rv, nil
+		}
+
+		if len(resp.NextCursor) == 0 {
+			return rv, nil
+		}
+
+		req.Cursor = resp.NextCursor
+	}
+}
+
+func (p *peer) Close() error {
+	return p.conn.Close()
+}
@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+)
+
+// Count of points each node occupies on the ring.
+// More points give more even distribution of series between nodes.
+const _virtualNodes = 128
+
+// A Ring assigns series to nodes using consistent hashing,
+// thus adding or removing a node moves only small part of series.
+type Ring struct {
+	hashes []uint32
+	owners map[uint32]entity.NetAddress
+}
+
+// NewRing creates ring from addresses of cluster nodes.
+func NewRing(nodes []entity.NetAddress) *Ring {
+	r := &Ring{
+		hashes: make([]uint32, 0, len(nodes)*_virtualNodes),
+		owners: make(map[uint32]entity.NetAddress, len(nodes)*_virtualNodes),
+	}
+
+	for _, node := range nodes {
+		for i := 0; i < _virtualNodes; i++ {
+			hash := crc32.ChecksumIEEE([]byte(node.String() + "#" + strconv.Itoa(i)))
+
+			// Resolve rare collisions deterministically
+			// so all nodes build the same ring regardless of order in config.
+			if prev, ok := r.owners[hash]; ok {
+				if prev < node {
+					continue
+				}
+			} else {
+				r.hashes = append(r.hashes, hash)
+			}
+
+			r.owners[hash] = node
+		}
+	}
+
+	sort.Slice(r.hashes, func(i, j int) bool {
+		return r.hashes[i] < r.hashes[j]
+	})
+
+	return r
+}
+
+// Owner returns address of the node owning the series with provided ID,
+// see services.CalculateID.
+func (r *Ring) Owner(id string) entity.NetAddress {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(id))
+
+	i := sort.Search(len(r.hashes), func(i int) bool {
+		return r.hashes[i] >= hash
+	})
+
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return r.owners[r.hashes[i]]
+}
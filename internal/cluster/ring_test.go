@@ -0,0 +1,56 @@
+package cluster_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/cluster"
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingOwnerDoesNotDependOnOrderOfNodes(t *testing.T) {
+	left := cluster.NewRing([]entity.NetAddress{"10.0.0.1:3200", "10.0.0.2:3200", "10.0.0.3:3200"})
+	right := cluster.NewRing([]entity.NetAddress{"10.0.0.3:3200", "10.0.0.1:3200", "10.0.0.2:3200"})
+
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("Metric%d:gauge", i)
+
+		require.Equal(t, left.Owner(id), right.Owner(id))
+	}
+}
+
+func TestRingDistributesSeriesBetweenNodes(t *testing.T) {
+	nodes := []entity.NetAddress{"10.0.0.1:3200", "10.0.0.2:3200", "10.0.0.3:3200"}
+	ring := cluster.NewRing(nodes)
+
+	owned := make(map[entity.NetAddress]int)
+	for i := 0; i < 3000; i++ {
+		owned[ring.Owner(fmt.Sprintf("Metric%d:counter", i))]++
+	}
+
+	for _, node := range nodes {
+		require.Greater(t, owned[node], 500, node)
+	}
+}
+
+func TestRingMovesFewSeriesOnNewNode(t *testing.T) {
+	before := cluster.NewRing([]entity.NetAddress{"10.0.0.1:3200", "10.0.0.2:3200"})
+	after := cluster.NewRing([]entity.NetAddress{"10.0.0.1:3200", "10.0.0.2:3200", "10.0.0.3:3200"})
+
+	moved := 0
+
+	for i := 0; i < 3000; i++ {
+		id := fmt.Sprintf("Metric%d:gauge", i)
+
+		owner := after.Owner(id)
+		if owner == before.Owner(id) {
+			continue
+		}
+
+		require.Equal(t, entity.NetAddress("10.0.0.3:3200"), owner)
+		moved++
+	}
+
+	require.Less(t, moved, 1500)
+}
@@ -33,6 +33,9 @@ func sendEchoRequest(
 			w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
 		}
 
+		// Headers are sent explicitly to verify that compression is still reported.
+		w.WriteHeader(http.StatusOK)
+
 		_, hErr = w.Write(body)
 		require.NoError(hErr)
 	})
@@ -164,3 +167,109 @@ func TestClientSentPlainTextAsCompressed(t *testing.T) {
 
 	require.Equal(t, http.StatusBadRequest, status)
 }
+
+func TestCompressResponseKeepsStatusAndHeaders(t *testing.T) {
+	tt := []struct {
+		name        string
+		contentType string
+		code        int
+		body        string
+		encoding    string
+	}{
+		{
+			name:        "Should compress response sent without explicit status",
+			contentType: "application/json",
+			body:        `{"text": "Hello, gopher"}`,
+			encoding:    "gzip",
+		},
+		{
+			name:        "Should compress response sent after explicit status",
+			contentType: "application/json",
+			code:        http.StatusCreated,
+			body:        `{"text": "Hello, gopher"}`,
+			encoding:    "gzip",
+		},
+		{
+			name:        "Should compress error response",
+			contentType: "application/json",
+			code:        http.StatusNotFound,
+			body:        `{"error": "not found"}`,
+			encoding:    "gzip",
+		},
+		{
+			name:        "Should compress empty response of supported type",
+			contentType: "text/html; charset=utf-8",
+			code:        http.StatusOK,
+			encoding:    "gzip",
+		},
+		{
+			name:        "Should not compress response without content",
+			contentType: "application/json",
+			code:        http.StatusNoContent,
+		},
+		{
+			name:        "Should not compress response of not supported type",
+			contentType: "text/plain",
+			code:        http.StatusInternalServerError,
+			body:        "Hello, gopher",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			handler := compression.CompressResponse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Header().Set("X-Request-Id", "42")
+
+				if tc.code != 0 {
+					w.WriteHeader(tc.code)
+				}
+
+				if len(tc.body) != 0 {
+					_, err := w.Write([]byte(tc.body))
+					require.NoError(err)
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			resp := rec.Result()
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			expectedCode := tc.code
+			if expectedCode == 0 {
+				expectedCode = http.StatusOK
+			}
+
+			require.Equal(expectedCode, resp.StatusCode)
+			require.Equal(tc.contentType, resp.Header.Get("Content-Type"))
+			require.Equal("42", resp.Header.Get("X-Request-Id"))
+			require.Equal(tc.encoding, resp.Header.Get("Content-Encoding"))
+
+			body := io.Reader(resp.Body)
+
+			if len(tc.encoding) != 0 {
+				gz, err := gzip.NewReader(resp.Body)
+				require.NoError(err)
+
+				defer func() {
+					_ = gz.Close()
+				}()
+
+				body = gz
+			}
+
+			data, err := io.ReadAll(body)
+			require.NoError(err)
+			require.Equal(tc.body, string(data))
+		})
+	}
+}
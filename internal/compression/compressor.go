@@ -1,8 +1,10 @@
 package compression
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
+	"net"
 	"net/http"
 	"sync"
 
@@ -46,23 +48,48 @@ func NewCompressor(w http.ResponseWriter, logger *zerolog.Logger) *Compressor {
 	}
 }
 
+func (c *Compressor) isSupported() bool {
+	_, ok := c.supportedContent[c.Header().Get("Content-Type")]
+	return ok
+}
+
+// bodyAllowed reports whether response with the status code may have body.
+func bodyAllowed(code int) bool {
+	return code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// startEncoding sets Content-Encoding header and prepares encoder of response content.
+func (c *Compressor) startEncoding() {
+	if c.encoder != nil {
+		return
+	}
+
+	encoder := gzipWritersPool.Get().(*gzip.Writer)
+	encoder.Reset(c.ResponseWriter)
+
+	c.encoder = encoder
+	c.Header().Set("Content-Encoding", "gzip")
+}
+
+// WriteHeader sends response headers with the status code.
+// Content-Encoding header is set in advance, as headers can't be changed after this call.
+func (c *Compressor) WriteHeader(code int) {
+	if c.isSupported() && bodyAllowed(code) {
+		c.startEncoding()
+	}
+
+	c.ResponseWriter.WriteHeader(code)
+}
+
 // Write compresses response content data in case of supported type.
 // The content type should be specified in the Content-Type header in advance.
 func (c *Compressor) Write(resp []byte) (int, error) {
-	contentType := c.Header().Get("Content-Type")
-	if _, ok := c.supportedContent[contentType]; !ok {
-		c.logger.Debug().Msg("Compression not supported for " + contentType)
+	if c.encoder == nil && !c.isSupported() {
+		c.logger.Debug().Msg("Compression not supported for " + c.Header().Get("Content-Type"))
 		return c.ResponseWriter.Write(resp)
 	}
 
-	if c.encoder == nil {
-		encoder := gzipWritersPool.Get().(*gzip.Writer)
-		encoder.Reset(c.ResponseWriter)
-
-		c.encoder = encoder
-	}
-
-	c.Header().Set("Content-Encoding", "gzip")
+	c.startEncoding()
 
 	return c.encoder.Write(resp)
 }
@@ -81,6 +108,18 @@ func (c *Compressor) Close() {
 	gzipWritersPool.Put(c.encoder)
 }
 
+// Hijack lets the handler take over the connection, e.g. to upgrade it to WebSocket.
+// Data sent over hijacked connection is never compressed.
+// Required by http.Hijacker interface.
+func (c *Compressor) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := c.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	return hijacker.Hijack()
+}
+
 // CompressResponse is net/http middleware executing gzip compression
 // is gzip is supported by client and response belongs to supported type.
 func CompressResponse(next http.Handler) http.Handler {
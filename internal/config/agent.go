@@ -19,6 +19,7 @@ type Agent struct {
 	Address        entity.NetAddress `env:"ADDRESS" json:"address"`
 	Transport      string            `env:"TRANSPORT" json:"transport"`
 	Secret         security.Secret   `env:"KEY" json:"key"`
+	Token          security.Secret   `env:"TOKEN" json:"token"`
 	PublicKeyPath  entity.FilePath   `env:"CRYPTO_KEY" json:"crypto_key"`
 	PollTimeout    time.Duration     `json:"-"`
 	ExportTimeout  time.Duration     `json:"-"`
@@ -32,6 +33,7 @@ func NewAgent() *Agent {
 		ReportInterval: 10 * time.Second,
 		PollInterval:   2 * time.Second,
 		Secret:         "",
+		Token:          "",
 		PublicKeyPath:  "",
 		PollTimeout:    2 * time.Second,
 		ExportTimeout:  4 * time.Second,
@@ -76,6 +78,12 @@ func (c *Agent) Parse() error {
 		"secret key for signature generation",
 	)
 
+	token := flag.String(
+		"token",
+		string(c.Token),
+		"bearer token to authorize requests to metrics collector",
+	)
+
 	keyPath := c.PublicKeyPath
 	flag.VarP(
 		&keyPath,
@@ -124,6 +132,9 @@ func (c *Agent) Parse() error {
 		case "key":
 			c.Secret = secret
 
+		case "token":
+			c.Token = security.Secret(*token)
+
 		case "crypto-key":
 			c.PublicKeyPath = keyPath
 
@@ -155,6 +166,10 @@ func (c Agent) String() string {
 		sb.WriteString(fmt.Sprintf("\t\tSecret key: %s\n", c.Secret))
 	}
 
+	if len(c.Token) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tToken: %s\n", c.Token))
+	}
+
 	if len(c.PublicKeyPath) > 0 {
 		sb.WriteString(fmt.Sprintf("\t\tPublic key path: %s\n", c.PublicKeyPath))
 	}
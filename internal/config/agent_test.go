@@ -28,6 +28,7 @@ func TestAgentConfigString(t *testing.T) {
 				PollInterval:   2 * time.Second,
 				ReportInterval: 10 * time.Second,
 				Secret:         "xxx",
+				Token:          "yyy",
 				PublicKeyPath:  "./keys/key.pem",
 				PollTimeout:    2 * time.Second,
 				ExportTimeout:  4 * time.Second,
@@ -57,6 +58,7 @@ func TestAgentConfigUnmarshalJSON(t *testing.T) {
 "poll_interval": "2s",
 "report_interval": "5s",
 "key": "xxx",
+"token": "yyy",
 "crypto_key": "./keys/key.pem",
 "debug": true
 }`,
@@ -66,6 +68,7 @@ func TestAgentConfigUnmarshalJSON(t *testing.T) {
 				PollInterval:   2 * time.Second,
 				ReportInterval: 5 * time.Second,
 				Secret:         "xxx",
+				Token:          "yyy",
 				PublicKeyPath:  "./keys/key.pem",
 				PollTimeout:    2 * time.Second,
 				ExportTimeout:  4 * time.Second,
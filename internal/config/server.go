@@ -14,32 +14,100 @@ import (
 )
 
 type Server struct {
-	Address        entity.NetAddress    `env:"ADDRESS" json:"address"`
-	GRPCAddress    entity.NetAddress    `env:"GRPC_ADDRESS" json:"grpc_address"`
-	StoreInterval  time.Duration        `env:"STORE_INTERVAL" json:"store_interval"`
-	StorePath      string               `env:"STORE_FILE" json:"store_file"`
-	RestoreOnStart bool                 `env:"RESTORE" json:"restore"`
-	Secret         security.Secret      `env:"KEY" json:"key"`
-	PrivateKeyPath entity.FilePath      `env:"CRYPTO_KEY" json:"crypto_key"`
-	TrustedSubnet  *net.IPNet           `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
-	DatabaseURL    security.DatabaseURL `env:"DATABASE_DSN" json:"database_dsn"`
-	PprofAddress   entity.NetAddress    `env:"PPROF_ADDRESS" json:"pprof_address"`
-	Debug          bool                 `env:"DEBUG" json:"debug"`
+	Address           entity.NetAddress    `env:"ADDRESS" json:"address"`
+	GRPCAddress       entity.NetAddress    `env:"GRPC_ADDRESS" json:"grpc_address"`
+	StoreInterval     time.Duration        `env:"STORE_INTERVAL" json:"store_interval"`
+	StorePath         string               `env:"STORE_FILE" json:"store_file"`
+	RestoreOnStart    bool                 `env:"RESTORE" json:"restore"`
+	Secret            security.Secret      `env:"KEY" json:"key"`
+	PrivateKeyPath    entity.FilePath      `env:"CRYPTO_KEY" json:"crypto_key"`
+	StoreKeyPath      entity.FilePath      `env:"STORE_KEY" json:"store_key"`
+	OldStoreKeys      []entity.FilePath    `env:"STORE_OLD_KEYS" json:"store_old_keys"`
+	TrustedSubnet     *net.IPNet           `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
+	TrustedProxies    *net.IPNet           `env:"TRUSTED_PROXIES" json:"trusted_proxies"`
+	TokensPath        entity.FilePath      `env:"TOKENS_FILE" json:"tokens_file"`
+	PoliciesPath      entity.FilePath      `env:"POLICIES_FILE" json:"policies_file"`
+	RateLimit         float64              `env:"RATE_LIMIT" json:"rate_limit"`
+	RateBurst         int                  `env:"RATE_BURST" json:"rate_burst"`
+	SeriesQuota       int                  `env:"SERIES_QUOTA" json:"series_quota"`
+	SeriesLimit       int                  `env:"SERIES_LIMIT" json:"series_limit"`
+	ClientSeriesLimit int                  `env:"CLIENT_SERIES_LIMIT" json:"client_series_limit"`
+	TenantsLimit      int                  `env:"TENANTS_LIMIT" json:"tenants_limit"`
+	DatabaseURL       security.DatabaseURL `env:"DATABASE_DSN" json:"database_dsn"`
+	Retention         time.Duration        `env:"HISTORY_RETENTION" json:"history_retention"`
+	StatsdAddress     entity.NetAddress    `env:"STATSD_ADDRESS" json:"statsd_address"`
+	StatsdFlush       time.Duration        `env:"STATSD_FLUSH_INTERVAL" json:"statsd_flush_interval"`
+	GraphiteAddress   entity.NetAddress    `env:"GRAPHITE_ADDRESS" json:"graphite_address"`
+	GraphiteMapping   []string             `env:"GRAPHITE_MAPPING" json:"graphite_mapping"`
+	UpstreamAddress   entity.NetAddress    `env:"UPSTREAM_ADDRESS" json:"upstream_address"`
+	UpstreamTransport string               `env:"UPSTREAM_TRANSPORT" json:"upstream_transport"`
+	UpstreamSecret    security.Secret      `env:"UPSTREAM_KEY" json:"upstream_key"`
+	UpstreamToken     security.Secret      `env:"UPSTREAM_TOKEN" json:"upstream_token"`
+	UpstreamKeyPath   entity.FilePath      `env:"UPSTREAM_CRYPTO_KEY" json:"upstream_crypto_key"`
+	UpstreamInterval  time.Duration        `env:"UPSTREAM_INTERVAL" json:"upstream_interval"`
+	UpstreamBuffer    int                  `env:"UPSTREAM_BUFFER_SIZE" json:"upstream_buffer_size"`
+	QueryWindow       time.Duration        `env:"QUERY_WINDOW" json:"query_window"`
+	Rules             []string             `env:"RULES" json:"rules"`
+	RulesInterval     time.Duration        `env:"RULES_INTERVAL" json:"rules_interval"`
+	Alerts            []string             `env:"ALERTS" json:"alerts"`
+	AlertsInterval    time.Duration        `env:"ALERTS_INTERVAL" json:"alerts_interval"`
+	AlertWebhooks     []string             `env:"ALERT_WEBHOOKS" json:"alert_webhooks"`
+	InhibitRules      []string             `env:"INHIBIT_RULES" json:"inhibit_rules"`
+	ClusterSelf       entity.NetAddress    `env:"CLUSTER_SELF" json:"cluster_self"`
+	ClusterNodes      []entity.NetAddress  `env:"CLUSTER_NODES" json:"cluster_nodes"`
+	ClusterToken      security.Secret      `env:"CLUSTER_TOKEN" json:"cluster_token"`
+	PprofAddress      entity.NetAddress    `env:"PPROF_ADDRESS" json:"pprof_address"`
+	ReadOnly          bool                 `env:"READ_ONLY" json:"read_only"`
+	Debug             bool                 `env:"DEBUG" json:"debug"`
 }
 
 func NewServer() *Server {
 	return &Server{
-		Address:        "0.0.0.0:8080",
-		GRPCAddress:    "0.0.0.0:50051",
-		StorePath:      "/tmp/devops-metrics-db.json",
-		StoreInterval:  300 * time.Second,
-		RestoreOnStart: true,
-		Secret:         "",
-		PrivateKeyPath: "",
-		TrustedSubnet:  nil,
-		DatabaseURL:    "",
-		Debug:          false,
-		PprofAddress:   "",
+		Address:           "0.0.0.0:8080",
+		GRPCAddress:       "0.0.0.0:50051",
+		StorePath:         "/tmp/devops-metrics-db.json",
+		StoreInterval:     300 * time.Second,
+		RestoreOnStart:    true,
+		Secret:            "",
+		PrivateKeyPath:    "",
+		StoreKeyPath:      "",
+		OldStoreKeys:      nil,
+		TrustedSubnet:     nil,
+		TrustedProxies:    nil,
+		TokensPath:        "",
+		PoliciesPath:      "",
+		RateLimit:         0,
+		RateBurst:         10,
+		SeriesQuota:       0,
+		SeriesLimit:       0,
+		ClientSeriesLimit: 0,
+		TenantsLimit:      100,
+		DatabaseURL:       "",
+		Retention:         7 * 24 * time.Hour,
+		Debug:             false,
+		StatsdAddress:     "",
+		StatsdFlush:       10 * time.Second,
+		GraphiteAddress:   "",
+		GraphiteMapping:   nil,
+		UpstreamAddress:   "",
+		UpstreamTransport: entity.TransportHTTP,
+		UpstreamSecret:    "",
+		UpstreamToken:     "",
+		UpstreamKeyPath:   "",
+		UpstreamInterval:  10 * time.Second,
+		UpstreamBuffer:    10000,
+		QueryWindow:       time.Hour,
+		Rules:             nil,
+		RulesInterval:     10 * time.Second,
+		Alerts:            nil,
+		AlertsInterval:    10 * time.Second,
+		AlertWebhooks:     nil,
+		InhibitRules:      nil,
+		ClusterSelf:       "",
+		ClusterNodes:      nil,
+		ClusterToken:      "",
+		PprofAddress:      "",
+		ReadOnly:          false,
 	}
 }
 
@@ -94,6 +162,21 @@ func (c *Server) Parse() error {
 		"path to private key (stored in PEM format) to decrypt agent -> server communications",
 	)
 
+	storeKeyPath := c.StoreKeyPath
+	flag.VarP(
+		&storeKeyPath,
+		"store-key",
+		"y",
+		"path to AES key (stored in PEM format) to encrypt metrics stored on disk",
+	)
+
+	oldStoreKeys := flag.StringSliceP(
+		"store-old-keys",
+		"o",
+		nil,
+		"comma separated paths to previously used AES keys to decrypt metrics stored on disk",
+	)
+
 	defaultSubnet := net.IPNet{}
 	if c.TrustedSubnet != nil {
 		defaultSubnet = *c.TrustedSubnet
@@ -106,6 +189,67 @@ func (c *Server) Parse() error {
 		"subnet in CIDR notation, requests from IP address from different subnets will be rejected",
 	)
 
+	defaultProxies := net.IPNet{}
+	if c.TrustedProxies != nil {
+		defaultProxies = *c.TrustedProxies
+	}
+
+	trustedProxies := flag.IPNet(
+		"trusted-proxies",
+		defaultProxies,
+		"subnet in CIDR notation of reverse proxies allowed to pass IP address of the client in X-Real-IP header",
+	)
+
+	tokensPath := c.TokensPath
+	flag.Var(
+		&tokensPath,
+		"tokens-file",
+		"path to file (stored in JSON format) with bearer tokens and their scopes, enables authorization of requests",
+	)
+
+	policiesPath := c.PoliciesPath
+	flag.Var(
+		&policiesPath,
+		"policies-file",
+		"path to file (stored in JSON format) with policies restricting access of tokens to metrics namespaces",
+	)
+
+	rateLimit := flag.Float64(
+		"rate-limit",
+		c.RateLimit,
+		"average count of requests per second allowed for each client (token or IP address), zero value disables limit",
+	)
+
+	rateBurst := flag.Int(
+		"rate-burst",
+		c.RateBurst,
+		"maximal count of requests of each client served in a burst above the rate limit",
+	)
+
+	seriesQuota := flag.Int(
+		"series-quota",
+		c.SeriesQuota,
+		"maximal count of series stored for each tenant, zero value disables quota",
+	)
+
+	seriesLimit := flag.Int(
+		"series-limit",
+		c.SeriesLimit,
+		"maximal count of series stored for all tenants, zero value disables limit",
+	)
+
+	clientSeries := flag.Int(
+		"client-series-limit",
+		c.ClientSeriesLimit,
+		"maximal count of series created by each client (token or IP address), zero value disables limit",
+	)
+
+	tenantsLimit := flag.Int(
+		"tenants-limit",
+		c.TenantsLimit,
+		"maximal count of tenants stored in memory or in files besides the default one, zero value disables limit",
+	)
+
 	databaseURL := flag.StringP(
 		"db-dsn",
 		"d",
@@ -113,6 +257,154 @@ func (c *Server) Parse() error {
 		"full database connection URL",
 	)
 
+	retention := flag.DurationP(
+		"history-retention",
+		"n",
+		c.Retention,
+		"how long history of metrics is kept in the database, zero value disables cleanup",
+	)
+
+	statsdAddress := c.StatsdAddress
+	flag.VarP(
+		&statsdAddress,
+		"statsd-address",
+		"u",
+		"enable StatsD UDP listener on specified address:port",
+	)
+
+	statsdFlush := flag.DurationP(
+		"statsd-flush-interval",
+		"l",
+		c.StatsdFlush,
+		"interval of aggregation of StatsD metrics before recording",
+	)
+
+	graphiteAddress := c.GraphiteAddress
+	flag.VarP(
+		&graphiteAddress,
+		"graphite-address",
+		"b",
+		"enable Graphite plaintext TCP listener on specified address:port",
+	)
+
+	graphiteMapping := flag.StringSliceP(
+		"graphite-mapping",
+		"j",
+		nil,
+		"comma separated rules mapping Graphite paths to metric names, e.g. servers.*.cpu=Cpu$1",
+	)
+
+	upstreamAddress := c.UpstreamAddress
+	flag.VarP(
+		&upstreamAddress,
+		"upstream-address",
+		"w",
+		"enable forwarding of received metrics to upstream collector on specified address:port",
+	)
+
+	upstreamTransport := flag.String(
+		"upstream-transport",
+		c.UpstreamTransport,
+		"transport used to forward metrics to upstream collector, either http or grpc",
+	)
+
+	upstreamSecret := c.UpstreamSecret
+	flag.Var(
+		&upstreamSecret,
+		"upstream-key",
+		"secret key for signature generation of metrics forwarded to upstream collector",
+	)
+
+	upstreamToken := flag.String(
+		"upstream-token",
+		string(c.UpstreamToken),
+		"bearer token to authorize requests to upstream collector",
+	)
+
+	upstreamKeyPath := c.UpstreamKeyPath
+	flag.Var(
+		&upstreamKeyPath,
+		"upstream-crypto-key",
+		"path to public key (stored in PEM format) to encrypt communications with upstream collector",
+	)
+
+	upstreamInterval := flag.Duration(
+		"upstream-interval",
+		c.UpstreamInterval,
+		"interval of forwarding of aggregated metrics to upstream collector",
+	)
+
+	upstreamBuffer := flag.Int(
+		"upstream-buffer-size",
+		c.UpstreamBuffer,
+		"maximal count of metrics buffered while upstream collector is unavailable",
+	)
+
+	queryWindow := flag.Duration(
+		"query-window",
+		c.QueryWindow,
+		"how long recent values of metrics are kept in memory for range queries, zero value disables them",
+	)
+
+	rules := flag.StringSlice(
+		"rules",
+		nil,
+		"comma separated rules calculating gauges from other metrics in the <name> = <expression> form",
+	)
+
+	rulesInterval := flag.Duration(
+		"rules-interval",
+		c.RulesInterval,
+		"interval of evaluation of rules",
+	)
+
+	alerts := flag.StringSlice(
+		"alerts",
+		nil,
+		"comma separated alert rules in the <name> = <expression> <operator> <threshold> [for <duration>] "+
+			"[severity <severity>] form",
+	)
+
+	alertsInterval := flag.Duration(
+		"alerts-interval",
+		c.AlertsInterval,
+		"interval of evaluation of alert rules",
+	)
+
+	alertWebhooks := flag.StringSlice(
+		"alert-webhooks",
+		nil,
+		"comma separated URLs notified about firing and resolved alerts",
+	)
+
+	inhibitRules := flag.StringSlice(
+		"inhibit-rules",
+		nil,
+		"comma separated rules muting alerts while other alerts fire in the <source matchers> => <target matchers> "+
+			"[equal <labels>] form",
+	)
+
+	clusterSelf := c.ClusterSelf
+	flag.VarP(
+		&clusterSelf,
+		"cluster-self",
+		"x",
+		"gRPC address:port of this node as listed in cluster nodes",
+	)
+
+	clusterNodes := flag.StringSliceP(
+		"cluster-nodes",
+		"z",
+		nil,
+		"comma separated gRPC addresses of all nodes of the cluster including this one",
+	)
+
+	clusterToken := flag.String(
+		"cluster-token",
+		string(c.ClusterToken),
+		"bearer token granting read and write scopes to authorize requests proxied to other nodes of the cluster",
+	)
+
 	pprofAddress := c.PprofAddress
 	flag.VarP(
 		&pprofAddress,
@@ -121,6 +413,13 @@ func (c *Server) Parse() error {
 		"enable pprof on specified address:port",
 	)
 
+	readOnly := flag.BoolP(
+		"read-only",
+		"m",
+		c.ReadOnly,
+		"start in maintenance mode rejecting all updates of metrics",
+	)
+
 	debug := flag.BoolP(
 		"debug",
 		"g",
@@ -167,15 +466,123 @@ func (c *Server) Parse() error {
 		case "crypto-key":
 			c.PrivateKeyPath = keyPath
 
+		case "store-key":
+			c.StoreKeyPath = storeKeyPath
+
+		case "store-old-keys":
+			c.OldStoreKeys = make([]entity.FilePath, 0, len(*oldStoreKeys))
+			for _, path := range *oldStoreKeys {
+				c.OldStoreKeys = append(c.OldStoreKeys, entity.FilePath(path))
+			}
+
 		case "trusted-subnet":
 			c.TrustedSubnet = trustedSubnet
 
+		case "trusted-proxies":
+			c.TrustedProxies = trustedProxies
+
+		case "tokens-file":
+			c.TokensPath = tokensPath
+
+		case "policies-file":
+			c.PoliciesPath = policiesPath
+
+		case "rate-limit":
+			c.RateLimit = *rateLimit
+
+		case "rate-burst":
+			c.RateBurst = *rateBurst
+
+		case "series-quota":
+			c.SeriesQuota = *seriesQuota
+
+		case "series-limit":
+			c.SeriesLimit = *seriesLimit
+
+		case "client-series-limit":
+			c.ClientSeriesLimit = *clientSeries
+
+		case "tenants-limit":
+			c.TenantsLimit = *tenantsLimit
+
 		case "db-dsn":
 			c.DatabaseURL = security.DatabaseURL(*databaseURL)
 
+		case "history-retention":
+			c.Retention = *retention
+
+		case "statsd-address":
+			c.StatsdAddress = statsdAddress
+
+		case "statsd-flush-interval":
+			c.StatsdFlush = *statsdFlush
+
+		case "graphite-address":
+			c.GraphiteAddress = graphiteAddress
+
+		case "graphite-mapping":
+			c.GraphiteMapping = *graphiteMapping
+
+		case "upstream-address":
+			c.UpstreamAddress = upstreamAddress
+
+		case "upstream-transport":
+			c.UpstreamTransport = *upstreamTransport
+
+		case "upstream-key":
+			c.UpstreamSecret = upstreamSecret
+
+		case "upstream-token":
+			c.UpstreamToken = security.Secret(*upstreamToken)
+
+		case "upstream-crypto-key":
+			c.UpstreamKeyPath = upstreamKeyPath
+
+		case "upstream-interval":
+			c.UpstreamInterval = *upstreamInterval
+
+		case "upstream-buffer-size":
+			c.UpstreamBuffer = *upstreamBuffer
+
+		case "query-window":
+			c.QueryWindow = *queryWindow
+
+		case "rules":
+			c.Rules = *rules
+
+		case "rules-interval":
+			c.RulesInterval = *rulesInterval
+
+		case "alerts":
+			c.Alerts = *alerts
+
+		case "alerts-interval":
+			c.AlertsInterval = *alertsInterval
+
+		case "alert-webhooks":
+			c.AlertWebhooks = *alertWebhooks
+
+		case "inhibit-rules":
+			c.InhibitRules = *inhibitRules
+
+		case "cluster-self":
+			c.ClusterSelf = clusterSelf
+
+		case "cluster-nodes":
+			c.ClusterNodes = make([]entity.NetAddress, 0, len(*clusterNodes))
+			for _, node := range *clusterNodes {
+				c.ClusterNodes = append(c.ClusterNodes, entity.NetAddress(node))
+			}
+
+		case "cluster-token":
+			c.ClusterToken = security.Secret(*clusterToken)
+
 		case "pprof-address":
 			c.PprofAddress = pprofAddress
 
+		case "read-only":
+			c.ReadOnly = *readOnly
+
 		case "debug":
 			c.Debug = *debug
 		}
@@ -189,9 +596,59 @@ func (c *Server) Parse() error {
 		return entity.ErrRestoreNoSource
 	}
 
+	if len(c.StoreKeyPath) == 0 && len(c.OldStoreKeys) != 0 {
+		return entity.ErrNoActiveStoreKey
+	}
+
+	if len(c.PoliciesPath) != 0 && len(c.TokensPath) == 0 {
+		return entity.ErrNoTokensForPolicies
+	}
+
+	if c.RateLimit < 0 || c.RateBurst < 0 {
+		return entity.ErrInvalidRateLimit
+	}
+
+	if c.SeriesQuota < 0 || c.SeriesLimit < 0 || c.ClientSeriesLimit < 0 {
+		return entity.ErrInvalidSeriesQuota
+	}
+
+	if c.TenantsLimit < 0 {
+		return entity.ErrInvalidTenantsLimit
+	}
+
+	if len(c.StatsdAddress) != 0 && c.StatsdFlush <= 0 {
+		return entity.ErrInvalidStatsdFlush
+	}
+
+	if len(c.UpstreamAddress) != 0 && (c.UpstreamInterval <= 0 || c.UpstreamBuffer <= 0) {
+		return entity.ErrInvalidUpstreamSettings
+	}
+
+	if len(c.Rules) != 0 && c.RulesInterval <= 0 {
+		return entity.ErrInvalidRulesInterval
+	}
+
+	if len(c.Alerts) != 0 && c.AlertsInterval <= 0 {
+		return entity.ErrInvalidAlertsInterval
+	}
+
+	if len(c.ClusterNodes) != 0 && !c.hasClusterNode(c.ClusterSelf) {
+		return entity.ErrInvalidClusterSettings
+	}
+
 	return nil
 }
 
+func (c Server) hasClusterNode(address entity.NetAddress) bool {
+	for _, node := range c.ClusterNodes {
+		if node == address {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c Server) String() string {
 	var sb strings.Builder
 
@@ -211,18 +668,123 @@ func (c Server) String() string {
 		sb.WriteString(fmt.Sprintf("\t\tPrivate key path: %s\n", c.PrivateKeyPath))
 	}
 
+	if len(c.StoreKeyPath) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tStore key path: %s\n", c.StoreKeyPath))
+	}
+
+	if len(c.OldStoreKeys) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tOld store key paths: %s\n", c.OldStoreKeys))
+	}
+
 	if c.TrustedSubnet != nil {
 		sb.WriteString(fmt.Sprintf("\t\tTrusted subnet: %s\n", c.TrustedSubnet.String()))
 	}
 
+	if c.TrustedProxies != nil {
+		sb.WriteString(fmt.Sprintf("\t\tTrusted proxies: %s\n", c.TrustedProxies.String()))
+	}
+
+	if len(c.TokensPath) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tTokens path: %s\n", c.TokensPath))
+	}
+
+	if len(c.PoliciesPath) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tPolicies path: %s\n", c.PoliciesPath))
+	}
+
+	if c.RateLimit > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tRate limit: %g\n", c.RateLimit))
+		sb.WriteString(fmt.Sprintf("\t\tRate burst: %d\n", c.RateBurst))
+	}
+
+	if c.SeriesQuota > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tSeries quota: %d\n", c.SeriesQuota))
+	}
+
+	if c.SeriesLimit > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tSeries limit: %d\n", c.SeriesLimit))
+	}
+
+	if c.ClientSeriesLimit > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tClient series limit: %d\n", c.ClientSeriesLimit))
+	}
+
+	if c.TenantsLimit > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tTenants limit: %d\n", c.TenantsLimit))
+	}
+
 	if len(c.DatabaseURL) > 0 {
 		sb.WriteString(fmt.Sprintf("\t\tDatabase URL: %s\n", c.DatabaseURL))
+		sb.WriteString(fmt.Sprintf("\t\tHistory retention: %s\n", c.Retention))
+	}
+
+	if len(c.StatsdAddress) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tStatsD address: %s\n", c.StatsdAddress))
+		sb.WriteString(fmt.Sprintf("\t\tStatsD flush interval: %s\n", c.StatsdFlush))
+	}
+
+	if len(c.GraphiteAddress) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tGraphite address: %s\n", c.GraphiteAddress))
+	}
+
+	if len(c.GraphiteMapping) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tGraphite mapping: %s\n", c.GraphiteMapping))
+	}
+
+	if len(c.UpstreamAddress) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tUpstream address: %s\n", c.UpstreamAddress))
+		sb.WriteString(fmt.Sprintf("\t\tUpstream transport: %s\n", c.UpstreamTransport))
+
+		if len(c.UpstreamSecret) > 0 {
+			sb.WriteString(fmt.Sprintf("\t\tUpstream secret key: %s\n", c.UpstreamSecret))
+		}
+
+		if len(c.UpstreamToken) > 0 {
+			sb.WriteString(fmt.Sprintf("\t\tUpstream token: %s\n", c.UpstreamToken))
+		}
+
+		if len(c.UpstreamKeyPath) > 0 {
+			sb.WriteString(fmt.Sprintf("\t\tUpstream public key path: %s\n", c.UpstreamKeyPath))
+		}
+
+		sb.WriteString(fmt.Sprintf("\t\tUpstream interval: %s\n", c.UpstreamInterval))
+		sb.WriteString(fmt.Sprintf("\t\tUpstream buffer size: %d\n", c.UpstreamBuffer))
+	}
+
+	sb.WriteString(fmt.Sprintf("\t\tQuery window: %s\n", c.QueryWindow))
+
+	if len(c.Rules) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tRules: %s\n", c.Rules))
+		sb.WriteString(fmt.Sprintf("\t\tRules interval: %s\n", c.RulesInterval))
+	}
+
+	if len(c.Alerts) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tAlerts: %s\n", c.Alerts))
+		sb.WriteString(fmt.Sprintf("\t\tAlerts interval: %s\n", c.AlertsInterval))
+	}
+
+	if len(c.AlertWebhooks) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tAlert webhooks: %s\n", c.AlertWebhooks))
+	}
+
+	if len(c.InhibitRules) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tInhibit rules: %s\n", c.InhibitRules))
+	}
+
+	if len(c.ClusterNodes) > 0 {
+		sb.WriteString(fmt.Sprintf("\t\tCluster self address: %s\n", c.ClusterSelf))
+		sb.WriteString(fmt.Sprintf("\t\tCluster nodes: %s\n", c.ClusterNodes))
+
+		if len(c.ClusterToken) > 0 {
+			sb.WriteString(fmt.Sprintf("\t\tCluster token: %s\n", c.ClusterToken))
+		}
 	}
 
 	if len(c.PprofAddress) > 0 {
 		sb.WriteString(fmt.Sprintf("\t\tPprof address: %s\n", c.PprofAddress))
 	}
 
+	sb.WriteString(fmt.Sprintf("\t\tRead-only: %t\n", c.ReadOnly))
 	sb.WriteString(fmt.Sprintf("\t\tDebug: %t\n", c.Debug))
 
 	return sb.String()
@@ -232,8 +794,15 @@ func (c *Server) UnmarshalJSON(data []byte) error {
 	type Alias Server
 
 	aux := &struct {
-		StoreInterval string `json:"store_interval"`
-		TrustedSubnet string `json:"trusted_subnet"`
+		StoreInterval    string `json:"store_interval"`
+		Retention        string `json:"history_retention"`
+		StatsdFlush      string `json:"statsd_flush_interval"`
+		UpstreamInterval string `json:"upstream_interval"`
+		QueryWindow      string `json:"query_window"`
+		RulesInterval    string `json:"rules_interval"`
+		AlertsInterval   string `json:"alerts_interval"`
+		TrustedSubnet    string `json:"trusted_subnet"`
+		TrustedProxies   string `json:"trusted_proxies"`
 		*Alias
 	}{
 		Alias: (*Alias)(c),
@@ -252,6 +821,48 @@ func (c *Server) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	if len(aux.Retention) != 0 {
+		c.Retention, err = time.ParseDuration(aux.Retention)
+		if err != nil {
+			return fmt.Errorf("server - UnmarshalJSON - time.ParseDuration: %w", err)
+		}
+	}
+
+	if len(aux.StatsdFlush) != 0 {
+		c.StatsdFlush, err = time.ParseDuration(aux.StatsdFlush)
+		if err != nil {
+			return fmt.Errorf("server - UnmarshalJSON - time.ParseDuration: %w", err)
+		}
+	}
+
+	if len(aux.UpstreamInterval) != 0 {
+		c.UpstreamInterval, err = time.ParseDuration(aux.UpstreamInterval)
+		if err != nil {
+			return fmt.Errorf("server - UnmarshalJSON - time.ParseDuration: %w", err)
+		}
+	}
+
+	if len(aux.QueryWindow) != 0 {
+		c.QueryWindow, err = time.ParseDuration(aux.QueryWindow)
+		if err != nil {
+			return fmt.Errorf("server - UnmarshalJSON - time.ParseDuration: %w", err)
+		}
+	}
+
+	if len(aux.RulesInterval) != 0 {
+		c.RulesInterval, err = time.ParseDuration(aux.RulesInterval)
+		if err != nil {
+			return fmt.Errorf("server - UnmarshalJSON - time.ParseDuration: %w", err)
+		}
+	}
+
+	if len(aux.AlertsInterval) != 0 {
+		c.AlertsInterval, err = time.ParseDuration(aux.AlertsInterval)
+		if err != nil {
+			return fmt.Errorf("server - UnmarshalJSON - time.ParseDuration: %w", err)
+		}
+	}
+
 	if len(aux.TrustedSubnet) != 0 {
 		_, c.TrustedSubnet, err = net.ParseCIDR(aux.TrustedSubnet)
 		if err != nil {
@@ -259,5 +870,12 @@ func (c *Server) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	if len(aux.TrustedProxies) != 0 {
+		_, c.TrustedProxies, err = net.ParseCIDR(aux.TrustedProxies)
+		if err != nil {
+			return fmt.Errorf("server - UnmarshalJSON - net.ParseCIDR: %w", err)
+		}
+	}
+
 	return nil
 }
@@ -7,23 +7,68 @@ import (
 )
 
 var (
+	ErrAccessDenied            = errors.New("access to metric denied")
 	ErrBadAddressFormat        = errors.New("expected address in host:port form")
 	ErrBadKeyFile              = errors.New("provided file doesn't contain key in the PEM format")
 	ErrEncodingNotSupported    = errors.New("encoding type not supported")
+	ErrFractionalDelta         = errors.New("delta sum must be integral to be recorded as counter")
 	ErrHTTP                    = errors.New("HTTP request failed")
 	ErrHealthCheckNotSupported = errors.New("storage doesn't support healthcheck")
 	ErrIncompleteRequest       = errors.New("metrics value not set")
+	ErrInsufficientScope       = errors.New("token doesn't grant required scope")
+	ErrInvalidAlertRule        = errors.New("invalid alert rule")
+	ErrInvalidAlertsInterval   = errors.New("alerts evaluation interval must be positive")
+	ErrInvalidClusterSettings  = errors.New("cluster nodes must include address of this node")
+	ErrInvalidCursor           = errors.New("invalid pagination cursor")
+	ErrInvalidGraphiteMapping  = errors.New("invalid Graphite mapping rule")
+	ErrInvalidInhibitRule      = errors.New("invalid inhibition rule")
+	ErrInvalidMatcher          = errors.New("expected matcher in <label>=<glob> form")
+	ErrInvalidPageSize         = errors.New("page size is out of range")
+	ErrInvalidPattern          = errors.New("invalid metric name pattern")
+	ErrInvalidPoliciesFile     = errors.New("invalid access policies file")
+	ErrInvalidQuery            = errors.New("invalid query expression")
+	ErrInvalidRateLimit        = errors.New("rate limit and burst must not be negative")
+	ErrInvalidRule             = errors.New("invalid rule")
+	ErrInvalidRulesInterval    = errors.New("rules evaluation interval must be positive")
+	ErrInvalidSeriesQuota      = errors.New("series quota and limits must not be negative")
 	ErrInvalidSignature        = errors.New("invalid signature")
+	ErrInvalidSilence          = errors.New("invalid silence")
+	ErrInvalidTenant           = errors.New("tenant name contains invalid characters or is too long")
+	ErrInvalidTenantsLimit     = errors.New("limit of tenants must not be negative")
+	ErrInvalidToken            = errors.New("authorization token is not valid")
+	ErrInvalidTokensFile       = errors.New("invalid tokens file")
+	ErrInvalidStatsdFlush      = errors.New("StatsD flush interval must be positive")
+	ErrInvalidUpstreamSettings = errors.New("upstream forwarding interval and buffer size must be positive")
+	ErrInvalidWebhook          = errors.New("webhook must be absolute HTTP(S) URL")
+	ErrMaintenance             = errors.New("service is in maintenance mode, updates are not accepted")
+	ErrMalformedGraphiteLine   = errors.New("malformed Graphite line")
+	ErrMalformedInfluxLine     = errors.New("malformed InfluxDB line")
+	ErrMalformedSnapshot       = errors.New("encrypted snapshot is malformed")
+	ErrMalformedStatsdLine     = errors.New("malformed StatsD line")
 	ErrMetricInvalidName       = errors.New("metric name contains invalid characters")
 	ErrMetricLongName          = errors.New("metric name is too long")
 	ErrMetricNotFound          = errors.New("metric not found")
 	ErrMetricNotImplemented    = errors.New("metric kind not supported")
+	ErrMissingToken            = errors.New("authorization token not provided")
+	ErrNoActiveStoreKey        = errors.New("old store keys were provided, but active store key is not set")
+	ErrNoTokensForPolicies     = errors.New("access policies were provided, but tokens file is not set")
 	ErrNotSigned               = errors.New("request not signed")
 	ErrNotSupportedKey         = errors.New("provided key type is not supported")
+	ErrRateLimited             = errors.New("too many requests")
 	ErrRecordKindDontMatch     = errors.New("kind of recorded metric doesn't match request")
+	ErrRequestRejected         = errors.New("request rejected by server")
 	ErrRestoreNoSource         = errors.New("state restoration was requested, but path to store file is not set")
+	ErrSeriesLimitExceeded     = errors.New("limit of series exceeded")
+	ErrSeriesQuotaExceeded     = errors.New("series quota of tenant exceeded")
+	ErrServiceUnavailable      = errors.New("service is temporarily unavailable")
+	ErrSilenceNotFound         = errors.New("silence not found")
+	ErrTenantNotAllowed        = errors.New("token doesn't grant access to requested tenant")
+	ErrTenantNotForwarded      = errors.New("metrics of tenants are not forwarded to upstream")
+	ErrTenantsLimitExceeded    = errors.New("limit of tenants exceeded")
 	ErrTransportNotSupported   = errors.New("transport type not supported")
 	ErrUnexpected              = errors.New("unexpected error")
+	ErrUnknownMethod           = errors.New("access scope of method is not defined")
+	ErrUnknownStoreKey         = errors.New("snapshot was encrypted with unknown key")
 	ErrUntrustedSource         = errors.New("request's source is not trusted")
 )
 
@@ -46,3 +91,35 @@ func UntrustedSourceError(src net.IP) error {
 func TransportNotSupportedError(name string) error {
 	return fmt.Errorf("%w (%s)", ErrTransportNotSupported, name)
 }
+
+func InvalidQueryError(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQuery, reason)
+}
+
+func InsufficientScopeError(scope string) error {
+	return fmt.Errorf("%w (%s)", ErrInsufficientScope, scope)
+}
+
+func AccessDeniedError(verb, name string) error {
+	return fmt.Errorf("%w (%s %s)", ErrAccessDenied, verb, name)
+}
+
+func RateLimitedError(client string) error {
+	return fmt.Errorf("%w (%s)", ErrRateLimited, client)
+}
+
+func SeriesQuotaExceededError(tenant string, quota int) error {
+	return fmt.Errorf("%w (%q: %d series)", ErrSeriesQuotaExceeded, tenant, quota)
+}
+
+func SeriesLimitExceededError(scope string, limit int) error {
+	return fmt.Errorf("%w: %s (%d series)", ErrSeriesLimitExceeded, scope, limit)
+}
+
+func TenantsLimitExceededError(limit int) error {
+	return fmt.Errorf("%w (%d tenants)", ErrTenantsLimitExceeded, limit)
+}
+
+func RequestRejectedError(reason error) error {
+	return fmt.Errorf("%w: %s", ErrRequestRejected, reason)
+}
@@ -0,0 +1,10 @@
+package entity
+
+const (
+	// DefaultPageSize is count of records returned on single page
+	// if client didn't request particular size.
+	DefaultPageSize = 100
+
+	// MaxPageSize is maximal count of records returned on single page.
+	MaxPageSize = 1000
+)
@@ -0,0 +1,21 @@
+package entity
+
+import "context"
+
+// DefaultTenant owns metrics of requests which didn't select a tenant,
+// as well as metrics received from StatsD, Graphite and recording rules.
+const DefaultTenant = ""
+
+type tenantKey struct{}
+
+// WithTenant returns copy of the context carrying the tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns tenant selected by the request, DefaultTenant if none.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+
+	return tenant
+}
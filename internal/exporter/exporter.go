@@ -4,6 +4,7 @@ package exporter
 
 import (
 	"context"
+	"time"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/alkurbatov/metrics-collector/internal/security"
@@ -16,6 +17,7 @@ type Exporter interface {
 	Add(name string, value metrics.Metric) Exporter
 	Send(ctx context.Context) Exporter
 	Error() error
+	RetryAfter() time.Duration
 	Reset()
 	Close() error
 }
@@ -25,17 +27,18 @@ func New(
 	transport string,
 	collectorAddress entity.NetAddress,
 	secret security.Secret,
+	token security.Secret,
 	publicKey security.PublicKey,
 ) Exporter {
 	switch transport {
 	case entity.TransportHTTP:
-		return NewHTTPExporter(collectorAddress, secret, publicKey)
+		return NewHTTPExporter(collectorAddress, secret, token, publicKey)
 
 	case entity.TransportGRPC:
-		return NewGRPCExporter(collectorAddress, secret)
+		return NewGRPCExporter(collectorAddress, secret, token)
 
 	default:
 		log.Warn().Msgf("Unknown transport type %s provided, fallback to %s", transport, entity.TransportHTTP)
-		return NewHTTPExporter(collectorAddress, secret, publicKey)
+		return NewHTTPExporter(collectorAddress, secret, token, publicKey)
 	}
 }
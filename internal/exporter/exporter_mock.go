@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ Exporter = (*ExporterMock)(nil)
+
+type ExporterMock struct {
+	mock.Mock
+}
+
+func (m *ExporterMock) Add(name string, value metrics.Metric) Exporter {
+	m.Called(name, value)
+	return m
+}
+
+func (m *ExporterMock) Send(ctx context.Context) Exporter {
+	m.Called(ctx)
+	return m
+}
+
+func (m *ExporterMock) Error() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *ExporterMock) RetryAfter() time.Duration {
+	args := m.Called()
+	return args.Get(0).(time.Duration)
+}
+
+func (m *ExporterMock) Reset() {
+	m.Called()
+}
+
+func (m *ExporterMock) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
@@ -2,39 +2,58 @@ package exporter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/alkurbatov/metrics-collector/internal/security"
 	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
 	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-// GRPCExporter sends collected metrics to metrics collector in single batch request.
+// GRPCExporter pushes collected metrics to metrics collector over stream, one metric per message,
+// as soon as they are added. Send closes the stream and waits for summary confirming
+// that the metrics were recorded.
 type GRPCExporter struct {
 	// Address and port of server providing gRPC API.
 	endpoint entity.NetAddress
 
 	conn *grpc.ClientConn
 
+	// Stream to push metrics, opened on the first added metric.
+	stream grpcapi.Metrics_StreamUpdateClient
+
+	// Cancels context of the stream.
+	cancel context.CancelFunc
+
 	// Entity to sign requests.
 	// If set to nil, requests will not be signed.
 	signer *security.Signer
 
-	// Internal buffer to store requests.
-	buffer []*grpcapi.MetricReq
+	// Bearer token to authorize requests.
+	// If empty, requests are sent without authorization.
+	token security.Secret
 
 	// Error happened during one of previous method calls.
 	// If at least one error occurred, further calls are noop.
 	err error
+
+	// Delay requested by metrics collector after failed Send.
+	retryAfter time.Duration
 }
 
 func NewGRPCExporter(
 	endpoint entity.NetAddress,
 	secret security.Secret,
+	token security.Secret,
 ) *GRPCExporter {
 	var signer *security.Signer
 	if len(secret) > 0 {
@@ -44,10 +63,11 @@ func NewGRPCExporter(
 	return &GRPCExporter{
 		endpoint: endpoint,
 		signer:   signer,
+		token:    token,
 	}
 }
 
-// Add a metric to internal buffer.
+// Add pushes a metric over the stream, the stream is opened if needed.
 func (g *GRPCExporter) Add(name string, value metrics.Metric) Exporter {
 	if g.err != nil {
 		return g
@@ -76,7 +96,16 @@ func (g *GRPCExporter) Add(name string, value metrics.Metric) Exporter {
 		req.Hash = hash
 	}
 
-	g.buffer = append(g.buffer, req)
+	if g.stream == nil {
+		if g.err = g.openStream(); g.err != nil {
+			return g
+		}
+	}
+
+	// If the stream was closed by server, actual reason of failure is returned by CloseAndRecv.
+	if err := g.stream.Send(req); err != nil && !errors.Is(err, io.EOF) {
+		g.err = toExportError(err, nil)
+	}
 
 	return g
 }
@@ -89,53 +118,168 @@ func (g *GRPCExporter) Error() error {
 	return fmt.Errorf("metrics export failed: %w", g.err)
 }
 
-// Send metrics stored in internal buffer to metrics collector in single batch request.
-func (g *GRPCExporter) Send(ctx context.Context) Exporter {
-	if g.err != nil {
-		return g
+// toExportError converts gRPC status to export error.
+// Throttled requests are reported with retry-after header, unlike requests exceeding quota.
+func toExportError(err error, header metadata.MD) error {
+	st := status.Convert(err)
+
+	switch st.Code() {
+	case codes.Unavailable:
+		return fmt.Errorf("%w: %s", entity.ErrServiceUnavailable, st.Message())
+
+	case codes.ResourceExhausted:
+		if len(header.Get(security.RetryAfterKey)) != 0 {
+			return fmt.Errorf("%w: %s", entity.ErrServiceUnavailable, st.Message())
+		}
+
+		return entity.RequestRejectedError(err)
+
+	// Invalid, unauthorized or not supported requests are rejected on retry as well.
+	case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied, codes.Unimplemented:
+		return entity.RequestRejectedError(err)
 	}
 
+	return err
+}
+
+// openStream connects to metrics collector and opens new stream to push metrics.
+func (g *GRPCExporter) openStream() error {
 	if g.conn == nil {
-		g.conn, g.err = grpc.DialContext(
-			ctx,
+		conn, err := grpc.Dial(
 			g.endpoint.String(),
 			grpc.WithTransportCredentials(insecure.NewCredentials()),
 		)
-		if g.err != nil {
-			return g
+		if err != nil {
+			return err
 		}
-	}
 
-	if len(g.buffer) == 0 {
-		g.err = entity.ErrIncompleteRequest
-		return g
+		g.conn = conn
 	}
 
 	clientIP, err := getOutboundIP()
 	if err != nil {
-		g.err = err
-		return g
+		return err
 	}
 
+	// Metrics are pushed before Send is called, thus the stream can't use context of the send.
 	md := metadata.New(map[string]string{"x-real-ip": clientIP.String()})
-	ctx = metadata.NewOutgoingContext(ctx, md)
+	if len(g.token) != 0 {
+		md.Set("authorization", security.BearerToken(g.token))
+	}
+
+	streamCtx, cancel := context.WithCancel(metadata.NewOutgoingContext(context.Background(), md))
+
 	client := grpcapi.NewMetricsClient(g.conn)
 
-	req := &grpcapi.BatchUpdateRequest{Data: g.buffer}
-	_, g.err = client.BatchUpdate(ctx, req)
+	stream, err := client.StreamUpdate(streamCtx)
+	if err != nil {
+		cancel()
+		return toExportError(err, nil)
+	}
+
+	g.stream = stream
+	g.cancel = cancel
+
+	return nil
+}
+
+// closeStream cancels the stream, so new one is opened for next metrics.
+// Metrics pushed over cancelled stream are not recorded by metrics collector.
+func (g *GRPCExporter) closeStream() {
+	g.cancel()
+	g.stream = nil
+	g.cancel = nil
+}
+
+// streamResult is outcome of the finished stream.
+type streamResult struct {
+	err error
+
+	// Headers sent by metrics collector.
+	header metadata.MD
+}
+
+// awaitSummary closes sending side of the stream and waits for summary of pushed metrics.
+// Returned headers are set only if the stream failed.
+func (g *GRPCExporter) awaitSummary(ctx context.Context) (metadata.MD, error) {
+	results := make(chan streamResult, 1)
+
+	// CloseAndRecv doesn't accept context, on timeout it is interrupted by cancellation of the stream.
+	go func(stream grpcapi.Metrics_StreamUpdateClient) {
+		summary, err := stream.CloseAndRecv()
+		if err == nil && summary.Rejected > 0 {
+			log.Warn().Int64("count", summary.Rejected).Msg("Metrics with invalid data rejected by server")
+		}
+
+		if err != nil {
+			// Headers are available, as the stream is finished.
+			header, _ := stream.Header()
+			results <- streamResult{err: toExportError(err, header), header: header}
+
+			return
+		}
+
+		results <- streamResult{}
+	}(g.stream)
+
+	select {
+	case result := <-results:
+		return result.header, result.err
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Send finishes the stream of pushed metrics.
+// Send succeeds only after metrics collector confirmed that the metrics were recorded.
+func (g *GRPCExporter) Send(ctx context.Context) Exporter {
+	if g.err != nil {
+		return g
+	}
+
+	if g.stream == nil {
+		g.err = entity.ErrIncompleteRequest
+		return g
+	}
+
+	defer g.closeStream()
+
+	header, err := g.awaitSummary(ctx)
+	if err != nil {
+		g.err = err
+
+		if values := header.Get(security.RetryAfterKey); len(values) != 0 {
+			g.retryAfter = parseRetryAfter(values[0])
+		}
+	}
 
 	return g
 }
 
+// RetryAfter returns delay requested by metrics collector after failed Send, zero if not requested.
+func (g *GRPCExporter) RetryAfter() time.Duration {
+	return g.retryAfter
+}
+
 // Reset reset state of exporter to initial.
 // This doesn't affected the underlying connection.
 func (g *GRPCExporter) Reset() {
-	g.buffer = make([]*grpcapi.MetricReq, 0)
+	// Metrics pushed over unfinished stream are discarded by metrics collector.
+	if g.stream != nil {
+		g.closeStream()
+	}
+
 	g.err = nil
+	g.retryAfter = 0
 }
 
-// Close gracefully finishes gRPC client connection.
+// Close discards unfinished stream and closes gRPC client connection.
 func (g *GRPCExporter) Close() error {
+	if g.stream != nil {
+		g.closeStream()
+	}
+
 	if g.conn == nil {
 		return nil
 	}
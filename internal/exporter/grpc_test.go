@@ -0,0 +1,217 @@
+package exporter_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/exporter"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// streamServer records metrics received over each finished stream.
+type streamServer struct {
+	grpcapi.UnimplementedMetricsServer
+
+	// Error returned instead of summary, if set.
+	err error
+
+	// Header sent along with the error.
+	header metadata.MD
+
+	mu      sync.Mutex
+	streams [][]*grpcapi.MetricReq
+}
+
+func (s *streamServer) StreamUpdate(stream grpcapi.Metrics_StreamUpdateServer) error {
+	received := make([]*grpcapi.MetricReq, 0)
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		received = append(received, req)
+	}
+
+	if s.err != nil {
+		_ = stream.SetHeader(s.header)
+		return s.err
+	}
+
+	s.mu.Lock()
+	s.streams = append(s.streams, received)
+	s.mu.Unlock()
+
+	return stream.SendAndClose(&grpcapi.StreamSummary{Recorded: int64(len(received))})
+}
+
+func (s *streamServer) received() [][]*grpcapi.MetricReq {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.streams
+}
+
+func startStreamServer(t *testing.T, srv *streamServer) entity.NetAddress {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	grpcapi.RegisterMetricsServer(server, srv)
+
+	go func() {
+		_ = server.Serve(lis)
+	}()
+
+	t.Cleanup(server.Stop)
+
+	return entity.NetAddress(lis.Addr().String())
+}
+
+func TestGRPCExporterPushesMetricsOverStream(t *testing.T) {
+	require := require.New(t)
+
+	srv := new(streamServer)
+	exp := exporter.NewGRPCExporter(startStreamServer(t, srv), "", "")
+	t.Cleanup(func() { require.NoError(exp.Close()) })
+
+	for i := 0; i < 2; i++ {
+		err := exp.
+			Add("PollCount", metrics.Counter(10)).
+			Add("Alloc", metrics.Gauge(11.23)).
+			Send(context.Background()).
+			Error()
+		require.NoError(err)
+
+		exp.Reset()
+	}
+
+	expected := []*grpcapi.MetricReq{
+		grpcapi.NewUpdateCounterReq("PollCount", 10),
+		grpcapi.NewUpdateGaugeReq("Alloc", 11.23),
+	}
+
+	streams := srv.received()
+	require.Len(streams, 2)
+
+	for _, received := range streams {
+		require.Len(received, len(expected))
+
+		for i := range expected {
+			require.Equal(expected[i].Id, received[i].Id)
+			require.Equal(expected[i].Mtype, received[i].Mtype)
+			require.Equal(expected[i].Delta, received[i].Delta)
+			require.Equal(expected[i].Value, received[i].Value)
+		}
+	}
+}
+
+func TestGRPCExporterFailures(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		header   metadata.MD
+		expected error
+		rejected bool
+		delay    time.Duration
+	}{
+		{
+			name:     "Should report unavailable server",
+			err:      status.Error(codes.Unavailable, "maintenance"),
+			expected: entity.ErrServiceUnavailable,
+		},
+		{
+			name:     "Should report unavailable server with retry delay",
+			err:      status.Error(codes.Unavailable, "maintenance"),
+			header:   metadata.Pairs(security.RetryAfterKey, "60"),
+			expected: entity.ErrServiceUnavailable,
+			delay:    time.Minute,
+		},
+		{
+			name:     "Should report throttled request as temporary unavailability",
+			err:      status.Error(codes.ResourceExhausted, "rate limit"),
+			header:   metadata.Pairs(security.RetryAfterKey, "1"),
+			expected: entity.ErrServiceUnavailable,
+			delay:    time.Second,
+		},
+		{
+			name:     "Should report exceeded quota as rejection",
+			err:      status.Error(codes.ResourceExhausted, "quota"),
+			expected: entity.ErrRequestRejected,
+			rejected: true,
+		},
+		{
+			name:     "Should report denied access as rejection",
+			err:      status.Error(codes.PermissionDenied, "access denied"),
+			expected: entity.ErrRequestRejected,
+			rejected: true,
+		},
+		{
+			name:     "Should pass internal error as is",
+			err:      status.Error(codes.Internal, "failure"),
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &streamServer{err: tc.err, header: tc.header}
+			exp := exporter.NewGRPCExporter(startStreamServer(t, srv), "", "")
+			t.Cleanup(func() { _ = exp.Close() })
+
+			err := exp.Add("PollCount", metrics.Counter(10)).Send(context.Background()).Error()
+			require.Error(t, err)
+			require.Equal(t, tc.rejected, errors.Is(err, entity.ErrRequestRejected))
+			require.Equal(t, tc.delay, exp.RetryAfter())
+
+			if tc.expected != nil {
+				require.ErrorIs(t, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestGRPCExporterResetDiscardsUnfinishedStream(t *testing.T) {
+	require := require.New(t)
+
+	srv := new(streamServer)
+	exp := exporter.NewGRPCExporter(startStreamServer(t, srv), "", "")
+	t.Cleanup(func() { require.NoError(exp.Close()) })
+
+	require.NoError(exp.Add("PollCount", metrics.Counter(10)).Error())
+	exp.Reset()
+
+	require.NoError(exp.Add("Alloc", metrics.Gauge(11.23)).Send(context.Background()).Error())
+
+	streams := srv.received()
+	require.Len(streams, 1)
+	require.Len(streams[0], 1)
+	require.Equal("Alloc", streams[0][0].Id)
+}
+
+func TestGRPCExporterSendWithoutMetrics(t *testing.T) {
+	exp := exporter.NewGRPCExporter(startStreamServer(t, new(streamServer)), "", "")
+	t.Cleanup(func() { _ = exp.Close() })
+
+	require.ErrorIs(t, exp.Send(context.Background()).Error(), entity.ErrIncompleteRequest)
+}
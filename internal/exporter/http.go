@@ -25,6 +25,10 @@ type HTTPExporter struct {
 	// If set to nil, requests will not be signed.
 	signer *security.Signer
 
+	// Bearer token to authorize requests.
+	// If empty, requests are sent without authorization.
+	token security.Secret
+
 	// Internal buffer to store requests.
 	buffer []metrics.MetricReq
 
@@ -35,11 +39,15 @@ type HTTPExporter struct {
 	// Error happened during one of previous method calls.
 	// If at least one error occurred, further calls are noop.
 	err error
+
+	// Delay requested by metrics collector after failed request.
+	retryAfter time.Duration
 }
 
 func NewHTTPExporter(
 	collectorAddress entity.NetAddress,
 	secret security.Secret,
+	token security.Secret,
 	publicKey security.PublicKey,
 ) *HTTPExporter {
 	baseURL := "http://" + collectorAddress.String()
@@ -56,6 +64,7 @@ func NewHTTPExporter(
 		baseURL:   baseURL,
 		client:    client,
 		signer:    signer,
+		token:     token,
 		buffer:    make([]metrics.MetricReq, 0),
 		publicKey: publicKey,
 		err:       nil,
@@ -137,6 +146,10 @@ func (h *HTTPExporter) doSend(ctx context.Context) error {
 	req.Header.Set("Content-Encoding", "gzip")
 	req.Header.Set("X-Real-IP", clientIP.String())
 
+	if len(h.token) != 0 {
+		req.Header.Set("Authorization", security.BearerToken(h.token))
+	}
+
 	resp, err := h.client.Do(req)
 	if err != nil {
 		return err
@@ -151,11 +164,23 @@ func (h *HTTPExporter) doSend(ctx context.Context) error {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return entity.HTTPError(resp.StatusCode, respBody)
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+
+	// Throttled requests should be retried later as well.
+	// Unlike throttling, exceeded quota is reported without retry delay.
+	case resp.StatusCode == http.StatusServiceUnavailable ||
+		resp.StatusCode == http.StatusTooManyRequests && len(resp.Header.Get("Retry-After")) != 0:
+		h.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return fmt.Errorf("%w: %s", entity.ErrServiceUnavailable, respBody)
+
+	// Invalid, unauthorized or exceeding quota requests are rejected on retry as well.
+	case resp.StatusCode >= http.StatusBadRequest && resp.StatusCode < http.StatusInternalServerError:
+		return entity.RequestRejectedError(entity.HTTPError(resp.StatusCode, respBody))
 	}
 
-	return nil
+	return entity.HTTPError(resp.StatusCode, respBody)
 }
 
 // Send metrics stored in internal buffer to metrics collector in single batch request.
@@ -174,11 +199,17 @@ func (h *HTTPExporter) Send(ctx context.Context) Exporter {
 	return h
 }
 
+// RetryAfter returns delay requested by metrics collector after failed Send, zero if not requested.
+func (h *HTTPExporter) RetryAfter() time.Duration {
+	return h.retryAfter
+}
+
 // Reset reset state of exporter to initial.
 // This doesn't affected the underlying connection.
 func (h *HTTPExporter) Reset() {
 	h.buffer = make([]metrics.MetricReq, 0)
 	h.err = nil
+	h.retryAfter = 0
 }
 
 // Close of HTTP exporter is no-op.
@@ -0,0 +1,100 @@
+package exporter_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/exporter"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPExporterFailures(t *testing.T) {
+	tt := []struct {
+		name       string
+		code       int
+		retryAfter string
+		expected   error
+		rejected   bool
+		delay      time.Duration
+	}{
+		{
+			name:       "Should report unavailable server",
+			code:       http.StatusServiceUnavailable,
+			retryAfter: "60",
+			expected:   entity.ErrServiceUnavailable,
+			delay:      time.Minute,
+		},
+		{
+			name:     "Should report unavailable server without retry delay",
+			code:     http.StatusServiceUnavailable,
+			expected: entity.ErrServiceUnavailable,
+		},
+		{
+			name:       "Should ignore invalid retry delay",
+			code:       http.StatusServiceUnavailable,
+			retryAfter: "soon",
+			expected:   entity.ErrServiceUnavailable,
+		},
+		{
+			name:       "Should report throttled request as temporary unavailability",
+			code:       http.StatusTooManyRequests,
+			retryAfter: "1",
+			expected:   entity.ErrServiceUnavailable,
+			delay:      time.Second,
+		},
+		{
+			name:     "Should report exceeded quota as rejection",
+			code:     http.StatusTooManyRequests,
+			expected: entity.ErrRequestRejected,
+			rejected: true,
+		},
+		{
+			name:     "Should report invalid request as rejection",
+			code:     http.StatusBadRequest,
+			expected: entity.ErrRequestRejected,
+			rejected: true,
+		},
+		{
+			name:     "Should report denied access as rejection",
+			code:     http.StatusForbidden,
+			expected: entity.ErrRequestRejected,
+			rejected: true,
+		},
+		{
+			name:     "Should report internal error as failure of request",
+			code:     http.StatusInternalServerError,
+			expected: entity.ErrHTTP,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if len(tc.retryAfter) != 0 {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+
+				http.Error(w, http.StatusText(tc.code), tc.code)
+			}))
+			defer srv.Close()
+
+			address := entity.NetAddress(strings.TrimPrefix(srv.URL, "http://"))
+			exp := exporter.NewHTTPExporter(address, "", "", nil)
+
+			err := exp.Add("PollCount", metrics.Counter(10)).Send(context.Background()).Error()
+			require.ErrorIs(t, err, tc.expected)
+			require.Equal(t, tc.rejected, errors.Is(err, entity.ErrRequestRejected))
+			require.Equal(t, tc.delay, exp.RetryAfter())
+
+			exp.Reset()
+			require.Zero(t, exp.RetryAfter())
+		})
+	}
+}
@@ -3,6 +3,9 @@ package exporter
 import (
 	"fmt"
 	"net"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 )
@@ -25,3 +28,26 @@ func getOutboundIP() (net.IP, error) {
 
 	return localAddr.IP, nil
 }
+
+// parseRetryAfter converts value of the Retry-After header into delay, zero if the value is invalid.
+// The value is either count of seconds or HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+
+	if delay := time.Until(date); delay > 0 {
+		return delay
+	}
+
+	return 0
+}
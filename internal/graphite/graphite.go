@@ -0,0 +1,229 @@
+// Package graphite implements TCP listener accepting metrics in Carbon plaintext format.
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Maximal count of records pushed to the recorder at once.
+	_maxBatchSize = 1000
+
+	// Maximal length of single line, connections sending longer lines are closed.
+	_maxLineSize = 64 << 10
+)
+
+// parseLine converts line in format "<path> <value> <timestamp>" into gauge.
+// The timestamp is validated but ignored, only the latest value is stored.
+func parseLine(line string, mapping *Mapping) (storage.Record, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return storage.Record{}, fmt.Errorf("%w: expected 3 fields, got %d", entity.ErrMalformedGraphiteLine, len(fields))
+	}
+
+	name, err := mapping.Name(fields[0])
+	if err != nil {
+		return storage.Record{}, fmt.Errorf("%w: %s", entity.ErrMalformedGraphiteLine, err)
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return storage.Record{}, fmt.Errorf("%w: invalid value %s", entity.ErrMalformedGraphiteLine, fields[1])
+	}
+
+	if _, err := strconv.ParseFloat(fields[2], 64); err != nil {
+		return storage.Record{}, fmt.Errorf("%w: invalid timestamp %s", entity.ErrMalformedGraphiteLine, fields[2])
+	}
+
+	return storage.Record{Name: name, Value: metrics.Gauge(value)}, nil
+}
+
+// Server receives Graphite metrics over TCP and records them via the recorder.
+type Server struct {
+	address  entity.NetAddress
+	mapping  *Mapping
+	recorder services.Recorder
+
+	listener net.Listener
+	notify   chan error
+
+	// Currently open client connections, closed on shutdown.
+	conns   map[net.Conn]struct{}
+	connsMu sync.Mutex
+
+	// Set on shutdown, connections accepted afterwards are closed immediately.
+	closed bool
+
+	// Tracks background tasks.
+	wg sync.WaitGroup
+}
+
+// New creates new instance of Graphite server.
+// If empty address string used, empty Server (without initialization)
+// is created. Such server is no-op.
+func New(address entity.NetAddress, mapping *Mapping, recorder services.Recorder) *Server {
+	if len(address) == 0 {
+		return &Server{}
+	}
+
+	return &Server{
+		address:  address,
+		mapping:  mapping,
+		recorder: recorder,
+		notify:   make(chan error, 1),
+		conns:    make(map[net.Conn]struct{}),
+	}
+}
+
+// Start launches the Graphite server.
+func (s *Server) Start() {
+	if len(s.address) == 0 {
+		return
+	}
+
+	listener, err := net.Listen("tcp", s.address.String())
+	if err != nil {
+		s.notify <- err
+		return
+	}
+
+	s.listener = listener
+
+	s.wg.Add(1)
+
+	go s.serve()
+}
+
+// serve accepts incoming connections until the listener is closed.
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.notify <- err
+			}
+
+			return
+		}
+
+		if !s.track(conn) {
+			_ = conn.Close()
+			return
+		}
+
+		s.wg.Add(1)
+
+		go s.handle(conn)
+	}
+}
+
+// track remembers the connection to close it on shutdown.
+// Returns false, if the server is already shutting down.
+func (s *Server) track(conn net.Conn) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+
+	return true
+}
+
+// handle reads lines from the client and records them in batches.
+// Batch is pushed as soon as all received data is processed.
+// If the client sends line longer than _maxLineSize, the connection is closed.
+func (s *Server) handle(conn net.Conn) {
+	defer s.wg.Done()
+
+	defer func() {
+		s.connsMu.Lock()
+		delete(s.conns, conn)
+		s.connsMu.Unlock()
+
+		_ = conn.Close()
+	}()
+
+	logger := log.With().Str("client", conn.RemoteAddr().String()).Logger()
+	reader := bufio.NewReaderSize(conn, _maxLineSize)
+	batch := make([]storage.Record, 0, _maxBatchSize)
+
+	for {
+		// Unlike ReadString, ReadSlice doesn't grow the buffer,
+		// thus client never sending newline can't exhaust memory.
+		raw, err := reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			logger.Warn().Int("limit", _maxLineSize).Msg("Graphite line is too long, closing connection")
+
+			raw = nil
+		}
+
+		if line := strings.TrimSpace(string(raw)); len(line) != 0 {
+			record, parseErr := parseLine(line, s.mapping)
+			if parseErr != nil {
+				logger.Warn().Err(parseErr).Str("line", line).Msg("Skipping Graphite line")
+			} else {
+				batch = append(batch, record)
+			}
+		}
+
+		if len(batch) != 0 && (err != nil || len(batch) >= _maxBatchSize || reader.Buffered() == 0) {
+			ctx := security.WithIdentity(context.Background(), security.GraphiteIdentity)
+			if _, pushErr := s.recorder.PushList(ctx, batch); pushErr != nil {
+				logger.Error().Err(pushErr).Int("count", len(batch)).Msg("Graphite metrics dropped")
+			}
+
+			batch = make([]storage.Record, 0, _maxBatchSize)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Notify reports errors received during start and work of the server.
+// Usually such errors are not recoverable.
+func (s *Server) Notify() <-chan error {
+	return s.notify
+}
+
+// Shutdown stops accepting new connections and closes existing ones.
+// Data received before shutdown is recorded.
+func (s *Server) Shutdown() error {
+	if s.listener == nil {
+		return nil
+	}
+
+	err := s.listener.Close()
+
+	s.connsMu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.connsMu.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
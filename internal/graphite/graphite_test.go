@@ -0,0 +1,215 @@
+package graphite_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/graphite"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func freeTCPAddress(t *testing.T) entity.NetAddress {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, listener.Close())
+	}()
+
+	return entity.NetAddress(listener.Addr().String())
+}
+
+// sendLines sends data to the Graphite server, waits for the expected count
+// of records, shuts the server down and returns all records pushed to the recorder.
+func sendLines(t *testing.T, data string, count int) []storage.Record {
+	t.Helper()
+	require := require.New(t)
+
+	var (
+		mu      sync.Mutex
+		records []storage.Record
+	)
+
+	m := new(services.RecorderMock)
+	m.On("PushList", mock.Anything, mock.Anything).
+		Return([]storage.Record{}, nil).
+		Run(func(args mock.Arguments) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			records = append(records, args.Get(1).([]storage.Record)...)
+		})
+
+	mapping, err := graphite.NewMapping([]string{"servers.*.cpu=Cpu$1"})
+	require.NoError(err)
+
+	address := freeTCPAddress(t)
+
+	srv := graphite.New(address, mapping, m)
+	srv.Start()
+
+	conn, err := net.Dial("tcp", address.String())
+	require.NoError(err)
+
+	_, err = conn.Write([]byte(data))
+	require.NoError(err)
+	require.NoError(conn.Close())
+
+	// Data may be received in several chunks, wait for all of them before shutdown.
+	require.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(records) >= count
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(srv.Shutdown())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	return records
+}
+
+func TestGraphiteServer(t *testing.T) {
+	tt := []struct {
+		name     string
+		data     string
+		expected []storage.Record
+	}{
+		{
+			name: "Should record values as gauges",
+			data: "servers.web1.cpu 0.5 1700000000\napp.requests 10 1700000000\n",
+			expected: []storage.Record{
+				{Name: "CpuWeb1", Value: metrics.Gauge(0.5)},
+				{Name: "AppRequests", Value: metrics.Gauge(10)},
+			},
+		},
+		{
+			name: "Should record last line without trailing newline",
+			data: "app.requests 10 1700000000",
+			expected: []storage.Record{
+				{Name: "AppRequests", Value: metrics.Gauge(10)},
+			},
+		},
+		{
+			name: "Should skip malformed lines",
+			data: "app.requests 10\napp.requests x 1700000000\napp.requests NaN 1700000000\n" +
+				"app.requests 1 x\n... 1 1700000000\n\napp.users 3 1700000000\n",
+			expected: []storage.Record{
+				{Name: "AppUsers", Value: metrics.Gauge(3)},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			records := sendLines(t, tc.data, len(tc.expected))
+
+			require.ElementsMatch(t, tc.expected, records)
+		})
+	}
+}
+
+func TestGraphiteServerClosesConnectionOnTooLongLine(t *testing.T) {
+	require := require.New(t)
+
+	m := new(services.RecorderMock)
+	m.On("PushList", mock.Anything, mock.Anything).Return([]storage.Record{}, nil)
+
+	mapping, err := graphite.NewMapping(nil)
+	require.NoError(err)
+
+	address := freeTCPAddress(t)
+
+	srv := graphite.New(address, mapping, m)
+	srv.Start()
+
+	defer func() {
+		require.NoError(srv.Shutdown())
+	}()
+
+	conn, err := net.Dial("tcp", address.String())
+	require.NoError(err)
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	_, err = conn.Write([]byte("app.requests 10 1700000000\n"))
+	require.NoError(err)
+
+	// Writes could fail as soon as the server closes the connection.
+	_, _ = conn.Write([]byte(strings.Repeat("a", 128<<10)))
+
+	require.NoError(conn.SetReadDeadline(time.Now().Add(2 * time.Second)))
+
+	_, err = conn.Read(make([]byte, 1))
+	require.Error(err)
+	require.False(errors.Is(err, os.ErrDeadlineExceeded), "Graphite server didn't close the connection")
+
+	m.AssertCalled(t, "PushList", mock.Anything, []storage.Record{{Name: "AppRequests", Value: metrics.Gauge(10)}})
+}
+
+func TestGraphiteServerIsNoopWithoutAddress(t *testing.T) {
+	srv := graphite.New("", nil, new(services.RecorderMock))
+	srv.Start()
+
+	require.Nil(t, srv.Notify())
+	require.NoError(t, srv.Shutdown())
+}
+
+func TestGraphiteServerNotifiesOnListenFailure(t *testing.T) {
+	srv := graphite.New("256.0.0.1:2003", nil, new(services.RecorderMock))
+	srv.Start()
+
+	select {
+	case err := <-srv.Notify():
+		require.Error(t, err)
+
+	case <-time.After(time.Second):
+		require.FailNow(t, "Graphite server didn't report failure")
+	}
+
+	require.NoError(t, srv.Shutdown())
+}
+
+func TestGraphiteServerShutdownClosesIdleConnections(t *testing.T) {
+	address := freeTCPAddress(t)
+
+	srv := graphite.New(address, nil, new(services.RecorderMock))
+	srv.Start()
+
+	conn, err := net.Dial("tcp", address.String())
+	require.NoError(t, err)
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- srv.Shutdown()
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Graphite server didn't close idle connection")
+	}
+}
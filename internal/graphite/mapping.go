@@ -0,0 +1,109 @@
+package graphite
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/validators"
+)
+
+// Placeholder of captured path component in name template, e.g. "$1".
+var placeholder = regexp.MustCompile(`\$(\d+)`)
+
+// A rule maps Graphite paths matching the pattern to metric names built from the template.
+type rule struct {
+	pattern  *regexp.Regexp
+	template string
+}
+
+// Mapping converts dotted Graphite paths into metric names.
+type Mapping struct {
+	rules []rule
+}
+
+// patternToRegexp converts pattern like "servers.*.cpu" into regular expression,
+// each '*' matches exactly one path component and captures it.
+func patternToRegexp(pattern string) (*regexp.Regexp, error) {
+	parts := strings.Split(pattern, ".")
+
+	for i, part := range parts {
+		if part == "*" {
+			parts[i] = `([^.]+)`
+			continue
+		}
+
+		if len(part) == 0 || strings.Contains(part, "*") {
+			return nil, fmt.Errorf("%w: %s", entity.ErrInvalidGraphiteMapping, pattern)
+		}
+
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	return regexp.Compile("^" + strings.Join(parts, `\.`) + "$")
+}
+
+// NewMapping creates mapping from rules in the "<pattern>=<template>" form,
+// e.g. "servers.*.cpu.*=Cpu$2Of$1". Each '*' in the pattern matches single
+// path component, "$N" in the template is replaced with N-th captured component.
+// Rules are checked in the provided order, paths not matching any rule
+// are converted to CamelCase, e.g. "servers.web1.cpu" becomes "ServersWeb1Cpu".
+func NewMapping(rules []string) (*Mapping, error) {
+	m := &Mapping{rules: make([]rule, 0, len(rules))}
+
+	for _, src := range rules {
+		pattern, template, ok := strings.Cut(src, "=")
+		if !ok || len(template) == 0 {
+			return nil, fmt.Errorf("%w: %s", entity.ErrInvalidGraphiteMapping, src)
+		}
+
+		re, err := patternToRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range placeholder.FindAllStringSubmatch(template, -1) {
+			n, _ := strconv.Atoi(match[1])
+			if n == 0 || n > re.NumSubexp() {
+				return nil, fmt.Errorf("%w: unknown placeholder %s in %s", entity.ErrInvalidGraphiteMapping, match[0], src)
+			}
+		}
+
+		if literal := placeholder.ReplaceAllString(template, ""); len(literal) != 0 {
+			if err := validators.ValidateMetricName(literal, ""); err != nil {
+				return nil, fmt.Errorf("%w: %s", entity.ErrInvalidGraphiteMapping, src)
+			}
+		}
+
+		m.rules = append(m.rules, rule{pattern: re, template: template})
+	}
+
+	return m, nil
+}
+
+// Name converts Graphite path into metric name acceptable by the service.
+func (m *Mapping) Name(path string) (string, error) {
+	name := validators.SanitizeMetricName(path)
+
+	for _, r := range m.rules {
+		captured := r.pattern.FindStringSubmatch(path)
+		if captured == nil {
+			continue
+		}
+
+		name = placeholder.ReplaceAllStringFunc(r.template, func(src string) string {
+			n, _ := strconv.Atoi(src[1:])
+			return validators.SanitizeMetricName(captured[n])
+		})
+
+		break
+	}
+
+	if err := validators.ValidateMetricName(name, ""); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
@@ -0,0 +1,114 @@
+package graphite_test
+
+import (
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/graphite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMappingWithInvalidRules(t *testing.T) {
+	tt := []struct {
+		name string
+		rule string
+	}{
+		{
+			name: "Should fail without template",
+			rule: "servers.*.cpu",
+		},
+		{
+			name: "Should fail on empty template",
+			rule: "servers.*.cpu=",
+		},
+		{
+			name: "Should fail on empty path component",
+			rule: "servers..cpu=Cpu",
+		},
+		{
+			name: "Should fail on partial wildcard",
+			rule: "servers.web*.cpu=Cpu$1",
+		},
+		{
+			name: "Should fail on unknown placeholder",
+			rule: "servers.*.cpu=Cpu$2",
+		},
+		{
+			name: "Should fail on zero placeholder",
+			rule: "servers.*.cpu=Cpu$0",
+		},
+		{
+			name: "Should fail on invalid characters in template",
+			rule: "servers.*.cpu=cpu_$1",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := graphite.NewMapping([]string{tc.rule})
+
+			require.ErrorIs(t, err, entity.ErrInvalidGraphiteMapping)
+		})
+	}
+}
+
+func TestMappingName(t *testing.T) {
+	rules := []string{
+		"servers.*.cpu.*=Cpu$2Of$1",
+		"servers.*.cpu.total=TotalCpu$1",
+		"servers.*.mem=Mem",
+	}
+
+	tt := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{
+			name:     "Should substitute captured components",
+			path:     "servers.web-1.cpu.user",
+			expected: "CpuUserOfWeb1",
+		},
+		{
+			name:     "Should apply first matching rule",
+			path:     "servers.web1.cpu.total",
+			expected: "CpuTotalOfWeb1",
+		},
+		{
+			name:     "Should use template without placeholders",
+			path:     "servers.web1.mem",
+			expected: "Mem",
+		},
+		{
+			name:     "Should require match of all components",
+			path:     "servers.web1.mem.free",
+			expected: "ServersWeb1MemFree",
+		},
+		{
+			name:     "Should convert unmatched path to CamelCase",
+			path:     "app.requests_total",
+			expected: "AppRequestsTotal",
+		},
+	}
+
+	mapping, err := graphite.NewMapping(rules)
+	require.NoError(t, err)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			name, err := mapping.Name(tc.path)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, name)
+		})
+	}
+}
+
+func TestMappingNameFailsOnInvalidPath(t *testing.T) {
+	mapping, err := graphite.NewMapping(nil)
+	require.NoError(t, err)
+
+	_, err = mapping.Name("...")
+
+	require.ErrorIs(t, err, entity.ErrMetricInvalidName)
+}
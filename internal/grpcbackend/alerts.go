@@ -0,0 +1,27 @@
+package grpcbackend
+
+import (
+	"context"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"google.golang.org/grpc"
+)
+
+// AlertsServer provides access to active alerts.
+type AlertsServer struct {
+	grpcapi.UnimplementedAlertsServer
+	alerts *alerting.Manager
+}
+
+// NewAlertsServer creates new instance of gRPC serving Alerts API and attaches it to the server.
+func NewAlertsServer(server *grpc.Server, alerts *alerting.Manager) {
+	s := &AlertsServer{alerts: alerts}
+
+	grpcapi.RegisterAlertsServer(server, s)
+}
+
+// List returns pending and firing alerts visible to the tenant of the request.
+func (s AlertsServer) List(ctx context.Context, _ *grpcapi.ListAlertsRequest) (*grpcapi.ListAlertsResponse, error) {
+	return toListAlertsResponse(s.alerts.AlertsOf(ctx)), nil
+}
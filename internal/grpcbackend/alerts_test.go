@@ -0,0 +1,109 @@
+package grpcbackend_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/grpcbackend"
+	"github.com/alkurbatov/metrics-collector/internal/query"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+type notifierStub struct{}
+
+func (notifierStub) Notify(alerting.Alert) {}
+
+func TestListAlerts(t *testing.T) {
+	require := require.New(t)
+
+	m := new(services.RecorderMock)
+	m.On("List", mock.Anything, mock.Anything).Return(storage.Page{Records: []storage.Record{
+		{Name: "PollCountAgent1", Value: metrics.Counter(10)},
+		{Name: "PollCountAgent2", Value: metrics.Counter(20)},
+	}}, nil)
+
+	rules, err := alerting.ParseRules([]string{
+		"HighPollCount = PollCount* > 5",
+		"TooManyPolls = sum(PollCount*) > 25 for 1m severity critical",
+	})
+	require.NoError(err)
+
+	engine := query.NewEngine(m, query.NewHistory(m, 0))
+	alerts := alerting.NewManager(engine, rules, nil, nil, notifierStub{})
+
+	now := time.UnixMilli(1000)
+	alerts.Evaluate(context.Background(), now)
+
+	srv := grpcbackend.New(grpcbackend.Deps{
+		Recorder:    m,
+		Engine:      engine,
+		Alerts:      alerts,
+		HealthCheck: &services.HealthCheckMock{},
+	}).Instance()
+
+	conn, closer := serveTestServer(t, srv)
+	defer closer()
+
+	client := grpcapi.NewAlertsClient(conn)
+	resp, err := client.List(context.Background(), &grpcapi.ListAlertsRequest{})
+	require.NoError(err)
+
+	expected := []*grpcapi.Alert{
+		{
+			Name:        "HighPollCount",
+			Metric:      "PollCountAgent1",
+			Severity:    alerting.DefaultSeverity,
+			Condition:   "PollCount* > 5",
+			State:       alerting.StateFiring,
+			Value:       10,
+			ActiveSince: 1000,
+			FiredAt:     1000,
+		},
+		{
+			Name:        "HighPollCount",
+			Metric:      "PollCountAgent2",
+			Severity:    alerting.DefaultSeverity,
+			Condition:   "PollCount* > 5",
+			State:       alerting.StateFiring,
+			Value:       20,
+			ActiveSince: 1000,
+			FiredAt:     1000,
+		},
+		{
+			Name:        "TooManyPolls",
+			Severity:    "critical",
+			Condition:   "sum(PollCount*) > 25",
+			State:       alerting.StatePending,
+			Value:       30,
+			ActiveSince: 1000,
+		},
+	}
+
+	require.Len(resp.Alerts, len(expected))
+
+	for i := range expected {
+		require.Equal(expected[i].Name, resp.Alerts[i].Name)
+		require.Equal(expected[i].Metric, resp.Alerts[i].Metric)
+		require.Equal(expected[i].Severity, resp.Alerts[i].Severity)
+		require.Equal(expected[i].Condition, resp.Alerts[i].Condition)
+		require.Equal(expected[i].State, resp.Alerts[i].State)
+		require.Equal(expected[i].Value, resp.Alerts[i].Value)
+		require.Equal(expected[i].ActiveSince, resp.Alerts[i].ActiveSince)
+		require.Equal(expected[i].FiredAt, resp.Alerts[i].FiredAt)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), security.TenantHeader, "acme")
+
+	resp, err = client.List(ctx, &grpcapi.ListAlertsRequest{})
+	require.NoError(err)
+	require.Empty(resp.Alerts, "alerts of the default tenant must not be visible to other tenants")
+}
@@ -4,34 +4,95 @@ package grpcbackend
 import (
 	"net"
 
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/alkurbatov/metrics-collector/internal/grpcserver"
 	"github.com/alkurbatov/metrics-collector/internal/logging"
+	"github.com/alkurbatov/metrics-collector/internal/query"
 	"github.com/alkurbatov/metrics-collector/internal/security"
 	"github.com/alkurbatov/metrics-collector/internal/services"
 	"google.golang.org/grpc"
 )
 
-func New(
-	address entity.NetAddress,
-	recorder services.Recorder,
-	healthcheck services.HealthCheck,
-	signer *security.Signer,
-	trustedSubnet *net.IPNet,
-) *grpcserver.Server {
-	interceptors := make([]grpc.UnaryServerInterceptor, 0, 2)
+// Scopes required to call methods of gRPC API, methods not listed here are denied.
+var _methodScopes = map[string]security.Scope{
+	"/metrics.collector.v1.Health/Ping":                               security.ScopePublic,
+	"/metrics.collector.v1.Metrics/Update":                            security.ScopeWrite,
+	"/metrics.collector.v1.Metrics/BatchUpdate":                       security.ScopeWrite,
+	"/metrics.collector.v1.Metrics/StreamUpdate":                      security.ScopeWrite,
+	"/metrics.collector.v1.Metrics/Delete":                            security.ScopeWrite,
+	"/metrics.collector.v1.Metrics/Get":                               security.ScopeRead,
+	"/metrics.collector.v1.Metrics/List":                              security.ScopeRead,
+	"/metrics.collector.v1.Metrics/Watch":                             security.ScopeRead,
+	"/metrics.collector.v1.Metrics/Query":                             security.ScopeRead,
+	"/metrics.collector.v1.Alerts/List":                               security.ScopeRead,
+	"/opentelemetry.proto.collector.metrics.v1.MetricsService/Export": security.ScopeWrite,
+}
+
+// Deps are dependencies of gRPC API, optional ones disable related features when not set.
+type Deps struct {
+	// Address the API is served on.
+	Address entity.NetAddress
+
+	Recorder    services.Recorder
+	Engine      *query.Engine
+	Alerts      *alerting.Manager
+	HealthCheck services.HealthCheck
+
+	// Signer verifies and signs metrics, if set.
+	Signer *security.Signer
+
+	// TrustedSubnet restricts updates to clients from the subnet, if set.
+	TrustedSubnet *net.IPNet
+
+	// TrustedProxies are allowed to pass address of the client in x-real-ip metadata, if set.
+	TrustedProxies *net.IPNet
+
+	// Tokens authorize requests, if set.
+	Tokens *security.Tokens
+
+	// Limiter limits rate of requests of each client, if set.
+	Limiter *security.RateLimiter
+}
+
+// New creates gRPC server serving the API.
+func New(deps Deps) *grpcserver.Server {
+	interceptors := make([]grpc.UnaryServerInterceptor, 0, 6)
 	interceptors = append(interceptors, logging.UnaryRequestsInterceptor)
 
-	if trustedSubnet != nil {
-		interceptors = append(interceptors, security.UnaryRequestsFilter(trustedSubnet))
+	streamInterceptors := make([]grpc.StreamServerInterceptor, 0, 6)
+	streamInterceptors = append(streamInterceptors, logging.StreamRequestsInterceptor)
+
+	if deps.TrustedSubnet != nil {
+		interceptors = append(interceptors, security.UnaryRequestsFilter(deps.TrustedSubnet, _methodScopes))
+		streamInterceptors = append(streamInterceptors, security.StreamRequestsFilter(deps.TrustedSubnet, _methodScopes))
+	}
+
+	if deps.Tokens != nil {
+		interceptors = append(interceptors, security.UnaryRequestsAuthorizer(deps.Tokens, _methodScopes))
+		streamInterceptors = append(streamInterceptors, security.StreamRequestsAuthorizer(deps.Tokens, _methodScopes))
 	}
 
+	interceptors = append(interceptors, security.UnaryClientIdentifier(deps.TrustedProxies))
+	streamInterceptors = append(streamInterceptors, security.StreamClientIdentifier(deps.TrustedProxies))
+
+	if deps.Limiter != nil {
+		interceptors = append(interceptors, security.UnaryRequestsLimiter(deps.Limiter, _methodScopes))
+		streamInterceptors = append(streamInterceptors, security.StreamRequestsLimiter(deps.Limiter, _methodScopes))
+	}
+
+	interceptors = append(interceptors, security.UnaryTenantSelector)
+	streamInterceptors = append(streamInterceptors, security.StreamTenantSelector)
+
 	grpcSrv := grpcserver.New(
-		address,
+		deps.Address,
 		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
 	)
-	NewHealthServer(grpcSrv.Instance(), healthcheck)
-	NewMetricsServer(grpcSrv.Instance(), recorder, signer)
+	NewHealthServer(grpcSrv.Instance(), deps.HealthCheck)
+	NewMetricsServer(grpcSrv.Instance(), deps.Recorder, deps.Engine, deps.Signer)
+	NewOTLPServer(grpcSrv.Instance(), deps.Recorder)
+	NewAlertsServer(grpcSrv.Instance(), deps.Alerts)
 
 	return grpcSrv
 }
@@ -0,0 +1,80 @@
+package grpcbackend_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/grpcbackend"
+	"github.com/alkurbatov/metrics-collector/internal/query"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// callWithoutToken calls the method without any token and returns the result code.
+func callWithoutToken(t *testing.T, conn *grpc.ClientConn, method string, info grpc.MethodInfo) codes.Code {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if !info.IsClientStream && !info.IsServerStream {
+		return status.Code(conn.Invoke(ctx, method, &emptypb.Empty{}, &emptypb.Empty{}))
+	}
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, method)
+	require.NoError(t, err)
+	require.NoError(t, stream.CloseSend())
+
+	return status.Code(stream.RecvMsg(&emptypb.Empty{}))
+}
+
+func TestAllMethodsHaveScopes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	err := os.WriteFile(path, []byte(`[{"name": "agent", "token": "agent-token", "scopes": ["write"]}]`), 0600)
+	require.NoError(t, err)
+
+	tokens, err := security.NewTokens(entity.FilePath(path))
+	require.NoError(t, err)
+
+	recorder := new(services.RecorderMock)
+	healthcheck := new(services.HealthCheckMock)
+	healthcheck.On("CheckStorage", mock.Anything).Return(nil)
+
+	engine := query.NewEngine(recorder, query.NewHistory(recorder, 0))
+	alerts := alerting.NewManager(engine, nil, nil, nil, nil)
+	srv := grpcbackend.New(grpcbackend.Deps{
+		Recorder:    recorder,
+		Engine:      engine,
+		Alerts:      alerts,
+		HealthCheck: healthcheck,
+		Tokens:      tokens,
+	}).Instance()
+
+	conn, closer := serveTestServer(t, srv)
+	defer closer()
+
+	for service, info := range srv.GetServiceInfo() {
+		for _, m := range info.Methods {
+			method := "/" + service + "/" + m.Name
+
+			t.Run(method, func(t *testing.T) {
+				// Methods without scope are denied even to clients with valid tokens,
+				// so anonymous request to them fails with PermissionDenied instead of Unauthenticated.
+				code := callWithoutToken(t, conn, method, m)
+
+				require.Contains(t, []codes.Code{codes.OK, codes.Unauthenticated}, code)
+			})
+		}
+	}
+
+	recorder.AssertNotCalled(t, "PushList", mock.Anything, mock.Anything)
+}
@@ -5,7 +5,9 @@ import (
 	"net"
 	"testing"
 
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
 	"github.com/alkurbatov/metrics-collector/internal/grpcbackend"
+	"github.com/alkurbatov/metrics-collector/internal/query"
 	"github.com/alkurbatov/metrics-collector/internal/security"
 	"github.com/alkurbatov/metrics-collector/internal/services"
 	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
@@ -44,7 +46,6 @@ func createTestServer(
 	key security.Secret,
 ) (*grpc.ClientConn, func()) {
 	t.Helper()
-	require := require.New(t)
 
 	if recorder == nil {
 		recorder = &services.RecorderMock{}
@@ -59,8 +60,48 @@ func createTestServer(
 		signer = security.NewSigner(key)
 	}
 
+	engine := query.NewEngine(recorder, query.NewHistory(recorder, 0))
+	alerts := alerting.NewManager(engine, nil, nil, nil, nil)
+	srv := grpcbackend.New(grpcbackend.Deps{
+		Recorder:    recorder,
+		Engine:      engine,
+		Alerts:      alerts,
+		HealthCheck: healthcheck,
+		Signer:      signer,
+	}).Instance()
+
+	return serveTestServer(t, srv)
+}
+
+func createFilteredTestServer(
+	t *testing.T,
+	recorder *services.RecorderMock,
+	trustedSubnet string,
+) (*grpc.ClientConn, func()) {
+	t.Helper()
+
+	_, subnet, err := net.ParseCIDR(trustedSubnet)
+	require.NoError(t, err)
+
+	engine := query.NewEngine(recorder, query.NewHistory(recorder, 0))
+	alerts := alerting.NewManager(engine, nil, nil, nil, nil)
+	healthcheck := &services.HealthCheckMock{}
+	srv := grpcbackend.New(grpcbackend.Deps{
+		Recorder:      recorder,
+		Engine:        engine,
+		Alerts:        alerts,
+		HealthCheck:   healthcheck,
+		TrustedSubnet: subnet,
+	}).Instance()
+
+	return serveTestServer(t, srv)
+}
+
+func serveTestServer(t *testing.T, srv *grpc.Server) (*grpc.ClientConn, func()) {
+	t.Helper()
+	require := require.New(t)
+
 	lis := bufconn.Listen(1024 * 1024)
-	srv := grpcbackend.New("", recorder, healthcheck, signer, nil).Instance()
 
 	go func() {
 		require.NoError(srv.Serve(lis))
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
 	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/query"
 	"github.com/alkurbatov/metrics-collector/internal/security"
 	"github.com/alkurbatov/metrics-collector/internal/storage"
 	"github.com/alkurbatov/metrics-collector/internal/validators"
@@ -112,3 +114,97 @@ func toMetricReqList(records []storage.Record, signer *security.Signer) ([]*grpc
 
 	return rv, nil
 }
+
+func toListOptions(req *grpcapi.ListRequest) (storage.ListOptions, error) {
+	opts := storage.ListOptions{
+		Prefix: req.Prefix,
+		Kind:   req.Mtype,
+		Limit:  int(req.Limit),
+		Cursor: req.Cursor,
+	}
+
+	if len(opts.Kind) != 0 {
+		if err := validators.ValidateMetricKind(opts.Kind); err != nil {
+			return opts, err
+		}
+	}
+
+	match, err := storage.NamePattern(req.Match, req.Regex)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Match = match
+
+	if opts.Limit == 0 {
+		opts.Limit = entity.DefaultPageSize
+	}
+
+	if opts.Limit < 0 || opts.Limit > entity.MaxPageSize {
+		return opts, entity.ErrInvalidPageSize
+	}
+
+	return opts, nil
+}
+
+func toFilter(req *grpcapi.WatchRequest) (storage.Filter, error) {
+	for _, kind := range req.Mtypes {
+		if err := validators.ValidateMetricKind(kind); err != nil {
+			return storage.Filter{}, err
+		}
+	}
+
+	for _, name := range req.Ids {
+		if err := validators.ValidateMetricName(name, ""); err != nil {
+			return storage.Filter{}, err
+		}
+	}
+
+	return storage.Filter{Names: req.Ids, Kinds: req.Mtypes}, nil
+}
+
+func toQueryResponse(result query.Result) *grpcapi.QueryResponse {
+	rv := &grpcapi.QueryResponse{Type: result.Type, Scalar: result.Scalar}
+
+	for _, s := range result.Vector {
+		rv.Vector = append(rv.Vector, &grpcapi.QuerySample{Id: s.Name, Mtype: s.Kind, Value: s.Value})
+	}
+
+	for _, s := range result.Matrix {
+		points := make([]*grpcapi.QueryPoint, 0, len(s.Points))
+		for _, p := range s.Points {
+			points = append(points, &grpcapi.QueryPoint{Timestamp: p.Timestamp.UnixMilli(), Value: p.Value})
+		}
+
+		rv.Matrix = append(rv.Matrix, &grpcapi.QuerySeries{Id: s.Name, Mtype: s.Kind, Points: points})
+	}
+
+	return rv
+}
+
+func toListAlertsResponse(alerts []alerting.Alert) *grpcapi.ListAlertsResponse {
+	rv := &grpcapi.ListAlertsResponse{Alerts: make([]*grpcapi.Alert, 0, len(alerts))}
+
+	for _, a := range alerts {
+		alert := &grpcapi.Alert{
+			Name:        a.Name,
+			Metric:      a.Metric,
+			Severity:    a.Severity,
+			Condition:   a.Condition,
+			State:       a.State,
+			Value:       a.Value,
+			ActiveSince: a.ActiveSince.UnixMilli(),
+			Labels:      a.Labels,
+			SilencedBy:  a.SilencedBy,
+			InhibitedBy: a.InhibitedBy,
+		}
+
+		if !a.FiredAt.IsZero() {
+			alert.FiredAt = a.FiredAt.UnixMilli()
+		}
+
+		rv.Alerts = append(rv.Alerts, alert)
+	}
+
+	return rv
+}
@@ -3,32 +3,71 @@ package grpcbackend
 import (
 	"context"
 	"errors"
+	"io"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/query"
 	"github.com/alkurbatov/metrics-collector/internal/security"
 	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
 	"github.com/alkurbatov/metrics-collector/internal/validators"
 	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// Maximal count of metrics received over single StreamUpdate stream,
+// as they are kept in memory until the client closes the stream.
+const _maxStreamMetrics = 10000
+
 // MetricsServer allows to store and retrieve metrics.
 type MetricsServer struct {
 	grpcapi.UnimplementedMetricsServer
 
 	recorder services.Recorder
+	engine   *query.Engine
 	signer   *security.Signer
 }
 
 // NewMetricsServer creates new instance of gRPC serving Metrics API and attaches it to the server.
-func NewMetricsServer(server *grpc.Server, recorder services.Recorder, signer *security.Signer) {
-	s := &MetricsServer{recorder: recorder, signer: signer}
+func NewMetricsServer(
+	server *grpc.Server,
+	recorder services.Recorder,
+	engine *query.Engine,
+	signer *security.Signer,
+) {
+	s := &MetricsServer{recorder: recorder, engine: engine, signer: signer}
 
 	grpcapi.RegisterMetricsServer(server, s)
 }
 
+// pushErrorStatus converts failure of metrics update to gRPC status.
+// Updates rejected due to maintenance or temporary unavailability of the service
+// should be repeated by clients later.
+func pushErrorStatus(err error) error {
+	if errors.Is(err, entity.ErrMaintenance) || errors.Is(err, entity.ErrServiceUnavailable) {
+		return status.Errorf(codes.Unavailable, err.Error())
+	}
+
+	if errors.Is(err, entity.ErrAccessDenied) {
+		return status.Errorf(codes.PermissionDenied, err.Error())
+	}
+
+	if errors.Is(err, entity.ErrTenantNotForwarded) {
+		return status.Errorf(codes.Unimplemented, err.Error())
+	}
+
+	if errors.Is(err, entity.ErrSeriesQuotaExceeded) ||
+		errors.Is(err, entity.ErrSeriesLimitExceeded) ||
+		errors.Is(err, entity.ErrTenantsLimitExceeded) {
+		return status.Errorf(codes.ResourceExhausted, err.Error())
+	}
+
+	return status.Errorf(codes.Internal, err.Error())
+}
+
 // Update pushes metric data to the server.
 func (s MetricsServer) Update(ctx context.Context, req *grpcapi.MetricReq) (*grpcapi.MetricReq, error) {
 	record, err := toRecord(ctx, req, s.signer)
@@ -42,7 +81,7 @@ func (s MetricsServer) Update(ctx context.Context, req *grpcapi.MetricReq) (*grp
 
 	recorded, err := s.recorder.Push(ctx, record)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, err.Error())
+		return nil, pushErrorStatus(err)
 	}
 
 	resp, err := toMetricReq(recorded, s.signer)
@@ -69,6 +108,10 @@ func (s MetricsServer) Get(ctx context.Context, req *grpcapi.GetMetricRequest) (
 			return nil, status.Errorf(codes.NotFound, err.Error())
 		}
 
+		if errors.Is(err, entity.ErrAccessDenied) {
+			return nil, status.Errorf(codes.PermissionDenied, err.Error())
+		}
+
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 
@@ -80,6 +123,30 @@ func (s MetricsServer) Get(ctx context.Context, req *grpcapi.GetMetricRequest) (
 	return resp, nil
 }
 
+// Delete removes stored metric.
+func (s MetricsServer) Delete(
+	ctx context.Context,
+	req *grpcapi.GetMetricRequest,
+) (*grpcapi.DeleteMetricResponse, error) {
+	if err := validators.ValidateMetricName(req.Id, req.Mtype); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if err := validators.ValidateMetricKind(req.Mtype); err != nil {
+		return nil, status.Errorf(codes.Unimplemented, err.Error())
+	}
+
+	if err := s.recorder.Delete(ctx, req.Mtype, req.Id); err != nil {
+		if errors.Is(err, entity.ErrMetricNotFound) {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+
+		return nil, pushErrorStatus(err)
+	}
+
+	return &grpcapi.DeleteMetricResponse{}, nil
+}
+
 // BatchUpdate pushes list of metrics data.
 func (s MetricsServer) BatchUpdate(
 	ctx context.Context,
@@ -96,7 +163,7 @@ func (s MetricsServer) BatchUpdate(
 
 	records, err = s.recorder.PushList(ctx, records)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, err.Error())
+		return nil, pushErrorStatus(err)
 	}
 
 	data, err := toMetricReqList(records, s.signer)
@@ -106,3 +173,126 @@ func (s MetricsServer) BatchUpdate(
 
 	return &grpcapi.BatchUpdateResponse{Data: data}, nil
 }
+
+// StreamUpdate receives metrics over client stream, one metric per message, and records them
+// as a whole after the client closed the stream, so failed stream doesn't record any metrics.
+// Metrics with invalid data are skipped and counted in the summary.
+func (s MetricsServer) StreamUpdate(stream grpcapi.Metrics_StreamUpdateServer) error {
+	ctx := stream.Context()
+	summary := &grpcapi.StreamSummary{}
+	records := make([]storage.Record, 0)
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(records) >= _maxStreamMetrics {
+			return status.Errorf(codes.ResourceExhausted, "stream exceeds %d metrics", _maxStreamMetrics)
+		}
+
+		record, err := toRecord(ctx, req, s.signer)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("name", req.Id).Msg("MetricsServer - StreamUpdate - toRecord")
+
+			summary.Rejected++
+
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	if len(records) > 0 {
+		recorded, err := s.recorder.PushList(ctx, records)
+		if err != nil {
+			return pushErrorStatus(err)
+		}
+
+		summary.Recorded = int64(len(recorded))
+	}
+
+	return stream.SendAndClose(summary)
+}
+
+// List retrieves single page of stored metrics ordered by name and type.
+func (s MetricsServer) List(ctx context.Context, req *grpcapi.ListRequest) (*grpcapi.ListResponse, error) {
+	opts, err := toListOptions(req)
+	if err != nil {
+		if errors.Is(err, entity.ErrMetricNotImplemented) {
+			return nil, status.Errorf(codes.Unimplemented, err.Error())
+		}
+
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	page, err := s.recorder.List(ctx, opts)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidCursor) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	data, err := toMetricReqList(page.Records, s.signer)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &grpcapi.ListResponse{Data: data, NextCursor: page.NextCursor}, nil
+}
+
+// Watch streams updates of selected metrics as soon as they are recorded.
+// Updates are dropped for clients which don't keep up with the stream,
+// thus slow clients never block writers.
+func (s MetricsServer) Watch(req *grpcapi.WatchRequest, stream grpcapi.Metrics_WatchServer) error {
+	filter, err := toFilter(req)
+	if err != nil {
+		if errors.Is(err, entity.ErrMetricNotImplemented) {
+			return status.Errorf(codes.Unimplemented, err.Error())
+		}
+
+		return status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	for record := range s.recorder.Watch(stream.Context(), filter) {
+		resp, err := toMetricReq(record, s.signer)
+		if err != nil {
+			return status.Errorf(codes.Internal, err.Error())
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Query evaluates expression over stored metrics.
+func (s MetricsServer) Query(ctx context.Context, req *grpcapi.QueryRequest) (*grpcapi.QueryResponse, error) {
+	if len(req.Expr) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, entity.InvalidQueryError("expression not set").Error())
+	}
+
+	result, err := s.engine.Query(ctx, req.Expr)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrInvalidQuery), errors.Is(err, entity.ErrInvalidPattern):
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+
+		case errors.Is(err, entity.ErrMetricNotFound):
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return toQueryResponse(result), nil
+}
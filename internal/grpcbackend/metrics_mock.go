@@ -22,6 +22,14 @@ func (m *MetricsServerMock) Get(ctx context.Context, req *grpcapi.GetMetricReque
 	return args.Get(0).(*grpcapi.MetricReq), args.Error(1)
 }
 
+func (m *MetricsServerMock) Delete(
+	ctx context.Context,
+	req *grpcapi.GetMetricRequest,
+) (*grpcapi.DeleteMetricResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*grpcapi.DeleteMetricResponse), args.Error(1)
+}
+
 func (m *MetricsServerMock) BatchUpdate(
 	ctx context.Context,
 	req *grpcapi.BatchUpdateRequest,
@@ -29,3 +37,18 @@ func (m *MetricsServerMock) BatchUpdate(
 	args := m.Called(ctx, req)
 	return args.Get(0).(*grpcapi.BatchUpdateResponse), args.Error(1)
 }
+
+func (m *MetricsServerMock) List(ctx context.Context, req *grpcapi.ListRequest) (*grpcapi.ListResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*grpcapi.ListResponse), args.Error(1)
+}
+
+func (m *MetricsServerMock) StreamUpdate(stream grpcapi.Metrics_StreamUpdateServer) error {
+	args := m.Called(stream)
+	return args.Error(0)
+}
+
+func (m *MetricsServerMock) Watch(req *grpcapi.WatchRequest, stream grpcapi.Metrics_WatchServer) error {
+	args := m.Called(req, stream)
+	return args.Error(0)
+}
@@ -2,6 +2,8 @@ package grpcbackend_test
 
 import (
 	"context"
+	"errors"
+	"io"
 	"testing"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
@@ -13,6 +15,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestUpdateMetric(t *testing.T) {
@@ -93,6 +96,30 @@ func TestUpdateMetric(t *testing.T) {
 			recorderErr: entity.ErrUnexpected,
 			expected:    codes.Internal,
 		},
+		{
+			name:        "Push metrics is unavailable in maintenance mode",
+			req:         grpcapi.NewUpdateGaugeReq("Alloc", 13),
+			recorderErr: entity.ErrMaintenance,
+			expected:    codes.Unavailable,
+		},
+		{
+			name:        "Push metrics fails on new series above quota",
+			req:         grpcapi.NewUpdateGaugeReq("Alloc", 13),
+			recorderErr: entity.SeriesQuotaExceededError("", 100),
+			expected:    codes.ResourceExhausted,
+		},
+		{
+			name:        "Push metrics fails on new series above global limit",
+			req:         grpcapi.NewUpdateGaugeReq("Alloc", 13),
+			recorderErr: entity.SeriesLimitExceededError("global", 1000),
+			expected:    codes.ResourceExhausted,
+		},
+		{
+			name:        "Push metrics fails for tenant not forwarded to upstream",
+			req:         grpcapi.NewUpdateGaugeReq("Alloc", 13),
+			recorderErr: entity.ErrTenantNotForwarded,
+			expected:    codes.Unimplemented,
+		},
 	}
 
 	for _, tc := range tt {
@@ -224,6 +251,14 @@ func TestGetMetric(t *testing.T) {
 				code: codes.InvalidArgument,
 			},
 		},
+		{
+			name:        "Get metric fails if access denied",
+			req:         grpcapi.NewGetGaugeReq("Alloc"),
+			recorderErr: entity.AccessDeniedError("read", "Alloc"),
+			expected: result{
+				code: codes.PermissionDenied,
+			},
+		},
 		{
 			name:        "Get metric fails if recorder is broken",
 			req:         grpcapi.NewGetGaugeReq("Alloc"),
@@ -255,6 +290,105 @@ func TestGetMetric(t *testing.T) {
 	}
 }
 
+func TestGetMetricOfTenant(t *testing.T) {
+	require := require.New(t)
+
+	ofTenant := mock.MatchedBy(func(ctx context.Context) bool {
+		return entity.TenantFromContext(ctx) == "acme"
+	})
+
+	m := new(services.RecorderMock)
+	m.On("Get", ofTenant, metrics.KindCounter, "PollCount").
+		Return(storage.Record{Name: "PollCount", Value: metrics.Counter(10)}, nil)
+
+	conn, closer := createTestServer(t, m, nil, "")
+	t.Cleanup(closer)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), security.TenantHeader, "acme")
+
+	resp, err := grpcapi.NewMetricsClient(conn).Get(ctx, grpcapi.NewGetCounterReq("PollCount"))
+	require.NoError(err)
+	requireEqual(t, grpcapi.NewUpdateCounterReq("PollCount", 10), resp)
+
+	_, err = grpcapi.NewMetricsClient(conn).Get(
+		metadata.AppendToOutgoingContext(context.Background(), security.TenantHeader, "../acme"),
+		grpcapi.NewGetCounterReq("PollCount"),
+	)
+	requireEqualCode(t, codes.InvalidArgument, err)
+}
+
+func TestDeleteMetric(t *testing.T) {
+	tt := []struct {
+		name        string
+		req         *grpcapi.GetMetricRequest
+		recorderErr error
+		expected    codes.Code
+	}{
+		{
+			name:     "Delete metric",
+			req:      grpcapi.NewGetCounterReq("PollCount"),
+			expected: codes.OK,
+		},
+		{
+			name:     "Delete metric fails if type is unknown",
+			req:      &grpcapi.GetMetricRequest{Id: "Alloc", Mtype: "unknown"},
+			expected: codes.Unimplemented,
+		},
+		{
+			name:     "Delete metric fails if name is invalid",
+			req:      grpcapi.NewGetGaugeReq("X;"),
+			expected: codes.InvalidArgument,
+		},
+		{
+			name:        "Delete metric fails if metric has unknown ID",
+			req:         grpcapi.NewGetGaugeReq("unknown"),
+			recorderErr: entity.ErrMetricNotFound,
+			expected:    codes.NotFound,
+		},
+		{
+			name:        "Delete metric fails if access denied",
+			req:         grpcapi.NewGetGaugeReq("Alloc"),
+			recorderErr: entity.AccessDeniedError("delete", "Alloc"),
+			expected:    codes.PermissionDenied,
+		},
+		{
+			name:        "Delete metric fails in maintenance mode",
+			req:         grpcapi.NewGetGaugeReq("Alloc"),
+			recorderErr: entity.ErrMaintenance,
+			expected:    codes.Unavailable,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(services.RecorderMock)
+			m.On("Delete", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+				Return(tc.recorderErr)
+
+			conn, closer := createTestServer(t, m, nil, "")
+			t.Cleanup(closer)
+
+			client := grpcapi.NewMetricsClient(conn)
+			_, err := client.Delete(context.Background(), tc.req)
+
+			requireEqualCode(t, tc.expected, err)
+		})
+	}
+}
+
+func TestDeleteMetricIsFilteredByTrustedSubnet(t *testing.T) {
+	m := new(services.RecorderMock)
+
+	conn, closer := createFilteredTestServer(t, m, "192.168.0.0/32")
+	t.Cleanup(closer)
+
+	client := grpcapi.NewMetricsClient(conn)
+	_, err := client.Delete(context.Background(), grpcapi.NewGetCounterReq("PollCount"))
+
+	requireEqualCode(t, codes.PermissionDenied, err)
+	m.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestBatchUpdate(t *testing.T) {
 	batchReq := []*grpcapi.MetricReq{
 		grpcapi.NewUpdateCounterReq("PollCount", 10),
@@ -330,6 +464,14 @@ func TestBatchUpdate(t *testing.T) {
 				code: codes.Internal,
 			},
 		},
+		{
+			name:        "Batch update is unavailable in maintenance mode",
+			data:        batchReq,
+			recorderErr: entity.ErrMaintenance,
+			expected: expected{
+				code: codes.Unavailable,
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -363,3 +505,397 @@ func TestBatchUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamUpdate(t *testing.T) {
+	type expected struct {
+		code    codes.Code
+		summary *grpcapi.StreamSummary
+	}
+
+	tt := []struct {
+		name        string
+		data        []*grpcapi.MetricReq
+		recorderRv  []storage.Record
+		recorderErr error
+		expected    expected
+	}{
+		{
+			name: "Stream update records received metrics",
+			data: []*grpcapi.MetricReq{
+				grpcapi.NewUpdateCounterReq("PollCount", 10),
+				grpcapi.NewUpdateGaugeReq("Alloc", 11.23),
+			},
+			recorderRv: []storage.Record{
+				{Name: "Alloc", Value: metrics.Gauge(11.23)},
+				{Name: "PollCount", Value: metrics.Counter(10)},
+			},
+			expected: expected{
+				code:    codes.OK,
+				summary: &grpcapi.StreamSummary{Recorded: 2},
+			},
+		},
+		{
+			name: "Stream update skips metrics of unknown kind",
+			data: []*grpcapi.MetricReq{
+				grpcapi.NewUpdateCounterReq("PollCount", 10),
+				{Id: "xxx", Mtype: "unknown"},
+			},
+			recorderRv: []storage.Record{
+				{Name: "PollCount", Value: metrics.Counter(10)},
+			},
+			expected: expected{
+				code:    codes.OK,
+				summary: &grpcapi.StreamSummary{Recorded: 1, Rejected: 1},
+			},
+		},
+		{
+			name: "Stream update summarizes empty stream",
+			expected: expected{
+				code:    codes.OK,
+				summary: &grpcapi.StreamSummary{},
+			},
+		},
+		{
+			name:        "Stream update fails if recorder is broken",
+			data:        []*grpcapi.MetricReq{grpcapi.NewUpdateCounterReq("PollCount", 10)},
+			recorderErr: entity.ErrUnexpected,
+			expected: expected{
+				code: codes.Internal,
+			},
+		},
+		{
+			name:        "Stream update is unavailable in maintenance mode",
+			data:        []*grpcapi.MetricReq{grpcapi.NewUpdateCounterReq("PollCount", 10)},
+			recorderErr: entity.ErrMaintenance,
+			expected: expected{
+				code: codes.Unavailable,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			m := new(services.RecorderMock)
+			m.On("PushList", mock.Anything, mock.Anything).Return(tc.recorderRv, tc.recorderErr)
+
+			conn, closer := createTestServer(t, m, nil, "")
+			t.Cleanup(closer)
+
+			client := grpcapi.NewMetricsClient(conn)
+			stream, err := client.StreamUpdate(context.Background())
+			require.NoError(err)
+
+			for _, req := range tc.data {
+				require.NoError(stream.Send(req))
+			}
+
+			summary, err := stream.CloseAndRecv()
+			if tc.expected.code != codes.OK {
+				requireEqualCode(t, tc.expected.code, err)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tc.expected.summary.Recorded, summary.Recorded)
+			require.Equal(tc.expected.summary.Rejected, summary.Rejected)
+		})
+	}
+}
+
+func TestStreamUpdateRecordsMetricsAfterStreamIsClosed(t *testing.T) {
+	require := require.New(t)
+
+	m := new(services.RecorderMock)
+	m.On("PushList", mock.Anything, mock.Anything).Return([]storage.Record{}, nil)
+
+	conn, closer := createTestServer(t, m, nil, "")
+	t.Cleanup(closer)
+
+	client := grpcapi.NewMetricsClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.StreamUpdate(ctx)
+	require.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(stream.Send(grpcapi.NewUpdateCounterReq("PollCount", 10)))
+	}
+
+	cancel()
+
+	_, err = stream.CloseAndRecv()
+	requireEqualCode(t, codes.Canceled, err)
+
+	stream, err = client.StreamUpdate(context.Background())
+	require.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(stream.Send(grpcapi.NewUpdateCounterReq("PollCount", 10)))
+	}
+
+	_, err = stream.CloseAndRecv()
+	require.NoError(err)
+
+	m.AssertNumberOfCalls(t, "PushList", 1)
+	require.Len(m.Calls[0].Arguments.Get(1), 3)
+}
+
+func TestList(t *testing.T) {
+	page := storage.Page{
+		Records: []storage.Record{
+			{Name: "Alloc", Value: metrics.Gauge(11.23)},
+			{Name: "PollCount", Value: metrics.Counter(10)},
+		},
+		NextCursor: "UG9sbENvdW50OmNvdW50ZXI",
+	}
+
+	type expected struct {
+		code     codes.Code
+		opts     storage.ListOptions
+		response *grpcapi.ListResponse
+	}
+
+	tt := []struct {
+		name        string
+		req         *grpcapi.ListRequest
+		recorderErr error
+		expected    expected
+	}{
+		{
+			name: "List returns first page of default size",
+			req:  &grpcapi.ListRequest{},
+			expected: expected{
+				code: codes.OK,
+				opts: storage.ListOptions{Limit: entity.DefaultPageSize},
+				response: &grpcapi.ListResponse{
+					Data: []*grpcapi.MetricReq{
+						grpcapi.NewUpdateGaugeReq("Alloc", 11.23),
+						grpcapi.NewUpdateCounterReq("PollCount", 10),
+					},
+					NextCursor: "UG9sbENvdW50OmNvdW50ZXI",
+				},
+			},
+		},
+		{
+			name: "List passes filters and cursor to recorder",
+			req:  &grpcapi.ListRequest{Prefix: "Poll", Mtype: "counter", Limit: 2, Cursor: "abc"},
+			expected: expected{
+				code: codes.OK,
+				opts: storage.ListOptions{Prefix: "Poll", Kind: "counter", Limit: 2, Cursor: "abc"},
+				response: &grpcapi.ListResponse{
+					Data: []*grpcapi.MetricReq{
+						grpcapi.NewUpdateGaugeReq("Alloc", 11.23),
+						grpcapi.NewUpdateCounterReq("PollCount", 10),
+					},
+					NextCursor: "UG9sbENvdW50OmNvdW50ZXI",
+				},
+			},
+		},
+		{
+			name: "List passes glob to recorder",
+			req:  &grpcapi.ListRequest{Match: "Poll*"},
+			expected: expected{
+				code: codes.OK,
+				opts: storage.ListOptions{Match: "^Poll.*$", Limit: entity.DefaultPageSize},
+				response: &grpcapi.ListResponse{
+					Data: []*grpcapi.MetricReq{
+						grpcapi.NewUpdateGaugeReq("Alloc", 11.23),
+						grpcapi.NewUpdateCounterReq("PollCount", 10),
+					},
+					NextCursor: "UG9sbENvdW50OmNvdW50ZXI",
+				},
+			},
+		},
+		{
+			name: "List passes regular expression to recorder",
+			req:  &grpcapi.ListRequest{Regex: "Count$"},
+			expected: expected{
+				code: codes.OK,
+				opts: storage.ListOptions{Match: "Count$", Limit: entity.DefaultPageSize},
+				response: &grpcapi.ListResponse{
+					Data: []*grpcapi.MetricReq{
+						grpcapi.NewUpdateGaugeReq("Alloc", 11.23),
+						grpcapi.NewUpdateCounterReq("PollCount", 10),
+					},
+					NextCursor: "UG9sbENvdW50OmNvdW50ZXI",
+				},
+			},
+		},
+		{
+			name: "List fails on invalid regular expression",
+			req:  &grpcapi.ListRequest{Regex: "Poll("},
+			expected: expected{
+				code: codes.InvalidArgument,
+			},
+		},
+		{
+			name: "List fails if both glob and regular expression provided",
+			req:  &grpcapi.ListRequest{Match: "Poll*", Regex: "Poll"},
+			expected: expected{
+				code: codes.InvalidArgument,
+			},
+		},
+		{
+			name: "List fails on unknown metric kind",
+			req:  &grpcapi.ListRequest{Mtype: "unknown"},
+			expected: expected{
+				code: codes.Unimplemented,
+			},
+		},
+		{
+			name: "List fails on negative limit",
+			req:  &grpcapi.ListRequest{Limit: -1},
+			expected: expected{
+				code: codes.InvalidArgument,
+			},
+		},
+		{
+			name: "List fails on too big limit",
+			req:  &grpcapi.ListRequest{Limit: entity.MaxPageSize + 1},
+			expected: expected{
+				code: codes.InvalidArgument,
+			},
+		},
+		{
+			name:        "List fails on invalid cursor",
+			req:         &grpcapi.ListRequest{Cursor: "???"},
+			recorderErr: entity.ErrInvalidCursor,
+			expected: expected{
+				code: codes.InvalidArgument,
+				opts: storage.ListOptions{Limit: entity.DefaultPageSize, Cursor: "???"},
+			},
+		},
+		{
+			name:        "List fails if recorder is broken",
+			req:         &grpcapi.ListRequest{},
+			recorderErr: entity.ErrUnexpected,
+			expected: expected{
+				code: codes.Internal,
+				opts: storage.ListOptions{Limit: entity.DefaultPageSize},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(services.RecorderMock)
+			m.On("List", mock.Anything, tc.expected.opts).Return(page, tc.recorderErr)
+
+			conn, closer := createTestServer(t, m, nil, "")
+			t.Cleanup(closer)
+
+			client := grpcapi.NewMetricsClient(conn)
+			resp, err := client.List(context.Background(), tc.req)
+
+			requireEqualCode(t, tc.expected.code, err)
+			if tc.expected.code == codes.OK {
+				require.Equal(t, tc.expected.response.Data, resp.Data)
+				require.Equal(t, tc.expected.response.NextCursor, resp.NextCursor)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	updates := []storage.Record{
+		{Name: "PollCount", Value: metrics.Counter(10)},
+		{Name: "PollCount", Value: metrics.Counter(15)},
+	}
+
+	type expected struct {
+		code     codes.Code
+		filter   storage.Filter
+		response []*grpcapi.MetricReq
+	}
+
+	tt := []struct {
+		name     string
+		req      *grpcapi.WatchRequest
+		expected expected
+	}{
+		{
+			name: "Watch streams updates of all metrics",
+			req:  &grpcapi.WatchRequest{},
+			expected: expected{
+				code: codes.OK,
+				response: []*grpcapi.MetricReq{
+					grpcapi.NewUpdateCounterReq("PollCount", 10),
+					grpcapi.NewUpdateCounterReq("PollCount", 15),
+				},
+			},
+		},
+		{
+			name: "Watch passes filter to recorder",
+			req:  &grpcapi.WatchRequest{Ids: []string{"PollCount"}, Mtypes: []string{"counter"}},
+			expected: expected{
+				code:   codes.OK,
+				filter: storage.Filter{Names: []string{"PollCount"}, Kinds: []string{"counter"}},
+				response: []*grpcapi.MetricReq{
+					grpcapi.NewUpdateCounterReq("PollCount", 10),
+					grpcapi.NewUpdateCounterReq("PollCount", 15),
+				},
+			},
+		},
+		{
+			name: "Watch fails on unknown metric kind",
+			req:  &grpcapi.WatchRequest{Mtypes: []string{"unknown"}},
+			expected: expected{
+				code: codes.Unimplemented,
+			},
+		},
+		{
+			name: "Watch fails on invalid metric name",
+			req:  &grpcapi.WatchRequest{Ids: []string{"X;"}},
+			expected: expected{
+				code: codes.InvalidArgument,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			ch := make(chan storage.Record, len(updates))
+			for _, record := range updates {
+				ch <- record
+			}
+			close(ch)
+
+			m := new(services.RecorderMock)
+			m.On("Watch", mock.Anything, tc.expected.filter).Return((<-chan storage.Record)(ch))
+
+			conn, closer := createTestServer(t, m, nil, "")
+			t.Cleanup(closer)
+
+			client := grpcapi.NewMetricsClient(conn)
+			stream, err := client.Watch(context.Background(), tc.req)
+			require.NoError(err)
+
+			received := make([]*grpcapi.MetricReq, 0)
+
+			for {
+				resp, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if err != nil {
+					requireEqualCode(t, tc.expected.code, err)
+					return
+				}
+
+				received = append(received, resp)
+			}
+
+			require.Equal(codes.OK, tc.expected.code)
+			require.Len(received, len(tc.expected.response))
+
+			for i := range received {
+				requireEqual(t, tc.expected.response[i], received[i])
+			}
+		})
+	}
+}
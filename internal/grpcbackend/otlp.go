@@ -0,0 +1,41 @@
+package grpcbackend
+
+import (
+	"context"
+
+	"github.com/alkurbatov/metrics-collector/internal/otlp"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"google.golang.org/grpc"
+)
+
+// OTLPServer receives metrics via OpenTelemetry protocol.
+type OTLPServer struct {
+	grpcapi.UnimplementedMetricsServiceServer
+
+	recorder services.Recorder
+}
+
+// NewOTLPServer creates new instance of gRPC serving OTLP MetricsService and attaches it to the server.
+func NewOTLPServer(server *grpc.Server, recorder services.Recorder) {
+	s := &OTLPServer{recorder: recorder}
+
+	grpcapi.RegisterMetricsServiceServer(server, s)
+}
+
+// Export records data points of OpenTelemetry metrics.
+// Data points which can't be converted are skipped and reported as partial success.
+func (s OTLPServer) Export(
+	ctx context.Context,
+	req *grpcapi.ExportMetricsServiceRequest,
+) (*grpcapi.ExportMetricsServiceResponse, error) {
+	records, partial := otlp.Convert(req)
+
+	if len(records) != 0 {
+		if _, err := s.recorder.PushList(ctx, records); err != nil {
+			return nil, pushErrorStatus(err)
+		}
+	}
+
+	return &grpcapi.ExportMetricsServiceResponse{PartialSuccess: partial}, nil
+}
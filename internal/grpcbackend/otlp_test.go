@@ -0,0 +1,138 @@
+package grpcbackend_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func newExportRequest(data ...*grpcapi.Metric) *grpcapi.ExportMetricsServiceRequest {
+	return &grpcapi.ExportMetricsServiceRequest{
+		ResourceMetrics: []*grpcapi.ResourceMetrics{
+			{ScopeMetrics: []*grpcapi.ScopeMetrics{{Metrics: data}}},
+		},
+	}
+}
+
+func newGaugeMetric(name string, value float64) *grpcapi.Metric {
+	return &grpcapi.Metric{
+		Name: name,
+		Data: &grpcapi.Metric_Gauge{Gauge: &grpcapi.Gauge{
+			DataPoints: []*grpcapi.NumberDataPoint{
+				{Value: &grpcapi.NumberDataPoint_AsDouble{AsDouble: value}},
+			},
+		}},
+	}
+}
+
+func TestExport(t *testing.T) {
+	type expected struct {
+		code     codes.Code
+		rejected int64
+	}
+
+	tt := []struct {
+		name        string
+		req         *grpcapi.ExportMetricsServiceRequest
+		records     []storage.Record
+		recorderErr error
+		expected    expected
+	}{
+		{
+			name:    "Export records data points",
+			req:     newExportRequest(newGaugeMetric("process.cpu.load", 0.5)),
+			records: []storage.Record{{Name: "ProcessCpuLoad", Value: metrics.Gauge(0.5)}},
+			expected: expected{
+				code: codes.OK,
+			},
+		},
+		{
+			name: "Export reports rejected data points",
+			req: newExportRequest(
+				newGaugeMetric("process.cpu.load", 0.5),
+				&grpcapi.Metric{
+					Name: "rpc.duration",
+					Data: &grpcapi.Metric_Summary{Summary: &grpcapi.Summary{
+						DataPoints: []*grpcapi.SummaryDataPoint{{}},
+					}},
+				},
+			),
+			records: []storage.Record{{Name: "ProcessCpuLoad", Value: metrics.Gauge(0.5)}},
+			expected: expected{
+				code:     codes.OK,
+				rejected: 1,
+			},
+		},
+		{
+			name: "Export accepts empty request",
+			req:  new(grpcapi.ExportMetricsServiceRequest),
+			expected: expected{
+				code: codes.OK,
+			},
+		},
+		{
+			name:        "Export fails if recorder is broken",
+			req:         newExportRequest(newGaugeMetric("process.cpu.load", 0.5)),
+			records:     []storage.Record{{Name: "ProcessCpuLoad", Value: metrics.Gauge(0.5)}},
+			recorderErr: entity.ErrUnexpected,
+			expected: expected{
+				code: codes.Internal,
+			},
+		},
+		{
+			name:        "Export is unavailable in maintenance mode",
+			req:         newExportRequest(newGaugeMetric("process.cpu.load", 0.5)),
+			records:     []storage.Record{{Name: "ProcessCpuLoad", Value: metrics.Gauge(0.5)}},
+			recorderErr: entity.ErrMaintenance,
+			expected: expected{
+				code: codes.Unavailable,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(services.RecorderMock)
+			m.On("PushList", mock.Anything, tc.records).Return(tc.records, tc.recorderErr)
+
+			conn, closer := createTestServer(t, m, nil, "")
+			t.Cleanup(closer)
+
+			client := grpcapi.NewMetricsServiceClient(conn)
+			resp, err := client.Export(context.Background(), tc.req)
+
+			requireEqualCode(t, tc.expected.code, err)
+
+			if len(tc.records) == 0 {
+				m.AssertNotCalled(t, "PushList", mock.Anything, mock.Anything)
+			}
+
+			if tc.expected.code != codes.OK {
+				return
+			}
+
+			require.Equal(t, tc.expected.rejected, resp.GetPartialSuccess().GetRejectedDataPoints())
+		})
+	}
+}
+
+func TestExportIsFilteredByTrustedSubnet(t *testing.T) {
+	m := new(services.RecorderMock)
+
+	conn, closer := createFilteredTestServer(t, m, "192.168.0.0/32")
+	t.Cleanup(closer)
+
+	client := grpcapi.NewMetricsServiceClient(conn)
+	_, err := client.Export(context.Background(), newExportRequest(newGaugeMetric("process.cpu.load", 0.5)))
+
+	requireEqualCode(t, codes.PermissionDenied, err)
+	m.AssertNotCalled(t, "PushList", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,93 @@
+package grpcbackend_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestQuery(t *testing.T) {
+	tt := []struct {
+		name     string
+		expr     string
+		expected *grpcapi.QueryResponse
+		code     codes.Code
+	}{
+		{
+			name:     "Should evaluate aggregate",
+			expr:     "sum(PollCount*)",
+			expected: &grpcapi.QueryResponse{Type: "scalar", Scalar: 30},
+			code:     codes.OK,
+		},
+		{
+			name: "Should evaluate list of metrics",
+			expr: "PollCount* * 2",
+			expected: &grpcapi.QueryResponse{
+				Type: "vector",
+				Vector: []*grpcapi.QuerySample{
+					{Id: "PollCountAgent1", Mtype: metrics.KindCounter, Value: 20},
+					{Id: "PollCountAgent2", Mtype: metrics.KindCounter, Value: 40},
+				},
+			},
+			code: codes.OK,
+		},
+		{
+			name: "Should fail if expression not set",
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "Should fail on invalid expression",
+			expr: "sum(PollCount*) *",
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "Should fail if no metrics match",
+			expr: "avg(Unknown)",
+			code: codes.NotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			m := new(services.RecorderMock)
+			m.On("List", mock.Anything, mock.MatchedBy(func(opts storage.ListOptions) bool {
+				return opts.Match == "^PollCount.*$"
+			})).Return(storage.Page{Records: []storage.Record{
+				{Name: "PollCountAgent1", Value: metrics.Counter(10)},
+				{Name: "PollCountAgent2", Value: metrics.Counter(20)},
+			}}, nil)
+			m.On("List", mock.Anything, mock.Anything).Return(storage.Page{}, nil)
+
+			conn, closer := createTestServer(t, m, nil, "")
+			defer closer()
+
+			client := grpcapi.NewMetricsClient(conn)
+			resp, err := client.Query(context.Background(), &grpcapi.QueryRequest{Expr: tc.expr})
+
+			if tc.code != codes.OK {
+				requireEqualCode(t, tc.code, err)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tc.expected.Type, resp.Type)
+			require.Equal(tc.expected.Scalar, resp.Scalar)
+			require.Len(resp.Vector, len(tc.expected.Vector))
+
+			for i := range tc.expected.Vector {
+				require.Equal(tc.expected.Vector[i].Id, resp.Vector[i].Id)
+				require.Equal(tc.expected.Vector[i].Mtype, resp.Vector[i].Mtype)
+				require.Equal(tc.expected.Vector[i].Value, resp.Vector[i].Value)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@
 package grpcserver
 
 import (
+	"context"
 	"net"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
@@ -15,21 +16,59 @@ type Server struct {
 	address entity.NetAddress
 	server  *grpc.Server
 	notify  chan error
+
+	// Closed on shutdown to interrupt long-living streaming calls.
+	quit chan struct{}
 }
 
 // New creates new instance of gRPC server.
 func New(address entity.NetAddress, opts ...grpc.ServerOption) *Server {
-	grpcServer := grpc.NewServer(opts...)
-
 	s := &Server{
 		address: address,
-		server:  grpcServer,
 		notify:  make(chan error, 1),
+		quit:    make(chan struct{}),
 	}
 
+	opts = append(opts, grpc.ChainStreamInterceptor(s.interruptStreams))
+	s.server = grpc.NewServer(opts...)
+
 	return s
 }
 
+// interruptedStream replaces context of the stream with cancellable one.
+type interruptedStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *interruptedStream) Context() context.Context {
+	return s.ctx
+}
+
+// interruptStreams cancels context of streaming calls on shutdown of the server,
+// otherwise graceful stop waits for long-living streams forever.
+func (s *Server) interruptStreams(
+	srv any,
+	ss grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, cancel := context.WithCancel(ss.Context())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-s.quit:
+			cancel()
+
+		case <-ctx.Done():
+		}
+	}()
+
+	return handler(srv, &interruptedStream{ServerStream: ss, ctx: ctx})
+}
+
 // Instance grants access to the underlying gRPC server.
 // Should be used to attach new API services.
 func (s *Server) Instance() *grpc.Server {
@@ -62,5 +101,6 @@ func (s *Server) Shutdown() {
 		return
 	}
 
+	close(s.quit)
 	s.server.GracefulStop()
 }
@@ -0,0 +1,35 @@
+package httpbackend
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+)
+
+type alertsResource struct {
+	alerts *alerting.Manager
+}
+
+func newAlertsResource(alerts *alerting.Manager) alertsResource {
+	return alertsResource{alerts: alerts}
+}
+
+// List godoc
+// @Tags Alerts
+// @Router /alerts [get]
+// @Summary List pending and firing alerts
+// @ID alerts_list
+// @Produce json
+// @Success 200 {object} metrics.AlertsResponse
+// @Failure 500 {string} string http.StatusInternalServerError
+func (h alertsResource) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(toAlertsResponse(h.alerts.AlertsOf(ctx))); err != nil {
+		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+}
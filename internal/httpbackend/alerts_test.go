@@ -0,0 +1,121 @@
+package httpbackend_test
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/httpbackend"
+	"github.com/alkurbatov/metrics-collector/internal/query"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type notifierStub struct{}
+
+func (notifierStub) Notify(alerting.Alert) {}
+
+func TestListAlerts(t *testing.T) {
+	tt := []struct {
+		name     string
+		rules    []string
+		expected string
+	}{
+		{
+			name: "Should list firing and pending alerts",
+			rules: []string{
+				"HighPollCount = PollCount* > 15",
+				"TooManyPolls = sum(PollCount*) > 25 for 1m severity critical",
+			},
+			expected: `{"data":[` +
+				`{"name":"HighPollCount","metric":"PollCountAgent2","severity":"warning",` +
+				`"condition":"PollCount* > 15","state":"firing","value":20,` +
+				`"active_since":"1970-01-01T00:00:01Z","fired_at":"1970-01-01T00:00:01Z"},` +
+				`{"name":"TooManyPolls","severity":"critical","condition":"sum(PollCount*) > 25",` +
+				`"state":"pending","value":30,"active_since":"1970-01-01T00:00:01Z"}]}`,
+		},
+		{
+			name:     "Should return empty list if no alerts",
+			rules:    []string{"HighPollCount = PollCount* > 100"},
+			expected: `{"data":[]}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			m := new(services.RecorderMock)
+			m.On("List", mock.Anything, mock.Anything).Return(storage.Page{Records: []storage.Record{
+				{Name: "PollCountAgent1", Value: metrics.Counter(10)},
+				{Name: "PollCountAgent2", Value: metrics.Counter(20)},
+			}}, nil)
+
+			rules, err := alerting.ParseRules(tc.rules)
+			require.NoError(err)
+
+			engine := query.NewEngine(m, query.NewHistory(m, 0))
+			alerts := alerting.NewManager(engine, rules, nil, nil, notifierStub{})
+			alerts.Evaluate(context.Background(), time.Unix(1, 0).UTC())
+
+			view, err := template.ParseFiles("../../web/views/metrics.html")
+			require.NoError(err)
+
+			router := httpbackend.Router(httpbackend.Deps{
+				Address:  "0.0.0.0:8080",
+				View:     view,
+				Recorder: m,
+				Engine:   engine,
+				Alerts:   alerts,
+			})
+			code, contentType, body := sendTestRequest(t, router, http.MethodGet, "/alerts", nil)
+
+			require.Equal(http.StatusOK, code)
+			require.Equal("application/json", contentType)
+			require.JSONEq(tc.expected, string(body))
+		})
+	}
+}
+
+func TestListAlertsOfTenant(t *testing.T) {
+	require := require.New(t)
+
+	m := new(services.RecorderMock)
+	m.On("List", mock.Anything, mock.Anything).Return(storage.Page{Records: []storage.Record{
+		{Name: "PollCountAgent1", Value: metrics.Counter(10)},
+	}}, nil)
+
+	rules, err := alerting.ParseRules([]string{"HighPollCount = PollCount* > 5"})
+	require.NoError(err)
+
+	engine := query.NewEngine(m, query.NewHistory(m, 0))
+	alerts := alerting.NewManager(engine, rules, nil, nil, notifierStub{})
+	alerts.Evaluate(context.Background(), time.Unix(1, 0).UTC())
+
+	view, err := template.ParseFiles("../../web/views/metrics.html")
+	require.NoError(err)
+
+	srv := httptest.NewServer(httpbackend.Router(httpbackend.Deps{
+		Address:  "0.0.0.0:8080",
+		View:     view,
+		Recorder: m,
+		Engine:   engine,
+		Alerts:   alerts,
+	}))
+	defer srv.Close()
+
+	code, body := sendTenantRequest(t, srv, http.MethodGet, "/alerts", "acme")
+	require.Equal(http.StatusOK, code)
+	require.JSONEq(`{"data":[]}`, body)
+
+	code, body = sendTenantRequest(t, srv, http.MethodGet, "/alerts", "")
+	require.Equal(http.StatusOK, code)
+	require.Contains(body, "PollCountAgent1")
+}
@@ -0,0 +1,182 @@
+package httpbackend_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/httpbackend"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthorizedRouter(t *testing.T) http.Handler {
+	t.Helper()
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	err := os.WriteFile(path, []byte(`[
+{"name": "agent", "token": "agent-token", "scopes": ["write"]},
+{"name": "viewer", "token": "viewer-token", "scopes": ["read"]},
+{"name": "ops", "token": "ops-token", "scopes": ["admin"]}
+]`), 0600)
+	require.NoError(err)
+
+	tokens, err := security.NewTokens(entity.FilePath(path))
+	require.NoError(err)
+
+	view, err := template.ParseFiles("../../web/views/metrics.html")
+	require.NoError(err)
+
+	m := new(services.MaintenanceMock)
+	m.On("Enable", mock.Anything)
+	m.On("Enabled").Return(true)
+
+	h := new(services.HealthCheckMock)
+	h.On("CheckStorage", mock.Anything).Return(nil)
+
+	r := new(services.RecorderMock)
+	r.On("List", mock.Anything, mock.Anything).Return(storage.Page{}, nil)
+
+	return httpbackend.Router(httpbackend.Deps{
+		Address:     "0.0.0.0:8080",
+		View:        view,
+		Recorder:    r,
+		HealthCheck: h,
+		Maintenance: m,
+		Tokens:      tokens,
+	})
+}
+
+func TestRoutesRequireTokenScopes(t *testing.T) {
+	tt := []struct {
+		name     string
+		method   string
+		path     string
+		payload  string
+		token    security.Secret
+		expected int
+	}{
+		{
+			name:     "Should allow read with read scope",
+			method:   http.MethodGet,
+			path:     "/maintenance",
+			token:    "viewer-token",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "Should allow read with admin scope",
+			method:   http.MethodGet,
+			path:     "/maintenance",
+			token:    "ops-token",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "Should reject read with write scope",
+			method:   http.MethodGet,
+			path:     "/maintenance",
+			token:    "agent-token",
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "Should reject read without token",
+			method:   http.MethodGet,
+			path:     "/maintenance",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "Should allow maintenance with admin scope",
+			method:   http.MethodPut,
+			path:     "/maintenance",
+			payload:  `{"enabled":true}`,
+			token:    "ops-token",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "Should reject maintenance with write scope",
+			method:   http.MethodPut,
+			path:     "/maintenance",
+			payload:  `{"enabled":true}`,
+			token:    "agent-token",
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "Should reject update with read scope",
+			method:   http.MethodPost,
+			path:     "/update/counter/PollCount/1",
+			token:    "viewer-token",
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "Should reject remote write without token",
+			method:   http.MethodPost,
+			path:     "/api/v1/write",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "Should allow metrics page with token in query",
+			method:   http.MethodGet,
+			path:     "/?token=viewer-token",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "Should reject metrics page with token in query without scope",
+			method:   http.MethodGet,
+			path:     "/?token=agent-token",
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "Should reject metrics page without token",
+			method:   http.MethodGet,
+			path:     "/",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "Should reject token in query outside of pages",
+			method:   http.MethodGet,
+			path:     "/maintenance?token=viewer-token",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "Should allow ping without token",
+			method:   http.MethodGet,
+			path:     "/ping",
+			expected: http.StatusOK,
+		},
+	}
+
+	router := newAuthorizedRouter(t)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			srv := httptest.NewServer(router)
+			defer srv.Close()
+
+			req, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.payload))
+			require.NoError(err)
+
+			if len(tc.token) != 0 {
+				req.Header.Set("Authorization", security.BearerToken(tc.token))
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(err)
+
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			require.Equal(tc.expected, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package httpbackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+)
+
+// Count of top series creators returned by default.
+const _defaultTopCreators = 10
+
+type cardinalityResource struct {
+	cardinality services.Cardinality
+}
+
+func newCardinalityResource(cardinality services.Cardinality) cardinalityResource {
+	return cardinalityResource{cardinality: cardinality}
+}
+
+func parseTopCreators(r *http.Request) (int, error) {
+	rawLimit := r.URL.Query().Get("limit")
+	if len(rawLimit) == 0 {
+		return _defaultTopCreators, nil
+	}
+
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil {
+		return 0, fmt.Errorf("httpbackend - parseTopCreators - strconv.Atoi: %w", err)
+	}
+
+	if limit <= 0 || limit > entity.MaxPageSize {
+		return 0, entity.ErrInvalidPageSize
+	}
+
+	return limit, nil
+}
+
+// Report godoc
+// @Tags Metrics
+// @Router /cardinality [get]
+// @Summary Get count of stored series and clients created most of them
+// @ID cardinality_report
+// @Produce json
+// @Param limit query int false "Count of top series creators, 10 by default."
+// @Success 200 {object} metrics.CardinalityResponse
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 500 {string} string http.StatusInternalServerError
+func (h cardinalityResource) Report(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, err := parseTopCreators(r)
+	if err != nil {
+		writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.cardinality.Report(ctx, limit)
+	if err != nil {
+		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(toCardinalityResponse(report)); err != nil {
+		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+}
@@ -0,0 +1,102 @@
+package httpbackend_test
+
+import (
+	"html/template"
+	"net/http"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/httpbackend"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardinalityReport(t *testing.T) {
+	type result struct {
+		code int
+		body string
+	}
+
+	report := services.CardinalityReport{
+		Series: 120,
+		Limits: services.SeriesLimits{Global: 1000, Client: 100},
+		Creators: []services.SeriesCreator{
+			{Client: "identity:agent", Series: 100},
+			{Client: "ip:10.0.0.1", Series: 20},
+		},
+	}
+
+	tt := []struct {
+		name      string
+		path      string
+		count     int
+		reportErr error
+		expected  result
+	}{
+		{
+			name:  "Should report top 10 series creators by default",
+			path:  "/cardinality",
+			count: 10,
+			expected: result{
+				code: http.StatusOK,
+				body: `{"series":120,"tenant_limit":0,"global_limit":1000,"client_limit":100,` +
+					`"top_creators":[{"client":"identity:agent","series":100},{"client":"ip:10.0.0.1","series":20}]}`,
+			},
+		},
+		{
+			name:  "Should report requested count of series creators",
+			path:  "/cardinality?limit=50",
+			count: 50,
+			expected: result{
+				code: http.StatusOK,
+				body: `{"series":120,"tenant_limit":0,"global_limit":1000,"client_limit":100,` +
+					`"top_creators":[{"client":"identity:agent","series":100},{"client":"ip:10.0.0.1","series":20}]}`,
+			},
+		},
+		{
+			name:      "Should fail if series cannot be counted",
+			path:      "/cardinality",
+			count:     10,
+			reportErr: entity.ErrUnexpected,
+			expected:  result{code: http.StatusInternalServerError},
+		},
+		{
+			name:     "Should fail on invalid limit",
+			path:     "/cardinality?limit=abc",
+			expected: result{code: http.StatusBadRequest},
+		},
+		{
+			name:     "Should fail on zero limit",
+			path:     "/cardinality?limit=0",
+			expected: result{code: http.StatusBadRequest},
+		},
+		{
+			name:     "Should fail on too large limit",
+			path:     "/cardinality?limit=100000",
+			expected: result{code: http.StatusBadRequest},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			m := new(services.CardinalityMock)
+			m.On("Report", mock.Anything, tc.count).Return(report, tc.reportErr)
+
+			view, err := template.ParseFiles("../../web/views/metrics.html")
+			require.NoError(err)
+
+			router := httpbackend.Router(httpbackend.Deps{Address: "0.0.0.0:8080", View: view, Cardinality: m})
+			code, _, body := sendTestRequest(t, router, http.MethodGet, tc.path, nil)
+
+			require.Equal(tc.expected.code, code)
+
+			if tc.expected.code == http.StatusOK {
+				require.JSONEq(tc.expected.body, string(body))
+				m.AssertExpectations(t)
+			}
+		})
+	}
+}
@@ -3,9 +3,11 @@ package httpbackend
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"html/template"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 
@@ -47,6 +49,67 @@ func parseUpdateMetricReqList(r *http.Request, signer *security.Signer) ([]stora
 	return rv, nil
 }
 
+func parseListOptions(r *http.Request) (storage.ListOptions, error) {
+	query := r.URL.Query()
+	opts := storage.ListOptions{
+		Prefix: query.Get("prefix"),
+		Kind:   query.Get("kind"),
+		Limit:  entity.DefaultPageSize,
+		Cursor: query.Get("cursor"),
+	}
+
+	if len(opts.Kind) != 0 {
+		if err := validators.ValidateMetricKind(opts.Kind); err != nil {
+			return opts, err
+		}
+	}
+
+	match, err := storage.NamePattern(query.Get("match"), query.Get("regex"))
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Match = match
+
+	if rawLimit := query.Get("limit"); len(rawLimit) != 0 {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil {
+			return opts, fmt.Errorf("httpbackend - parseListOptions - strconv.Atoi: %w", err)
+		}
+
+		if limit <= 0 || limit > entity.MaxPageSize {
+			return opts, entity.ErrInvalidPageSize
+		}
+
+		opts.Limit = limit
+	}
+
+	return opts, nil
+}
+
+// listView represents data rendered on HTML page with list of metrics.
+type listView struct {
+	// Tenant owning the metrics, empty for the default tenant.
+	Tenant string
+
+	Records []storage.Record
+
+	// Query string of the request to the next page, empty if there are no more records.
+	NextPage string
+}
+
+func newListView(r *http.Request, page storage.Page) listView {
+	rv := listView{Tenant: entity.TenantFromContext(r.Context()), Records: page.Records}
+
+	if len(page.NextCursor) != 0 {
+		query := r.URL.Query()
+		query.Set("cursor", page.NextCursor)
+		rv.NextPage = "?" + query.Encode()
+	}
+
+	return rv
+}
+
 func newMetricsResource(
 	view *template.Template,
 	recorder services.Recorder,
@@ -66,8 +129,11 @@ func newMetricsResource(
 // @Param value path string true "Metrics value, must be convertable to `int64` or `float64`."
 // @Success 200 {string} string
 // @Failure 400 {string} string http.StatusBadRequest
+// @Failure 403 {string} string "Access to metric denied"
+// @Failure 429 {string} string "Too many requests or series quota exceeded"
 // @Failure 500 {string} string http.StatusInternalServerError
 // @Failure 501 {string} string "Metric type is not supported"
+// @Failure 503 {string} string "Server is in maintenance mode"
 func (h metricsResource) Update(w http.ResponseWriter, r *http.Request) {
 	req := metrics.MetricReq{
 		ID:    chi.URLParam(r, "name"),
@@ -110,7 +176,7 @@ func (h metricsResource) Update(w http.ResponseWriter, r *http.Request) {
 
 	recorded, err := h.recorder.Push(r.Context(), record)
 	if err != nil {
-		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		writePushErrorResponse(ctx, w, err)
 		return
 	}
 
@@ -129,8 +195,11 @@ func (h metricsResource) Update(w http.ResponseWriter, r *http.Request) {
 // @Param request body metrics.MetricReq true "Request parameters."
 // @Success 200 {object} metrics.MetricReq
 // @Failure 400 {string} string http.StatusBadRequest
+// @Failure 403 {string} string "Access to metric denied"
+// @Failure 429 {string} string "Too many requests or series quota exceeded"
 // @Failure 500 {string} string http.StatusInternalServerError
 // @Failure 501 {string} string "Metric type is not supported"
+// @Failure 503 {string} string "Server is in maintenance mode"
 func (h metricsResource) UpdateJSON(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -154,7 +223,7 @@ func (h metricsResource) UpdateJSON(w http.ResponseWriter, r *http.Request) {
 
 	recorded, err := h.recorder.Push(r.Context(), record)
 	if err != nil {
-		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		writePushErrorResponse(ctx, w, err)
 		return
 	}
 
@@ -181,8 +250,11 @@ func (h metricsResource) UpdateJSON(w http.ResponseWriter, r *http.Request) {
 // @Param request body []metrics.MetricReq true "List of metrics to update."
 // @Success 200 {object} []metrics.MetricReq
 // @Failure 400 {string} string http.StatusBadRequest
+// @Failure 403 {string} string "Access to metric denied"
+// @Failure 429 {string} string "Too many requests or series quota exceeded"
 // @Failure 500 {string} string http.StatusInternalServerError
 // @Failure 501 {string} string "Metric type is not supported"
+// @Failure 503 {string} string "Server is in maintenance mode"
 func (h metricsResource) BatchUpdateJSON(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -200,7 +272,7 @@ func (h metricsResource) BatchUpdateJSON(w http.ResponseWriter, r *http.Request)
 
 	records, err := h.recorder.PushList(r.Context(), req)
 	if err != nil {
-		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		writePushErrorResponse(ctx, w, err)
 		return
 	}
 
@@ -228,6 +300,7 @@ func (h metricsResource) BatchUpdateJSON(w http.ResponseWriter, r *http.Request)
 // @Param name path string true "Metrics name."
 // @Success 200 {string} string
 // @Failure 400 {string} string http.StatusBadRequest
+// @Failure 403 {string} string "Access to metric denied"
 // @Failure 404 {string} string "Metric not found"
 // @Failure 500 {string} string http.StatusInternalServerError
 // @Failure 501 {string} string "Metric type is not supported"
@@ -254,6 +327,11 @@ func (h metricsResource) Get(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, entity.ErrAccessDenied) {
+			writeErrorResponse(ctx, w, http.StatusForbidden, err)
+			return
+		}
+
 		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
 
 		return
@@ -265,6 +343,50 @@ func (h metricsResource) Get(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Delete godoc
+// @Tags Metrics
+// @Router /value/{type}/{name} [delete]
+// @Summary Delete stored metric
+// @ID metrics_delete
+// @Param type path string true "Metrics type (e.g. `counter`, `gauge`)."
+// @Param name path string true "Metrics name."
+// @Success 204
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 403 {string} string "Access to metric denied"
+// @Failure 404 {string} string "Metric not found"
+// @Failure 500 {string} string http.StatusInternalServerError
+// @Failure 501 {string} string "Metric type is not supported"
+// @Failure 503 {string} string "Server is in maintenance mode"
+func (h metricsResource) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	kind := chi.URLParam(r, "kind")
+	name := chi.URLParam(r, "name")
+
+	if err := validators.ValidateMetricName(name, kind); err != nil {
+		writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validators.ValidateMetricKind(kind); err != nil {
+		writeErrorResponse(ctx, w, http.StatusNotImplemented, err)
+		return
+	}
+
+	if err := h.recorder.Delete(ctx, kind, name); err != nil {
+		if errors.Is(err, entity.ErrMetricNotFound) {
+			writeErrorResponse(ctx, w, http.StatusNotFound, err)
+			return
+		}
+
+		writePushErrorResponse(ctx, w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // GetJSON godoc
 // @Tags Metrics
 // @Router /value [post]
@@ -275,6 +397,7 @@ func (h metricsResource) Get(w http.ResponseWriter, r *http.Request) {
 // @Param request body metrics.MetricReq true "Request parameters: `id` and `type` are required."
 // @Success 200 {object} metrics.MetricReq
 // @Failure 400 {string} string http.StatusBadRequest
+// @Failure 403 {string} string "Access to metric denied"
 // @Failure 404 {string} string "Metric not found"
 // @Failure 500 {string} string http.StatusInternalServerError
 // @Failure 501 {string} string "Metric type is not supported"
@@ -304,6 +427,11 @@ func (h metricsResource) GetJSON(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, entity.ErrAccessDenied) {
+			writeErrorResponse(ctx, w, http.StatusForbidden, err)
+			return
+		}
+
 		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
 
 		return
@@ -326,23 +454,107 @@ func (h metricsResource) GetJSON(w http.ResponseWriter, r *http.Request) {
 // List godoc
 // @Tags Metrics
 // @Router / [get]
-// @Summary Get HTML page with full list of stored metrics
+// @Summary Get HTML page with list of stored metrics
 // @ID metrics_list
 // @Produce html
+// @Param prefix query string false "Select only metrics which names start with the prefix."
+// @Param match query string false "Select only metrics which names match glob (e.g. `Heap*`)."
+// @Param regex query string false "Select only metrics which names match regular expression."
+// @Param kind query string false "Select only metrics of specified type (e.g. `counter`, `gauge`)."
+// @Param limit query int false "Maximal count of metrics on single page (100 by default)."
+// @Param cursor query string false "Position in the list returned by previous request."
 // @Success 200
+// @Failure 400 {string} string http.StatusBadRequest
 // @Failure 500 {string} string http.StatusInternalServerError
+// @Failure 501 {string} string "Metric type is not supported"
 func (h metricsResource) List(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	opts, err := parseListOptions(r)
+	if err != nil {
+		if errors.Is(err, entity.ErrMetricNotImplemented) {
+			writeErrorResponse(ctx, w, http.StatusNotImplemented, err)
+			return
+		}
+
+		writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	records, err := h.recorder.List(r.Context())
+	page, err := h.recorder.List(r.Context(), opts)
 	if err != nil {
+		if errors.Is(err, entity.ErrInvalidCursor) {
+			writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
 		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+
 		return
 	}
 
-	if err := h.view.Execute(w, records); err != nil {
+	if err := h.view.Execute(w, newListView(r, page)); err != nil {
+		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// ListJSON godoc
+// @Tags Metrics
+// @Router /values [get]
+// @Summary Get single page of stored metrics as JSON
+// @ID metrics_json_list
+// @Produce json
+// @Param prefix query string false "Select only metrics which names start with the prefix."
+// @Param match query string false "Select only metrics which names match glob (e.g. `Heap*`)."
+// @Param regex query string false "Select only metrics which names match regular expression."
+// @Param kind query string false "Select only metrics of specified type (e.g. `counter`, `gauge`)."
+// @Param limit query int false "Maximal count of metrics on single page (100 by default)."
+// @Param cursor query string false "Position in the list returned by previous request."
+// @Success 200 {object} metrics.ListResponse
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 500 {string} string http.StatusInternalServerError
+// @Failure 501 {string} string "Metric type is not supported"
+func (h metricsResource) ListJSON(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	opts, err := parseListOptions(r)
+	if err != nil {
+		if errors.Is(err, entity.ErrMetricNotImplemented) {
+			writeErrorResponse(ctx, w, http.StatusNotImplemented, err)
+			return
+		}
+
+		writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	page, err := h.recorder.List(r.Context(), opts)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidCursor) {
+			writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+
+		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	data, err := toMetricReqList(page.Records, h.signer)
+	if err != nil {
+		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	resp := metrics.ListResponse{Data: data, NextCursor: page.NextCursor}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
 		return
 	}
@@ -379,3 +591,66 @@ func (h livenessProbe) Ping(w http.ResponseWriter, r *http.Request) {
 
 	writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
 }
+
+// maintenanceState represents current mode of the service.
+type maintenanceState struct {
+	// Whether the service is in read-only mode.
+	Enabled bool `json:"enabled"`
+}
+
+type maintenanceResource struct {
+	maintenance services.Maintenance
+}
+
+func newMaintenanceResource(maintenance services.Maintenance) maintenanceResource {
+	return maintenanceResource{maintenance: maintenance}
+}
+
+func (h maintenanceResource) writeState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	resp := maintenanceState{Enabled: h.maintenance.Enabled()}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		writeErrorResponse(r.Context(), w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// Get godoc
+// @Tags Maintenance
+// @Router /maintenance [get]
+// @Summary Get current maintenance mode of the server
+// @ID maintenance_info
+// @Produce json
+// @Success 200 {object} maintenanceState
+// @Failure 500 {string} string http.StatusInternalServerError
+func (h maintenanceResource) Get(w http.ResponseWriter, r *http.Request) {
+	h.writeState(w, r)
+}
+
+// Set godoc
+// @Tags Maintenance
+// @Router /maintenance [put]
+// @Summary Enable or disable maintenance (read-only) mode
+// @ID maintenance_update
+// @Accept json
+// @Produce json
+// @Param request body maintenanceState true "Requested mode."
+// @Success 200 {object} maintenanceState
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 500 {string} string http.StatusInternalServerError
+func (h maintenanceResource) Set(w http.ResponseWriter, r *http.Request) {
+	req := new(maintenanceState)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeErrorResponse(r.Context(), w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Enabled {
+		h.maintenance.Enable(r.Context())
+	} else {
+		h.maintenance.Disable()
+	}
+
+	h.writeState(w, r)
+}
@@ -7,10 +7,13 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/alkurbatov/metrics-collector/internal/httpbackend"
+	"github.com/alkurbatov/metrics-collector/internal/query"
 	"github.com/alkurbatov/metrics-collector/internal/security"
 	"github.com/alkurbatov/metrics-collector/internal/services"
 	"github.com/alkurbatov/metrics-collector/internal/storage"
@@ -36,7 +39,19 @@ func newRouter(
 	view, err := template.ParseFiles("../../web/views/metrics.html")
 	require.NoError(t, err)
 
-	return httpbackend.Router("0.0.0.0:8080", view, recorder, healthcheck, signer, nil, nil)
+	engine := query.NewEngine(recorder, query.NewHistory(recorder, 0))
+
+	return httpbackend.Router(httpbackend.Deps{
+		Address:     "0.0.0.0:8080",
+		View:        view,
+		Recorder:    recorder,
+		Engine:      engine,
+		Alerts:      alerting.NewManager(engine, nil, nil, nil, nil),
+		Silences:    alerting.NewSilences(storage.NewMemStorage()),
+		HealthCheck: healthcheck,
+		Maintenance: new(services.MaintenanceMock),
+		Signer:      signer,
+	})
 }
 
 func sendTestRequest(t *testing.T, router http.Handler, method, path string, payload []byte) (int, string, []byte) {
@@ -143,6 +158,38 @@ func TestUpdateMetric(t *testing.T) {
 				code: http.StatusInternalServerError,
 			},
 		},
+		{
+			name:        "Should ask to retry later in maintenance mode",
+			path:        "/update/counter/PollCount/10",
+			recorderErr: entity.ErrMaintenance,
+			expected: result{
+				code: http.StatusServiceUnavailable,
+			},
+		},
+		{
+			name:        "Should reject new series above quota",
+			path:        "/update/counter/PollCount/10",
+			recorderErr: entity.SeriesQuotaExceededError("", 100),
+			expected: result{
+				code: http.StatusTooManyRequests,
+			},
+		},
+		{
+			name:        "Should reject new series above client limit",
+			path:        "/update/counter/PollCount/10",
+			recorderErr: entity.SeriesLimitExceededError("client ip:10.0.0.1", 100),
+			expected: result{
+				code: http.StatusTooManyRequests,
+			},
+		},
+		{
+			name:        "Should reject metrics of tenant not forwarded to upstream",
+			path:        "/update/counter/PollCount/10",
+			recorderErr: entity.ErrTenantNotForwarded,
+			expected: result{
+				code: http.StatusNotImplemented,
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -266,6 +313,14 @@ func TestUpdateJSONMetric(t *testing.T) {
 				code: http.StatusInternalServerError,
 			},
 		},
+		{
+			name:        "Should ask to retry later in maintenance mode",
+			req:         metrics.NewUpdateCounterReq("PollCount", 13),
+			recorderErr: entity.ErrMaintenance,
+			expected: result{
+				code: http.StatusServiceUnavailable,
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -377,6 +432,34 @@ func TestBatchUpdate(t *testing.T) {
 			recorderErr: entity.ErrUnexpected,
 			expected:    result{code: http.StatusInternalServerError},
 		},
+		{
+			name:        "Should ask to retry later in maintenance mode",
+			req:         batchReq,
+			recorderRv:  nil,
+			recorderErr: entity.ErrMaintenance,
+			expected:    result{code: http.StatusServiceUnavailable},
+		},
+		{
+			name:        "Should reject new series above quota",
+			req:         batchReq,
+			recorderRv:  nil,
+			recorderErr: entity.SeriesQuotaExceededError("", 100),
+			expected:    result{code: http.StatusTooManyRequests},
+		},
+		{
+			name:        "Should reject new series above global limit",
+			req:         batchReq,
+			recorderRv:  nil,
+			recorderErr: entity.SeriesLimitExceededError("global", 1000),
+			expected:    result{code: http.StatusTooManyRequests},
+		},
+		{
+			name:        "Should reject metrics of tenant not forwarded to upstream",
+			req:         batchReq,
+			recorderRv:  nil,
+			recorderErr: entity.ErrTenantNotForwarded,
+			expected:    result{code: http.StatusNotImplemented},
+		},
 	}
 
 	for _, tc := range tt {
@@ -477,6 +560,14 @@ func TestGetMetric(t *testing.T) {
 				code: http.StatusBadRequest,
 			},
 		},
+		{
+			name:        "Should fail if access denied",
+			path:        "/value/gauge/Alloc",
+			recorderErr: entity.AccessDeniedError("read", "Alloc"),
+			expected: result{
+				code: http.StatusForbidden,
+			},
+		},
 		{
 			name:        "Should fail on broken recorder",
 			path:        "/value/gauge/Alloc",
@@ -509,6 +600,69 @@ func TestGetMetric(t *testing.T) {
 	}
 }
 
+func TestDeleteMetric(t *testing.T) {
+	tt := []struct {
+		name        string
+		path        string
+		recorderErr error
+		expected    int
+	}{
+		{
+			name:     "Should delete metric",
+			path:     "/value/counter/PollCount",
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "Should fail if metric kind unknown",
+			path:     "/value/unknown/Alloc",
+			expected: http.StatusNotImplemented,
+		},
+		{
+			name:     "Should fail on metric with invalid name",
+			path:     "/value/gauge/X;",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:        "Should fail on unknown metric",
+			path:        "/value/gauge/unknown",
+			recorderErr: entity.ErrMetricNotFound,
+			expected:    http.StatusNotFound,
+		},
+		{
+			name:        "Should fail if access denied",
+			path:        "/value/gauge/Alloc",
+			recorderErr: entity.AccessDeniedError("delete", "Alloc"),
+			expected:    http.StatusForbidden,
+		},
+		{
+			name:        "Should fail in maintenance mode",
+			path:        "/value/gauge/Alloc",
+			recorderErr: entity.ErrMaintenance,
+			expected:    http.StatusServiceUnavailable,
+		},
+		{
+			name:        "Should fail on broken recorder",
+			path:        "/value/gauge/Alloc",
+			recorderErr: entity.ErrUnexpected,
+			expected:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(services.RecorderMock)
+			m.On("Delete", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+				Return(tc.recorderErr)
+
+			router := newRouter(t, "", m, nil)
+
+			code, _, _ := sendTestRequest(t, router, http.MethodDelete, tc.path, nil)
+
+			assert.Equal(t, tc.expected, code)
+		})
+	}
+}
+
 func TestGetJSONMetric(t *testing.T) {
 	type result struct {
 		code int
@@ -648,22 +802,72 @@ func TestListMetrics(t *testing.T) {
 		{Name: "B", Value: metrics.Gauge(11.345)}}
 
 	type result struct {
-		code int
+		code     int
+		nextPage bool
 	}
 
 	tt := []struct {
 		name        string
-		recorderRV  []storage.Record
+		path        string
+		opts        storage.ListOptions
+		recorderRV  storage.Page
 		recorderErr error
 		expected    result
 	}{
 		{
-			name:       "Should provide HTML page with all metrics",
-			recorderRV: stored,
+			name:       "Should provide HTML page with metrics",
+			path:       "/",
+			opts:       storage.ListOptions{Limit: entity.DefaultPageSize},
+			recorderRV: storage.Page{Records: stored},
 			expected:   result{code: http.StatusOK},
 		},
+		{
+			name:       "Should provide link to next page",
+			path:       "/?prefix=A&kind=counter&limit=1",
+			opts:       storage.ListOptions{Prefix: "A", Kind: metrics.KindCounter, Limit: 1},
+			recorderRV: storage.Page{Records: stored[:1], NextCursor: "xxx"},
+			expected:   result{code: http.StatusOK, nextPage: true},
+		},
+		{
+			name:       "Should pass name pattern to recorder",
+			path:       "/?match=B*",
+			opts:       storage.ListOptions{Match: "^B.*$", Limit: entity.DefaultPageSize},
+			recorderRV: storage.Page{Records: stored[1:]},
+			expected:   result{code: http.StatusOK},
+		},
+		{
+			name:       "Should pass cursor to recorder",
+			path:       "/?cursor=xxx",
+			opts:       storage.ListOptions{Limit: entity.DefaultPageSize, Cursor: "xxx"},
+			recorderRV: storage.Page{Records: stored[1:]},
+			expected:   result{code: http.StatusOK},
+		},
+		{
+			name:     "Should fail on invalid page size",
+			path:     "/?limit=0",
+			expected: result{code: http.StatusBadRequest},
+		},
+		{
+			name:     "Should fail on too big page size",
+			path:     "/?limit=100000",
+			expected: result{code: http.StatusBadRequest},
+		},
+		{
+			name:     "Should fail on unknown metric kind",
+			path:     "/?kind=unknown",
+			expected: result{code: http.StatusNotImplemented},
+		},
+		{
+			name:        "Should fail on invalid cursor",
+			path:        "/?cursor=xxx",
+			opts:        storage.ListOptions{Limit: entity.DefaultPageSize, Cursor: "xxx"},
+			recorderErr: entity.ErrInvalidCursor,
+			expected:    result{code: http.StatusBadRequest},
+		},
 		{
 			name:        "Should fail on broken recorder",
+			path:        "/",
+			opts:        storage.ListOptions{Limit: entity.DefaultPageSize},
 			recorderErr: entity.ErrUnexpected,
 			expected:    result{code: http.StatusInternalServerError},
 		},
@@ -672,17 +876,18 @@ func TestListMetrics(t *testing.T) {
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			m := new(services.RecorderMock)
-			m.On("List", mock.Anything).Return(tc.recorderRV, tc.recorderErr)
+			m.On("List", mock.Anything, tc.opts).Return(tc.recorderRV, tc.recorderErr)
 
 			router := newRouter(t, "", m, nil)
 			require := require.New(t)
 
-			code, contentType, body := sendTestRequest(t, router, http.MethodGet, "/", nil)
+			code, contentType, body := sendTestRequest(t, router, http.MethodGet, tc.path, nil)
 
 			require.Equal(tc.expected.code, code)
 
 			if tc.expected.code == http.StatusOK {
 				require.Equal("text/html; charset=utf-8", contentType)
+				require.Equal(tc.expected.nextPage, strings.Contains(string(body), "cursor="+tc.recorderRV.NextCursor))
 			}
 
 			require.NotZero(len(body))
@@ -690,6 +895,100 @@ func TestListMetrics(t *testing.T) {
 	}
 }
 
+func TestListJSONMetrics(t *testing.T) {
+	stored := []storage.Record{
+		{Name: "HeapAlloc", Value: metrics.Gauge(11.345)},
+		{Name: "HeapIdle", Value: metrics.Gauge(3)},
+	}
+
+	type result struct {
+		code int
+		body string
+	}
+
+	tt := []struct {
+		name        string
+		path        string
+		opts        storage.ListOptions
+		recorderRV  storage.Page
+		recorderErr error
+		expected    result
+	}{
+		{
+			name:       "Should list metrics matching glob",
+			path:       "/values?match=Heap*&kind=gauge",
+			opts:       storage.ListOptions{Match: "^Heap.*$", Kind: metrics.KindGauge, Limit: entity.DefaultPageSize},
+			recorderRV: storage.Page{Records: stored},
+			expected: result{
+				code: http.StatusOK,
+				body: `{"data":[{"id":"HeapAlloc","type":"gauge","value":11.345},` +
+					`{"id":"HeapIdle","type":"gauge","value":3}]}` + "\n",
+			},
+		},
+		{
+			name:       "Should list metrics matching regular expression",
+			path:       "/values?regex=Alloc%7CIdle&limit=1",
+			opts:       storage.ListOptions{Match: "Alloc|Idle", Limit: 1},
+			recorderRV: storage.Page{Records: stored[:1], NextCursor: "xxx"},
+			expected: result{
+				code: http.StatusOK,
+				body: `{"data":[{"id":"HeapAlloc","type":"gauge","value":11.345}],"next_cursor":"xxx"}` + "\n",
+			},
+		},
+		{
+			name:       "Should provide empty list if nothing found",
+			path:       "/values?match=Stack*",
+			opts:       storage.ListOptions{Match: "^Stack.*$", Limit: entity.DefaultPageSize},
+			recorderRV: storage.Page{Records: []storage.Record{}},
+			expected: result{
+				code: http.StatusOK,
+				body: `{"data":[]}` + "\n",
+			},
+		},
+		{
+			name:     "Should fail on invalid regular expression",
+			path:     "/values?regex=Heap(",
+			expected: result{code: http.StatusBadRequest},
+		},
+		{
+			name:     "Should fail if both glob and regular expression provided",
+			path:     "/values?match=Heap*&regex=Heap",
+			expected: result{code: http.StatusBadRequest},
+		},
+		{
+			name:     "Should fail on unknown metric kind",
+			path:     "/values?kind=unknown",
+			expected: result{code: http.StatusNotImplemented},
+		},
+		{
+			name:        "Should fail on broken recorder",
+			path:        "/values",
+			opts:        storage.ListOptions{Limit: entity.DefaultPageSize},
+			recorderErr: entity.ErrUnexpected,
+			expected:    result{code: http.StatusInternalServerError},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(services.RecorderMock)
+			m.On("List", mock.Anything, tc.opts).Return(tc.recorderRV, tc.recorderErr)
+
+			router := newRouter(t, "", m, nil)
+			require := require.New(t)
+
+			code, contentType, body := sendTestRequest(t, router, http.MethodGet, tc.path, nil)
+
+			require.Equal(tc.expected.code, code)
+
+			if tc.expected.code == http.StatusOK {
+				require.Equal("application/json", contentType)
+				require.Equal(tc.expected.body, string(body))
+			}
+		})
+	}
+}
+
 func TestPing(t *testing.T) {
 	type result struct {
 		code int
@@ -744,3 +1043,76 @@ func TestPing(t *testing.T) {
 		})
 	}
 }
+
+func TestMaintenance(t *testing.T) {
+	type result struct {
+		code int
+		body string
+	}
+
+	tt := []struct {
+		name     string
+		method   string
+		payload  string
+		enabled  bool
+		expected result
+	}{
+		{
+			name:     "Should return current mode",
+			method:   http.MethodGet,
+			enabled:  true,
+			expected: result{code: http.StatusOK, body: `{"enabled":true}`},
+		},
+		{
+			name:     "Should enable maintenance mode",
+			method:   http.MethodPut,
+			payload:  `{"enabled":true}`,
+			enabled:  true,
+			expected: result{code: http.StatusOK, body: `{"enabled":true}`},
+		},
+		{
+			name:     "Should disable maintenance mode",
+			method:   http.MethodPut,
+			payload:  `{"enabled":false}`,
+			enabled:  false,
+			expected: result{code: http.StatusOK, body: `{"enabled":false}`},
+		},
+		{
+			name:     "Should fail on invalid request",
+			method:   http.MethodPut,
+			payload:  `{"enabled":`,
+			expected: result{code: http.StatusBadRequest},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			m := new(services.MaintenanceMock)
+			m.On("Enable", mock.Anything)
+			m.On("Disable")
+			m.On("Enabled").Return(tc.enabled)
+
+			view, err := template.ParseFiles("../../web/views/metrics.html")
+			require.NoError(err)
+
+			router := httpbackend.Router(httpbackend.Deps{Address: "0.0.0.0:8080", View: view, Maintenance: m})
+			code, _, body := sendTestRequest(t, router, tc.method, "/maintenance", []byte(tc.payload))
+
+			require.Equal(tc.expected.code, code)
+
+			if tc.expected.code == http.StatusOK {
+				require.JSONEq(tc.expected.body, string(body))
+			}
+
+			if tc.method == http.MethodPut && tc.expected.code == http.StatusOK {
+				if tc.enabled {
+					m.AssertCalled(t, "Enable", mock.Anything)
+				} else {
+					m.AssertCalled(t, "Disable")
+				}
+			}
+		})
+	}
+}
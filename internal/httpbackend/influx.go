@@ -0,0 +1,271 @@
+package httpbackend
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/internal/validators"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+)
+
+const (
+	// Maximal size of decompressed line protocol request.
+	_influxWriteMaxSize = 32 << 20
+
+	// Maximal length of single line of line protocol.
+	_influxMaxLineSize = 1 << 20
+)
+
+// splitEscaped splits s by separator ignoring escaped separators.
+// If quoted is set, separators inside double quotes are ignored as well.
+func splitEscaped(s string, sep byte, quoted bool) []string {
+	rv := make([]string, 0, 1)
+	start := 0
+	inQuotes := false
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+
+		case quoted && s[i] == '"':
+			inQuotes = !inQuotes
+
+		case s[i] == sep && !inQuotes:
+			rv = append(rv, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(rv, s[start:])
+}
+
+// unescape removes escaping backslashes from measurement names, tags and field keys.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+
+		sb.WriteByte(s[i])
+	}
+
+	return sb.String()
+}
+
+// splitPair splits "key=value" pair by the first unescaped '='.
+func splitPair(s string) (string, string, bool) {
+	parts := splitEscaped(s, '=', false)
+	if len(parts) < 2 || len(parts[0]) == 0 {
+		return "", "", false
+	}
+
+	return parts[0], strings.Join(parts[1:], "="), true
+}
+
+// parseFieldValue converts field value into metric value.
+// All numeric and boolean fields are recorded as gauges: integer fields usually hold
+// absolute or cumulative values (e.g. Telegraf counters), which can't be summed as deltas.
+// String fields are not supported and reported with ok set to false.
+func parseFieldValue(src string) (value metrics.Metric, ok bool, err error) {
+	if len(src) == 0 {
+		return nil, false, fmt.Errorf("%w: empty field value", entity.ErrMalformedInfluxLine)
+	}
+
+	switch src {
+	case "t", "T", "true", "True", "TRUE":
+		return metrics.Gauge(1), true, nil
+
+	case "f", "F", "false", "False", "FALSE":
+		return metrics.Gauge(0), true, nil
+	}
+
+	switch src[len(src)-1] {
+	case '"':
+		if len(src) < 2 || src[0] != '"' {
+			return nil, false, fmt.Errorf("%w: invalid string field %s", entity.ErrMalformedInfluxLine, src)
+		}
+
+		return nil, false, nil
+
+	case 'i':
+		v, err := strconv.ParseInt(src[:len(src)-1], 10, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: invalid integer field %s", entity.ErrMalformedInfluxLine, src)
+		}
+
+		return metrics.Gauge(v), true, nil
+
+	case 'u':
+		v, err := strconv.ParseUint(src[:len(src)-1], 10, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: invalid unsigned field %s", entity.ErrMalformedInfluxLine, src)
+		}
+
+		return metrics.Gauge(v), true, nil
+	}
+
+	v, err := strconv.ParseFloat(src, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, false, fmt.Errorf("%w: invalid float field %s", entity.ErrMalformedInfluxLine, src)
+	}
+
+	return metrics.Gauge(v), true, nil
+}
+
+// parseInfluxLine converts line in format
+// "<measurement>[,<tag>=<value>...] <field>=<value>[,<field>=<value>...] [timestamp]"
+// into records, one per each supported field.
+// Name of each record is built from the measurement and the field key followed by
+// tags as described in validators.SanitizeLabels, e.g. "cpu,host=web1 usage_idle=90"
+// becomes "CpuUsageIdleHostWeb1" followed by hash of the tags.
+// The timestamp is validated but ignored, only the latest value is stored.
+func parseInfluxLine(line string) ([]storage.Record, error) {
+	sections := splitEscaped(line, ' ', true)
+	if len(sections) != 2 && len(sections) != 3 {
+		return nil, fmt.Errorf("%w: expected 2 or 3 sections, got %d", entity.ErrMalformedInfluxLine, len(sections))
+	}
+
+	if len(sections) == 3 {
+		if _, err := strconv.ParseInt(sections[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: invalid timestamp %s", entity.ErrMalformedInfluxLine, sections[2])
+		}
+	}
+
+	series := splitEscaped(sections[0], ',', false)
+
+	measurement := unescape(series[0])
+	if len(measurement) == 0 {
+		return nil, fmt.Errorf("%w: empty measurement", entity.ErrMalformedInfluxLine)
+	}
+
+	tags := make(map[string]string, len(series)-1)
+
+	for _, src := range series[1:] {
+		key, value, ok := splitPair(src)
+		if !ok || len(value) == 0 {
+			return nil, fmt.Errorf("%w: invalid tag %s", entity.ErrMalformedInfluxLine, src)
+		}
+
+		tags[unescape(key)] = unescape(value)
+	}
+
+	suffix := validators.SanitizeLabels(tags)
+
+	fields := splitEscaped(sections[1], ',', true)
+	rv := make([]storage.Record, 0, len(fields))
+
+	for _, src := range fields {
+		key, rawValue, ok := splitPair(src)
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid field %s", entity.ErrMalformedInfluxLine, src)
+		}
+
+		value, ok, err := parseFieldValue(rawValue)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		name := validators.SanitizeMetricName(measurement+"_"+unescape(key)) + suffix
+		if err := validators.ValidateMetricName(name, value.Kind()); err != nil {
+			return nil, fmt.Errorf("%w: %s", entity.ErrMalformedInfluxLine, err)
+		}
+
+		rv = append(rv, storage.Record{Name: name, Value: value})
+	}
+
+	return rv, nil
+}
+
+// readInfluxRequest parses all lines of line protocol request.
+func readInfluxRequest(w http.ResponseWriter, r *http.Request) ([]storage.Record, error) {
+	scanner := bufio.NewScanner(http.MaxBytesReader(w, r.Body, _influxWriteMaxSize))
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), _influxMaxLineSize)
+
+	rv := make([]storage.Record, 0)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		records, err := parseInfluxLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("httpbackend - readInfluxRequest - line %d: %w", n, err)
+		}
+
+		rv = append(rv, records...)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("httpbackend - readInfluxRequest - scanner.Scan: %w", err)
+	}
+
+	return rv, nil
+}
+
+// InfluxWrite godoc
+// @Tags Metrics
+// @Router /api/v2/write [post]
+// @Summary Push metrics using InfluxDB line protocol
+// @Description Compatible with InfluxDB v2 write API, e.g. Telegraf `outputs.influxdb_v2` plugin.
+// @Description Integer, unsigned, float and boolean fields are recorded as gauges,
+// @Description string fields are ignored. Name of the metric is built from the measurement and the field key
+// @Description followed by keys and values of tags ordered by tag keys and hash of the tags,
+// @Description e.g. `cpu,host=web1 usage_idle=90` becomes gauge `CpuUsageIdleHostWeb1` followed by 8 hex digits.
+// @Description Timestamps are ignored.
+// @Description Query parameters `org`, `bucket` and `precision` are accepted but ignored.
+// @Description The whole request is rejected if any line is malformed.
+// @ID metrics_influx_write
+// @Accept plain
+// @Param request body string true "Metrics in InfluxDB line protocol."
+// @Success 204
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 403 {string} string "Access to metric denied"
+// @Failure 413 {string} string "Decompressed request is larger than 32 MiB"
+// @Failure 429 {string} string "Too many requests or series quota exceeded"
+// @Failure 500 {string} string http.StatusInternalServerError
+// @Failure 503 {string} string "Server is in maintenance mode"
+func (h metricsResource) InfluxWrite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	records, err := readInfluxRequest(w, r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeErrorResponse(ctx, w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+
+		writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	if len(records) != 0 {
+		if _, err := h.recorder.PushList(ctx, records); err != nil {
+			writePushErrorResponse(ctx, w, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
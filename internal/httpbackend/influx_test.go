@@ -0,0 +1,271 @@
+package httpbackend_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func sendInfluxWriteRequest(t *testing.T, router http.Handler, payload []byte, encoding string) int {
+	t.Helper()
+	require := require.New(t)
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	req, err := http.NewRequest(
+		http.MethodPost,
+		srv.URL+"/api/v2/write?org=metrics&bucket=metrics&precision=ns",
+		bytes.NewReader(payload),
+	)
+	require.NoError(err)
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	if len(encoding) != 0 {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(err)
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	return resp.StatusCode
+}
+
+func TestInfluxWrite(t *testing.T) {
+	tt := []struct {
+		name        string
+		payload     string
+		records     []storage.Record
+		recorderErr error
+		expected    int
+	}{
+		{
+			name:    "Should record numeric and boolean fields as gauges",
+			payload: "cpu,host=web1,dc=eu usage_idle=90.5,irq=3i,steal=4u,online=true 1700000000000000000",
+			records: []storage.Record{
+				{Name: "CpuUsageIdleDcEuHostWeb1c71c6c03", Value: metrics.Gauge(90.5)},
+				{Name: "CpuIrqDcEuHostWeb1c71c6c03", Value: metrics.Gauge(3)},
+				{Name: "CpuStealDcEuHostWeb1c71c6c03", Value: metrics.Gauge(4)},
+				{Name: "CpuOnlineDcEuHostWeb1c71c6c03", Value: metrics.Gauge(1)},
+			},
+			expected: http.StatusNoContent,
+		},
+		{
+			name:    "Should accept lines without tags and timestamps",
+			payload: "mem free=10,used=2.5e3\n\n# comment\nswap in=0i\n",
+			records: []storage.Record{
+				{Name: "MemFree", Value: metrics.Gauge(10)},
+				{Name: "MemUsed", Value: metrics.Gauge(2500)},
+				{Name: "SwapIn", Value: metrics.Gauge(0)},
+			},
+			expected: http.StatusNoContent,
+		},
+		{
+			name:    "Should ignore string fields",
+			payload: `system,host=web1 uptime_format="1 day, 2:03",load1=0.5,ok=F`,
+			records: []storage.Record{
+				{Name: "SystemLoad1HostWeb1f6001228", Value: metrics.Gauge(0.5)},
+				{Name: "SystemOkHostWeb1f6001228", Value: metrics.Gauge(0)},
+			},
+			expected: http.StatusNoContent,
+		},
+		{
+			name:    "Should distinguish tags with the same values",
+			payload: "cpu,host=web1 usage_idle=90\ncpu,region=web1 usage_idle=80",
+			records: []storage.Record{
+				{Name: "CpuUsageIdleHostWeb1f6001228", Value: metrics.Gauge(90)},
+				{Name: "CpuUsageIdleRegionWeb1fd4a2676", Value: metrics.Gauge(80)},
+			},
+			expected: http.StatusNoContent,
+		},
+		{
+			name:    "Should unescape measurement, tags and fields",
+			payload: `disk\ io,path=/var\,log read\ bytes=1i`,
+			records: []storage.Record{
+				{Name: "DiskIoReadBytesPathVarLoge096f986", Value: metrics.Gauge(1)},
+			},
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "Should accept request with string fields only",
+			payload:  `log message="hello"`,
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "Should accept empty request",
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "Should reject line without fields",
+			payload:  "cpu,host=web1",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Should reject line with empty tag value",
+			payload:  "cpu,host= usage=1",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Should reject invalid integer field",
+			payload:  "cpu usage=1.5i",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Should reject unsigned field out of range",
+			payload:  "cpu usage=18446744073709551616u",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Should reject NaN field",
+			payload:  "cpu usage=NaN",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Should reject invalid timestamp",
+			payload:  "cpu usage=1 yesterday",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Should reject whole request if any line is malformed",
+			payload:  "cpu usage=1\ncpu usage=",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:        "Should fail if recorder is broken",
+			payload:     "cpu usage=1",
+			records:     []storage.Record{{Name: "CpuUsage", Value: metrics.Gauge(1)}},
+			recorderErr: entity.ErrUnexpected,
+			expected:    http.StatusInternalServerError,
+		},
+		{
+			name:        "Should be unavailable in maintenance mode",
+			payload:     "cpu usage=1",
+			records:     []storage.Record{{Name: "CpuUsage", Value: metrics.Gauge(1)}},
+			recorderErr: entity.ErrMaintenance,
+			expected:    http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			m := new(services.RecorderMock)
+			m.On("PushList", mock.Anything, tc.records).Return(tc.records, tc.recorderErr)
+
+			router := newRouter(t, "", m, nil)
+			code := sendInfluxWriteRequest(t, router, []byte(tc.payload), "")
+
+			require.Equal(tc.expected, code)
+
+			if len(tc.records) == 0 {
+				m.AssertNotCalled(t, "PushList", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestInfluxWriteStoresLatestValueOfIntegerField(t *testing.T) {
+	require := require.New(t)
+
+	recorder := services.NewMetricsRecorder(storage.NewDataStore(nil, "", 0, nil, 0))
+	router := newRouter(t, "", recorder, nil)
+
+	for i := 0; i < 2; i++ {
+		code := sendInfluxWriteRequest(t, router, []byte("net,host=web1 bytes_recv=1024i"), "")
+		require.Equal(http.StatusNoContent, code)
+	}
+
+	code, _, body := sendTestRequest(t, router, http.MethodGet, "/value/gauge/NetBytesRecvHostWeb1f6001228", nil)
+	require.Equal(http.StatusOK, code)
+	require.Equal("1024", string(body))
+}
+
+func TestInfluxWriteAcceptsCompressedRequest(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte("cpu usage=1"))
+	require.NoError(err)
+	require.NoError(gz.Close())
+
+	records := []storage.Record{{Name: "CpuUsage", Value: metrics.Gauge(1)}}
+
+	m := new(services.RecorderMock)
+	m.On("PushList", mock.Anything, records).Return(records, nil)
+
+	router := newRouter(t, "", m, nil)
+	code := sendInfluxWriteRequest(t, router, buf.Bytes(), "gzip")
+
+	require.Equal(http.StatusNoContent, code)
+	m.AssertExpectations(t)
+}
+
+func TestInfluxWriteRejectsTooLargeRequest(t *testing.T) {
+	// Maximal size of decompressed line protocol request.
+	const maxSize = 32 << 20
+
+	line := []byte("cpu usage=1\n")
+	records := []storage.Record{{Name: "CpuUsage", Value: metrics.Gauge(1)}}
+
+	tt := []struct {
+		name     string
+		size     int
+		expected int
+	}{
+		{
+			name:     "Should accept request of maximal size",
+			size:     maxSize,
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "Should reject request one byte over the limit",
+			size:     maxSize + 1,
+			expected: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			// Pad the metric line with comments up to the requested size.
+			payload := bytes.Repeat([]byte("#"), tc.size)
+			copy(payload, line)
+
+			for i := len(line) + 1023; i < tc.size; i += 1024 {
+				payload[i] = '\n'
+			}
+
+			m := new(services.RecorderMock)
+			m.On("PushList", mock.Anything, records).Return(records, nil)
+
+			router := newRouter(t, "", m, nil)
+			code := sendInfluxWriteRequest(t, router, payload, "")
+
+			require.Equal(tc.expected, code)
+
+			if tc.expected == http.StatusNoContent {
+				m.AssertExpectations(t)
+			} else {
+				m.AssertNotCalled(t, "PushList", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package httpbackend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/internal/validators"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Time allowed to write single message to the client.
+	_liveWriteTimeout = 10 * time.Second
+
+	// Time allowed to read next pong message from the client.
+	_livePongTimeout = 60 * time.Second
+
+	// Interval between pings sent to the client, must be less than pong timeout.
+	_livePingInterval = _livePongTimeout * 9 / 10
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+func parseFilter(r *http.Request) (storage.Filter, error) {
+	query := r.URL.Query()
+	filter := storage.Filter{
+		Names: query["name"],
+		Kinds: query["kind"],
+	}
+
+	for _, kind := range filter.Kinds {
+		if err := validators.ValidateMetricKind(kind); err != nil {
+			return filter, err
+		}
+	}
+
+	for _, name := range filter.Names {
+		if err := validators.ValidateMetricName(name, ""); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+// waitForDisconnect processes control messages from the client
+// and cancels the context as soon as the client goes away.
+func waitForDisconnect(conn *websocket.Conn, cancel context.CancelFunc) {
+	defer cancel()
+
+	_ = conn.SetReadDeadline(time.Now().Add(_livePongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(_livePongTimeout))
+	})
+
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+// Live godoc
+// @Tags Metrics
+// @Router /live [get]
+// @Summary Stream updates of metrics over WebSocket
+// @Description Each message is JSON encoded metrics.MetricReq.
+// @Description Updates could be dropped, if the client doesn't keep up with the stream.
+// @ID metrics_live
+// @Param name query []string false "Names of metrics to watch for." collectionFormat(multi)
+// @Param kind query []string false "Types of metrics to watch for (e.g. `counter`, `gauge`)." collectionFormat(multi)
+// @Success 101
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 501 {string} string "Metric type is not supported"
+func (h metricsResource) Live(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		if errors.Is(err, entity.ErrMetricNotImplemented) {
+			writeErrorResponse(ctx, w, http.StatusNotImplemented, err)
+			return
+		}
+
+		writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	// In case of failure Upgrade replies to the client on its own.
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("metricsResource - Live - upgrader.Upgrade")
+		return
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go waitForDisconnect(conn, cancel)
+
+	ticker := time.NewTicker(_livePingInterval)
+	defer ticker.Stop()
+
+	updates := h.recorder.Watch(ctx, filter)
+
+	for {
+		select {
+		case record, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			resp, err := toMetricReq(record, h.signer)
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("metricsResource - Live - toMetricReq")
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(_liveWriteTimeout))
+			if err := conn.WriteJSON(resp); err != nil {
+				log.Ctx(ctx).Info().Err(err).Msg("Live updates client disconnected")
+				return
+			}
+
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(_liveWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Ctx(ctx).Info().Err(err).Msg("Live updates client disconnected")
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package httpbackend_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveUpdates(t *testing.T) {
+	require := require.New(t)
+
+	updates := make(chan storage.Record, 2)
+	updates <- storage.Record{Name: "PollCount", Value: metrics.Counter(10)}
+	updates <- storage.Record{Name: "PollCount", Value: metrics.Counter(15)}
+	close(updates)
+
+	filter := storage.Filter{Names: []string{"PollCount"}, Kinds: []string{"counter"}}
+
+	m := new(services.RecorderMock)
+	m.On("Watch", mock.Anything, filter).Return((<-chan storage.Record)(updates))
+
+	srv := httptest.NewServer(newRouter(t, "", m, nil))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/live?name=PollCount&kind=counter"
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(err)
+
+	defer func() {
+		_ = resp.Body.Close()
+		_ = conn.Close()
+	}()
+
+	for _, expected := range []metrics.Counter{10, 15} {
+		var msg metrics.MetricReq
+
+		require.NoError(conn.ReadJSON(&msg))
+		require.Equal(metrics.NewUpdateCounterReq("PollCount", expected), msg)
+	}
+
+	_, _, err = conn.ReadMessage()
+	require.Error(err)
+}
+
+func TestLiveUpdatesFailsOnInvalidFilter(t *testing.T) {
+	tt := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{
+			name:     "Should fail on unknown metric kind",
+			query:    "kind=unknown",
+			expected: http.StatusNotImplemented,
+		},
+		{
+			name:     "Should fail on invalid metric name",
+			query:    "name=X-Y",
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newRouter(t, "", new(services.RecorderMock), nil)
+			code, _, _ := sendTestRequest(t, router, http.MethodGet, "/live?"+tc.query, nil)
+
+			require.Equal(t, tc.expected, code)
+		})
+	}
+}
@@ -3,9 +3,13 @@ package httpbackend
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
 	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/query"
 	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
 	"github.com/alkurbatov/metrics-collector/internal/storage"
 	"github.com/alkurbatov/metrics-collector/internal/validators"
 	"github.com/alkurbatov/metrics-collector/pkg/metrics"
@@ -93,3 +97,101 @@ func toMetricReqList(records []storage.Record, signer *security.Signer) ([]*metr
 
 	return rv, nil
 }
+
+func toQueryResponse(result query.Result) metrics.QueryResponse {
+	rv := metrics.QueryResponse{Type: result.Type}
+
+	switch result.Type {
+	case query.ResultScalar:
+		value := result.Scalar
+		rv.Scalar = &value
+
+	case query.ResultVector:
+		rv.Vector = make([]metrics.QuerySample, 0, len(result.Vector))
+
+		for _, s := range result.Vector {
+			rv.Vector = append(rv.Vector, metrics.QuerySample{ID: s.Name, MType: s.Kind, Value: s.Value})
+		}
+
+	case query.ResultMatrix:
+		rv.Matrix = make([]metrics.QuerySeries, 0, len(result.Matrix))
+
+		for _, s := range result.Matrix {
+			points := make([]metrics.QueryPoint, 0, len(s.Points))
+			for _, p := range s.Points {
+				points = append(points, metrics.QueryPoint{Timestamp: p.Timestamp.UnixMilli(), Value: p.Value})
+			}
+
+			rv.Matrix = append(rv.Matrix, metrics.QuerySeries{ID: s.Name, MType: s.Kind, Points: points})
+		}
+	}
+
+	return rv
+}
+
+func toAlertsResponse(alerts []alerting.Alert) metrics.AlertsResponse {
+	rv := metrics.AlertsResponse{Data: make([]metrics.Alert, 0, len(alerts))}
+
+	for i := range alerts {
+		a := alerts[i]
+		alert := metrics.Alert{
+			Name:        a.Name,
+			Metric:      a.Metric,
+			Severity:    a.Severity,
+			Condition:   a.Condition,
+			State:       a.State,
+			Value:       a.Value,
+			ActiveSince: a.ActiveSince,
+			Labels:      a.Labels,
+			SilencedBy:  a.SilencedBy,
+			InhibitedBy: a.InhibitedBy,
+		}
+
+		if !a.FiredAt.IsZero() {
+			alert.FiredAt = &a.FiredAt
+		}
+
+		rv.Data = append(rv.Data, alert)
+	}
+
+	return rv
+}
+
+func toSilence(req metrics.Silence) storage.Silence {
+	return storage.Silence{
+		ID:        req.ID,
+		Matchers:  req.Matchers,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		CreatedBy: req.CreatedBy,
+		Comment:   req.Comment,
+	}
+}
+
+func toSilenceResponse(silence storage.Silence, now time.Time) metrics.Silence {
+	return metrics.Silence{
+		ID:        silence.ID,
+		Matchers:  silence.Matchers,
+		StartsAt:  silence.StartsAt,
+		EndsAt:    silence.EndsAt,
+		CreatedBy: silence.CreatedBy,
+		Comment:   silence.Comment,
+		State:     alerting.SilenceState(silence, now),
+	}
+}
+
+func toCardinalityResponse(report services.CardinalityReport) metrics.CardinalityResponse {
+	rv := metrics.CardinalityResponse{
+		Series:      report.Series,
+		TenantLimit: report.Limits.Tenant,
+		GlobalLimit: report.Limits.Global,
+		ClientLimit: report.Limits.Client,
+		TopCreators: make([]metrics.SeriesCreator, 0, len(report.Creators)),
+	}
+
+	for _, creator := range report.Creators {
+		rv.TopCreators = append(rv.TopCreators, metrics.SeriesCreator{Client: creator.Client, Series: creator.Series})
+	}
+
+	return rv
+}
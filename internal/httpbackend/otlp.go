@@ -0,0 +1,99 @@
+package httpbackend
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/alkurbatov/metrics-collector/internal/otlp"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Maximal size of decompressed OTLP request.
+	_otlpMaxSize = 32 << 20
+
+	// Content type of OTLP requests and responses encoded in protobuf.
+	_otlpContentType = "application/x-protobuf"
+)
+
+// readExportRequest decodes body of OTLP export request.
+func readExportRequest(r *http.Request) (*grpcapi.ExportMetricsServiceRequest, error) {
+	raw, err := io.ReadAll(io.LimitReader(r.Body, _otlpMaxSize))
+	if err != nil {
+		return nil, fmt.Errorf("httpbackend - readExportRequest - io.ReadAll: %w", err)
+	}
+
+	req := new(grpcapi.ExportMetricsServiceRequest)
+	if err := proto.Unmarshal(raw, req); err != nil {
+		return nil, fmt.Errorf("httpbackend - readExportRequest - proto.Unmarshal: %w", err)
+	}
+
+	return req, nil
+}
+
+// OTLPWrite godoc
+// @Tags Metrics
+// @Router /v1/metrics [post]
+// @Summary Push metrics using OpenTelemetry protocol (OTLP/HTTP)
+// @Description Gauges and cumulative sums are stored as gauges holding the latest value, delta sums as counters.
+// @Description Histograms are stored as gauge holding the mean value and `<name>Count` holding count of events.
+// @Description Name of the metric is built from the metric name followed by keys and values of data point
+// @Description and resource attributes ordered by keys and by a hash of the attributes.
+// @Description Resource attributes clashing with data point attributes are prefixed with `resource.`.
+// @Description Data points which can't be converted are skipped and reported as partial success.
+// @ID metrics_otlp_write
+// @Accept application/x-protobuf
+// @Produce application/x-protobuf
+// @Param Content-Encoding header string false "Either empty or `gzip`."
+// @Param request body string true "Protobuf ExportMetricsServiceRequest."
+// @Success 200 {string} string "Protobuf ExportMetricsServiceResponse."
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 403 {string} string "Access to metric denied"
+// @Failure 429 {string} string "Too many requests or series quota exceeded"
+// @Failure 415 {string} string http.StatusUnsupportedMediaType
+// @Failure 500 {string} string http.StatusInternalServerError
+// @Failure 503 {string} string "Server is in maintenance mode"
+func (h metricsResource) OTLPWrite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if contentType := r.Header.Get("Content-Type"); contentType != _otlpContentType {
+		writeErrorResponse(
+			ctx,
+			w,
+			http.StatusUnsupportedMediaType,
+			fmt.Errorf("httpbackend - OTLPWrite - content type not supported: %s", contentType),
+		)
+
+		return
+	}
+
+	req, err := readExportRequest(r)
+	if err != nil {
+		writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+
+	records, partial := otlp.Convert(req)
+
+	if len(records) != 0 {
+		if _, err := h.recorder.PushList(ctx, records); err != nil {
+			writePushErrorResponse(ctx, w, err)
+			return
+		}
+	}
+
+	resp, err := proto.Marshal(&grpcapi.ExportMetricsServiceResponse{PartialSuccess: partial})
+	if err != nil {
+		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", _otlpContentType)
+
+	if _, err := w.Write(resp); err != nil {
+		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+}
@@ -0,0 +1,202 @@
+package httpbackend_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func sendOTLPRequest(
+	t *testing.T,
+	router http.Handler,
+	payload []byte,
+	contentType, encoding string,
+) (int, *grpcapi.ExportMetricsServiceResponse) {
+	t.Helper()
+	require := require.New(t)
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/metrics", bytes.NewReader(payload))
+	require.NoError(err)
+
+	req.Header.Set("Content-Type", contentType)
+
+	if len(encoding) != 0 {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(err)
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(err)
+
+	rv := new(grpcapi.ExportMetricsServiceResponse)
+	require.NoError(proto.Unmarshal(body, rv))
+
+	return resp.StatusCode, rv
+}
+
+func newOTLPPayload(t *testing.T, data ...*grpcapi.Metric) []byte {
+	t.Helper()
+
+	req := &grpcapi.ExportMetricsServiceRequest{
+		ResourceMetrics: []*grpcapi.ResourceMetrics{
+			{ScopeMetrics: []*grpcapi.ScopeMetrics{{Metrics: data}}},
+		},
+	}
+
+	raw, err := proto.Marshal(req)
+	require.NoError(t, err)
+
+	return raw
+}
+
+func newOTLPSum(name string, value int64) *grpcapi.Metric {
+	return &grpcapi.Metric{
+		Name: name,
+		Data: &grpcapi.Metric_Sum{Sum: &grpcapi.Sum{
+			AggregationTemporality: grpcapi.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+			IsMonotonic:            true,
+			DataPoints: []*grpcapi.NumberDataPoint{
+				{Value: &grpcapi.NumberDataPoint_AsInt{AsInt: value}},
+			},
+		}},
+	}
+}
+
+func TestOTLPWrite(t *testing.T) {
+	tt := []struct {
+		name        string
+		payload     []byte
+		contentType string
+		records     []storage.Record
+		recorderErr error
+		expected    int
+		rejected    int64
+	}{
+		{
+			name:        "Should record data points",
+			payload:     newOTLPPayload(t, newOTLPSum("http.requests", 5)),
+			contentType: "application/x-protobuf",
+			records:     []storage.Record{{Name: "HttpRequests", Value: metrics.Counter(5)}},
+			expected:    http.StatusOK,
+		},
+		{
+			name: "Should report rejected data points",
+			payload: newOTLPPayload(
+				t,
+				newOTLPSum("http.requests", 5),
+				&grpcapi.Metric{
+					Name: "http.requests",
+					Data: &grpcapi.Metric_Sum{Sum: &grpcapi.Sum{
+						DataPoints: []*grpcapi.NumberDataPoint{{}},
+					}},
+				},
+			),
+			contentType: "application/x-protobuf",
+			records:     []storage.Record{{Name: "HttpRequests", Value: metrics.Counter(5)}},
+			expected:    http.StatusOK,
+			rejected:    1,
+		},
+		{
+			name:        "Should accept empty request",
+			contentType: "application/x-protobuf",
+			expected:    http.StatusOK,
+		},
+		{
+			name:        "Should reject JSON encoded request",
+			payload:     []byte("{}"),
+			contentType: "application/json",
+			expected:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Should fail if request is not protobuf message",
+			payload:     []byte("xxx"),
+			contentType: "application/x-protobuf",
+			expected:    http.StatusBadRequest,
+		},
+		{
+			name:        "Should fail if recorder is broken",
+			payload:     newOTLPPayload(t, newOTLPSum("http.requests", 5)),
+			contentType: "application/x-protobuf",
+			records:     []storage.Record{{Name: "HttpRequests", Value: metrics.Counter(5)}},
+			recorderErr: entity.ErrUnexpected,
+			expected:    http.StatusInternalServerError,
+		},
+		{
+			name:        "Should be unavailable in maintenance mode",
+			payload:     newOTLPPayload(t, newOTLPSum("http.requests", 5)),
+			contentType: "application/x-protobuf",
+			records:     []storage.Record{{Name: "HttpRequests", Value: metrics.Counter(5)}},
+			recorderErr: entity.ErrMaintenance,
+			expected:    http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			m := new(services.RecorderMock)
+			m.On("PushList", mock.Anything, tc.records).Return(tc.records, tc.recorderErr)
+
+			router := newRouter(t, "", m, nil)
+			code, resp := sendOTLPRequest(t, router, tc.payload, tc.contentType, "")
+
+			require.Equal(tc.expected, code)
+
+			if len(tc.records) == 0 {
+				m.AssertNotCalled(t, "PushList", mock.Anything, mock.Anything)
+			}
+
+			if code == http.StatusOK {
+				require.Equal(tc.rejected, resp.GetPartialSuccess().GetRejectedDataPoints())
+			}
+		})
+	}
+}
+
+func TestOTLPWriteAcceptsCompressedRequest(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(newOTLPPayload(t, newOTLPSum("http.requests", 5)))
+	require.NoError(err)
+	require.NoError(gz.Close())
+
+	records := []storage.Record{{Name: "HttpRequests", Value: metrics.Counter(5)}}
+
+	m := new(services.RecorderMock)
+	m.On("PushList", mock.Anything, records).Return(records, nil)
+
+	router := newRouter(t, "", m, nil)
+	code, _ := sendOTLPRequest(t, router, buf.Bytes(), "application/x-protobuf", "gzip")
+
+	require.Equal(http.StatusOK, code)
+	m.AssertExpectations(t)
+}
@@ -0,0 +1,64 @@
+package httpbackend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/query"
+)
+
+type queryResource struct {
+	engine *query.Engine
+}
+
+func newQueryResource(engine *query.Engine) queryResource {
+	return queryResource{engine: engine}
+}
+
+// Query godoc
+// @Tags Metrics
+// @Router /query [get]
+// @Summary Evaluate expression over stored metrics
+// @Description Supported functions are `sum`, `avg`, `min`, `max`, `count` and `rate`,
+// @Description e.g. `sum(PollCount*)`, `max(HeapInuse[10m])` or `rate(PollCount:counter[5m]) * 60`.
+// @ID metrics_query
+// @Produce json
+// @Param expr query string true "Expression to evaluate."
+// @Success 200 {object} metrics.QueryResponse
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 404 {string} string "No metrics match the expression"
+// @Failure 500 {string} string http.StatusInternalServerError
+func (h queryResource) Query(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	expr := r.URL.Query().Get("expr")
+	if len(expr) == 0 {
+		writeErrorResponse(ctx, w, http.StatusBadRequest, entity.InvalidQueryError("expression not set"))
+		return
+	}
+
+	result, err := h.engine.Query(ctx, expr)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrInvalidQuery), errors.Is(err, entity.ErrInvalidPattern):
+			writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+
+		case errors.Is(err, entity.ErrMetricNotFound):
+			writeErrorResponse(ctx, w, http.StatusNotFound, err)
+
+		default:
+			writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(toQueryResponse(result)); err != nil {
+		writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+		return
+	}
+}
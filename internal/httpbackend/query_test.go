@@ -0,0 +1,102 @@
+package httpbackend_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	type result struct {
+		code int
+		body string
+	}
+
+	tt := []struct {
+		name        string
+		expr        string
+		records     []storage.Record
+		recorderErr error
+		expected    result
+	}{
+		{
+			name: "Should evaluate aggregate",
+			expr: "sum(PollCount*)",
+			records: []storage.Record{
+				{Name: "PollCountAgent1", Value: metrics.Counter(10)},
+				{Name: "PollCountAgent2", Value: metrics.Counter(20)},
+			},
+			expected: result{
+				code: http.StatusOK,
+				body: `{"type":"scalar","scalar":30}` + "\n",
+			},
+		},
+		{
+			name: "Should report zero scalar",
+			expr: "count(Unknown)",
+			expected: result{
+				code: http.StatusOK,
+				body: `{"type":"scalar","scalar":0}` + "\n",
+			},
+		},
+		{
+			name:    "Should evaluate list of metrics",
+			expr:    "HeapAlloc / 2",
+			records: []storage.Record{{Name: "HeapAlloc", Value: metrics.Gauge(11)}},
+			expected: result{
+				code: http.StatusOK,
+				body: `{"type":"vector","vector":[{"id":"HeapAlloc","type":"gauge","value":5.5}]}` + "\n",
+			},
+		},
+		{
+			name:     "Should fail if expression not set",
+			expected: result{code: http.StatusBadRequest},
+		},
+		{
+			name:     "Should fail on invalid expression",
+			expr:     "sum(",
+			expected: result{code: http.StatusBadRequest},
+		},
+		{
+			name:     "Should fail if no metrics match",
+			expr:     "max(Unknown)",
+			expected: result{code: http.StatusNotFound},
+		},
+		{
+			name:        "Should fail if recorder fails",
+			expr:        "sum(PollCount*)",
+			recorderErr: errors.New("failure"),
+			expected:    result{code: http.StatusInternalServerError},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(services.RecorderMock)
+			m.On("List", mock.Anything, mock.Anything).Return(storage.Page{Records: tc.records}, tc.recorderErr)
+
+			router := newRouter(t, "", m, nil)
+			code, contentType, body := sendTestRequest(
+				t,
+				router,
+				http.MethodGet,
+				"/query?expr="+url.QueryEscape(tc.expr),
+				nil,
+			)
+
+			require.Equal(t, tc.expected.code, code)
+
+			if tc.expected.code == http.StatusOK {
+				require.Equal(t, "application/json", contentType)
+				require.Equal(t, tc.expected.body, string(body))
+			}
+		})
+	}
+}
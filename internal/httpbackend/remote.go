@@ -0,0 +1,159 @@
+package httpbackend
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/internal/validators"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/golang/snappy"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Maximal size of remote write request, both compressed and decompressed.
+	_remoteWriteMaxSize = 32 << 20
+
+	// Label holding name of Prometheus series.
+	_prometheusNameLabel = "__name__"
+)
+
+// toSeriesName creates metric name from labels of Prometheus series.
+// Labels other than the name are appended as described in validators.SanitizeLabels,
+// thus series with the same name but different labels are stored separately.
+func toSeriesName(labels []*grpcapi.Label) (string, error) {
+	var name string
+
+	rest := make(map[string]string, len(labels))
+
+	for _, label := range labels {
+		if label.Name == _prometheusNameLabel {
+			name = label.Value
+			continue
+		}
+
+		rest[label.Name] = label.Value
+	}
+
+	if len(name) == 0 {
+		return "", fmt.Errorf("httpbackend - toSeriesName - %s: %w", _prometheusNameLabel, entity.ErrIncompleteRequest)
+	}
+
+	return validators.SanitizeMetricName(name) + validators.SanitizeLabels(rest), nil
+}
+
+// fromTimeSeries converts Prometheus series into gauge holding the latest sample.
+// Prometheus counters are cumulative, thus they are recorded as gauges as well.
+func fromTimeSeries(series *grpcapi.TimeSeries) (storage.Record, error) {
+	name, err := toSeriesName(series.Labels)
+	if err != nil {
+		return storage.Record{}, err
+	}
+
+	if err := validators.ValidateMetricName(name, metrics.KindGauge); err != nil {
+		return storage.Record{}, err
+	}
+
+	var latest *grpcapi.Sample
+
+	for _, sample := range series.Samples {
+		// Skip staleness markers and other values which can't be stored.
+		if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+			continue
+		}
+
+		if latest == nil || sample.Timestamp >= latest.Timestamp {
+			latest = sample
+		}
+	}
+
+	if latest == nil {
+		return storage.Record{}, fmt.Errorf("httpbackend - fromTimeSeries - %s: %w", name, entity.ErrIncompleteRequest)
+	}
+
+	return storage.Record{Name: name, Value: metrics.Gauge(latest.Value)}, nil
+}
+
+// readWriteRequest decompresses and decodes body of remote write request.
+func readWriteRequest(r *http.Request) (*grpcapi.WriteRequest, error) {
+	compressed, err := io.ReadAll(io.LimitReader(r.Body, _remoteWriteMaxSize))
+	if err != nil {
+		return nil, fmt.Errorf("httpbackend - readWriteRequest - io.ReadAll: %w", err)
+	}
+
+	size, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("httpbackend - readWriteRequest - snappy.DecodedLen: %w", err)
+	}
+
+	if size > _remoteWriteMaxSize {
+		return nil, fmt.Errorf("httpbackend - readWriteRequest - snappy.DecodedLen: request is too large (%d)", size)
+	}
+
+	raw, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("httpbackend - readWriteRequest - snappy.Decode: %w", err)
+	}
+
+	req := new(grpcapi.WriteRequest)
+	if err := proto.Unmarshal(raw, req); err != nil {
+		return nil, fmt.Errorf("httpbackend - readWriteRequest - proto.Unmarshal: %w", err)
+	}
+
+	return req, nil
+}
+
+// RemoteWrite godoc
+// @Tags Metrics
+// @Router /api/v1/write [post]
+// @Summary Push samples using Prometheus remote write protocol
+// @Description Each series is stored as gauge holding the latest sample.
+// @Description Name of the gauge is built from the series name followed by names and values of other labels
+// @Description ordered by label names and hash of the labels, e.g. `up{job="api"}` becomes `UpJobApibe913344`.
+// @Description Series which can't be converted are skipped.
+// @ID metrics_remote_write
+// @Accept application/x-protobuf
+// @Param Content-Encoding header string true "Must be `snappy`."
+// @Param request body string true "Snappy compressed protobuf WriteRequest."
+// @Success 204
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 403 {string} string "Access to metric denied"
+// @Failure 429 {string} string "Too many requests or series quota exceeded"
+// @Failure 500 {string} string http.StatusInternalServerError
+// @Failure 503 {string} string "Server is in maintenance mode"
+func (h metricsResource) RemoteWrite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := readWriteRequest(r)
+	if err != nil {
+		writeErrorResponse(ctx, w, http.StatusBadRequest, err)
+		return
+	}
+
+	records := make([]storage.Record, 0, len(req.Timeseries))
+
+	for _, series := range req.Timeseries {
+		record, err := fromTimeSeries(series)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Skipping remote write series")
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	if len(records) != 0 {
+		if _, err := h.recorder.PushList(ctx, records); err != nil {
+			writePushErrorResponse(ctx, w, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,178 @@
+package httpbackend_test
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func newSeries(value float64, timestamp int64, labels ...string) *grpcapi.TimeSeries {
+	series := &grpcapi.TimeSeries{
+		Samples: []*grpcapi.Sample{{Value: value, Timestamp: timestamp}},
+	}
+
+	for i := 0; i < len(labels); i += 2 {
+		series.Labels = append(series.Labels, &grpcapi.Label{Name: labels[i], Value: labels[i+1]})
+	}
+
+	return series
+}
+
+func sendRemoteWriteRequest(t *testing.T, router http.Handler, payload []byte) int {
+	t.Helper()
+	require := require.New(t)
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/write", bytes.NewReader(payload))
+	require.NoError(err)
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(err)
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	return resp.StatusCode
+}
+
+func TestRemoteWrite(t *testing.T) {
+	tt := []struct {
+		name        string
+		series      []*grpcapi.TimeSeries
+		records     []storage.Record
+		recorderErr error
+		expected    int
+	}{
+		{
+			name: "Should record latest sample of each series as gauge",
+			series: []*grpcapi.TimeSeries{
+				{
+					Labels: []*grpcapi.Label{{Name: "__name__", Value: "go_goroutines"}},
+					Samples: []*grpcapi.Sample{
+						{Value: 12, Timestamp: 2000},
+						{Value: 10, Timestamp: 1000},
+					},
+				},
+				newSeries(1, 1000, "job", "api", "__name__", "up", "instance", "localhost:9090"),
+			},
+			records: []storage.Record{
+				{Name: "GoGoroutines", Value: metrics.Gauge(12)},
+				{Name: "UpInstanceLocalhost9090JobApi619ef7cc", Value: metrics.Gauge(1)},
+			},
+			expected: http.StatusNoContent,
+		},
+		{
+			name: "Should store series with the same concatenated label values separately",
+			series: []*grpcapi.TimeSeries{
+				newSeries(1, 1000, "__name__", "up", "job", "ab", "instance", "c"),
+				newSeries(2, 1000, "__name__", "up", "job", "a", "instance", "bc"),
+			},
+			records: []storage.Record{
+				{Name: "UpInstanceCJobAb8fc55a1d", Value: metrics.Gauge(1)},
+				{Name: "UpInstanceBcJobAdc8d5903", Value: metrics.Gauge(2)},
+			},
+			expected: http.StatusNoContent,
+		},
+		{
+			name: "Should skip series without name",
+			series: []*grpcapi.TimeSeries{
+				newSeries(1, 1000, "job", "api"),
+				newSeries(2, 1000, "__name__", "up"),
+			},
+			records: []storage.Record{
+				{Name: "Up", Value: metrics.Gauge(2)},
+			},
+			expected: http.StatusNoContent,
+		},
+		{
+			name: "Should skip staleness markers",
+			series: []*grpcapi.TimeSeries{
+				newSeries(math.NaN(), 1000, "__name__", "up"),
+			},
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "Should accept empty request",
+			expected: http.StatusNoContent,
+		},
+		{
+			name:        "Should fail if recorder is broken",
+			series:      []*grpcapi.TimeSeries{newSeries(1, 1000, "__name__", "up")},
+			records:     []storage.Record{{Name: "Up", Value: metrics.Gauge(1)}},
+			recorderErr: entity.ErrUnexpected,
+			expected:    http.StatusInternalServerError,
+		},
+		{
+			name:        "Should be unavailable in maintenance mode",
+			series:      []*grpcapi.TimeSeries{newSeries(1, 1000, "__name__", "up")},
+			records:     []storage.Record{{Name: "Up", Value: metrics.Gauge(1)}},
+			recorderErr: entity.ErrMaintenance,
+			expected:    http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			m := new(services.RecorderMock)
+			m.On("PushList", mock.Anything, tc.records).Return(tc.records, tc.recorderErr)
+
+			raw, err := proto.Marshal(&grpcapi.WriteRequest{Timeseries: tc.series})
+			require.NoError(err)
+
+			router := newRouter(t, "", m, nil)
+			code := sendRemoteWriteRequest(t, router, snappy.Encode(nil, raw))
+
+			require.Equal(tc.expected, code)
+
+			if len(tc.records) == 0 {
+				m.AssertNotCalled(t, "PushList", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRemoteWriteFailsOnMalformedRequest(t *testing.T) {
+	tt := []struct {
+		name    string
+		payload []byte
+	}{
+		{
+			name:    "Should fail if request is not compressed",
+			payload: []byte("xxx"),
+		},
+		{
+			name:    "Should fail if request is not protobuf message",
+			payload: snappy.Encode(nil, []byte("xxx")),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newRouter(t, "", new(services.RecorderMock), nil)
+			code := sendRemoteWriteRequest(t, router, tc.payload)
+
+			require.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+}
@@ -11,9 +11,15 @@ package httpbackend
 // @Tag.name Metrics
 // @Tag.description "Metrics API"
 
+// @Tag.name Alerts
+// @Tag.description "API to inspect active alerts"
+
 // @Tag.name Healthcheck
 // @Tag.description "API to inspect service health state"
 
+// @Tag.name Maintenance
+// @Tag.description "API to switch service to read-only mode"
+
 import (
 	"html/template"
 	"net"
@@ -21,9 +27,11 @@ import (
 
 	// Import pregenerated OpenAPI (Swagger) documentation.
 	_ "github.com/alkurbatov/metrics-collector/docs/api"
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
 	"github.com/alkurbatov/metrics-collector/internal/compression"
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/alkurbatov/metrics-collector/internal/logging"
+	"github.com/alkurbatov/metrics-collector/internal/query"
 	"github.com/alkurbatov/metrics-collector/internal/security"
 	"github.com/alkurbatov/metrics-collector/internal/services"
 	"github.com/go-chi/chi/middleware"
@@ -31,50 +39,182 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func Router(
-	address entity.NetAddress,
-	view *template.Template,
-	recorder services.Recorder,
-	healthcheck services.HealthCheck,
-	signer *security.Signer,
-	privateKey security.PrivateKey,
-	trustedSubnet *net.IPNet,
-) http.Handler {
-	metrics := newMetricsResource(view, recorder, signer)
-	probe := newLivenessProbe(healthcheck)
+// authorize returns middleware checking bearer token of requests,
+// if tokens are not set the middleware is no-op.
+func authorize(tokens *security.Tokens, scope security.Scope) func(next http.Handler) http.Handler {
+	if tokens == nil {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
 
-	r := chi.NewRouter()
+	return security.AuthorizeRequest(tokens, scope)
+}
 
-	r.Use(logging.RequestsLogger)
-	r.Use(middleware.StripSlashes)
+// authorizePage works as authorize, but also accepts token from query or cookie,
+// as browsers can't set authorization header.
+func authorizePage(tokens *security.Tokens, scope security.Scope) func(next http.Handler) http.Handler {
+	if tokens == nil {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	return security.AuthorizePageRequest(tokens, scope)
+}
 
-	if privateKey != nil {
-		r.Use(security.DecryptRequest(privateKey))
+// limit returns middleware limiting rate of requests,
+// if limiter is not set the middleware is no-op.
+func limit(limiter *security.RateLimiter) func(next http.Handler) http.Handler {
+	if limiter == nil {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
 	}
 
-	r.Use(compression.DecompressRequest)
-	r.Use(compression.CompressResponse)
+	return security.LimitRequests(limiter)
+}
+
+// Deps are dependencies of HTTP API, optional ones disable related features when not set.
+type Deps struct {
+	// Address the API is served on, used to build link to the documentation.
+	Address entity.NetAddress
+
+	// View renders the page with list of metrics.
+	View *template.Template
 
-	r.Get("/", metrics.List)
+	Recorder    services.Recorder
+	Engine      *query.Engine
+	Alerts      *alerting.Manager
+	Silences    *alerting.Silences
+	HealthCheck services.HealthCheck
+	Maintenance services.Maintenance
+	Cardinality services.Cardinality
 
-	r.Post("/value", metrics.GetJSON)
-	r.Get("/value/{kind}/{name}", metrics.Get)
+	// Signer verifies and signs metrics, if set.
+	Signer *security.Signer
 
+	// PrivateKey decrypts requests, if set.
+	PrivateKey security.PrivateKey
+
+	// TrustedSubnet restricts updates to clients from the subnet, if set.
+	TrustedSubnet *net.IPNet
+
+	// TrustedProxies are allowed to pass address of the client in X-Real-IP header, if set.
+	TrustedProxies *net.IPNet
+
+	// Tokens authorize requests, if set.
+	Tokens *security.Tokens
+
+	// Limiter limits rate of requests of each client, if set.
+	Limiter *security.RateLimiter
+}
+
+// Router creates handler serving HTTP API.
+func Router(deps Deps) http.Handler {
+	metrics := newMetricsResource(deps.View, deps.Recorder, deps.Signer)
+	probe := newLivenessProbe(deps.HealthCheck)
+	admin := newMaintenanceResource(deps.Maintenance)
+	queries := newQueryResource(deps.Engine)
+	alarms := newAlertsResource(deps.Alerts)
+	mutes := newSilencesResource(deps.Silences)
+	series := newCardinalityResource(deps.Cardinality)
+
+	r := chi.NewRouter()
+
+	r.Use(logging.RequestsLogger)
+	r.Use(middleware.StripSlashes)
+
+	// Prometheus sends snappy compressed requests without encryption,
+	// thus remote write doesn't pass through decryption and gzip decompression.
+	// OpenTelemetry exporters don't support encryption as well.
 	r.Group(func(r chi.Router) {
-		if trustedSubnet != nil {
-			r.Use(security.FilterRequest(trustedSubnet))
+		if deps.TrustedSubnet != nil {
+			r.Use(security.FilterRequest(deps.TrustedSubnet))
 		}
 
-		r.Post("/update", metrics.UpdateJSON)
-		r.Post("/updates", metrics.BatchUpdateJSON)
-		r.Post("/update/{kind}/{name}/{value}", metrics.Update)
+		r.Use(authorize(deps.Tokens, security.ScopeWrite))
+		r.Use(security.IdentifyClient(deps.TrustedProxies))
+		r.Use(limit(deps.Limiter))
+		r.Use(security.SelectTenant)
+
+		r.Post("/api/v1/write", metrics.RemoteWrite)
+		r.With(compression.DecompressRequest).Post("/v1/metrics", metrics.OTLPWrite)
 	})
 
-	r.Get("/ping", probe.Ping)
+	r.Group(func(r chi.Router) {
+		if deps.PrivateKey != nil {
+			r.Use(security.DecryptRequest(deps.PrivateKey))
+		}
 
-	r.Get("/docs/*", httpSwagger.Handler(
-		httpSwagger.URL("http://"+address.String()+"/docs/doc.json"),
-	))
+		r.Use(compression.DecompressRequest)
+		r.Use(compression.CompressResponse)
+
+		r.Group(func(r chi.Router) {
+			r.Use(authorizePage(deps.Tokens, security.ScopeRead))
+			r.Use(security.IdentifyClient(deps.TrustedProxies))
+			r.Use(limit(deps.Limiter))
+			r.Use(security.SelectTenant)
+
+			r.Get("/", metrics.List)
+			r.Get("/live", metrics.Live)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(authorize(deps.Tokens, security.ScopeRead))
+			r.Use(security.IdentifyClient(deps.TrustedProxies))
+			r.Use(limit(deps.Limiter))
+			r.Use(security.SelectTenant)
+
+			r.Post("/value", metrics.GetJSON)
+			r.Get("/values", metrics.ListJSON)
+			r.Get("/value/{kind}/{name}", metrics.Get)
+			r.Get("/query", queries.Query)
+			r.Get("/alerts", alarms.List)
+			r.Get("/silences", mutes.List)
+			r.Get("/silences/{id}", mutes.Get)
+
+			r.Get("/maintenance", admin.Get)
+		})
+
+		r.Group(func(r chi.Router) {
+			if deps.TrustedSubnet != nil {
+				r.Use(security.FilterRequest(deps.TrustedSubnet))
+			}
+
+			r.Group(func(r chi.Router) {
+				r.Use(authorize(deps.Tokens, security.ScopeWrite))
+				r.Use(security.IdentifyClient(deps.TrustedProxies))
+				r.Use(limit(deps.Limiter))
+				r.Use(security.SelectTenant)
+
+				r.Post("/update", metrics.UpdateJSON)
+				r.Post("/updates", metrics.BatchUpdateJSON)
+				r.Post("/update/{kind}/{name}/{value}", metrics.Update)
+				r.Post("/api/v2/write", metrics.InfluxWrite)
+				r.Delete("/value/{kind}/{name}", metrics.Delete)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(authorize(deps.Tokens, security.ScopeAdmin))
+				r.Use(security.IdentifyClient(deps.TrustedProxies))
+				r.Use(limit(deps.Limiter))
+
+				r.Put("/maintenance", admin.Set)
+				r.Get("/cardinality", series.Report)
+
+				r.Post("/silences", mutes.Create)
+				r.Put("/silences/{id}", mutes.Update)
+				r.Delete("/silences/{id}", mutes.Delete)
+			})
+		})
+
+		r.Get("/ping", probe.Ping)
+
+		r.Get("/docs/*", httpSwagger.Handler(
+			httpSwagger.URL("http://"+deps.Address.String()+"/docs/doc.json"),
+		))
+	})
 
 	return r
 }
@@ -0,0 +1,166 @@
+package httpbackend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+type silencesResource struct {
+	silences *alerting.Silences
+}
+
+func newSilencesResource(silences *alerting.Silences) silencesResource {
+	return silencesResource{silences: silences}
+}
+
+func (h silencesResource) writeSilence(w http.ResponseWriter, r *http.Request, code int, silence storage.Silence) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(toSilenceResponse(silence, time.Now())); err != nil {
+		writeErrorResponse(r.Context(), w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (h silencesResource) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, entity.ErrInvalidSilence):
+		writeErrorResponse(r.Context(), w, http.StatusBadRequest, err)
+
+	case errors.Is(err, entity.ErrSilenceNotFound):
+		writeErrorResponse(r.Context(), w, http.StatusNotFound, err)
+
+	default:
+		writeErrorResponse(r.Context(), w, http.StatusInternalServerError, err)
+	}
+}
+
+// List godoc
+// @Tags Alerts
+// @Router /silences [get]
+// @Summary List silences including expired ones
+// @ID silences_list
+// @Produce json
+// @Success 200 {object} metrics.SilencesResponse
+// @Failure 500 {string} string http.StatusInternalServerError
+func (h silencesResource) List(w http.ResponseWriter, r *http.Request) {
+	silences := h.silences.List()
+	now := time.Now()
+
+	resp := metrics.SilencesResponse{Data: make([]metrics.Silence, 0, len(silences))}
+	for _, s := range silences {
+		resp.Data = append(resp.Data, toSilenceResponse(s, now))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		writeErrorResponse(r.Context(), w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// Get godoc
+// @Tags Alerts
+// @Router /silences/{id} [get]
+// @Summary Get silence
+// @ID silences_info
+// @Produce json
+// @Param id path string true "ID of the silence."
+// @Success 200 {object} metrics.Silence
+// @Failure 404 {string} string http.StatusNotFound
+// @Failure 500 {string} string http.StatusInternalServerError
+func (h silencesResource) Get(w http.ResponseWriter, r *http.Request) {
+	silence, err := h.silences.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.writeSilence(w, r, http.StatusOK, silence)
+}
+
+// Create godoc
+// @Tags Alerts
+// @Router /silences [post]
+// @Summary Mute notifications about alerts matching all matchers during the time window
+// @ID silences_create
+// @Accept json
+// @Produce json
+// @Param request body metrics.Silence true "Silence to create."
+// @Success 201 {object} metrics.Silence
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 500 {string} string http.StatusInternalServerError
+func (h silencesResource) Create(w http.ResponseWriter, r *http.Request) {
+	req := new(metrics.Silence)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeErrorResponse(r.Context(), w, http.StatusBadRequest, err)
+		return
+	}
+
+	silence, err := h.silences.Create(r.Context(), toSilence(*req), time.Now())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.writeSilence(w, r, http.StatusCreated, silence)
+}
+
+// Update godoc
+// @Tags Alerts
+// @Router /silences/{id} [put]
+// @Summary Replace silence, e.g. to prolong or expire it
+// @ID silences_update
+// @Accept json
+// @Produce json
+// @Param id path string true "ID of the silence."
+// @Param request body metrics.Silence true "New state of the silence."
+// @Success 200 {object} metrics.Silence
+// @Failure 400 {string} string http.StatusBadRequest
+// @Failure 404 {string} string http.StatusNotFound
+// @Failure 500 {string} string http.StatusInternalServerError
+func (h silencesResource) Update(w http.ResponseWriter, r *http.Request) {
+	req := new(metrics.Silence)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeErrorResponse(r.Context(), w, http.StatusBadRequest, err)
+		return
+	}
+
+	req.ID = chi.URLParam(r, "id")
+
+	silence, err := h.silences.Update(r.Context(), toSilence(*req))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.writeSilence(w, r, http.StatusOK, silence)
+}
+
+// Delete godoc
+// @Tags Alerts
+// @Router /silences/{id} [delete]
+// @Summary Remove silence
+// @ID silences_delete
+// @Param id path string true "ID of the silence."
+// @Success 204
+// @Failure 404 {string} string http.StatusNotFound
+// @Failure 500 {string} string http.StatusInternalServerError
+func (h silencesResource) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.silences.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,144 @@
+package httpbackend_test
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/httpbackend"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func newSilencesRouter(t *testing.T, silences *alerting.Silences) http.Handler {
+	t.Helper()
+
+	view, err := template.ParseFiles("../../web/views/metrics.html")
+	require.NoError(t, err)
+
+	return httpbackend.Router(httpbackend.Deps{Address: "0.0.0.0:8080", View: view, Silences: silences})
+}
+
+func TestSilencesLifecycle(t *testing.T) {
+	require := require.New(t)
+
+	router := newSilencesRouter(t, alerting.NewSilences(storage.NewMemStorage()))
+	endsAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	code, contentType, body := sendTestRequest(
+		t,
+		router,
+		http.MethodPost,
+		"/silences",
+		[]byte(`{"matchers":["alertname=HighCpu*"],"ends_at":"`+endsAt.Format(time.RFC3339)+`","comment":"deploy"}`),
+	)
+	require.Equal(http.StatusCreated, code)
+	require.Equal("application/json", contentType)
+
+	var created metrics.Silence
+	require.NoError(json.Unmarshal(body, &created))
+	require.NotEmpty(created.ID)
+	require.Equal([]string{"alertname=HighCpu*"}, created.Matchers)
+	require.True(endsAt.Equal(created.EndsAt))
+	require.Equal("deploy", created.Comment)
+	require.Equal(alerting.SilenceActive, created.State)
+
+	code, _, body = sendTestRequest(t, router, http.MethodGet, "/silences", nil)
+	require.Equal(http.StatusOK, code)
+
+	var list metrics.SilencesResponse
+	require.NoError(json.Unmarshal(body, &list))
+	require.Len(list.Data, 1)
+	require.Equal(created.ID, list.Data[0].ID)
+
+	// Expire the silence.
+	code, _, body = sendTestRequest(
+		t,
+		router,
+		http.MethodPut,
+		"/silences/"+created.ID,
+		[]byte(`{"matchers":["alertname=HighCpu*"],"ends_at":"`+time.Now().UTC().Format(time.RFC3339Nano)+`"}`),
+	)
+	require.Equal(http.StatusOK, code)
+
+	var updated metrics.Silence
+	require.NoError(json.Unmarshal(body, &updated))
+	require.Equal(created.ID, updated.ID)
+	require.True(created.StartsAt.Equal(updated.StartsAt))
+	require.Equal(alerting.SilenceExpired, updated.State)
+
+	code, _, body = sendTestRequest(t, router, http.MethodGet, "/silences/"+created.ID, nil)
+	require.Equal(http.StatusOK, code)
+
+	var stored metrics.Silence
+	require.NoError(json.Unmarshal(body, &stored))
+	require.Equal(updated, stored)
+
+	code, _, _ = sendTestRequest(t, router, http.MethodDelete, "/silences/"+created.ID, nil)
+	require.Equal(http.StatusNoContent, code)
+
+	code, _, _ = sendTestRequest(t, router, http.MethodGet, "/silences/"+created.ID, nil)
+	require.Equal(http.StatusNotFound, code)
+}
+
+func TestSilencesFails(t *testing.T) {
+	tt := []struct {
+		name     string
+		method   string
+		path     string
+		payload  string
+		expected int
+	}{
+		{
+			name:     "Should fail on malformed request",
+			method:   http.MethodPost,
+			path:     "/silences",
+			payload:  `{"matchers":`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Should fail on invalid matcher",
+			method:   http.MethodPost,
+			path:     "/silences",
+			payload:  `{"matchers":["HighCpu"],"ends_at":"2100-01-01T00:00:00Z"}`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Should fail on silence without end",
+			method:   http.MethodPost,
+			path:     "/silences",
+			payload:  `{"matchers":["alertname=HighCpu"]}`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "Should fail to update unknown silence",
+			method:   http.MethodPut,
+			path:     "/silences/unknown",
+			payload:  `{"matchers":["alertname=HighCpu"],"ends_at":"2100-01-01T00:00:00Z"}`,
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "Should fail to delete unknown silence",
+			method:   http.MethodDelete,
+			path:     "/silences/unknown",
+			expected: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			silences := alerting.NewSilences(storage.NewMemStorage())
+			require.NoError(t, silences.Load(context.Background()))
+
+			router := newSilencesRouter(t, silences)
+			code, _, _ := sendTestRequest(t, router, tc.method, tc.path, []byte(tc.payload))
+
+			require.Equal(t, tc.expected, code)
+		})
+	}
+}
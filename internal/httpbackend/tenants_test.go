@@ -0,0 +1,78 @@
+package httpbackend_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func sendTenantRequest(t *testing.T, srv *httptest.Server, method, path, tenant string) (int, string) {
+	t.Helper()
+	require := require.New(t)
+
+	req, err := http.NewRequest(method, srv.URL+path, nil)
+	require.NoError(err)
+
+	if len(tenant) != 0 {
+		req.Header.Set(security.TenantHeader, tenant)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(err)
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(err)
+
+	return resp.StatusCode, string(body)
+}
+
+func TestTenantsAreIsolated(t *testing.T) {
+	require := require.New(t)
+
+	recorder := services.NewMetricsRecorder(storage.NewDataStore(nil, "", 0, nil, 0))
+
+	srv := httptest.NewServer(newRouter(t, "", recorder, nil))
+	defer srv.Close()
+
+	code, _ := sendTenantRequest(t, srv, http.MethodPost, "/update/counter/PollCount/10", "acme")
+	require.Equal(http.StatusOK, code)
+
+	code, _ = sendTenantRequest(t, srv, http.MethodGet, "/value/counter/PollCount", "")
+	require.Equal(http.StatusNotFound, code)
+
+	code, _ = sendTenantRequest(t, srv, http.MethodGet, "/value/counter/PollCount", "beta")
+	require.Equal(http.StatusNotFound, code)
+
+	code, body := sendTenantRequest(t, srv, http.MethodGet, "/value/counter/PollCount", "acme")
+	require.Equal(http.StatusOK, code)
+	require.Equal("10", body)
+
+	code, body = sendTenantRequest(t, srv, http.MethodGet, "/values", "")
+	require.Equal(http.StatusOK, code)
+	require.JSONEq(`{"data":[]}`, body)
+
+	code, body = sendTenantRequest(t, srv, http.MethodGet, "/", "acme")
+	require.Equal(http.StatusOK, code)
+	require.Contains(body, "<title>Collected metrics of acme</title>")
+	require.Contains(body, "PollCount")
+}
+
+func TestInvalidTenantRejected(t *testing.T) {
+	recorder := services.NewMetricsRecorder(storage.NewDataStore(nil, "", 0, nil, 0))
+
+	srv := httptest.NewServer(newRouter(t, "", recorder, nil))
+	defer srv.Close()
+
+	code, _ := sendTenantRequest(t, srv, http.MethodGet, "/values", "../acme")
+	require.Equal(t, http.StatusBadRequest, code)
+}
@@ -2,15 +2,51 @@ package httpbackend
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/rs/zerolog/log"
 )
 
+// Count of seconds after which clients should retry updates rejected due to maintenance.
+const _maintenanceRetryAfter = "60"
+
 func writeErrorResponse(ctx context.Context, w http.ResponseWriter, code int, err error) {
 	log.Ctx(ctx).Error().Err(err).Msg("")
 
 	resp := fmt.Sprintf("%d %v", code, err)
 	http.Error(w, resp, code)
 }
+
+// writePushErrorResponse reports failed update of metrics.
+// Updates rejected due to maintenance or temporary unavailability of the service
+// should be repeated by clients later.
+func writePushErrorResponse(ctx context.Context, w http.ResponseWriter, err error) {
+	if errors.Is(err, entity.ErrMaintenance) || errors.Is(err, entity.ErrServiceUnavailable) {
+		w.Header().Set("Retry-After", _maintenanceRetryAfter)
+		writeErrorResponse(ctx, w, http.StatusServiceUnavailable, err)
+
+		return
+	}
+
+	if errors.Is(err, entity.ErrAccessDenied) {
+		writeErrorResponse(ctx, w, http.StatusForbidden, err)
+		return
+	}
+
+	if errors.Is(err, entity.ErrTenantNotForwarded) {
+		writeErrorResponse(ctx, w, http.StatusNotImplemented, err)
+		return
+	}
+
+	if errors.Is(err, entity.ErrSeriesQuotaExceeded) ||
+		errors.Is(err, entity.ErrSeriesLimitExceeded) ||
+		errors.Is(err, entity.ErrTenantsLimitExceeded) {
+		writeErrorResponse(ctx, w, http.StatusTooManyRequests, err)
+		return
+	}
+
+	writeErrorResponse(ctx, w, http.StatusInternalServerError, err)
+}
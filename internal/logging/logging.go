@@ -110,3 +110,56 @@ func UnaryRequestsInterceptor(
 
 	return resp, err
 }
+
+// StreamRequestsInterceptor is grpc stream interceptor which logs incoming requests and responses.
+func StreamRequestsInterceptor(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	id := generateRequestID()
+
+	logger := log.With().Str("req-id", id).Logger()
+	ctx := logger.WithContext(ss.Context())
+
+	l := logger.Info().
+		Str("transport", entity.TransportGRPC).
+		Str("method", info.FullMethod)
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		values := md.Get("x-real-ip")
+		if len(values) > 0 {
+			l.Str("client-ip", values[0])
+		}
+	}
+
+	l.Msg("")
+
+	err := handler(srv, &loggedServerStream{ServerStream: ss, ctx: ctx})
+
+	status, ok := status.FromError(err)
+	if ok {
+		logger.Info().
+			Str("status", status.Code().String()).
+			Msg("")
+	} else {
+		logger.Info().
+			Err(err).
+			Msg("")
+	}
+
+	return err
+}
+
+// loggedServerStream passes context with request's logger to stream handlers.
+type loggedServerStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *loggedServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,289 @@
+// Package otlp converts metrics received via OpenTelemetry protocol (OTLP)
+// into records supported by the service.
+package otlp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/internal/validators"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Prefix of resource attributes describing OpenTelemetry SDK rather than the source of metrics.
+	_sdkAttributesPrefix = "telemetry."
+
+	// Prefix of resource attributes conflicting with attributes of data points.
+	_resourceAttributesPrefix = "resource."
+)
+
+// attributesLabels converts attributes into labels.
+// If skipSDK is set, attributes describing OpenTelemetry SDK are skipped.
+func attributesLabels(attrs []*grpcapi.KeyValue, skipSDK bool) map[string]string {
+	rv := make(map[string]string, len(attrs))
+
+	for _, attr := range attrs {
+		if skipSDK && strings.HasPrefix(attr.Key, _sdkAttributesPrefix) {
+			continue
+		}
+
+		rv[attr.Key] = attributeValue(attr.Value)
+	}
+
+	return rv
+}
+
+// seriesName builds name of series from name of the metric, attributes of data point
+// and attributes of resource as described in validators.SanitizeLabels.
+// Resource attributes having the same keys as attributes of data point are prefixed with "resource.".
+func seriesName(name string, attrs []*grpcapi.KeyValue, resource map[string]string) string {
+	labels := attributesLabels(attrs, false)
+
+	for key, value := range resource {
+		if _, ok := labels[key]; ok {
+			key = _resourceAttributesPrefix + key
+		}
+
+		labels[key] = value
+	}
+
+	return name + validators.SanitizeLabels(labels)
+}
+
+// attributeValue converts value of attribute to string.
+// Values of unsupported types are converted to empty string.
+func attributeValue(value *grpcapi.AnyValue) string {
+	switch v := value.GetValue().(type) {
+	case *grpcapi.AnyValue_StringValue:
+		return v.StringValue
+
+	case *grpcapi.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+
+	case *grpcapi.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+
+	case *grpcapi.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'f', -1, 64)
+	}
+
+	return ""
+}
+
+// pointValue returns value of data point as float.
+func pointValue(point *grpcapi.NumberDataPoint) (float64, error) {
+	switch v := point.GetValue().(type) {
+	case *grpcapi.NumberDataPoint_AsInt:
+		return float64(v.AsInt), nil
+
+	case *grpcapi.NumberDataPoint_AsDouble:
+		if math.IsNaN(v.AsDouble) || math.IsInf(v.AsDouble, 0) {
+			return 0, fmt.Errorf("otlp - pointValue - %v: %w", v.AsDouble, entity.ErrIncompleteRequest)
+		}
+
+		return v.AsDouble, nil
+	}
+
+	return 0, fmt.Errorf("otlp - pointValue: %w", entity.ErrIncompleteRequest)
+}
+
+// A converter accumulates records created from single export request.
+type converter struct {
+	records []storage.Record
+
+	// Indexes of gauges in records and timestamps of their values,
+	// used to keep only the latest value of each gauge.
+	gauges map[string]int
+	times  map[string]uint64
+
+	rejected int64
+	reason   error
+}
+
+func (c *converter) reject(count int, err error) {
+	if count == 0 {
+		return
+	}
+
+	log.Warn().Err(err).Int("count", count).Msg("Skipping OTLP data points")
+
+	c.rejected += int64(count)
+
+	if c.reason == nil {
+		c.reason = err
+	}
+}
+
+func (c *converter) addGauge(name string, value float64, timestamp uint64) error {
+	if err := validators.ValidateMetricName(name, metrics.KindGauge); err != nil {
+		return err
+	}
+
+	if i, ok := c.gauges[name]; ok {
+		if timestamp >= c.times[name] {
+			c.records[i].Value = metrics.Gauge(value)
+			c.times[name] = timestamp
+		}
+
+		return nil
+	}
+
+	c.gauges[name] = len(c.records)
+	c.times[name] = timestamp
+	c.records = append(c.records, storage.Record{Name: name, Value: metrics.Gauge(value)})
+
+	return nil
+}
+
+func (c *converter) addCounter(name string, value float64) error {
+	if err := validators.ValidateMetricName(name, metrics.KindCounter); err != nil {
+		return err
+	}
+
+	// Rounding would silently lose fractional parts of each delta.
+	if value != math.Trunc(value) {
+		return fmt.Errorf("otlp - addCounter - %v: %w", value, entity.ErrFractionalDelta)
+	}
+
+	c.records = append(c.records, storage.Record{Name: name, Value: metrics.Counter(value)})
+
+	return nil
+}
+
+// addNumberPoints records points of gauge or sum.
+// Gauges and cumulative sums are recorded as gauges holding the latest value,
+// delta sums are recorded as counters, points with fractional deltas are rejected.
+func (c *converter) addNumberPoints(
+	name string,
+	resource map[string]string,
+	points []*grpcapi.NumberDataPoint,
+	temporality grpcapi.AggregationTemporality,
+) {
+	for _, point := range points {
+		value, err := pointValue(point)
+		if err != nil {
+			c.reject(1, err)
+			continue
+		}
+
+		pointName := seriesName(name, point.Attributes, resource)
+
+		if temporality == grpcapi.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+			err = c.addCounter(pointName, value)
+		} else {
+			err = c.addGauge(pointName, value, point.TimeUnixNano)
+		}
+
+		if err != nil {
+			c.reject(1, err)
+		}
+	}
+}
+
+// addHistogramPoints records mean value of histogram as gauge and count of events as "<name>Count".
+// Count of delta histograms is recorded as counter, count of cumulative histograms as gauge.
+func (c *converter) addHistogramPoints(name string, resource map[string]string, histogram *grpcapi.Histogram) {
+	for _, point := range histogram.DataPoints {
+		pointName := seriesName(name, point.Attributes, resource)
+
+		var err error
+
+		if histogram.AggregationTemporality == grpcapi.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+			err = c.addCounter(pointName+"Count", float64(point.Count))
+		} else {
+			err = c.addGauge(pointName+"Count", float64(point.Count), point.TimeUnixNano)
+		}
+
+		if err == nil && point.Sum != nil && point.Count != 0 {
+			err = c.addGauge(pointName, *point.Sum/float64(point.Count), point.TimeUnixNano)
+		}
+
+		if err != nil {
+			c.reject(1, err)
+		}
+	}
+}
+
+func (c *converter) addMetric(metric *grpcapi.Metric, resource map[string]string) {
+	name := validators.SanitizeMetricName(metric.Name)
+
+	switch data := metric.Data.(type) {
+	case *grpcapi.Metric_Gauge:
+		c.addNumberPoints(
+			name,
+			resource,
+			data.Gauge.DataPoints,
+			grpcapi.AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED,
+		)
+
+	case *grpcapi.Metric_Sum:
+		if data.Sum.AggregationTemporality == grpcapi.AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED {
+			c.reject(
+				len(data.Sum.DataPoints),
+				fmt.Errorf("otlp - addMetric - %s: aggregation temporality not set", metric.Name),
+			)
+			return
+		}
+
+		c.addNumberPoints(name, resource, data.Sum.DataPoints, data.Sum.AggregationTemporality)
+
+	case *grpcapi.Metric_Histogram:
+		if data.Histogram.AggregationTemporality == grpcapi.AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED {
+			c.reject(
+				len(data.Histogram.DataPoints),
+				fmt.Errorf("otlp - addMetric - %s: aggregation temporality not set", metric.Name),
+			)
+
+			return
+		}
+
+		c.addHistogramPoints(name, resource, data.Histogram)
+
+	case *grpcapi.Metric_ExponentialHistogram:
+		c.reject(len(data.ExponentialHistogram.DataPoints), entity.MetricNotImplementedError("exponential histogram"))
+
+	case *grpcapi.Metric_Summary:
+		c.reject(len(data.Summary.DataPoints), entity.MetricNotImplementedError("summary"))
+	}
+}
+
+// Convert creates records from OTLP export request.
+// Name of each record is built from the metric name followed by keys and values of data point
+// attributes and resource attributes ordered by keys and hash of the attributes, e.g. metric
+// "http.requests" with attribute method="GET" of service with service.name="checkout" becomes
+// "HttpRequestsMethodGETServiceNameCheckoute90d0f54".
+// Resource attributes describing OpenTelemetry SDK ("telemetry.*") are not included.
+// Data points which can't be converted are skipped and reported as partial success.
+func Convert(req *grpcapi.ExportMetricsServiceRequest) ([]storage.Record, *grpcapi.ExportMetricsPartialSuccess) {
+	c := &converter{
+		records: make([]storage.Record, 0),
+		gauges:  make(map[string]int),
+		times:   make(map[string]uint64),
+	}
+
+	for _, rm := range req.ResourceMetrics {
+		resource := attributesLabels(rm.GetResource().GetAttributes(), true)
+
+		for _, sm := range rm.ScopeMetrics {
+			for _, metric := range sm.Metrics {
+				c.addMetric(metric, resource)
+			}
+		}
+	}
+
+	if c.rejected == 0 {
+		return c.records, nil
+	}
+
+	return c.records, &grpcapi.ExportMetricsPartialSuccess{
+		RejectedDataPoints: c.rejected,
+		ErrorMessage:       c.reason.Error(),
+	}
+}
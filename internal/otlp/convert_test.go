@@ -0,0 +1,264 @@
+package otlp_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/otlp"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func stringAttr(key, value string) *grpcapi.KeyValue {
+	return &grpcapi.KeyValue{
+		Key:   key,
+		Value: &grpcapi.AnyValue{Value: &grpcapi.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+func doublePoint(value float64, timestamp uint64, attrs ...*grpcapi.KeyValue) *grpcapi.NumberDataPoint {
+	return &grpcapi.NumberDataPoint{
+		Attributes:   attrs,
+		TimeUnixNano: timestamp,
+		Value:        &grpcapi.NumberDataPoint_AsDouble{AsDouble: value},
+	}
+}
+
+func intPoint(value int64, timestamp uint64, attrs ...*grpcapi.KeyValue) *grpcapi.NumberDataPoint {
+	return &grpcapi.NumberDataPoint{
+		Attributes:   attrs,
+		TimeUnixNano: timestamp,
+		Value:        &grpcapi.NumberDataPoint_AsInt{AsInt: value},
+	}
+}
+
+func newRequest(resource []*grpcapi.KeyValue, data ...*grpcapi.Metric) *grpcapi.ExportMetricsServiceRequest {
+	return &grpcapi.ExportMetricsServiceRequest{
+		ResourceMetrics: []*grpcapi.ResourceMetrics{
+			{
+				Resource: &grpcapi.Resource{Attributes: resource},
+				ScopeMetrics: []*grpcapi.ScopeMetrics{
+					{
+						Scope:   &grpcapi.InstrumentationScope{Name: "test"},
+						Metrics: data,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestConvert(t *testing.T) {
+	delta := grpcapi.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	cumulative := grpcapi.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+
+	tt := []struct {
+		name     string
+		req      *grpcapi.ExportMetricsServiceRequest
+		expected []storage.Record
+		rejected int64
+	}{
+		{
+			name: "Should record latest point of gauge",
+			req: newRequest(nil, &grpcapi.Metric{
+				Name: "process.memory.usage",
+				Data: &grpcapi.Metric_Gauge{Gauge: &grpcapi.Gauge{
+					DataPoints: []*grpcapi.NumberDataPoint{doublePoint(20, 2000), doublePoint(10, 1000)},
+				}},
+			}),
+			expected: []storage.Record{
+				{Name: "ProcessMemoryUsage", Value: metrics.Gauge(20)},
+			},
+		},
+		{
+			name: "Should record delta sum as counter",
+			req: newRequest(nil, &grpcapi.Metric{
+				Name: "http.requests",
+				Data: &grpcapi.Metric_Sum{Sum: &grpcapi.Sum{
+					AggregationTemporality: delta,
+					IsMonotonic:            true,
+					DataPoints:             []*grpcapi.NumberDataPoint{intPoint(3, 1000), doublePoint(2, 2000)},
+				}},
+			}),
+			expected: []storage.Record{
+				{Name: "HttpRequests", Value: metrics.Counter(3)},
+				{Name: "HttpRequests", Value: metrics.Counter(2)},
+			},
+		},
+		{
+			name: "Should reject fractional points of delta sum",
+			req: newRequest(nil, &grpcapi.Metric{
+				Name: "http.requests",
+				Data: &grpcapi.Metric_Sum{Sum: &grpcapi.Sum{
+					AggregationTemporality: delta,
+					DataPoints: []*grpcapi.NumberDataPoint{
+						doublePoint(0.4, 1000),
+						doublePoint(1.6, 2000),
+						doublePoint(-0.5, 3000),
+						doublePoint(-2, 4000),
+					},
+				}},
+			}),
+			expected: []storage.Record{
+				{Name: "HttpRequests", Value: metrics.Counter(-2)},
+			},
+			rejected: 3,
+		},
+		{
+			name: "Should record cumulative sum as gauge",
+			req: newRequest(nil, &grpcapi.Metric{
+				Name: "http.requests",
+				Data: &grpcapi.Metric_Sum{Sum: &grpcapi.Sum{
+					AggregationTemporality: cumulative,
+					IsMonotonic:            true,
+					DataPoints:             []*grpcapi.NumberDataPoint{intPoint(3, 1000), intPoint(5, 2000)},
+				}},
+			}),
+			expected: []storage.Record{
+				{Name: "HttpRequests", Value: metrics.Gauge(5)},
+			},
+		},
+		{
+			name: "Should record mean and count of histograms",
+			req: newRequest(
+				nil,
+				&grpcapi.Metric{
+					Name: "db.query",
+					Data: &grpcapi.Metric_Histogram{Histogram: &grpcapi.Histogram{
+						AggregationTemporality: delta,
+						DataPoints: []*grpcapi.HistogramDataPoint{
+							{Count: 4, Sum: proto.Float64(10), TimeUnixNano: 1000},
+						},
+					}},
+				},
+				&grpcapi.Metric{
+					Name: "http.duration",
+					Data: &grpcapi.Metric_Histogram{Histogram: &grpcapi.Histogram{
+						AggregationTemporality: cumulative,
+						DataPoints: []*grpcapi.HistogramDataPoint{
+							{Count: 2, Sum: proto.Float64(3), TimeUnixNano: 1000},
+							{Count: 0, TimeUnixNano: 2000, Attributes: []*grpcapi.KeyValue{stringAttr("route", "api")}},
+						},
+					}},
+				},
+			),
+			expected: []storage.Record{
+				{Name: "DbQueryCount", Value: metrics.Counter(4)},
+				{Name: "DbQuery", Value: metrics.Gauge(2.5)},
+				{Name: "HttpDurationCount", Value: metrics.Gauge(2)},
+				{Name: "HttpDuration", Value: metrics.Gauge(1.5)},
+				{Name: "HttpDurationRouteApia79c785aCount", Value: metrics.Gauge(0)},
+			},
+		},
+		{
+			name: "Should append keys and values of point and resource attributes",
+			req: newRequest(
+				[]*grpcapi.KeyValue{
+					stringAttr("service.name", "checkout"),
+					stringAttr("telemetry.sdk.language", "go"),
+					{Key: "replica", Value: &grpcapi.AnyValue{Value: &grpcapi.AnyValue_IntValue{IntValue: 2}}},
+				},
+				&grpcapi.Metric{
+					Name: "http.requests",
+					Data: &grpcapi.Metric_Gauge{Gauge: &grpcapi.Gauge{
+						DataPoints: []*grpcapi.NumberDataPoint{
+							doublePoint(1, 1000, stringAttr("method", "GET"), stringAttr("code", "200")),
+						},
+					}},
+				},
+			),
+			expected: []storage.Record{
+				{Name: "HttpRequestsCode200MethodGETReplica2ServiceNameCheckout0823bf97", Value: metrics.Gauge(1)},
+			},
+		},
+		{
+			name: "Should store points with the same concatenated attribute values separately",
+			req: newRequest(
+				[]*grpcapi.KeyValue{stringAttr("host", "web1")},
+				&grpcapi.Metric{
+					Name: "http.requests",
+					Data: &grpcapi.Metric_Gauge{Gauge: &grpcapi.Gauge{
+						DataPoints: []*grpcapi.NumberDataPoint{
+							doublePoint(1, 1000, stringAttr("code", "20"), stringAttr("method", "0GET")),
+							doublePoint(2, 1000, stringAttr("code", "200"), stringAttr("method", "GET")),
+							doublePoint(3, 1000, stringAttr("host", "web2")),
+						},
+					}},
+				},
+			),
+			expected: []storage.Record{
+				{Name: "HttpRequestsCode20HostWeb1Method0GET59fb57fa", Value: metrics.Gauge(1)},
+				{Name: "HttpRequestsCode200HostWeb1MethodGET032f8462", Value: metrics.Gauge(2)},
+				{Name: "HttpRequestsHostWeb2ResourceHostWeb136308a56", Value: metrics.Gauge(3)},
+			},
+		},
+		{
+			name: "Should reject unsupported and invalid data points",
+			req: newRequest(
+				nil,
+				&grpcapi.Metric{
+					Name: "rpc.duration",
+					Data: &grpcapi.Metric_Summary{Summary: &grpcapi.Summary{
+						DataPoints: []*grpcapi.SummaryDataPoint{{}, {}},
+					}},
+				},
+				&grpcapi.Metric{
+					Name: "rpc.size",
+					Data: &grpcapi.Metric_ExponentialHistogram{ExponentialHistogram: &grpcapi.ExponentialHistogram{
+						DataPoints: []*grpcapi.ExponentialHistogramDataPoint{{}},
+					}},
+				},
+				&grpcapi.Metric{
+					Name: "rpc.calls",
+					Data: &grpcapi.Metric_Sum{Sum: &grpcapi.Sum{
+						DataPoints: []*grpcapi.NumberDataPoint{intPoint(1, 1000)},
+					}},
+				},
+				&grpcapi.Metric{
+					Name: "rpc.load",
+					Data: &grpcapi.Metric_Gauge{Gauge: &grpcapi.Gauge{
+						DataPoints: []*grpcapi.NumberDataPoint{
+							doublePoint(math.NaN(), 1000),
+							{TimeUnixNano: 1000},
+							doublePoint(1, 1000),
+						},
+					}},
+				},
+				&grpcapi.Metric{
+					Name: "...",
+					Data: &grpcapi.Metric_Gauge{Gauge: &grpcapi.Gauge{
+						DataPoints: []*grpcapi.NumberDataPoint{doublePoint(1, 1000)},
+					}},
+				},
+			),
+			expected: []storage.Record{
+				{Name: "RpcLoad", Value: metrics.Gauge(1)},
+			},
+			rejected: 7,
+		},
+		{
+			name:     "Should accept empty request",
+			req:      new(grpcapi.ExportMetricsServiceRequest),
+			expected: []storage.Record{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			records, partial := otlp.Convert(tc.req)
+
+			require.Equal(t, tc.expected, records)
+
+			if tc.rejected == 0 {
+				require.Nil(t, partial)
+				return
+			}
+
+			require.Equal(t, tc.rejected, partial.RejectedDataPoints)
+			require.NotEmpty(t, partial.ErrorMessage)
+		})
+	}
+}
@@ -0,0 +1,396 @@
+// Package query implements small expression language evaluating aggregates over stored metrics.
+//
+// Expressions consist of selectors, functions and arithmetic operations, e.g.:
+//
+//	sum(PollCount*)              - sum of current values of metrics which names match glob
+//	max(HeapInuse:gauge[10m])    - maximal value of the gauge over the last 10 minutes
+//	rate(PollCount[5m]) * 60     - per-minute growth rate of the counter
+//	sum(HeapInuse) / 1024 / 1024 - arithmetic on aggregates and numbers
+//
+// Supported functions are sum, avg, min, max and count aggregating list of metrics
+// (or all values of metrics over a range) into single number, and rate calculating
+// per-second growth of each metric over a range.
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+)
+
+// readChecker is implemented by recorders restricting access to metrics,
+// e.g. services.AccessRecorder. It allows to apply the same restrictions to history.
+type readChecker interface {
+	Readable(ctx context.Context, name string) bool
+}
+
+// Engine evaluates query expressions over metrics of the recorder.
+// Current values of metrics are read from the recorder, history - from History.
+type Engine struct {
+	recorder services.Recorder
+	history  *History
+}
+
+// NewEngine creates new instance of Engine.
+func NewEngine(recorder services.Recorder, history *History) *Engine {
+	return &Engine{recorder: recorder, history: history}
+}
+
+// Query parses and evaluates the expression.
+func (e *Engine) Query(ctx context.Context, expr string) (Result, error) {
+	parsed, err := ParseExpression(expr)
+	if err != nil {
+		return Result{}, fmt.Errorf("Engine - Query - ParseExpression: %w", err)
+	}
+
+	return e.Evaluate(ctx, parsed)
+}
+
+// Evaluate calculates value of previously parsed expression.
+func (e *Engine) Evaluate(ctx context.Context, expr Expression) (Result, error) {
+	rv, err := e.eval(ctx, expr.tree, time.Now())
+	if err != nil {
+		return Result{}, fmt.Errorf("Engine - Evaluate - e.eval: %w", err)
+	}
+
+	if err := validateResult(rv); err != nil {
+		return Result{}, fmt.Errorf("Engine - Evaluate - validateResult: %w", err)
+	}
+
+	return rv, nil
+}
+
+func (e *Engine) eval(ctx context.Context, tree node, now time.Time) (Result, error) {
+	switch n := tree.(type) {
+	case numberLiteral:
+		return scalar(n.value), nil
+
+	case selector:
+		if n.window == 0 {
+			return e.selectCurrent(ctx, n)
+		}
+
+		return e.selectRange(ctx, n, now)
+
+	case negation:
+		arg, err := e.eval(ctx, n.arg, now)
+		if err != nil {
+			return Result{}, err
+		}
+
+		return apply("*", arg, scalar(-1))
+
+	case binaryOperation:
+		left, err := e.eval(ctx, n.left, now)
+		if err != nil {
+			return Result{}, err
+		}
+
+		right, err := e.eval(ctx, n.right, now)
+		if err != nil {
+			return Result{}, err
+		}
+
+		return apply(n.operator, left, right)
+
+	case call:
+		arg, err := e.eval(ctx, n.arg, now)
+		if err != nil {
+			return Result{}, err
+		}
+
+		if n.function == "rate" {
+			return rate(arg)
+		}
+
+		return aggregate(n.function, arg)
+	}
+
+	return Result{}, fmt.Errorf("Engine - eval: %w", entity.ErrUnexpected)
+}
+
+func (e *Engine) selectCurrent(ctx context.Context, s selector) (Result, error) {
+	page, err := e.recorder.List(ctx, storage.ListOptions{Match: s.match, Kind: s.kind})
+	if err != nil {
+		return Result{}, err
+	}
+
+	samples := make([]Sample, 0, len(page.Records))
+
+	for _, record := range page.Records {
+		value, err := toFloat(record.Value)
+		if err != nil {
+			return Result{}, err
+		}
+
+		samples = append(samples, Sample{Name: record.Name, Kind: record.Value.Kind(), Value: value})
+	}
+
+	return vector(samples), nil
+}
+
+func (e *Engine) selectRange(ctx context.Context, s selector, now time.Time) (Result, error) {
+	if e.history.Window() <= 0 {
+		return Result{}, entity.InvalidQueryError("range selectors are disabled")
+	}
+
+	// History is collected for the default tenant only.
+	if entity.TenantFromContext(ctx) != entity.DefaultTenant {
+		return Result{}, entity.InvalidQueryError("range selectors are not available for tenants")
+	}
+
+	if s.window > e.history.Window() {
+		return Result{}, entity.InvalidQueryError(
+			fmt.Sprintf("range %s exceeds available history of %s", s.window, e.history.Window()),
+		)
+	}
+
+	series := e.history.Select(s.pattern, s.kind, now.Add(-s.window))
+
+	if checker, ok := e.recorder.(readChecker); ok {
+		allowed := make([]Series, 0, len(series))
+
+		for _, item := range series {
+			if checker.Readable(ctx, item.Name) {
+				allowed = append(allowed, item)
+			}
+		}
+
+		series = allowed
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Name != series[j].Name {
+			return series[i].Name < series[j].Name
+		}
+
+		return series[i].Kind < series[j].Kind
+	})
+
+	return matrix(series), nil
+}
+
+func calculate(operator string, left, right float64) (float64, error) {
+	switch operator {
+	case "+":
+		return left + right, nil
+
+	case "-":
+		return left - right, nil
+
+	case "*":
+		return left * right, nil
+
+	case "/":
+		if right == 0 {
+			return 0, entity.InvalidQueryError("division by zero")
+		}
+
+		return left / right, nil
+	}
+
+	return 0, fmt.Errorf("query - calculate: %w", entity.ErrUnexpected)
+}
+
+// apply performs arithmetic operation on numbers or on each value of a vector and a number.
+// Vector containing single metric acts as a number in operations with another vector,
+// e.g. "(TotalMemory - FreeMemory) / TotalMemory".
+func apply(operator string, left, right Result) (Result, error) {
+	if left.Type == ResultMatrix || right.Type == ResultMatrix {
+		return Result{}, entity.InvalidQueryError("arithmetic on ranges is not supported, aggregate them first")
+	}
+
+	if left.Type == ResultVector && right.Type == ResultVector {
+		switch {
+		case len(left.Vector) == 0 || len(right.Vector) == 0:
+			return vector([]Sample{}), nil
+
+		case len(right.Vector) == 1:
+			right = scalar(right.Vector[0].Value)
+
+		case len(left.Vector) == 1:
+			left = scalar(left.Vector[0].Value)
+
+		default:
+			return Result{}, entity.InvalidQueryError(
+				"arithmetic between two lists of several metrics is not supported, aggregate one of them first",
+			)
+		}
+	}
+
+	if left.Type == ResultScalar && right.Type == ResultScalar {
+		value, err := calculate(operator, left.Scalar, right.Scalar)
+		if err != nil {
+			return Result{}, err
+		}
+
+		return scalar(value), nil
+	}
+
+	samples := left.Vector
+	if right.Type == ResultVector {
+		samples = right.Vector
+	}
+
+	rv := make([]Sample, 0, len(samples))
+
+	for _, s := range samples {
+		var (
+			value float64
+			err   error
+		)
+
+		if left.Type == ResultVector {
+			value, err = calculate(operator, s.Value, right.Scalar)
+		} else {
+			value, err = calculate(operator, left.Scalar, s.Value)
+		}
+
+		if err != nil {
+			return Result{}, err
+		}
+
+		rv = append(rv, Sample{Name: s.Name, Kind: s.Kind, Value: value})
+	}
+
+	return vector(rv), nil
+}
+
+// aggregate reduces current values of metrics or all values of metrics over a range to single number.
+func aggregate(function string, arg Result) (Result, error) {
+	values := make([]float64, 0)
+
+	switch arg.Type {
+	case ResultVector:
+		for _, s := range arg.Vector {
+			values = append(values, s.Value)
+		}
+
+	case ResultMatrix:
+		for _, s := range arg.Matrix {
+			for _, p := range s.Points {
+				values = append(values, p.Value)
+			}
+		}
+
+	default:
+		return Result{}, entity.InvalidQueryError(fmt.Sprintf("%s expects metrics, got number", function))
+	}
+
+	switch function {
+	case "sum":
+		return scalar(sum(values)), nil
+
+	case "count":
+		return scalar(float64(len(values))), nil
+	}
+
+	if len(values) == 0 {
+		return Result{}, fmt.Errorf("%w: no metrics match selector of %s", entity.ErrMetricNotFound, function)
+	}
+
+	switch function {
+	case "avg":
+		return scalar(sum(values) / float64(len(values))), nil
+
+	case "min":
+		rv := values[0]
+		for _, v := range values[1:] {
+			rv = math.Min(rv, v)
+		}
+
+		return scalar(rv), nil
+
+	case "max":
+		rv := values[0]
+		for _, v := range values[1:] {
+			rv = math.Max(rv, v)
+		}
+
+		return scalar(rv), nil
+	}
+
+	return Result{}, fmt.Errorf("query - aggregate: %w", entity.ErrUnexpected)
+}
+
+func sum(values []float64) float64 {
+	rv := 0.0
+	for _, v := range values {
+		rv += v
+	}
+
+	return rv
+}
+
+// rate calculates per-second growth of each metric over a range.
+// Decrease of a counter is treated as reset, e.g. due to restart of the server.
+// Metrics having less than two values in the range are skipped.
+func rate(arg Result) (Result, error) {
+	if arg.Type != ResultMatrix {
+		return Result{}, entity.InvalidQueryError("rate expects range of metrics, e.g. PollCount[5m]")
+	}
+
+	rv := make([]Sample, 0, len(arg.Matrix))
+
+	for _, s := range arg.Matrix {
+		if len(s.Points) < 2 {
+			continue
+		}
+
+		first, last := s.Points[0], s.Points[len(s.Points)-1]
+
+		elapsed := last.Timestamp.Sub(first.Timestamp).Seconds()
+		if elapsed <= 0 {
+			continue
+		}
+
+		increase := last.Value - first.Value
+
+		if s.Kind == metrics.KindCounter {
+			increase = 0
+
+			for i := 1; i < len(s.Points); i++ {
+				delta := s.Points[i].Value - s.Points[i-1].Value
+				if delta < 0 {
+					delta = s.Points[i].Value
+				}
+
+				increase += delta
+			}
+		}
+
+		rv = append(rv, Sample{Name: s.Name, Kind: s.Kind, Value: increase / elapsed})
+	}
+
+	return vector(rv), nil
+}
+
+// validateResult verifies that the result can be reported to clients.
+func validateResult(rv Result) error {
+	finite := func(v float64) bool {
+		return !math.IsNaN(v) && !math.IsInf(v, 0)
+	}
+
+	switch rv.Type {
+	case ResultScalar:
+		if !finite(rv.Scalar) {
+			return entity.InvalidQueryError("result is not a finite number")
+		}
+
+	case ResultVector:
+		for _, s := range rv.Vector {
+			if !finite(s.Value) {
+				return entity.InvalidQueryError(fmt.Sprintf("value of %s is not a finite number", s.Name))
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,339 @@
+package query_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/query"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEngine(t *testing.T) *query.Engine {
+	t.Helper()
+	require := require.New(t)
+
+	recorder := services.NewMetricsRecorder(storage.NewMemStorage())
+
+	_, err := recorder.PushList(context.Background(), []storage.Record{
+		{Name: "PollCountAgent1", Value: metrics.Counter(10)},
+		{Name: "PollCountAgent2", Value: metrics.Counter(20)},
+		{Name: "HeapInuse", Value: metrics.Gauge(100)},
+		{Name: "HeapAlloc", Value: metrics.Gauge(50)},
+	})
+	require.NoError(err)
+
+	now := time.Now()
+	history := query.NewHistory(recorder, time.Hour)
+
+	history.Record(storage.Record{Name: "HeapInuse", Value: metrics.Gauge(500)}, now.Add(-15*time.Minute))
+	history.Record(storage.Record{Name: "HeapInuse", Value: metrics.Gauge(300)}, now.Add(-9*time.Minute))
+	history.Record(storage.Record{Name: "HeapInuse", Value: metrics.Gauge(200)}, now.Add(-5*time.Minute))
+
+	history.Record(storage.Record{Name: "PollCount", Value: metrics.Counter(10)}, now.Add(-60*time.Second))
+	history.Record(storage.Record{Name: "PollCount", Value: metrics.Counter(40)}, now.Add(-30*time.Second))
+	history.Record(storage.Record{Name: "PollCount", Value: metrics.Counter(70)}, now)
+
+	history.Record(storage.Record{Name: "Restarts", Value: metrics.Counter(10)}, now.Add(-60*time.Second))
+	history.Record(storage.Record{Name: "Restarts", Value: metrics.Counter(40)}, now.Add(-30*time.Second))
+	history.Record(storage.Record{Name: "Restarts", Value: metrics.Counter(5)}, now)
+
+	return query.NewEngine(recorder, history)
+}
+
+func TestQueryScalar(t *testing.T) {
+	tt := []struct {
+		name     string
+		expr     string
+		expected float64
+	}{
+		{
+			name:     "Should sum current values of metrics matching glob",
+			expr:     "sum(PollCount*)",
+			expected: 30,
+		},
+		{
+			name:     "Should average current values",
+			expr:     "avg(Heap*)",
+			expected: 75,
+		},
+		{
+			name:     "Should count metrics",
+			expr:     "count(*)",
+			expected: 4,
+		},
+		{
+			name:     "Should select metrics of specified kind",
+			expr:     "count(*:gauge)",
+			expected: 2,
+		},
+		{
+			name:     "Should find maximum over range",
+			expr:     "max(HeapInuse[10m])",
+			expected: 300,
+		},
+		{
+			name:     "Should find minimum over range",
+			expr:     "min(HeapInuse:gauge[20m])",
+			expected: 200,
+		},
+		{
+			name:     "Should sum zero metrics",
+			expr:     "sum(Unknown*)",
+			expected: 0,
+		},
+		{
+			name:     "Should apply arithmetic to aggregates",
+			expr:     "sum(Heap*) / 10 - max(PollCount*)",
+			expected: -5,
+		},
+		{
+			name:     "Should respect precedence of operators and parentheses",
+			expr:     "2 * (1 + 2) - -3 * 2",
+			expected: 12,
+		},
+		{
+			name:     "Should parse numbers in scientific notation",
+			expr:     "1.5e2 + 2E-1",
+			expected: 150.2,
+		},
+	}
+
+	engine := newTestEngine(t)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rv, err := engine.Query(context.Background(), tc.expr)
+
+			require.NoError(t, err)
+			require.Equal(t, query.ResultScalar, rv.Type)
+			require.InDelta(t, tc.expected, rv.Scalar, 1e-9)
+		})
+	}
+}
+
+func TestQueryVector(t *testing.T) {
+	tt := []struct {
+		name     string
+		expr     string
+		expected []query.Sample
+	}{
+		{
+			name: "Should select current values of metrics",
+			expr: "PollCount*:counter",
+			expected: []query.Sample{
+				{Name: "PollCountAgent1", Kind: metrics.KindCounter, Value: 10},
+				{Name: "PollCountAgent2", Kind: metrics.KindCounter, Value: 20},
+			},
+		},
+		{
+			name: "Should treat separated asterisk as multiplication",
+			expr: "HeapAlloc * 2",
+			expected: []query.Sample{
+				{Name: "HeapAlloc", Kind: metrics.KindGauge, Value: 100},
+			},
+		},
+		{
+			name: "Should apply arithmetic to each metric",
+			expr: "100 - HeapAlloc",
+			expected: []query.Sample{
+				{Name: "HeapAlloc", Kind: metrics.KindGauge, Value: 50},
+			},
+		},
+		{
+			name: "Should treat single metric as number in arithmetic with another metric",
+			expr: "(HeapInuse - HeapAlloc) / HeapInuse * 100",
+			expected: []query.Sample{
+				{Name: "HeapInuse", Kind: metrics.KindGauge, Value: 50},
+			},
+		},
+		{
+			name: "Should apply single metric to each metric of another list",
+			expr: "PollCount* / HeapAlloc",
+			expected: []query.Sample{
+				{Name: "PollCountAgent1", Kind: metrics.KindCounter, Value: 0.2},
+				{Name: "PollCountAgent2", Kind: metrics.KindCounter, Value: 0.4},
+			},
+		},
+		{
+			name:     "Should return nothing if operand is missing",
+			expr:     "HeapInuse - Unknown",
+			expected: []query.Sample{},
+		},
+		{
+			name: "Should negate metrics",
+			expr: "-HeapAlloc",
+			expected: []query.Sample{
+				{Name: "HeapAlloc", Kind: metrics.KindGauge, Value: -50},
+			},
+		},
+		{
+			name: "Should calculate rate of counters",
+			expr: "rate(PollCount[5m]) * 60",
+			expected: []query.Sample{
+				{Name: "PollCount", Kind: metrics.KindCounter, Value: 60},
+			},
+		},
+		{
+			name: "Should handle reset of counters",
+			expr: "rate(Restarts[5m])",
+			expected: []query.Sample{
+				{Name: "Restarts", Kind: metrics.KindCounter, Value: 35.0 / 60},
+			},
+		},
+		{
+			name: "Should calculate rate of gauges",
+			expr: "rate(HeapInuse[10m])",
+			expected: []query.Sample{
+				{Name: "HeapInuse", Kind: metrics.KindGauge, Value: -100.0 / 240},
+			},
+		},
+		{
+			name:     "Should skip metrics without enough values for rate",
+			expr:     "rate(HeapInuse[6m])",
+			expected: []query.Sample{},
+		},
+	}
+
+	engine := newTestEngine(t)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rv, err := engine.Query(context.Background(), tc.expr)
+
+			require.NoError(t, err)
+			require.Equal(t, query.ResultVector, rv.Type)
+			require.Len(t, rv.Vector, len(tc.expected))
+
+			for i := range tc.expected {
+				require.Equal(t, tc.expected[i].Name, rv.Vector[i].Name)
+				require.Equal(t, tc.expected[i].Kind, rv.Vector[i].Kind)
+				require.InDelta(t, tc.expected[i].Value, rv.Vector[i].Value, 1e-9)
+			}
+		})
+	}
+}
+
+func TestQueryMatrix(t *testing.T) {
+	engine := newTestEngine(t)
+
+	rv, err := engine.Query(context.Background(), "Heap*[10m]")
+
+	require.NoError(t, err)
+	require.Equal(t, query.ResultMatrix, rv.Type)
+	require.Len(t, rv.Matrix, 1)
+	require.Equal(t, "HeapInuse", rv.Matrix[0].Name)
+	require.Len(t, rv.Matrix[0].Points, 2)
+	require.Equal(t, 300.0, rv.Matrix[0].Points[0].Value)
+	require.Equal(t, 200.0, rv.Matrix[0].Points[1].Value)
+}
+
+func TestQueryFails(t *testing.T) {
+	tt := []struct {
+		name     string
+		expr     string
+		expected error
+	}{
+		{
+			name:     "Should fail on unexpected character",
+			expr:     "sum(PollCount#)",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on unterminated call",
+			expr:     "sum(PollCount*",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on missing operand",
+			expr:     "sum(PollCount*) +",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on trailing tokens",
+			expr:     "HeapAlloc HeapInuse",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on unknown kind",
+			expr:     "HeapAlloc:histogram",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on invalid range",
+			expr:     "HeapInuse[10]",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on unterminated range",
+			expr:     "HeapInuse[10m",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail if range exceeds history",
+			expr:     "max(HeapInuse[2h])",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on arithmetic between lists of several metrics",
+			expr:     "Heap* / PollCount*",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on arithmetic with range",
+			expr:     "HeapInuse[10m] * 2",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on rate of current values",
+			expr:     "rate(PollCount)",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on aggregation of number",
+			expr:     "sum(1)",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on division by zero",
+			expr:     "HeapAlloc / (1 - 1)",
+			expected: entity.ErrInvalidQuery,
+		},
+		{
+			name:     "Should fail on maximum of nothing",
+			expr:     "max(Unknown)",
+			expected: entity.ErrMetricNotFound,
+		},
+	}
+
+	engine := newTestEngine(t)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := engine.Query(context.Background(), tc.expr)
+
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestQueryFailsIfHistoryDisabled(t *testing.T) {
+	recorder := services.NewMetricsRecorder(storage.NewMemStorage())
+	engine := query.NewEngine(recorder, query.NewHistory(recorder, 0))
+
+	_, err := engine.Query(context.Background(), "max(HeapInuse[1m])")
+
+	require.ErrorIs(t, err, entity.ErrInvalidQuery)
+}
+
+func TestQueryFailsOnRangeOfTenant(t *testing.T) {
+	engine := newTestEngine(t)
+
+	_, err := engine.Query(entity.WithTenant(context.Background(), "acme"), "max(HeapInuse[10m])")
+
+	require.ErrorIs(t, err, entity.ErrInvalidQuery)
+}
@@ -0,0 +1,163 @@
+package query
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+)
+
+// Interval between removals of samples which fell out of the history window.
+const _historyCleanupInterval = time.Minute
+
+// History keeps recent values of metrics in memory to serve range selectors.
+// Only values recorded since start of the server are available.
+type History struct {
+	recorder services.Recorder
+	window   time.Duration
+
+	mu     sync.RWMutex
+	series map[string]*Series
+}
+
+// NewHistory creates new instance of History keeping values of metrics
+// recorded by the recorder during the window. Zero window disables the history.
+func NewHistory(recorder services.Recorder, window time.Duration) *History {
+	return &History{
+		recorder: recorder,
+		window:   window,
+		series:   make(map[string]*Series),
+	}
+}
+
+// Window returns maximal range of history available for queries.
+func (h *History) Window() time.Duration {
+	return h.window
+}
+
+// Collect records all updates of metrics reported by the recorder
+// till the context is done.
+func (h *History) Collect(ctx context.Context) {
+	if h.window <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(_historyCleanupInterval)
+	defer ticker.Stop()
+
+	updates := h.recorder.Watch(ctx, storage.Filter{})
+
+	for {
+		select {
+		case record, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			h.Record(record, time.Now())
+
+		case now := <-ticker.C:
+			h.cleanup(now)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Record adds value of the metric recorded at the moment to the history.
+func (h *History) Record(record storage.Record, at time.Time) {
+	if h.window <= 0 {
+		return
+	}
+
+	value, err := toFloat(record.Value)
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id := services.CalculateID(record.Name, record.Value.Kind())
+
+	s, ok := h.series[id]
+	if !ok {
+		s = &Series{Name: record.Name, Kind: record.Value.Kind()}
+		h.series[id] = s
+	}
+
+	s.Points = append(s.Points, Point{Timestamp: at, Value: value})
+}
+
+// cleanup removes samples which fell out of the window, as well as series without samples.
+func (h *History) cleanup(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	from := now.Add(-h.window)
+
+	for id, s := range h.series {
+		s.Points = s.Points[firstAfter(s.Points, from):]
+
+		if len(s.Points) == 0 {
+			delete(h.series, id)
+		}
+	}
+}
+
+// firstAfter returns index of the first point recorded not earlier than the moment.
+func firstAfter(points []Point, from time.Time) int {
+	for i, p := range points {
+		if !p.Timestamp.Before(from) {
+			return i
+		}
+	}
+
+	return len(points)
+}
+
+// Select returns copy of history of metrics matching the pattern and kind
+// recorded not earlier than the moment. Empty kind matches any kind.
+func (h *History) Select(pattern *regexp.Regexp, kind string, from time.Time) []Series {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	rv := make([]Series, 0)
+
+	for _, s := range h.series {
+		if len(kind) != 0 && s.Kind != kind || !pattern.MatchString(s.Name) {
+			continue
+		}
+
+		points := s.Points[firstAfter(s.Points, from):]
+		if len(points) == 0 {
+			continue
+		}
+
+		rv = append(rv, Series{
+			Name:   s.Name,
+			Kind:   s.Kind,
+			Points: append([]Point(nil), points...),
+		})
+	}
+
+	return rv
+}
+
+func toFloat(value metrics.Metric) (float64, error) {
+	switch v := value.(type) {
+	case metrics.Counter:
+		return float64(v), nil
+
+	case metrics.Gauge:
+		return float64(v), nil
+	}
+
+	return 0, entity.MetricNotImplementedError(value.Kind())
+}
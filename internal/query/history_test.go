@@ -0,0 +1,65 @@
+package query_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/query"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryCollectsUpdates(t *testing.T) {
+	require := require.New(t)
+
+	updates := make(chan storage.Record, 2)
+	updates <- storage.Record{Name: "PollCount", Value: metrics.Counter(1)}
+	updates <- storage.Record{Name: "PollCount", Value: metrics.Counter(5)}
+	close(updates)
+
+	m := new(services.RecorderMock)
+	m.On("Watch", mock.Anything, storage.Filter{}).Return((<-chan storage.Record)(updates))
+
+	history := query.NewHistory(m, time.Hour)
+	history.Collect(context.Background())
+
+	series := history.Select(regexp.MustCompile("^PollCount$"), metrics.KindCounter, time.Now().Add(-time.Minute))
+	require.Len(series, 1)
+	require.Len(series[0].Points, 2)
+	require.Equal(1.0, series[0].Points[0].Value)
+	require.Equal(5.0, series[0].Points[1].Value)
+
+	m.AssertExpectations(t)
+}
+
+func TestHistorySelectsMetrics(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Now()
+	history := query.NewHistory(new(services.RecorderMock), time.Hour)
+
+	history.Record(storage.Record{Name: "Alloc", Value: metrics.Gauge(1)}, now.Add(-10*time.Minute))
+	history.Record(storage.Record{Name: "Alloc", Value: metrics.Counter(2)}, now.Add(-5*time.Minute))
+	history.Record(storage.Record{Name: "Frees", Value: metrics.Gauge(3)}, now)
+
+	require.Len(history.Select(regexp.MustCompile(""), "", now.Add(-time.Hour)), 3)
+	require.Len(history.Select(regexp.MustCompile(""), metrics.KindGauge, now.Add(-time.Hour)), 2)
+	require.Len(history.Select(regexp.MustCompile("^Alloc$"), "", now.Add(-time.Hour)), 2)
+	require.Len(history.Select(regexp.MustCompile(""), "", now.Add(-time.Minute)), 1)
+}
+
+func TestHistoryWithZeroWindowIsDisabled(t *testing.T) {
+	m := new(services.RecorderMock)
+	history := query.NewHistory(m, 0)
+
+	history.Collect(context.Background())
+	history.Record(storage.Record{Name: "Alloc", Value: metrics.Gauge(1)}, time.Now())
+
+	require.Empty(t, history.Select(regexp.MustCompile(""), "", time.Time{}))
+	m.AssertNotCalled(t, "Watch", mock.Anything, mock.Anything)
+}
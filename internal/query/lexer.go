@@ -0,0 +1,135 @@
+package query
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenName
+	tokenDuration
+	tokenLeftParen
+	tokenRightParen
+	tokenColon
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+
+	// Position of the token in the expression, used in error messages.
+	pos int
+}
+
+func isNameChar(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '*' || r == '?')
+}
+
+func isNumberChar(r rune) bool {
+	return unicode.IsDigit(r) || r == '.'
+}
+
+func isDurationChar(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// tokenize splits expression into list of tokens terminated by tokenEOF.
+func tokenize(expr string) ([]token, error) {
+	src := []rune(expr)
+	rv := make([]token, 0)
+
+	for pos := 0; pos < len(src); {
+		r := src[pos]
+
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+
+		case r == '(':
+			rv = append(rv, token{kind: tokenLeftParen, text: "(", pos: pos})
+			pos++
+
+		case r == ')':
+			rv = append(rv, token{kind: tokenRightParen, text: ")", pos: pos})
+			pos++
+
+		case r == ':':
+			rv = append(rv, token{kind: tokenColon, text: ":", pos: pos})
+			pos++
+
+		case r == '+' || r == '-' || r == '*' && isOperatorContext(rv) || r == '/':
+			rv = append(rv, token{kind: tokenOperator, text: string(r), pos: pos})
+			pos++
+
+		case r == '[':
+			end := pos + 1
+			for end < len(src) && isDurationChar(src[end]) {
+				end++
+			}
+
+			if end == len(src) || src[end] != ']' {
+				return nil, entity.InvalidQueryError(fmt.Sprintf("unterminated range at position %d", pos))
+			}
+
+			rv = append(rv, token{kind: tokenDuration, text: string(src[pos+1 : end]), pos: pos})
+			pos = end + 1
+
+		case isNumberChar(r):
+			end := pos + 1
+			for end < len(src) && (isNumberChar(src[end]) || isExponent(src, end)) {
+				end++
+			}
+
+			rv = append(rv, token{kind: tokenNumber, text: string(src[pos:end]), pos: pos})
+			pos = end
+
+		case isNameChar(r):
+			end := pos + 1
+			for end < len(src) && isNameChar(src[end]) {
+				end++
+			}
+
+			rv = append(rv, token{kind: tokenName, text: string(src[pos:end]), pos: pos})
+			pos = end
+
+		default:
+			return nil, entity.InvalidQueryError(fmt.Sprintf("unexpected character %q at position %d", r, pos))
+		}
+	}
+
+	return append(rv, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+// isOperatorContext reports whether '*' following the tokens is multiplication
+// rather than wildcard of a name, i.e. whether it follows an operand.
+func isOperatorContext(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+
+	switch tokens[len(tokens)-1].kind {
+	case tokenNumber, tokenName, tokenDuration, tokenRightParen:
+		return true
+	}
+
+	return false
+}
+
+// isExponent reports whether the character at the position continues exponent of a number,
+// e.g. "e", "E" or sign following them.
+func isExponent(src []rune, pos int) bool {
+	r := src[pos]
+
+	if r == 'e' || r == 'E' {
+		return true
+	}
+
+	return (r == '+' || r == '-') && (src[pos-1] == 'e' || src[pos-1] == 'E')
+}
@@ -0,0 +1,281 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/internal/validators"
+)
+
+// A node is element of syntax tree of parsed expression.
+type node interface{}
+
+type numberLiteral struct {
+	value float64
+}
+
+// A selector picks metrics by name glob and optional kind.
+// If range is set, the selector picks history of the metrics, otherwise their current values.
+type selector struct {
+	// Regular expression matching names of metrics, see storage.NamePattern.
+	match   string
+	pattern *regexp.Regexp
+	kind    string
+	window  time.Duration
+}
+
+type call struct {
+	function string
+	arg      node
+}
+
+type negation struct {
+	arg node
+}
+
+type binaryOperation struct {
+	operator string
+	left     node
+	right    node
+}
+
+var _functions = map[string]bool{
+	"sum":   true,
+	"avg":   true,
+	"min":   true,
+	"max":   true,
+	"count": true,
+	"rate":  true,
+}
+
+// parser builds syntax tree using recursive descent:
+//
+//	expr      = term { ("+" | "-") term }
+//	term      = unary { ("*" | "/") unary }
+//	unary     = "-" unary | primary
+//	primary   = number | "(" expr ")" | function "(" expr ")" | selector
+//	selector  = name [ ":" kind ] [ "[" duration "]" ]
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// An Expression is parsed query expression ready for evaluation.
+type Expression struct {
+	text string
+	tree node
+}
+
+// ParseExpression verifies syntax of the expression and prepares it for evaluation.
+func ParseExpression(expr string) (Expression, error) {
+	tree, err := parse(expr)
+	if err != nil {
+		return Expression{}, err
+	}
+
+	return Expression{text: strings.TrimSpace(expr), tree: tree}, nil
+}
+
+func (e Expression) String() string {
+	return e.text
+}
+
+// parse converts expression into syntax tree.
+func parse(expr string) (node, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	rv, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+
+	return rv, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *parser) unexpected(tok token) error {
+	if tok.kind == tokenEOF {
+		return entity.InvalidQueryError("unexpected end of expression")
+	}
+
+	return entity.InvalidQueryError(fmt.Sprintf("unexpected %q at position %d", tok.text, tok.pos))
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, p.unexpected(tok)
+	}
+
+	return tok, nil
+}
+
+func (p *parser) expr() (node, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for tok := p.peek(); tok.kind == tokenOperator && (tok.text == "+" || tok.text == "-"); tok = p.peek() {
+		p.next()
+
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binaryOperation{operator: tok.text, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) term() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for tok := p.peek(); tok.kind == tokenOperator && (tok.text == "*" || tok.text == "/"); tok = p.peek() {
+		p.next()
+
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binaryOperation{operator: tok.text, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if tok := p.peek(); tok.kind == tokenOperator && tok.text == "-" {
+		p.next()
+
+		arg, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return negation{arg: arg}, nil
+	}
+
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, entity.InvalidQueryError(fmt.Sprintf("invalid number %q at position %d", tok.text, tok.pos))
+		}
+
+		return numberLiteral{value: value}, nil
+
+	case tokenLeftParen:
+		rv, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(tokenRightParen); err != nil {
+			return nil, err
+		}
+
+		return rv, nil
+
+	case tokenName:
+		if p.peek().kind == tokenLeftParen && _functions[tok.text] {
+			return p.call(tok)
+		}
+
+		return p.selector(tok)
+	}
+
+	return nil, p.unexpected(tok)
+}
+
+func (p *parser) call(name token) (node, error) {
+	p.next()
+
+	arg, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.expect(tokenRightParen); err != nil {
+		return nil, err
+	}
+
+	return call{function: name.text, arg: arg}, nil
+}
+
+func (p *parser) selector(name token) (node, error) {
+	expr, err := storage.NamePattern(name.text, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rv := selector{match: expr, pattern: regexp.MustCompile(expr)}
+
+	if p.peek().kind == tokenColon {
+		p.next()
+
+		kind, err := p.expect(tokenName)
+		if err != nil {
+			return nil, err
+		}
+
+		if validators.ValidateMetricKind(kind.text) != nil {
+			return nil, entity.InvalidQueryError(
+				fmt.Sprintf("unknown metric kind %q at position %d", kind.text, kind.pos),
+			)
+		}
+
+		rv.kind = kind.text
+	}
+
+	if tok := p.peek(); tok.kind == tokenDuration {
+		p.next()
+
+		window, err := time.ParseDuration(tok.text)
+		if err != nil || window <= 0 {
+			return nil, entity.InvalidQueryError(fmt.Sprintf("invalid range %q at position %d", tok.text, tok.pos))
+		}
+
+		rv.window = window
+	}
+
+	return rv, nil
+}
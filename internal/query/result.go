@@ -0,0 +1,51 @@
+package query
+
+import "time"
+
+// Types of query results.
+const (
+	ResultScalar = "scalar"
+	ResultVector = "vector"
+	ResultMatrix = "matrix"
+)
+
+// A Sample is current value of single metric.
+type Sample struct {
+	Name  string
+	Kind  string
+	Value float64
+}
+
+// A Point is value of a metric recorded at the moment.
+type Point struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// A Series is history of values of single metric ordered by time.
+type Series struct {
+	Name   string
+	Kind   string
+	Points []Point
+}
+
+// A Result is value of evaluated expression. Depending on the type, it is either
+// single number, list of current values of metrics or list of histories of metrics.
+type Result struct {
+	Type   string
+	Scalar float64
+	Vector []Sample
+	Matrix []Series
+}
+
+func scalar(value float64) Result {
+	return Result{Type: ResultScalar, Scalar: value}
+}
+
+func vector(samples []Sample) Result {
+	return Result{Type: ResultVector, Vector: samples}
+}
+
+func matrix(series []Series) Result {
+	return Result{Type: ResultMatrix, Matrix: series}
+}
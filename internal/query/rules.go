@@ -0,0 +1,74 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/internal/validators"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+)
+
+// A Rule defines gauge calculated from other metrics,
+// e.g. "MemUsedPct = (TotalMemory - FreeMemory) / TotalMemory * 100".
+type Rule struct {
+	Name string
+	Expr Expression
+}
+
+// ParseRules parses list of rules in the <name> = <expression> form.
+func ParseRules(defs []string) ([]Rule, error) {
+	rv := make([]Rule, 0, len(defs))
+	names := make(map[string]bool, len(defs))
+
+	for _, def := range defs {
+		name, expr, found := strings.Cut(def, "=")
+		if !found {
+			return nil, fmt.Errorf("%w: %q, expected <name> = <expression>", entity.ErrInvalidRule, def)
+		}
+
+		name = strings.TrimSpace(name)
+		if err := validators.ValidateMetricName(name, metrics.KindGauge); err != nil {
+			return nil, fmt.Errorf("%w: %q, %s", entity.ErrInvalidRule, def, err)
+		}
+
+		if names[name] {
+			return nil, fmt.Errorf("%w: %q, metric %s is already defined", entity.ErrInvalidRule, def, name)
+		}
+
+		names[name] = true
+
+		parsed, err := ParseExpression(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q, %s", entity.ErrInvalidRule, def, err)
+		}
+
+		rv = append(rv, Rule{Name: name, Expr: parsed})
+	}
+
+	return rv, nil
+}
+
+// EvaluateRule calculates current value of the gauge defined by the rule.
+// The expression must evaluate to single number or to list containing single metric.
+func (e *Engine) EvaluateRule(ctx context.Context, rule Rule) (storage.Record, error) {
+	rv, err := e.Evaluate(ctx, rule.Expr)
+	if err != nil {
+		return storage.Record{}, fmt.Errorf("Engine - EvaluateRule - e.Evaluate: %w", err)
+	}
+
+	switch {
+	case rv.Type == ResultScalar:
+		return storage.Record{Name: rule.Name, Value: metrics.Gauge(rv.Scalar)}, nil
+
+	case rv.Type == ResultVector && len(rv.Vector) == 1:
+		return storage.Record{Name: rule.Name, Value: metrics.Gauge(rv.Vector[0].Value)}, nil
+
+	case rv.Type == ResultVector && len(rv.Vector) == 0:
+		return storage.Record{}, fmt.Errorf("%w: no metrics match the expression", entity.ErrMetricNotFound)
+	}
+
+	return storage.Record{}, entity.InvalidQueryError("expression of rule must evaluate to single value")
+}
@@ -0,0 +1,118 @@
+package query_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/query"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	require := require.New(t)
+
+	rules, err := query.ParseRules([]string{
+		"MemUsedPct = (TotalMemory - FreeMemory) / TotalMemory * 100",
+		"HeapUsed=sum(Heap*)",
+	})
+
+	require.NoError(err)
+	require.Len(rules, 2)
+	require.Equal("MemUsedPct", rules[0].Name)
+	require.Equal("(TotalMemory - FreeMemory) / TotalMemory * 100", rules[0].Expr.String())
+	require.Equal("HeapUsed", rules[1].Name)
+	require.Equal("sum(Heap*)", rules[1].Expr.String())
+}
+
+func TestParseRulesFails(t *testing.T) {
+	tt := []struct {
+		name  string
+		rules []string
+	}{
+		{
+			name:  "Should fail without expression",
+			rules: []string{"MemUsedPct"},
+		},
+		{
+			name:  "Should fail on invalid name",
+			rules: []string{"mem_used = 1"},
+		},
+		{
+			name:  "Should fail on empty name",
+			rules: []string{" = 1"},
+		},
+		{
+			name:  "Should fail on invalid expression",
+			rules: []string{"MemUsedPct = TotalMemory -"},
+		},
+		{
+			name:  "Should fail on duplicated name",
+			rules: []string{"HeapUsed = sum(Heap*)", "HeapUsed = 1"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := query.ParseRules(tc.rules)
+
+			require.ErrorIs(t, err, entity.ErrInvalidRule)
+		})
+	}
+}
+
+func TestEvaluateRule(t *testing.T) {
+	tt := []struct {
+		name     string
+		rule     string
+		expected float64
+		err      error
+	}{
+		{
+			name:     "Should evaluate rule producing number",
+			rule:     "HeapTotal = sum(Heap*)",
+			expected: 150,
+		},
+		{
+			name:     "Should evaluate rule producing single metric",
+			rule:     "HeapUsedPct = (HeapInuse - HeapAlloc) / HeapInuse * 100",
+			expected: 50,
+		},
+		{
+			name: "Should fail if metrics are missing",
+			rule: "MemUsedPct = (TotalMemory - FreeMemory) / TotalMemory * 100",
+			err:  entity.ErrMetricNotFound,
+		},
+		{
+			name: "Should fail if rule produces several metrics",
+			rule: "PollCountDouble = PollCount* * 2",
+			err:  entity.ErrInvalidQuery,
+		},
+		{
+			name: "Should fail if rule produces range",
+			rule: "HeapHistory = HeapInuse[10m]",
+			err:  entity.ErrInvalidQuery,
+		},
+	}
+
+	engine := newTestEngine(t)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := query.ParseRules([]string{tc.rule})
+			require.NoError(t, err)
+
+			record, err := engine.EvaluateRule(context.Background(), rules[0])
+
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, rules[0].Name, record.Name)
+			require.Equal(t, metrics.Gauge(tc.expected), record.Value)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+// Package relay implements forwarding of received metrics to upstream collector.
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/exporter"
+	"github.com/alkurbatov/metrics-collector/internal/recovery"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+var _ services.Recorder = (*Relay)(nil)
+
+// Maximal time of single attempt to forward metrics.
+const _forwardTimeout = 10 * time.Second
+
+// Relay records metrics locally and periodically forwards them to upstream collector.
+// Metrics received between forwards are aggregated: counters are summed,
+// gauges keep the last value. If forwarding fails temporarily, aggregated metrics are kept
+// and forwarded on the next attempt together with metrics received meanwhile.
+// Metrics rejected by upstream, e.g. due to exceeded quota, are dropped.
+// Only metrics of the default tenant are forwarded, updates of other tenants are rejected.
+type Relay struct {
+	services.Recorder
+
+	exporter   exporter.Exporter
+	interval   time.Duration
+	bufferSize int
+
+	// Metrics aggregated since the last successful forward.
+	buffer   map[string]storage.Record
+	bufferMu sync.Mutex
+
+	// Count of metrics dropped due to buffer overflow since the last forward.
+	dropped int64
+
+	// Total count of metrics rejected by upstream.
+	rejected atomic.Int64
+
+	// Closed on shutdown to stop background tasks.
+	quit chan struct{}
+
+	// Tracks background tasks.
+	wg sync.WaitGroup
+}
+
+// New creates new instance of Relay wrapping the recorder.
+// If exporter is nil, Relay just passes metrics to the recorder. Such relay is no-op.
+func New(
+	recorder services.Recorder,
+	exp exporter.Exporter,
+	interval time.Duration,
+	bufferSize int,
+) *Relay {
+	if exp == nil {
+		return &Relay{Recorder: recorder}
+	}
+
+	return &Relay{
+		Recorder:   recorder,
+		exporter:   exp,
+		interval:   interval,
+		bufferSize: bufferSize,
+		buffer:     make(map[string]storage.Record),
+		quit:       make(chan struct{}),
+	}
+}
+
+// add aggregates records in the buffer.
+// If keepNewer is set, buffered gauges are not replaced, as they hold more recent values.
+func (r *Relay) add(records []storage.Record, keepNewer bool) {
+	r.bufferMu.Lock()
+	defer r.bufferMu.Unlock()
+
+	for _, record := range records {
+		id := services.CalculateID(record.Name, record.Value.Kind())
+
+		prev, ok := r.buffer[id]
+		if !ok {
+			if len(r.buffer) >= r.bufferSize {
+				r.dropped++
+				continue
+			}
+
+			r.buffer[id] = record
+
+			continue
+		}
+
+		if record.Value.Kind() == metrics.KindCounter {
+			record.Value = prev.Value.(metrics.Counter) + record.Value.(metrics.Counter)
+		} else if keepNewer {
+			continue
+		}
+
+		r.buffer[id] = record
+	}
+}
+
+// admit rejects updates of tenants, as upstream receives metrics of the default tenant only.
+// Otherwise metrics of tenants would be stored locally, but silently never forwarded.
+func (r *Relay) admit(ctx context.Context) error {
+	if r.exporter != nil && entity.TenantFromContext(ctx) != entity.DefaultTenant {
+		return entity.ErrTenantNotForwarded
+	}
+
+	return nil
+}
+
+// Push records metric data and schedules forwarding of it.
+func (r *Relay) Push(ctx context.Context, record storage.Record) (storage.Record, error) {
+	if err := r.admit(ctx); err != nil {
+		return storage.Record{}, fmt.Errorf("Relay - Push - r.admit: %w", err)
+	}
+
+	rv, err := r.Recorder.Push(ctx, record)
+	if err != nil || r.exporter == nil {
+		return rv, err
+	}
+
+	r.add([]storage.Record{record}, false)
+
+	return rv, nil
+}
+
+// PushList records list of metrics data and schedules forwarding of it.
+func (r *Relay) PushList(ctx context.Context, records []storage.Record) ([]storage.Record, error) {
+	if err := r.admit(ctx); err != nil {
+		return nil, fmt.Errorf("Relay - PushList - r.admit: %w", err)
+	}
+
+	rv, err := r.Recorder.PushList(ctx, records)
+	if err != nil || r.exporter == nil {
+		return rv, err
+	}
+
+	r.add(records, false)
+
+	return rv, nil
+}
+
+// Start launches periodical forwarding of metrics.
+func (r *Relay) Start() {
+	if r.exporter == nil {
+		return
+	}
+
+	r.wg.Add(1)
+
+	go r.forwardPeriodically()
+}
+
+func (r *Relay) forwardPeriodically() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			func() {
+				defer recovery.TryRecover()
+
+				ctx, cancel := context.WithTimeout(context.Background(), _forwardTimeout)
+				defer cancel()
+
+				r.forward(ctx)
+			}()
+
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// forward sends aggregated metrics to upstream collector in single batch request.
+// The metrics are considered forwarded only after upstream confirmed that it has recorded them:
+// HTTP exporter waits for response, gRPC exporter waits for summary of the stream.
+// On temporary failure, e.g. network error, the metrics are returned to the buffer.
+// Rejected metrics are not accepted on retry either, thus they are dropped.
+func (r *Relay) forward(ctx context.Context) {
+	r.bufferMu.Lock()
+	batch := r.buffer
+	dropped := r.dropped
+	r.buffer = make(map[string]storage.Record)
+	r.dropped = 0
+	r.bufferMu.Unlock()
+
+	if dropped > 0 {
+		log.Warn().Int64("count", dropped).Msg("Relay buffer is full, metrics were not forwarded")
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+
+	defer r.exporter.Reset()
+
+	records := make([]storage.Record, 0, len(batch))
+
+	for _, record := range batch {
+		r.exporter.Add(record.Name, record.Value)
+		records = append(records, record)
+	}
+
+	err := r.exporter.Send(ctx).Error()
+	if err == nil {
+		log.Debug().Int("count", len(records)).Msg("Metrics forwarded to upstream")
+		return
+	}
+
+	if errors.Is(err, entity.ErrRequestRejected) {
+		total := r.rejected.Add(int64(len(records)))
+		log.Error().Err(err).Int("count", len(records)).Int64("total", total).Msg("Upstream rejected metrics, dropped")
+
+		return
+	}
+
+	if errors.Is(err, entity.ErrServiceUnavailable) {
+		log.Warn().Err(err).Int("count", len(records)).Msg("Upstream is unavailable, postpone forwarding")
+	} else {
+		log.Error().Err(err).Int("count", len(records)).Msg("Failed to forward metrics to upstream")
+	}
+
+	r.add(records, true)
+}
+
+// Rejected returns total count of metrics dropped, because upstream rejected them.
+func (r *Relay) Rejected() int64 {
+	return r.rejected.Load()
+}
+
+// Shutdown stops periodical forwarding and makes last attempt to forward buffered metrics.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	if r.exporter == nil {
+		return nil
+	}
+
+	close(r.quit)
+	r.wg.Wait()
+
+	r.forward(ctx)
+
+	return r.exporter.Close()
+}
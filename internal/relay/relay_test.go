@@ -0,0 +1,302 @@
+package relay_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/exporter"
+	"github.com/alkurbatov/metrics-collector/internal/relay"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// forwarded collects metrics added to the exporter mock.
+type forwarded struct {
+	sync.Mutex
+	records []storage.Record
+}
+
+func (f *forwarded) Get() []storage.Record {
+	f.Lock()
+	defer f.Unlock()
+
+	return append([]storage.Record(nil), f.records...)
+}
+
+func (f *forwarded) Reset() {
+	f.Lock()
+	defer f.Unlock()
+
+	f.records = nil
+}
+
+func newExporterMock(f *forwarded) *exporter.ExporterMock {
+	m := new(exporter.ExporterMock)
+	m.On("Add", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		f.Lock()
+		defer f.Unlock()
+
+		f.records = append(f.records, storage.Record{
+			Name:  args.String(0),
+			Value: args.Get(1).(metrics.Metric),
+		})
+	})
+	m.On("Send", mock.Anything)
+	m.On("Reset")
+	m.On("Close").Return(nil)
+
+	return m
+}
+
+func newRecorderMock() *services.RecorderMock {
+	m := new(services.RecorderMock)
+	m.On("Push", mock.Anything, mock.Anything).Return(storage.Record{}, nil)
+	m.On("PushList", mock.Anything, mock.Anything).Return([]storage.Record{}, nil)
+
+	return m
+}
+
+func TestRelayForwardsAggregatedMetrics(t *testing.T) {
+	require := require.New(t)
+
+	f := new(forwarded)
+	exp := newExporterMock(f)
+	exp.On("Error").Return(nil)
+
+	r := relay.New(newRecorderMock(), exp, time.Hour, 100)
+	r.Start()
+
+	_, err := r.PushList(context.Background(), []storage.Record{
+		{Name: "PollCount", Value: metrics.Counter(1)},
+		{Name: "Alloc", Value: metrics.Gauge(10)},
+	})
+	require.NoError(err)
+
+	_, err = r.Push(context.Background(), storage.Record{Name: "PollCount", Value: metrics.Counter(2)})
+	require.NoError(err)
+
+	_, err = r.Push(context.Background(), storage.Record{Name: "Alloc", Value: metrics.Gauge(20)})
+	require.NoError(err)
+
+	require.NoError(r.Shutdown(context.Background()))
+
+	require.ElementsMatch([]storage.Record{
+		{Name: "PollCount", Value: metrics.Counter(3)},
+		{Name: "Alloc", Value: metrics.Gauge(20)},
+	}, f.Get())
+	exp.AssertCalled(t, "Close")
+}
+
+func TestRelayRetriesFailedForward(t *testing.T) {
+	tt := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "Should retry if upstream is unavailable",
+			err:  entity.ErrServiceUnavailable,
+		},
+		{
+			name: "Should retry if upstream didn't acknowledge the metrics",
+			err:  entity.ErrUnexpected,
+		},
+		{
+			name: "Should retry on internal error of upstream",
+			err:  entity.HTTPError(http.StatusInternalServerError, []byte("failure")),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			failed := make(chan struct{})
+
+			f := new(forwarded)
+			exp := newExporterMock(f)
+			exp.On("Error").Return(tc.err).Once().Run(func(mock.Arguments) {
+				close(failed)
+			})
+			exp.On("Error").Return(nil)
+
+			r := relay.New(newRecorderMock(), exp, 20*time.Millisecond, 100)
+			r.Start()
+
+			_, err := r.PushList(context.Background(), []storage.Record{
+				{Name: "PollCount", Value: metrics.Counter(1)},
+				{Name: "Alloc", Value: metrics.Gauge(10)},
+			})
+			require.NoError(err)
+
+			select {
+			case <-failed:
+			case <-time.After(2 * time.Second):
+				require.FailNow("Relay didn't try to forward metrics")
+			}
+
+			f.Reset()
+
+			_, err = r.PushList(context.Background(), []storage.Record{
+				{Name: "PollCount", Value: metrics.Counter(2)},
+				{Name: "Alloc", Value: metrics.Gauge(20)},
+			})
+			require.NoError(err)
+
+			require.NoError(r.Shutdown(context.Background()))
+
+			// Failed metrics are forwarded again, either separately or merged with the new ones.
+			var (
+				pollCount metrics.Counter
+				alloc     metrics.Gauge
+			)
+
+			for _, record := range f.Get() {
+				switch v := record.Value.(type) {
+				case metrics.Counter:
+					pollCount += v
+
+				case metrics.Gauge:
+					alloc = v
+				}
+			}
+
+			require.Equal(metrics.Counter(3), pollCount)
+			require.Equal(metrics.Gauge(20), alloc)
+		})
+	}
+}
+
+func TestRelayDropsRejectedMetrics(t *testing.T) {
+	require := require.New(t)
+
+	failed := make(chan struct{})
+
+	f := new(forwarded)
+	exp := newExporterMock(f)
+	exp.On("Error").Return(entity.RequestRejectedError(entity.ErrSeriesQuotaExceeded)).Once().Run(
+		func(mock.Arguments) {
+			close(failed)
+		},
+	)
+	exp.On("Error").Return(nil)
+
+	r := relay.New(newRecorderMock(), exp, 20*time.Millisecond, 100)
+	r.Start()
+
+	_, err := r.PushList(context.Background(), []storage.Record{
+		{Name: "PollCount", Value: metrics.Counter(1)},
+		{Name: "Alloc", Value: metrics.Gauge(10)},
+	})
+	require.NoError(err)
+
+	select {
+	case <-failed:
+	case <-time.After(2 * time.Second):
+		require.FailNow("Relay didn't try to forward metrics")
+	}
+
+	f.Reset()
+
+	_, err = r.PushList(context.Background(), []storage.Record{{Name: "PollCount", Value: metrics.Counter(2)}})
+	require.NoError(err)
+
+	require.NoError(r.Shutdown(context.Background()))
+
+	require.Equal([]storage.Record{{Name: "PollCount", Value: metrics.Counter(2)}}, f.Get())
+	require.Equal(int64(2), r.Rejected())
+}
+
+func TestRelayDropsMetricsOnBufferOverflow(t *testing.T) {
+	require := require.New(t)
+
+	f := new(forwarded)
+	exp := newExporterMock(f)
+	exp.On("Error").Return(nil)
+
+	r := relay.New(newRecorderMock(), exp, time.Hour, 1)
+	r.Start()
+
+	_, err := r.PushList(context.Background(), []storage.Record{
+		{Name: "PollCount", Value: metrics.Counter(1)},
+		{Name: "Alloc", Value: metrics.Gauge(10)},
+		{Name: "PollCount", Value: metrics.Counter(1)},
+	})
+	require.NoError(err)
+
+	require.NoError(r.Shutdown(context.Background()))
+
+	require.Equal([]storage.Record{{Name: "PollCount", Value: metrics.Counter(2)}}, f.Get())
+}
+
+func TestRelayDoesntForwardRejectedMetrics(t *testing.T) {
+	require := require.New(t)
+
+	rec := new(services.RecorderMock)
+	rec.On("PushList", mock.Anything, mock.Anything).Return([]storage.Record{}, entity.ErrMaintenance)
+
+	exp := new(exporter.ExporterMock)
+	exp.On("Close").Return(nil)
+
+	r := relay.New(rec, exp, time.Hour, 100)
+	r.Start()
+
+	_, err := r.PushList(context.Background(), []storage.Record{{Name: "PollCount", Value: metrics.Counter(1)}})
+	require.ErrorIs(err, entity.ErrMaintenance)
+
+	require.NoError(r.Shutdown(context.Background()))
+	exp.AssertNotCalled(t, "Send", mock.Anything)
+}
+
+func TestRelayRejectsMetricsOfTenants(t *testing.T) {
+	require := require.New(t)
+
+	rec := newRecorderMock()
+
+	exp := new(exporter.ExporterMock)
+	exp.On("Close").Return(nil)
+
+	r := relay.New(rec, exp, time.Hour, 100)
+	r.Start()
+
+	ctx := entity.WithTenant(context.Background(), "acme")
+
+	_, err := r.Push(ctx, storage.Record{Name: "PollCount", Value: metrics.Counter(1)})
+	require.ErrorIs(err, entity.ErrTenantNotForwarded)
+
+	_, err = r.PushList(ctx, []storage.Record{{Name: "Alloc", Value: metrics.Gauge(10)}})
+	require.ErrorIs(err, entity.ErrTenantNotForwarded)
+
+	require.NoError(r.Shutdown(context.Background()))
+	rec.AssertNotCalled(t, "Push", mock.Anything, mock.Anything)
+	rec.AssertNotCalled(t, "PushList", mock.Anything, mock.Anything)
+	exp.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	exp.AssertNotCalled(t, "Send", mock.Anything)
+}
+
+func TestRelayIsNoopWithoutExporter(t *testing.T) {
+	require := require.New(t)
+
+	rec := newRecorderMock()
+
+	r := relay.New(rec, nil, time.Second, 100)
+	r.Start()
+
+	_, err := r.PushList(context.Background(), []storage.Record{{Name: "PollCount", Value: metrics.Counter(1)}})
+	require.NoError(err)
+
+	acme := entity.WithTenant(context.Background(), "acme")
+
+	_, err = r.Push(acme, storage.Record{Name: "Alloc", Value: metrics.Gauge(10)})
+	require.NoError(err)
+
+	require.NoError(r.Shutdown(context.Background()))
+	rec.AssertCalled(t, "PushList", mock.Anything, mock.Anything)
+	rec.AssertCalled(t, "Push", mock.Anything, mock.Anything)
+}
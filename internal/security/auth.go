@@ -0,0 +1,232 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	_bearerPrefix = "Bearer "
+
+	// Name of query parameter and cookie carrying token of browser clients.
+	_tokenParam = "token"
+)
+
+// BearerToken formats value of authorization header (or gRPC metadata) carrying the token.
+func BearerToken(token Secret) string {
+	return _bearerPrefix + string(token)
+}
+
+// parseBearerToken extracts token from value of authorization header.
+func parseBearerToken(value string) string {
+	if len(value) < len(_bearerPrefix) || !strings.EqualFold(value[:len(_bearerPrefix)], _bearerPrefix) {
+		return ""
+	}
+
+	return strings.TrimSpace(value[len(_bearerPrefix):])
+}
+
+// AuthorizeRequest is a HTTP middleware that rejects requests without bearer token
+// granting the scope.
+func AuthorizeRequest(tokens *Tokens, scope Scope) func(next http.Handler) http.Handler {
+	return authorizeRequest(tokens, scope, func(w http.ResponseWriter, r *http.Request) string {
+		return parseBearerToken(r.Header.Get("Authorization"))
+	})
+}
+
+// AuthorizePageRequest is a HTTP middleware for pages opened in browser, which can't set
+// authorization header. Besides of bearer token it accepts the token from query parameter
+// or cookie named "token". The token passed in query is moved to the cookie, so links
+// and WebSocket connections of the page are authorized as well.
+func AuthorizePageRequest(tokens *Tokens, scope Scope) func(next http.Handler) http.Handler {
+	return authorizeRequest(tokens, scope, func(w http.ResponseWriter, r *http.Request) string {
+		if token := parseBearerToken(r.Header.Get("Authorization")); len(token) != 0 {
+			return token
+		}
+
+		query := r.URL.Query()
+		if token := query.Get(_tokenParam); len(token) != 0 {
+			http.SetCookie(w, &http.Cookie{
+				Name:     _tokenParam,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+
+			// Don't leak the token to links rendered by the page.
+			query.Del(_tokenParam)
+			r.URL.RawQuery = query.Encode()
+
+			return token
+		}
+
+		if cookie, err := r.Cookie(_tokenParam); err == nil {
+			return cookie.Value
+		}
+
+		return ""
+	})
+}
+
+// authorizeRequest rejects requests, if token returned by tokenOf doesn't grant the scope.
+func authorizeRequest(
+	tokens *Tokens,
+	scope Scope,
+	tokenOf func(w http.ResponseWriter, r *http.Request) string,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			identity, err := tokens.Authorize(tokenOf(w, r), scope)
+			if err != nil {
+				logger := log.Ctx(r.Context())
+				logger.Error().
+					Err(err).
+					Str("client", identity.Name).
+					Msg("security - AuthorizeRequest - tokens.Authorize")
+
+				if errors.Is(err, entity.ErrInsufficientScope) {
+					http.Error(w, err.Error(), http.StatusForbidden)
+					return
+				}
+
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// authorizeIncomingRequest checks bearer token of incoming gRPC request
+// and returns context carrying identity of the client.
+func authorizeIncomingRequest(ctx context.Context, tokens *Tokens, scope Scope) (context.Context, error) {
+	var token string
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		values := md.Get("authorization")
+		if len(values) > 0 {
+			token = parseBearerToken(values[0])
+		}
+	}
+
+	identity, err := tokens.Authorize(token, scope)
+	if err != nil {
+		logger := log.Ctx(ctx)
+		logger.Error().
+			Err(err).
+			Str("client", identity.Name).
+			Msg("security - authorizeIncomingRequest - tokens.Authorize")
+
+		if errors.Is(err, entity.ErrInsufficientScope) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return WithIdentity(ctx, identity), nil
+}
+
+// scopeOf returns scope required to call the method.
+// Methods missing in the scopes map are denied, so new methods can't be exposed by mistake.
+func scopeOf(ctx context.Context, scopes map[string]Scope, method string) (Scope, error) {
+	scope, ok := scopes[method]
+	if !ok {
+		logger := log.Ctx(ctx)
+		logger.Error().Str("method", method).Msg("security - scopeOf - access scope of method is not defined")
+
+		return "", status.Error(codes.PermissionDenied, entity.ErrUnknownMethod.Error())
+	}
+
+	return scope, nil
+}
+
+// UnaryRequestsAuthorizer is grpc unary interceptor that rejects requests without
+// bearer token granting the scope required by called method.
+// Public methods don't require authorization, methods missing in the scopes map are denied.
+func UnaryRequestsAuthorizer(
+	tokens *Tokens,
+	scopes map[string]Scope,
+) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		scope, err := scopeOf(ctx, scopes, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		if scope == ScopePublic {
+			return handler(ctx, req)
+		}
+
+		ctx, err = authorizeIncomingRequest(ctx, tokens, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRequestsAuthorizer is grpc stream interceptor that rejects requests without
+// bearer token granting the scope required by called method.
+// Public methods don't require authorization, methods missing in the scopes map are denied.
+func StreamRequestsAuthorizer(
+	tokens *Tokens,
+	scopes map[string]Scope,
+) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		scope, err := scopeOf(stream.Context(), scopes, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		if scope == ScopePublic {
+			return handler(srv, stream)
+		}
+
+		ctx, err := authorizeIncomingRequest(stream.Context(), tokens, scope)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &wrappedServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// wrappedServerStream passes context filled by interceptors (e.g. with identity
+// of the client) to stream handlers.
+type wrappedServerStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *wrappedServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,331 @@
+package security_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/grpcbackend"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func sendAuthorizedHTTPRequest(t *testing.T, authorization string) (int, string) {
+	t.Helper()
+	require := require.New(t)
+
+	var client string
+
+	router := chi.NewRouter()
+	router.Use(security.AuthorizeRequest(createTestTokens(t), security.ScopeWrite))
+	router.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := security.IdentityFromContext(r.Context())
+		require.True(ok)
+
+		client = identity.Name
+	})
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, nil)
+	require.NoError(err)
+
+	if len(authorization) != 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(err)
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	return resp.StatusCode, client
+}
+
+func createAuthorizedGRPCClient(t *testing.T, mockAPI *grpcbackend.MetricsServerMock) grpcapi.MetricsClient {
+	t.Helper()
+	require := require.New(t)
+
+	scopes := map[string]security.Scope{
+		"/metrics.collector.v1.Metrics/Update":       security.ScopeWrite,
+		"/metrics.collector.v1.Metrics/StreamUpdate": security.ScopeWrite,
+		"/metrics.collector.v1.Metrics/Get":          security.ScopePublic,
+	}
+	tokens := createTestTokens(t)
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(security.UnaryRequestsAuthorizer(tokens, scopes)),
+		grpc.StreamInterceptor(security.StreamRequestsAuthorizer(tokens, scopes)),
+	)
+	grpcapi.RegisterMetricsServer(srv, mockAPI)
+
+	go func() {
+		require.NoError(srv.Serve(lis))
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+	conn, err := grpc.Dial("", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(err)
+
+	t.Cleanup(func() {
+		require.NoError(conn.Close())
+		srv.Stop()
+		require.NoError(lis.Close())
+	})
+
+	return grpcapi.NewMetricsClient(conn)
+}
+
+func TestAuthorizeHTTPRequest(t *testing.T) {
+	tt := []struct {
+		name          string
+		authorization string
+		expected      int
+		client        string
+	}{
+		{
+			name:          "Accepts token granting scope",
+			authorization: security.BearerToken("agent-token"),
+			expected:      http.StatusOK,
+			client:        "agent",
+		},
+		{
+			name:          "Accepts lowercase authorization scheme",
+			authorization: "bearer ops-token",
+			expected:      http.StatusOK,
+			client:        "ops",
+		},
+		{
+			name:          "Rejects token without scope",
+			authorization: security.BearerToken("viewer-token"),
+			expected:      http.StatusForbidden,
+		},
+		{
+			name:          "Rejects unknown token",
+			authorization: security.BearerToken("xxx"),
+			expected:      http.StatusUnauthorized,
+		},
+		{
+			name:          "Rejects not bearer authorization",
+			authorization: "Basic YWdlbnQ6YWdlbnQ=",
+			expected:      http.StatusUnauthorized,
+		},
+		{
+			name:     "Rejects request without token",
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			status, client := sendAuthorizedHTTPRequest(t, tc.authorization)
+
+			require.Equal(t, tc.expected, status)
+			require.Equal(t, tc.client, client)
+		})
+	}
+}
+
+func TestAuthorizePageRequest(t *testing.T) {
+	tt := []struct {
+		name          string
+		authorization string
+		query         string
+		cookie        string
+		expected      int
+		client        string
+		setCookie     string
+	}{
+		{
+			name:          "Accepts bearer token",
+			authorization: security.BearerToken("viewer-token"),
+			expected:      http.StatusOK,
+			client:        "viewer",
+		},
+		{
+			name:      "Accepts token in query and moves it to cookie",
+			query:     "?token=viewer-token&kind=counter",
+			expected:  http.StatusOK,
+			client:    "viewer",
+			setCookie: "viewer-token",
+		},
+		{
+			name:     "Accepts token in cookie",
+			cookie:   "viewer-token",
+			expected: http.StatusOK,
+			client:   "viewer",
+		},
+		{
+			name:      "Rejects token in query without scope",
+			query:     "?token=agent-token",
+			expected:  http.StatusForbidden,
+			setCookie: "agent-token",
+		},
+		{
+			name:     "Rejects unknown token in cookie",
+			cookie:   "xxx",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "Rejects request without token",
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			var client, query string
+
+			router := chi.NewRouter()
+			router.Use(security.AuthorizePageRequest(createTestTokens(t), security.ScopeRead))
+			router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				identity, ok := security.IdentityFromContext(r.Context())
+				require.True(ok)
+
+				client = identity.Name
+				query = r.URL.RawQuery
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/"+tc.query, nil)
+			if len(tc.authorization) != 0 {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+
+			if len(tc.cookie) != 0 {
+				req.AddCookie(&http.Cookie{Name: "token", Value: tc.cookie})
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			require.Equal(tc.expected, resp.Code)
+			require.Equal(tc.client, client)
+			require.NotContains(query, "token")
+
+			result := resp.Result()
+			defer func() {
+				_ = result.Body.Close()
+			}()
+
+			var setCookie string
+			for _, cookie := range result.Cookies() {
+				if cookie.Name == "token" {
+					setCookie = cookie.Value
+				}
+			}
+
+			require.Equal(tc.setCookie, setCookie)
+		})
+	}
+}
+
+func TestAuthorizeGRPCRequest(t *testing.T) {
+	tt := []struct {
+		name     string
+		token    string
+		expected codes.Code
+	}{
+		{
+			name:     "Accepts token granting scope",
+			token:    "agent-token",
+			expected: codes.OK,
+		},
+		{
+			name:     "Rejects token without scope",
+			token:    "viewer-token",
+			expected: codes.PermissionDenied,
+		},
+		{
+			name:     "Rejects unknown token",
+			token:    "xxx",
+			expected: codes.Unauthenticated,
+		},
+		{
+			name:     "Rejects request without token",
+			expected: codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(grpcbackend.MetricsServerMock)
+			m.On("Update", mock.Anything, mock.AnythingOfType("*grpcapi.MetricReq")).Return(&grpcapi.MetricReq{}, nil)
+
+			client := createAuthorizedGRPCClient(t, m)
+
+			ctx := context.Background()
+			if len(tc.token) != 0 {
+				token := security.BearerToken(security.Secret(tc.token))
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", token)
+			}
+
+			_, err := client.Update(ctx, &grpcapi.MetricReq{})
+
+			requireEqualCode(t, tc.expected, err)
+		})
+	}
+}
+
+func TestAuthorizeGRPCRequestAppliedToStreamUpdate(t *testing.T) {
+	m := new(grpcbackend.MetricsServerMock)
+	m.On("StreamUpdate", mock.Anything).Return(nil)
+
+	client := createAuthorizedGRPCClient(t, m)
+
+	stream, err := client.StreamUpdate(context.Background())
+	require.NoError(t, err)
+
+	_, err = stream.CloseAndRecv()
+	requireEqualCode(t, codes.Unauthenticated, err)
+}
+
+func TestAuthorizeGRPCRequestNotAppliedToPublicMethods(t *testing.T) {
+	m := new(grpcbackend.MetricsServerMock)
+	m.On("Get", mock.Anything, mock.AnythingOfType("*grpcapi.GetMetricRequest")).
+		Return(&grpcapi.MetricReq{}, nil)
+
+	client := createAuthorizedGRPCClient(t, m)
+
+	_, err := client.Get(context.Background(), &grpcapi.GetMetricRequest{})
+	requireEqualCode(t, codes.OK, err)
+}
+
+func TestAuthorizeGRPCRequestDeniesUnknownMethods(t *testing.T) {
+	m := new(grpcbackend.MetricsServerMock)
+	client := createAuthorizedGRPCClient(t, m)
+	ctx := metadata.AppendToOutgoingContext(
+		context.Background(),
+		"authorization",
+		security.BearerToken("ops-token"),
+	)
+
+	_, err := client.List(ctx, &grpcapi.ListRequest{})
+	requireEqualCode(t, codes.PermissionDenied, err)
+
+	stream, err := client.Watch(ctx, &grpcapi.WatchRequest{})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	requireEqualCode(t, codes.PermissionDenied, err)
+
+	m.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	m.AssertNotCalled(t, "Watch", mock.Anything, mock.Anything)
+}
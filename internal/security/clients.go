@@ -0,0 +1,122 @@
+package security
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+type clientKey struct{}
+
+// ClientOf returns name of the client, which is name of the identity
+// if the request was authorized, otherwise IP address of the client.
+func ClientOf(ctx context.Context, ip string) string {
+	if identity, ok := IdentityFromContext(ctx); ok {
+		return IdentityClient(identity.Name)
+	}
+
+	return "ip:" + ip
+}
+
+// IdentityClient returns name of client authorized with the identity.
+func IdentityClient(name string) string {
+	return "identity:" + name
+}
+
+// WithClient returns copy of the context carrying name of the client.
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext returns name of the client stored in the context.
+// Requests not received through API (e.g. rules evaluation or StatsD listener)
+// don't have client.
+func ClientFromContext(ctx context.Context) (string, bool) {
+	client, ok := ctx.Value(clientKey{}).(string)
+
+	return client, ok
+}
+
+// clientIP returns IP address of the client sent request from the remote address.
+// Value of X-Real-IP header is used only if the request came from trusted proxy,
+// otherwise any client could impersonate other clients.
+func clientIP(remoteAddr, realIP string, trustedProxies *net.IPNet) string {
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		ip = remoteAddr
+	}
+
+	if len(realIP) != 0 && trustedProxies != nil && trustedProxies.Contains(net.ParseIP(ip)) {
+		return realIP
+	}
+
+	return ip
+}
+
+// IdentifyClient is a HTTP middleware that passes name of the client to handlers.
+// X-Real-IP header is trusted only in requests sent by trustedProxies.
+// It must be applied after authorization of requests.
+func IdentifyClient(trustedProxies *net.IPNet) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r.RemoteAddr, r.Header.Get("X-Real-IP"), trustedProxies)
+
+			next.ServeHTTP(w, r.WithContext(WithClient(r.Context(), ClientOf(r.Context(), ip))))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// identifyIncomingClient returns context carrying name of the client of incoming gRPC request.
+func identifyIncomingClient(ctx context.Context, trustedProxies *net.IPNet) context.Context {
+	var remoteAddr, realIP string
+
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		values := md.Get("x-real-ip")
+		if len(values) > 0 {
+			realIP = values[0]
+		}
+	}
+
+	return WithClient(ctx, ClientOf(ctx, clientIP(remoteAddr, realIP, trustedProxies)))
+}
+
+// UnaryClientIdentifier is grpc unary interceptor that passes name of the client to handlers.
+// x-real-ip metadata is trusted only in requests sent by trustedProxies.
+// It must be applied after authorization of requests.
+func UnaryClientIdentifier(trustedProxies *net.IPNet) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		return handler(identifyIncomingClient(ctx, trustedProxies), req)
+	}
+}
+
+// StreamClientIdentifier is grpc stream interceptor that passes name of the client to handlers.
+// x-real-ip metadata is trusted only in requests sent by trustedProxies.
+// It must be applied after authorization of requests.
+func StreamClientIdentifier(trustedProxies *net.IPNet) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		_ *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := identifyIncomingClient(stream.Context(), trustedProxies)
+
+		return handler(srv, &wrappedServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
@@ -0,0 +1,86 @@
+package security_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentifyClient(t *testing.T) {
+	tt := []struct {
+		name           string
+		identity       string
+		clientIP       string
+		trustedProxies string
+		expected       string
+	}{
+		{
+			name:     "Should identify authorized client by identity",
+			identity: "agent",
+			clientIP: "10.0.0.1",
+			expected: "identity:agent",
+		},
+		{
+			name:           "Should identify anonymous client by X-Real-IP header set by trusted proxy",
+			clientIP:       "10.0.0.1",
+			trustedProxies: "192.0.2.0/24",
+			expected:       "ip:10.0.0.1",
+		},
+		{
+			name:     "Should ignore X-Real-IP header if trusted proxies not set",
+			clientIP: "10.0.0.1",
+			expected: "ip:192.0.2.1",
+		},
+		{
+			name:           "Should ignore X-Real-IP header set by untrusted client",
+			clientIP:       "10.0.0.1",
+			trustedProxies: "10.0.0.0/8",
+			expected:       "ip:192.0.2.1",
+		},
+		{
+			name:     "Should identify anonymous client by remote address",
+			expected: "ip:192.0.2.1",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			var trustedProxies *net.IPNet
+			if len(tc.trustedProxies) != 0 {
+				var err error
+
+				_, trustedProxies, err = net.ParseCIDR(tc.trustedProxies)
+				require.NoError(err)
+			}
+
+			var client string
+
+			handler := security.IdentifyClient(trustedProxies)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					client, _ = security.ClientFromContext(r.Context())
+				}),
+			)
+
+			ctx := context.Background()
+			if len(tc.identity) != 0 {
+				ctx = security.WithIdentity(ctx, security.Identity{Name: tc.identity})
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx)
+			if len(tc.clientIP) != 0 {
+				req.Header.Set("X-Real-IP", tc.clientIP)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Equal(tc.expected, client)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package security
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+)
+
+// Size of identifier of a key in encrypted snapshots.
+const _keyIDSize = 4
+
+// Prefix of encrypted snapshots, allows to distinguish them from plain JSON ones.
+var _sealedMagic = []byte("MCSEALv1")
+
+// StoreKey is AES key used to encrypt data at rest.
+type StoreKey struct {
+	// Short fingerprint of the key, stored in encrypted snapshots.
+	id []byte
+
+	aead cipher.AEAD
+}
+
+// NewStoreKey reads AES key (16, 24 or 32 bytes long) from file.
+func NewStoreKey(path entity.FilePath) (*StoreKey, error) {
+	block, err := newRawKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	aesCipher, err := aes.NewCipher(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("security - NewStoreKey - aes.NewCipher: %w", entity.ErrNotSupportedKey)
+	}
+
+	aead, err := cipher.NewGCM(aesCipher)
+	if err != nil {
+		return nil, fmt.Errorf("security - NewStoreKey - cipher.NewGCM: %w", err)
+	}
+
+	hash := sha256.Sum256(block.Bytes)
+
+	return &StoreKey{id: hash[:_keyIDSize], aead: aead}, nil
+}
+
+// A Keyring encrypts data with the active key and decrypts data
+// encrypted with the active key or any of the old keys.
+// Old keys allow to restore snapshots created before key rotation.
+type Keyring struct {
+	active *StoreKey
+
+	keys map[string]*StoreKey
+}
+
+// NewKeyring reads active and old keys from files.
+func NewKeyring(activeKeyPath entity.FilePath, oldKeyPaths ...entity.FilePath) (*Keyring, error) {
+	active, err := NewStoreKey(activeKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Keyring{
+		active: active,
+		keys:   map[string]*StoreKey{string(active.id): active},
+	}
+
+	for _, path := range oldKeyPaths {
+		key, err := NewStoreKey(path)
+		if err != nil {
+			return nil, err
+		}
+
+		k.keys[string(key.id)] = key
+	}
+
+	return k, nil
+}
+
+// Seal encrypts and authenticates the data with the active key.
+// Resulting message has the following layout: magic | key ID | nonce | ciphertext.
+func (k *Keyring) Seal(data []byte) ([]byte, error) {
+	header := make([]byte, 0, len(_sealedMagic)+_keyIDSize)
+	header = append(header, _sealedMagic...)
+	header = append(header, k.active.id...)
+
+	nonce := make([]byte, k.active.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("security - Seal - io.ReadFull: %w", err)
+	}
+
+	msg := make([]byte, 0, len(header)+len(nonce)+len(data)+k.active.aead.Overhead())
+	msg = append(msg, header...)
+	msg = append(msg, nonce...)
+
+	return k.active.aead.Seal(msg, nonce, data, header), nil
+}
+
+// Open verifies and decrypts the data encrypted by Seal.
+// Data without encryption header is returned as is, which allows to restore
+// snapshots created before the encryption was enabled.
+func (k *Keyring) Open(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, _sealedMagic) {
+		return data, nil
+	}
+
+	headerSize := len(_sealedMagic) + _keyIDSize
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("security - Open - len: %w", entity.ErrMalformedSnapshot)
+	}
+
+	key, ok := k.keys[string(data[len(_sealedMagic):headerSize])]
+	if !ok {
+		return nil, fmt.Errorf("security - Open - k.keys: %w", entity.ErrUnknownStoreKey)
+	}
+
+	nonceSize := key.aead.NonceSize()
+	if len(data) < headerSize+nonceSize {
+		return nil, fmt.Errorf("security - Open - len: %w", entity.ErrMalformedSnapshot)
+	}
+
+	nonce := data[headerSize : headerSize+nonceSize]
+
+	msg, err := key.aead.Open(nil, nonce, data[headerSize+nonceSize:], data[:headerSize])
+	if err != nil {
+		return nil, fmt.Errorf("security - Open - aead.Open: %w", err)
+	}
+
+	return msg, nil
+}
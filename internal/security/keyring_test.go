@@ -0,0 +1,136 @@
+package security_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/security/securitytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStoreKey(t *testing.T) {
+	tt := []struct {
+		name    string
+		size    int
+		isValid bool
+	}{
+		{name: "Should accept AES-128 key", size: 16, isValid: true},
+		{name: "Should accept AES-192 key", size: 24, isValid: true},
+		{name: "Should accept AES-256 key", size: 32, isValid: true},
+		{name: "Should reject key of unsupported size", size: 7, isValid: false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := security.NewStoreKey(securitytest.StoreKey(t, tc.size))
+
+			if tc.isValid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, entity.ErrNotSupportedKey)
+			}
+		})
+	}
+}
+
+func TestNewStoreKeyFailsOnBadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.pem")
+	err := os.WriteFile(path, []byte("xxx"), 0600)
+	require.NoError(t, err)
+
+	_, err = security.NewStoreKey(entity.FilePath(path))
+	require.ErrorIs(t, err, entity.ErrBadKeyFile)
+}
+
+func TestKeyringSealOpen(t *testing.T) {
+	require := require.New(t)
+	msg := []byte(`{"records":{}}`)
+
+	keyring, err := security.NewKeyring(securitytest.StoreKey(t, 32))
+	require.NoError(err)
+
+	sealed, err := keyring.Seal(msg)
+	require.NoError(err)
+	require.NotContains(string(sealed), string(msg))
+
+	opened, err := keyring.Open(sealed)
+	require.NoError(err)
+	require.Equal(msg, opened)
+}
+
+func TestKeyringOpensDataSealedWithOldKey(t *testing.T) {
+	require := require.New(t)
+	msg := []byte(`{"records":{}}`)
+	oldKey := securitytest.StoreKey(t, 32)
+
+	oldKeyring, err := security.NewKeyring(oldKey)
+	require.NoError(err)
+
+	sealed, err := oldKeyring.Seal(msg)
+	require.NoError(err)
+
+	keyring, err := security.NewKeyring(securitytest.StoreKey(t, 32), oldKey)
+	require.NoError(err)
+
+	opened, err := keyring.Open(sealed)
+	require.NoError(err)
+	require.Equal(msg, opened)
+}
+
+func TestKeyringOpenPassesPlainData(t *testing.T) {
+	msg := []byte(`{"records":{}}`)
+
+	keyring, err := security.NewKeyring(securitytest.StoreKey(t, 32))
+	require.NoError(t, err)
+
+	opened, err := keyring.Open(msg)
+	require.NoError(t, err)
+	require.Equal(t, msg, opened)
+}
+
+func TestKeyringOpenFailsOnUnknownKey(t *testing.T) {
+	require := require.New(t)
+
+	oldKeyring, err := security.NewKeyring(securitytest.StoreKey(t, 32))
+	require.NoError(err)
+
+	sealed, err := oldKeyring.Seal([]byte(`{"records":{}}`))
+	require.NoError(err)
+
+	keyring, err := security.NewKeyring(securitytest.StoreKey(t, 32))
+	require.NoError(err)
+
+	_, err = keyring.Open(sealed)
+	require.ErrorIs(err, entity.ErrUnknownStoreKey)
+}
+
+func TestKeyringOpenFailsOnTamperedData(t *testing.T) {
+	require := require.New(t)
+
+	keyring, err := security.NewKeyring(securitytest.StoreKey(t, 32))
+	require.NoError(err)
+
+	sealed, err := keyring.Seal([]byte(`{"records":{}}`))
+	require.NoError(err)
+
+	sealed[len(sealed)-1] ^= 0xff
+
+	_, err = keyring.Open(sealed)
+	require.Error(err)
+}
+
+func TestKeyringOpenFailsOnTruncatedData(t *testing.T) {
+	require := require.New(t)
+
+	keyring, err := security.NewKeyring(securitytest.StoreKey(t, 32))
+	require.NoError(err)
+
+	sealed, err := keyring.Seal([]byte(`{"records":{}}`))
+	require.NoError(err)
+
+	_, err = keyring.Open(sealed[:10])
+	require.ErrorIs(err, entity.ErrMalformedSnapshot)
+}
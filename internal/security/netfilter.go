@@ -4,7 +4,6 @@ import (
 	"context"
 	"net"
 	"net/http"
-	"strings"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/rs/zerolog/log"
@@ -36,10 +35,38 @@ func FilterRequest(trustedSubnet *net.IPNet) func(next http.Handler) http.Handle
 	}
 }
 
+// filterIncomingRequest rejects incoming gRPC requests which don't match trusted subnet.
+func filterIncomingRequest(ctx context.Context, trustedSubnet *net.IPNet) error {
+	var clientIP net.IP
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		values := md.Get("x-real-ip")
+		if len(values) > 0 {
+			clientIP = net.ParseIP(values[0])
+		}
+	}
+
+	if !trustedSubnet.Contains(clientIP) {
+		return entity.UntrustedSourceError(clientIP)
+	}
+
+	return nil
+}
+
+// filteredMethod checks whether the method modifies data and thus
+// should be available from trusted subnet only.
+func filteredMethod(scopes map[string]Scope, method string) bool {
+	scope := scopes[method]
+
+	return scope == ScopeWrite || scope == ScopeAdmin
+}
+
 // UnaryRequestsFilter is grpc unary interceptor that rejects requests which
-// don't match trusted subnet.
+// don't match trusted subnet. Only methods requiring write or admin scope are filtered.
 func UnaryRequestsFilter(
 	trustedSubnet *net.IPNet,
+	scopes map[string]Scope,
 ) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
@@ -47,23 +74,11 @@ func UnaryRequestsFilter(
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (any, error) {
-		if !strings.HasSuffix(info.FullMethod, "Update") {
+		if !filteredMethod(scopes, info.FullMethod) {
 			return handler(ctx, req)
 		}
 
-		var clientIP net.IP
-
-		md, ok := metadata.FromIncomingContext(ctx)
-		if ok {
-			values := md.Get("x-real-ip")
-			if len(values) > 0 {
-				clientIP = net.ParseIP(values[0])
-			}
-		}
-
-		if !trustedSubnet.Contains(clientIP) {
-			err := entity.UntrustedSourceError(clientIP)
-
+		if err := filterIncomingRequest(ctx, trustedSubnet); err != nil {
 			logger := log.Ctx(ctx)
 			logger.Error().Err(err).Msg("security - UnaryRequestsFilter - trustedSubnet.Contains")
 
@@ -73,3 +88,30 @@ func UnaryRequestsFilter(
 		return handler(ctx, req)
 	}
 }
+
+// StreamRequestsFilter is grpc stream interceptor that rejects requests which
+// don't match trusted subnet. Only methods requiring write or admin scope are filtered.
+func StreamRequestsFilter(
+	trustedSubnet *net.IPNet,
+	scopes map[string]Scope,
+) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if !filteredMethod(scopes, info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		if err := filterIncomingRequest(stream.Context(), trustedSubnet); err != nil {
+			logger := log.Ctx(stream.Context())
+			logger.Error().Err(err).Msg("security - StreamRequestsFilter - trustedSubnet.Contains")
+
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		return handler(srv, stream)
+	}
+}
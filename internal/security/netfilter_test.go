@@ -72,9 +72,18 @@ func sendGRPCRequest(
 	_, subnet, err := net.ParseCIDR(trustedSubnet)
 	require.NoError(err)
 
+	scopes := map[string]security.Scope{
+		"/metrics.collector.v1.Metrics/Update":       security.ScopeWrite,
+		"/metrics.collector.v1.Metrics/BatchUpdate":  security.ScopeWrite,
+		"/metrics.collector.v1.Metrics/StreamUpdate": security.ScopeWrite,
+		"/metrics.collector.v1.Metrics/Delete":       security.ScopeWrite,
+		"/metrics.collector.v1.Metrics/Get":          security.ScopeRead,
+	}
+
 	lis := bufconn.Listen(1024 * 1024)
 	srv := grpc.NewServer(
-		grpc.UnaryInterceptor(security.UnaryRequestsFilter(subnet)),
+		grpc.UnaryInterceptor(security.UnaryRequestsFilter(subnet, scopes)),
+		grpc.StreamInterceptor(security.StreamRequestsFilter(subnet, scopes)),
 	)
 
 	grpcapi.RegisterMetricsServer(srv, mockAPI)
@@ -214,6 +223,20 @@ func TestFilterGRPCRequestAppliedToBatchUpdate(t *testing.T) {
 	requireEqualCode(t, codes.PermissionDenied, err)
 }
 
+func TestFilterGRPCRequestAppliedToStreamUpdate(t *testing.T) {
+	m := new(grpcbackend.MetricsServerMock)
+	m.On("StreamUpdate", mock.Anything).Return(nil)
+
+	client, closer := sendGRPCRequest(t, m, "192.168.0.0/32")
+	t.Cleanup(closer)
+
+	stream, err := client.StreamUpdate(context.Background())
+	require.NoError(t, err)
+
+	_, err = stream.CloseAndRecv()
+	requireEqualCode(t, codes.PermissionDenied, err)
+}
+
 func TestFilterGRPCRequestNotAppliedToGet(t *testing.T) {
 	m := new(grpcbackend.MetricsServerMock)
 	m.On("Get", mock.Anything, mock.AnythingOfType("*grpcapi.GetMetricRequest")).
@@ -225,3 +248,15 @@ func TestFilterGRPCRequestNotAppliedToGet(t *testing.T) {
 	_, err := client.Get(context.Background(), &grpcapi.GetMetricRequest{})
 	requireEqualCode(t, codes.OK, err)
 }
+
+func TestFilterGRPCRequestAppliedToDelete(t *testing.T) {
+	m := new(grpcbackend.MetricsServerMock)
+	m.On("Delete", mock.Anything, mock.AnythingOfType("*grpcapi.GetMetricRequest")).
+		Return(&grpcapi.DeleteMetricResponse{}, nil)
+
+	client, closer := sendGRPCRequest(t, m, "192.168.0.0/32")
+	t.Cleanup(closer)
+
+	_, err := client.Delete(context.Background(), &grpcapi.GetMetricRequest{})
+	requireEqualCode(t, codes.PermissionDenied, err)
+}
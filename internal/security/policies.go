@@ -0,0 +1,123 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+)
+
+// A Verb is an action performed over metrics.
+type Verb string
+
+const (
+	VerbRead   Verb = "read"
+	VerbWrite  Verb = "write"
+	VerbDelete Verb = "delete"
+)
+
+// Identity of policies applied to all authenticated clients.
+const _anyIdentity = "*"
+
+func (v Verb) valid() bool {
+	switch v {
+	case VerbRead, VerbWrite, VerbDelete:
+		return true
+	}
+
+	return false
+}
+
+// A Policy allows the identity to perform the verbs over metrics which names
+// start with one of the prefixes. Empty prefix matches all metrics.
+type Policy struct {
+	Identity string   `json:"identity"`
+	Verbs    []Verb   `json:"verbs"`
+	Prefixes []string `json:"prefixes"`
+}
+
+func (p Policy) allows(verb Verb, name string) bool {
+	granted := false
+
+	for _, v := range p.Verbs {
+		if v == verb {
+			granted = true
+			break
+		}
+	}
+
+	if !granted {
+		return false
+	}
+
+	for _, prefix := range p.Prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Policies control access of identities to metrics namespaces.
+// Everything not allowed by a policy explicitly is denied.
+type Policies struct {
+	byIdentity map[string][]Policy
+}
+
+// NewPolicies reads access policies from file in JSON format, e.g.:
+// [{"identity": "team-a", "verbs": ["read", "write"], "prefixes": ["TeamA"]}].
+func NewPolicies(path entity.FilePath) (*Policies, error) {
+	data, err := os.ReadFile(path.String())
+	if err != nil {
+		return nil, fmt.Errorf("security - NewPolicies - os.ReadFile: %w", err)
+	}
+
+	policies := make([]Policy, 0)
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("security - NewPolicies - json.Unmarshal: %w", err)
+	}
+
+	p := &Policies{byIdentity: make(map[string][]Policy)}
+
+	for _, policy := range policies {
+		if len(policy.Identity) == 0 || len(policy.Verbs) == 0 || len(policy.Prefixes) == 0 {
+			return nil, fmt.Errorf("%w: policy must have identity, verbs and prefixes", entity.ErrInvalidPoliciesFile)
+		}
+
+		for _, verb := range policy.Verbs {
+			if !verb.valid() {
+				return nil, fmt.Errorf(
+					"%w: unknown verb %s of %s",
+					entity.ErrInvalidPoliciesFile,
+					verb,
+					policy.Identity,
+				)
+			}
+		}
+
+		p.byIdentity[policy.Identity] = append(p.byIdentity[policy.Identity], policy)
+	}
+
+	return p, nil
+}
+
+// Allowed reports whether the identity may perform the verb over the metric.
+// Identities granted the admin scope are not restricted.
+func (p *Policies) Allowed(identity Identity, verb Verb, name string) bool {
+	if identity.Allows(ScopeAdmin) {
+		return true
+	}
+
+	for _, key := range []string{identity.Name, _anyIdentity} {
+		for _, policy := range p.byIdentity[key] {
+			if policy.allows(verb, name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,131 @@
+package security_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/stretchr/testify/require"
+)
+
+const _testPolicies = `[
+{"identity": "team-a", "verbs": ["read", "write"], "prefixes": ["TeamA", "Shared"]},
+{"identity": "team-b", "verbs": ["read", "write", "delete"], "prefixes": ["TeamB"]},
+{"identity": "*", "verbs": ["read"], "prefixes": ["Public"]}
+]`
+
+func createPoliciesFile(t *testing.T, data string) entity.FilePath {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policies.json")
+	err := os.WriteFile(path, []byte(data), 0600)
+	require.NoError(t, err)
+
+	return entity.FilePath(path)
+}
+
+func TestNewPoliciesFailsOnInvalidFile(t *testing.T) {
+	tt := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "Should reject policy without identity",
+			data: `[{"verbs": ["read"], "prefixes": [""]}]`,
+		},
+		{
+			name: "Should reject policy without verbs",
+			data: `[{"identity": "team-a", "prefixes": [""]}]`,
+		},
+		{
+			name: "Should reject policy without prefixes",
+			data: `[{"identity": "team-a", "verbs": ["read"]}]`,
+		},
+		{
+			name: "Should reject unknown verb",
+			data: `[{"identity": "team-a", "verbs": ["admin"], "prefixes": [""]}]`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := security.NewPolicies(createPoliciesFile(t, tc.data))
+
+			require.ErrorIs(t, err, entity.ErrInvalidPoliciesFile)
+		})
+	}
+}
+
+func TestNewPoliciesFailsOnMalformedJSON(t *testing.T) {
+	_, err := security.NewPolicies(createPoliciesFile(t, "xxx"))
+
+	require.Error(t, err)
+}
+
+func TestPoliciesAllowed(t *testing.T) {
+	tt := []struct {
+		name     string
+		identity security.Identity
+		verb     security.Verb
+		metric   string
+		expected bool
+	}{
+		{
+			name:     "Should allow verb over metric with granted prefix",
+			identity: security.Identity{Name: "team-a", Scopes: []security.Scope{security.ScopeWrite}},
+			verb:     security.VerbWrite,
+			metric:   "TeamAAlloc",
+			expected: true,
+		},
+		{
+			name:     "Should allow verb over metric with any of granted prefixes",
+			identity: security.Identity{Name: "team-a", Scopes: []security.Scope{security.ScopeRead}},
+			verb:     security.VerbRead,
+			metric:   "SharedCount",
+			expected: true,
+		},
+		{
+			name:     "Should deny verb not granted",
+			identity: security.Identity{Name: "team-a", Scopes: []security.Scope{security.ScopeWrite}},
+			verb:     security.VerbDelete,
+			metric:   "TeamAAlloc",
+		},
+		{
+			name:     "Should deny metric of other namespace",
+			identity: security.Identity{Name: "team-a", Scopes: []security.Scope{security.ScopeRead}},
+			verb:     security.VerbRead,
+			metric:   "TeamBAlloc",
+		},
+		{
+			name:     "Should apply policies of any identity",
+			identity: security.Identity{Name: "team-b", Scopes: []security.Scope{security.ScopeRead}},
+			verb:     security.VerbRead,
+			metric:   "PublicUptime",
+			expected: true,
+		},
+		{
+			name:     "Should deny identity without policies",
+			identity: security.Identity{Name: "viewer", Scopes: []security.Scope{security.ScopeRead}},
+			verb:     security.VerbRead,
+			metric:   "TeamAAlloc",
+		},
+		{
+			name:     "Should not restrict admin",
+			identity: security.Identity{Name: "ops", Scopes: []security.Scope{security.ScopeAdmin}},
+			verb:     security.VerbDelete,
+			metric:   "TeamAAlloc",
+			expected: true,
+		},
+	}
+
+	policies, err := security.NewPolicies(createPoliciesFile(t, _testPolicies))
+	require.NoError(t, err)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, policies.Allowed(tc.identity, tc.verb, tc.metric))
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package security
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RetryAfterKey is gRPC metadata key carrying count of seconds after which
+// rejected request could be repeated.
+const RetryAfterKey = "retry-after"
+
+// Buckets of clients idle longer than the interval are checked and removed if full.
+const _bucketsCleanupInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter limits rate of requests of each client using token bucket algorithm,
+// see ClientOf.
+type RateLimiter struct {
+	sync.Mutex
+
+	// Count of tokens added to bucket of a client every second.
+	rate float64
+
+	// Maximal count of tokens in bucket, i.e. maximal burst of requests.
+	burst float64
+
+	// Clients which requests are not limited, e.g. other nodes of the cluster.
+	exempt map[string]struct{}
+
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+// NewRateLimiter creates new instance of RateLimiter allowing each client to send
+// rate requests per second on average with bursts up to burst requests.
+// Requests of the exempt identities are not limited.
+func NewRateLimiter(rate float64, burst int, exempt ...string) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := &RateLimiter{
+		rate:        rate,
+		burst:       float64(burst),
+		exempt:      make(map[string]struct{}, len(exempt)),
+		buckets:     make(map[string]*bucket),
+		lastCleanup: time.Now(),
+	}
+
+	for _, name := range exempt {
+		l.exempt[IdentityClient(name)] = struct{}{}
+	}
+
+	return l
+}
+
+// Allow takes token from bucket of the client.
+// If the bucket is empty, returns time after which new token becomes available.
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	l.cleanup(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// cleanup removes buckets of clients which have been idle long enough to refill them.
+func (l *RateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < _bucketsCleanupInterval {
+		return
+	}
+
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+
+	l.lastCleanup = now
+}
+
+// limit checks rate of requests of the client making the request.
+// If the client exceeds allowed rate, returns time after which the request could be repeated.
+func (l *RateLimiter) limit(ctx context.Context) (time.Duration, error) {
+	client, _ := ClientFromContext(ctx)
+
+	if _, exempt := l.exempt[client]; exempt {
+		return 0, nil
+	}
+
+	if ok, retryAfter := l.Allow(client); !ok {
+		return retryAfter, entity.RateLimitedError(client)
+	}
+
+	return 0, nil
+}
+
+// retryAfterSeconds formats delay as count of whole seconds rounded up.
+func retryAfterSeconds(delay time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(delay.Seconds()))))
+}
+
+// LimitRequests is a HTTP middleware that rejects requests of clients
+// exceeding allowed rate. It must be applied after identification of clients.
+func LimitRequests(limiter *RateLimiter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			retryAfter, err := limiter.limit(r.Context())
+			if err != nil {
+				logger := log.Ctx(r.Context())
+				logger.Error().Err(err).Msg("security - LimitRequests - limiter.limit")
+
+				w.Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// limitIncomingRequest checks rate of incoming gRPC requests of the client
+// and sets retry-after header if the client exceeds allowed rate.
+func limitIncomingRequest(ctx context.Context, limiter *RateLimiter, setHeader func(metadata.MD) error) error {
+	retryAfter, err := limiter.limit(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("security - limitIncomingRequest - limiter.limit")
+
+		_ = setHeader(metadata.Pairs(RetryAfterKey, retryAfterSeconds(retryAfter)))
+
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return nil
+}
+
+// UnaryRequestsLimiter is grpc unary interceptor that rejects requests of clients
+// exceeding allowed rate with the ResourceExhausted code and sets retry-after header.
+// Public methods and methods missing in the scopes map are not limited.
+// It must be applied after identification of clients.
+func UnaryRequestsLimiter(
+	limiter *RateLimiter,
+	scopes map[string]Scope,
+) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if scope, ok := scopes[info.FullMethod]; !ok || scope == ScopePublic {
+			return handler(ctx, req)
+		}
+
+		setHeader := func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		}
+
+		if err := limitIncomingRequest(ctx, limiter, setHeader); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// limitedServerStream checks rate of messages received over the stream,
+// so long-lived streams are limited in the same way as unary requests.
+type limitedServerStream struct {
+	grpc.ServerStream
+
+	limiter *RateLimiter
+}
+
+// RecvMsg receives single message and rejects it, if the client exceeds allowed rate.
+func (s *limitedServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return limitIncomingRequest(s.Context(), s.limiter, s.SetHeader)
+}
+
+// StreamRequestsLimiter is grpc stream interceptor that rejects messages of clients
+// exceeding allowed rate with the ResourceExhausted code and sets retry-after header.
+// Each message received over the stream counts as single request.
+// Public methods and methods missing in the scopes map are not limited.
+// It must be applied after identification of clients.
+func StreamRequestsLimiter(
+	limiter *RateLimiter,
+	scopes map[string]Scope,
+) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if scope, ok := scopes[info.FullMethod]; !ok || scope == ScopePublic {
+			return handler(srv, stream)
+		}
+
+		return handler(srv, &limitedServerStream{ServerStream: stream, limiter: limiter})
+	}
+}
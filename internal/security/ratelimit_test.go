@@ -0,0 +1,245 @@
+package security_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/grpcbackend"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/pkg/grpcapi"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Tokens are refilled very slowly, so tests don't depend on timings.
+const _testRate = 0.001
+
+func newLimitedHandler(limiter *security.RateLimiter) http.Handler {
+	handler := security.LimitRequests(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Requests created by httptest come from 192.0.2.1,
+	// trust it as proxy to identify clients by X-Real-IP header.
+	_, proxies, _ := net.ParseCIDR("192.0.2.0/24")
+
+	return security.IdentifyClient(proxies)(handler)
+}
+
+func TestRateLimiterAllowsBurst(t *testing.T) {
+	require := require.New(t)
+	limiter := security.NewRateLimiter(_testRate, 2)
+
+	for i := 0; i < 2; i++ {
+		ok, _ := limiter.Allow("agent")
+		require.True(ok)
+	}
+
+	ok, retryAfter := limiter.Allow("agent")
+	require.False(ok)
+	require.Greater(retryAfter.Seconds(), 900.0)
+
+	ok, _ = limiter.Allow("another-agent")
+	require.True(ok)
+}
+
+func TestLimitHTTPRequests(t *testing.T) {
+	tt := []struct {
+		name     string
+		identity string
+		clientIP string
+		expected int
+	}{
+		{
+			name:     "Should limit client by identity",
+			identity: "agent",
+			clientIP: "10.0.0.1",
+			expected: http.StatusTooManyRequests,
+		},
+		{
+			name:     "Should limit anonymous client by IP address",
+			clientIP: "10.0.0.1",
+			expected: http.StatusTooManyRequests,
+		},
+		{
+			name:     "Should not limit exempt identity",
+			identity: "cluster",
+			clientIP: "10.0.0.1",
+			expected: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			limiter := security.NewRateLimiter(_testRate, 1, "cluster")
+			handler := newLimitedHandler(limiter)
+
+			ctx := context.Background()
+			if len(tc.identity) != 0 {
+				ctx = security.WithIdentity(ctx, security.Identity{Name: tc.identity})
+			}
+
+			var w *httptest.ResponseRecorder
+
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx)
+				req.Header.Set("X-Real-IP", tc.clientIP)
+
+				w = httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+			}
+
+			require.Equal(tc.expected, w.Code)
+
+			if tc.expected == http.StatusTooManyRequests {
+				require.Equal("1000", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestLimitHTTPRequestsOfDifferentIPs(t *testing.T) {
+	require := require.New(t)
+
+	limiter := security.NewRateLimiter(_testRate, 1)
+	handler := newLimitedHandler(limiter)
+
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("X-Real-IP", ip)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(http.StatusOK, w.Code)
+	}
+}
+
+func createLimitedGRPCClient(t *testing.T, api grpcapi.MetricsServer, burst int) grpcapi.MetricsClient {
+	t.Helper()
+	require := require.New(t)
+
+	scopes := map[string]security.Scope{
+		"/metrics.collector.v1.Metrics/Update":       security.ScopeWrite,
+		"/metrics.collector.v1.Metrics/StreamUpdate": security.ScopeWrite,
+	}
+	limiter := security.NewRateLimiter(_testRate, burst)
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(security.UnaryClientIdentifier(nil), security.UnaryRequestsLimiter(limiter, scopes)),
+		grpc.ChainStreamInterceptor(
+			security.StreamClientIdentifier(nil),
+			security.StreamRequestsLimiter(limiter, scopes),
+		),
+	)
+	grpcapi.RegisterMetricsServer(srv, api)
+
+	go func() {
+		require.NoError(srv.Serve(lis))
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+	conn, err := grpc.Dial("", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(err)
+
+	t.Cleanup(func() {
+		require.NoError(conn.Close())
+		srv.Stop()
+		require.NoError(lis.Close())
+	})
+
+	return grpcapi.NewMetricsClient(conn)
+}
+
+func TestLimitGRPCRequests(t *testing.T) {
+	require := require.New(t)
+
+	m := new(grpcbackend.MetricsServerMock)
+	m.On("Update", mock.Anything, mock.AnythingOfType("*grpcapi.MetricReq")).Return(&grpcapi.MetricReq{}, nil)
+
+	client := createLimitedGRPCClient(t, m, 1)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-real-ip", "10.0.0.1")
+
+	_, err := client.Update(ctx, &grpcapi.MetricReq{})
+	require.NoError(err)
+
+	var header metadata.MD
+
+	_, err = client.Update(ctx, &grpcapi.MetricReq{}, grpc.Header(&header))
+	requireEqualCode(t, codes.ResourceExhausted, err)
+	require.Equal([]string{"1000"}, header.Get(security.RetryAfterKey))
+}
+
+func TestLimitGRPCRequestsNotAppliedToPublicMethods(t *testing.T) {
+	m := new(grpcbackend.MetricsServerMock)
+	m.On("Get", mock.Anything, mock.AnythingOfType("*grpcapi.GetMetricRequest")).
+		Return(&grpcapi.MetricReq{}, nil)
+
+	client := createLimitedGRPCClient(t, m, 1)
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(context.Background(), &grpcapi.GetMetricRequest{})
+		requireEqualCode(t, codes.OK, err)
+	}
+}
+
+// streamingServer receives all metrics sent over StreamUpdate stream.
+type streamingServer struct {
+	grpcapi.UnimplementedMetricsServer
+}
+
+func (s streamingServer) StreamUpdate(stream grpcapi.Metrics_StreamUpdateServer) error {
+	summary := &grpcapi.StreamSummary{}
+
+	for {
+		_, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(summary)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		summary.Recorded++
+	}
+}
+
+func TestLimitGRPCStreamMessages(t *testing.T) {
+	require := require.New(t)
+
+	const burst = 3
+
+	client := createLimitedGRPCClient(t, streamingServer{}, burst)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-real-ip", "10.0.0.1")
+
+	stream, err := client.StreamUpdate(ctx)
+	require.NoError(err)
+
+	for i := 0; i <= burst; i++ {
+		// Send returns io.EOF once the server aborted the stream, the status is returned by CloseAndRecv.
+		if err := stream.Send(grpcapi.NewUpdateCounterReq("PollCount", 1)); err != nil {
+			require.ErrorIs(err, io.EOF)
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+	requireEqualCode(t, codes.ResourceExhausted, err)
+
+	header, err := stream.Header()
+	require.NoError(err)
+	require.Equal([]string{"1000"}, header.Get(security.RetryAfterKey))
+}
@@ -0,0 +1,29 @@
+// Package securitytest provides fixtures for tests of code using encryption keys.
+package securitytest
+
+import (
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+// StoreKey writes random AES key of the size (in bytes) in PEM format
+// to temporary file and returns path to the file.
+func StoreKey(t *testing.T, size int) entity.FilePath {
+	t.Helper()
+
+	key := make([]byte, size)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "store.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "AES KEY", Bytes: key}), 0600)
+	require.NoError(t, err)
+
+	return entity.FilePath(path)
+}
@@ -0,0 +1,138 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TenantHeader is HTTP header (or gRPC metadata key) selecting tenant of the request.
+const TenantHeader = "X-Tenant"
+
+// Tenant names are used in names of dump files,
+// thus only safe characters are allowed.
+var _tenantName = regexp.MustCompile(`^[A-Za-z\d_-]{1,64}$`)
+
+// ValidateTenant verifies that provided tenant name is acceptable.
+func ValidateTenant(name string) error {
+	if !_tenantName.MatchString(name) {
+		return entity.ErrInvalidTenant
+	}
+
+	return nil
+}
+
+// selectTenant returns tenant of the request.
+// Clients bound to a tenant always work with it, other clients may request any tenant.
+func selectTenant(ctx context.Context, requested string) (string, error) {
+	identity, ok := IdentityFromContext(ctx)
+	if ok && len(identity.Tenant) != 0 {
+		if len(requested) != 0 && requested != identity.Tenant {
+			return "", fmt.Errorf("%w: %s", entity.ErrTenantNotAllowed, requested)
+		}
+
+		return identity.Tenant, nil
+	}
+
+	if len(requested) == 0 {
+		return entity.DefaultTenant, nil
+	}
+
+	if err := ValidateTenant(requested); err != nil {
+		return "", err
+	}
+
+	return requested, nil
+}
+
+// SelectTenant is a HTTP middleware that passes tenant of the request to handlers.
+// It must be applied after authorization of requests.
+func SelectTenant(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		tenant, err := selectTenant(r.Context(), r.Header.Get(TenantHeader))
+		if err != nil {
+			logger := log.Ctx(r.Context())
+			logger.Error().Err(err).Msg("security - SelectTenant - selectTenant")
+
+			if errors.Is(err, entity.ErrTenantNotAllowed) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(entity.WithTenant(r.Context(), tenant)))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// selectIncomingTenant returns context carrying tenant of incoming gRPC request.
+func selectIncomingTenant(ctx context.Context) (context.Context, error) {
+	var requested string
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		values := md.Get(TenantHeader)
+		if len(values) > 0 {
+			requested = values[0]
+		}
+	}
+
+	tenant, err := selectTenant(ctx, requested)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("security - selectIncomingTenant - selectTenant")
+
+		if errors.Is(err, entity.ErrTenantNotAllowed) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return entity.WithTenant(ctx, tenant), nil
+}
+
+// UnaryTenantSelector is grpc unary interceptor that passes tenant of the request to handlers.
+// It must be applied after authorization of requests.
+func UnaryTenantSelector(
+	ctx context.Context,
+	req any,
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, err := selectIncomingTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamTenantSelector is grpc stream interceptor that passes tenant of the request to handlers.
+// It must be applied after authorization of requests.
+func StreamTenantSelector(
+	srv any,
+	stream grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := selectIncomingTenant(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &wrappedServerStream{ServerStream: stream, ctx: ctx})
+}
@@ -0,0 +1,147 @@
+package security_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+var _tenantTests = []struct {
+	name      string
+	tenant    string
+	requested string
+	expected  string
+	code      int
+	grpcCode  codes.Code
+}{
+	{
+		name:     "Should select default tenant if tenant not requested",
+		expected: entity.DefaultTenant,
+		code:     http.StatusOK,
+		grpcCode: codes.OK,
+	},
+	{
+		name:      "Should select requested tenant",
+		requested: "beta",
+		expected:  "beta",
+		code:      http.StatusOK,
+		grpcCode:  codes.OK,
+	},
+	{
+		name:     "Should select tenant of the client",
+		tenant:   "acme",
+		expected: "acme",
+		code:     http.StatusOK,
+		grpcCode: codes.OK,
+	},
+	{
+		name:      "Should accept tenant of the client requested explicitly",
+		tenant:    "acme",
+		requested: "acme",
+		expected:  "acme",
+		code:      http.StatusOK,
+		grpcCode:  codes.OK,
+	},
+	{
+		name:      "Should reject foreign tenant",
+		tenant:    "acme",
+		requested: "beta",
+		code:      http.StatusForbidden,
+		grpcCode:  codes.PermissionDenied,
+	},
+	{
+		name:      "Should reject invalid tenant",
+		requested: "../acme",
+		code:      http.StatusBadRequest,
+		grpcCode:  codes.InvalidArgument,
+	},
+}
+
+func TestValidateTenant(t *testing.T) {
+	tt := []struct {
+		name     string
+		tenant   string
+		expected error
+	}{
+		{
+			name:   "Should accept letters, digits, dashes and underscores",
+			tenant: "Team_A-01",
+		},
+		{
+			name:     "Should reject empty name",
+			expected: entity.ErrInvalidTenant,
+		},
+		{
+			name:     "Should reject path separators",
+			tenant:   "team/a",
+			expected: entity.ErrInvalidTenant,
+		},
+		{
+			name:     "Should reject too long name",
+			tenant:   "a123456789b123456789c123456789d123456789e123456789f123456789g12345",
+			expected: entity.ErrInvalidTenant,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.ErrorIs(t, security.ValidateTenant(tc.tenant), tc.expected)
+		})
+	}
+}
+
+func TestSelectTenantOfHTTPRequest(t *testing.T) {
+	for _, tc := range _tenantTests {
+		t.Run(tc.name, func(t *testing.T) {
+			var selected string
+
+			handler := security.SelectTenant(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				selected = entity.TenantFromContext(r.Context())
+			}))
+
+			ctx := security.WithIdentity(context.Background(), security.Identity{Name: "agent", Tenant: tc.tenant})
+			req := httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx)
+
+			if len(tc.requested) != 0 {
+				req.Header.Set(security.TenantHeader, tc.requested)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tc.code, w.Code)
+			require.Equal(t, tc.expected, selected)
+		})
+	}
+}
+
+func TestSelectTenantOfGRPCRequest(t *testing.T) {
+	for _, tc := range _tenantTests {
+		t.Run(tc.name, func(t *testing.T) {
+			var selected string
+
+			handler := func(ctx context.Context, req any) (any, error) {
+				selected = entity.TenantFromContext(ctx)
+				return nil, nil
+			}
+
+			ctx := security.WithIdentity(context.Background(), security.Identity{Name: "agent", Tenant: tc.tenant})
+			if len(tc.requested) != 0 {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(security.TenantHeader, tc.requested))
+			}
+
+			_, err := security.UnaryTenantSelector(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+
+			requireEqualCode(t, tc.grpcCode, err)
+			require.Equal(t, tc.expected, selected)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package security
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+)
+
+// A Scope grants access to a group of API methods.
+type Scope string
+
+const (
+	// ScopeRead grants access to metrics, queries, alerts and silences.
+	ScopeRead Scope = "read"
+
+	// ScopeWrite grants access to updates of metrics.
+	ScopeWrite Scope = "write"
+
+	// ScopeAdmin grants access to maintenance mode and silences management
+	// as well as all other scopes.
+	ScopeAdmin Scope = "admin"
+
+	// ScopePublic marks API methods available without token, it can't be granted to tokens.
+	ScopePublic Scope = "public"
+)
+
+func (s Scope) valid() bool {
+	switch s {
+	case ScopeRead, ScopeWrite, ScopeAdmin:
+		return true
+	}
+
+	return false
+}
+
+// An Identity describes client authenticated by token.
+type Identity struct {
+	// Human readable name of the client, used in logs.
+	Name string
+
+	Scopes []Scope
+
+	// Tenant the client is bound to, empty if the client may select any tenant.
+	Tenant string
+}
+
+// Identities of built-in sources of metrics, which don't present tokens.
+// Access policies are applied to them as to any other client.
+var (
+	StatsdIdentity   = Identity{Name: "statsd"}
+	GraphiteIdentity = Identity{Name: "graphite"}
+	RulesIdentity    = Identity{Name: "rules"}
+	AlertsIdentity   = Identity{Name: "alerts"}
+)
+
+// builtin reports whether the name belongs to identity of built-in source of metrics.
+func builtin(name string) bool {
+	for _, identity := range []Identity{StatsdIdentity, GraphiteIdentity, RulesIdentity, AlertsIdentity} {
+		if identity.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Allows reports whether the identity was granted the scope.
+func (i Identity) Allows(scope Scope) bool {
+	for _, s := range i.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+type identityKey struct{}
+
+// WithIdentity returns copy of the context carrying the identity.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns identity of authenticated client, if any.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+
+	return identity, ok
+}
+
+// tokenEntry describes single token in the tokens file.
+type tokenEntry struct {
+	Name   string  `json:"name"`
+	Token  string  `json:"token"`
+	Scopes []Scope `json:"scopes"`
+	Tenant string  `json:"tenant"`
+}
+
+// Tokens authenticate clients by bearer tokens.
+type Tokens struct {
+	// Identities indexed by SHA256 hash of the token,
+	// thus raw tokens are not kept in memory.
+	identities map[[sha256.Size]byte]Identity
+}
+
+// NewTokens reads tokens from file in JSON format, e.g.:
+// [{"name": "agent", "token": "secret", "scopes": ["write"], "tenant": "team-a"}].
+// The tenant is optional.
+func NewTokens(path entity.FilePath) (*Tokens, error) {
+	data, err := os.ReadFile(path.String())
+	if err != nil {
+		return nil, fmt.Errorf("security - NewTokens - os.ReadFile: %w", err)
+	}
+
+	entries := make([]tokenEntry, 0)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("security - NewTokens - json.Unmarshal: %w", err)
+	}
+
+	t := &Tokens{identities: make(map[[sha256.Size]byte]Identity, len(entries))}
+
+	for _, entry := range entries {
+		if len(entry.Name) == 0 || len(entry.Token) == 0 || len(entry.Scopes) == 0 {
+			return nil, fmt.Errorf("%w: token must have name, value and scopes", entity.ErrInvalidTokensFile)
+		}
+
+		for _, scope := range entry.Scopes {
+			if !scope.valid() {
+				return nil, fmt.Errorf("%w: unknown scope %s of %s", entity.ErrInvalidTokensFile, scope, entry.Name)
+			}
+		}
+
+		if len(entry.Tenant) != 0 {
+			if err := ValidateTenant(entry.Tenant); err != nil {
+				return nil, fmt.Errorf("%w: %s of %s", entity.ErrInvalidTokensFile, err, entry.Name)
+			}
+		}
+
+		if builtin(entry.Name) {
+			return nil, fmt.Errorf("%w: name %s is reserved", entity.ErrInvalidTokensFile, entry.Name)
+		}
+
+		hash := sha256.Sum256([]byte(entry.Token))
+		if _, ok := t.identities[hash]; ok {
+			return nil, fmt.Errorf("%w: duplicate token of %s", entity.ErrInvalidTokensFile, entry.Name)
+		}
+
+		t.identities[hash] = Identity{Name: entry.Name, Scopes: entry.Scopes, Tenant: entry.Tenant}
+	}
+
+	return t, nil
+}
+
+// Authorize finds identity owning the token and checks that it was granted the scope.
+func (t *Tokens) Authorize(token string, scope Scope) (Identity, error) {
+	if len(token) == 0 {
+		return Identity{}, entity.ErrMissingToken
+	}
+
+	identity, ok := t.identities[sha256.Sum256([]byte(token))]
+	if !ok {
+		return Identity{}, entity.ErrInvalidToken
+	}
+
+	if !identity.Allows(scope) {
+		return identity, entity.InsufficientScopeError(string(scope))
+	}
+
+	return identity, nil
+}
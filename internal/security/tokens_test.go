@@ -0,0 +1,153 @@
+package security_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/stretchr/testify/require"
+)
+
+const _testTokens = `[
+{"name": "agent", "token": "agent-token", "scopes": ["write"]},
+{"name": "viewer", "token": "viewer-token", "scopes": ["read"]},
+{"name": "ops", "token": "ops-token", "scopes": ["admin"]},
+{"name": "acme-agent", "token": "acme-token", "scopes": ["write"], "tenant": "acme"}
+]`
+
+func createTokensFile(t *testing.T, data string) entity.FilePath {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	err := os.WriteFile(path, []byte(data), 0600)
+	require.NoError(t, err)
+
+	return entity.FilePath(path)
+}
+
+func createTestTokens(t *testing.T) *security.Tokens {
+	t.Helper()
+
+	tokens, err := security.NewTokens(createTokensFile(t, _testTokens))
+	require.NoError(t, err)
+
+	return tokens
+}
+
+func TestNewTokensFailsOnInvalidFile(t *testing.T) {
+	tt := []struct {
+		name     string
+		data     string
+		expected error
+	}{
+		{
+			name:     "Should reject token without name",
+			data:     `[{"token": "xxx", "scopes": ["read"]}]`,
+			expected: entity.ErrInvalidTokensFile,
+		},
+		{
+			name:     "Should reject empty token",
+			data:     `[{"name": "agent", "token": "", "scopes": ["read"]}]`,
+			expected: entity.ErrInvalidTokensFile,
+		},
+		{
+			name:     "Should reject token without scopes",
+			data:     `[{"name": "agent", "token": "xxx"}]`,
+			expected: entity.ErrInvalidTokensFile,
+		},
+		{
+			name:     "Should reject unknown scope",
+			data:     `[{"name": "agent", "token": "xxx", "scopes": ["delete"]}]`,
+			expected: entity.ErrInvalidTokensFile,
+		},
+		{
+			name:     "Should reject invalid tenant",
+			data:     `[{"name": "agent", "token": "xxx", "scopes": ["read"], "tenant": "../acme"}]`,
+			expected: entity.ErrInvalidTokensFile,
+		},
+		{
+			name:     "Should reject name of built-in source",
+			data:     `[{"name": "statsd", "token": "xxx", "scopes": ["write"]}]`,
+			expected: entity.ErrInvalidTokensFile,
+		},
+		{
+			name: "Should reject duplicate token",
+			data: `[{"name": "agent", "token": "xxx", "scopes": ["read"]},
+{"name": "other", "token": "xxx", "scopes": ["write"]}]`,
+			expected: entity.ErrInvalidTokensFile,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := security.NewTokens(createTokensFile(t, tc.data))
+
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestNewTokensFailsOnMalformedJSON(t *testing.T) {
+	_, err := security.NewTokens(createTokensFile(t, "xxx"))
+
+	require.Error(t, err)
+}
+
+func TestAuthorize(t *testing.T) {
+	tt := []struct {
+		name     string
+		token    string
+		scope    security.Scope
+		client   string
+		expected error
+	}{
+		{
+			name:   "Should grant scope of token",
+			token:  "agent-token",
+			scope:  security.ScopeWrite,
+			client: "agent",
+		},
+		{
+			name:   "Should grant any scope to admin",
+			token:  "ops-token",
+			scope:  security.ScopeRead,
+			client: "ops",
+		},
+		{
+			name:     "Should reject scope not granted to token",
+			token:    "viewer-token",
+			scope:    security.ScopeWrite,
+			client:   "viewer",
+			expected: entity.ErrInsufficientScope,
+		},
+		{
+			name:     "Should reject unknown token",
+			token:    "xxx",
+			scope:    security.ScopeRead,
+			expected: entity.ErrInvalidToken,
+		},
+		{
+			name:     "Should reject missing token",
+			scope:    security.ScopeRead,
+			expected: entity.ErrMissingToken,
+		},
+	}
+
+	tokens := createTestTokens(t)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			identity, err := tokens.Authorize(tc.token, tc.scope)
+
+			if tc.expected != nil {
+				require.ErrorIs(t, err, tc.expected)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.client, identity.Name)
+		})
+	}
+}
@@ -10,22 +10,33 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/alkurbatov/metrics-collector/internal/alerting"
+	"github.com/alkurbatov/metrics-collector/internal/cluster"
 	"github.com/alkurbatov/metrics-collector/internal/config"
+	"github.com/alkurbatov/metrics-collector/internal/exporter"
+	"github.com/alkurbatov/metrics-collector/internal/graphite"
 	"github.com/alkurbatov/metrics-collector/internal/grpcbackend"
 	"github.com/alkurbatov/metrics-collector/internal/grpcserver"
 	"github.com/alkurbatov/metrics-collector/internal/httpbackend"
 	"github.com/alkurbatov/metrics-collector/internal/httpserver"
 	"github.com/alkurbatov/metrics-collector/internal/prof"
+	"github.com/alkurbatov/metrics-collector/internal/query"
 	"github.com/alkurbatov/metrics-collector/internal/recovery"
+	"github.com/alkurbatov/metrics-collector/internal/relay"
 	"github.com/alkurbatov/metrics-collector/internal/security"
 	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/statsd"
 	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/internal/validators"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
 )
 
 const _defaultShutdownTimeout = 60 * time.Second
 
+// Interval between runs of partitions maintenance of the samples table.
+const _partitionsMaintenanceInterval = time.Hour
+
 type Server struct {
 	// Full configuration of the service.
 	config *config.Server
@@ -33,12 +44,48 @@ type Server struct {
 	// Storage backend (in memory, file, database).
 	storage storage.Storage
 
+	// Switch of read-only mode.
+	maintenance services.Maintenance
+
 	// Instance of HTTP server providing HTTP API.
 	httpServer *httpserver.Server
 
 	// Instance of gRPC server providing gRPC API.
 	grpcServer *grpcserver.Server
 
+	// Instance of UDP server accepting StatsD metrics.
+	statsdServer *statsd.Server
+
+	// Instance of TCP server accepting Graphite metrics.
+	graphiteServer *graphite.Server
+
+	// Forwards received metrics to upstream collector.
+	relay *relay.Relay
+
+	// Shards metrics between nodes of the cluster.
+	cluster *cluster.Recorder
+
+	// Recent values of metrics used by range queries.
+	history *query.History
+
+	// Evaluates queries and rules.
+	engine *query.Engine
+
+	// Rules calculating gauges from other metrics.
+	rules []query.Rule
+
+	// Records gauges calculated by rules.
+	recorder services.Recorder
+
+	// Tracks state of alerts.
+	alerts *alerting.Manager
+
+	// Mutes notifications about alerts.
+	silences *alerting.Silences
+
+	// Delivers notifications about firing and resolved alerts.
+	webhook *alerting.Webhook
+
 	// Instance of HTTP server serving pprof endpoints.
 	// Works on different port.
 	profiler *prof.Profiler
@@ -61,15 +108,118 @@ func New(cfg *config.Server) (*Server, error) {
 		}
 	}
 
-	dataStore := storage.NewDataStore(pool, cfg.StorePath, cfg.StoreInterval)
-	recorder := services.NewMetricsRecorder(dataStore)
-	healthcheck := services.NewHealthCheck(dataStore)
+	var sealer storage.Sealer
+	if len(cfg.StoreKeyPath) != 0 {
+		sealer, err = security.NewKeyring(cfg.StoreKeyPath, cfg.OldStoreKeys...)
+		if err != nil {
+			return nil, fmt.Errorf("Server - New - security.NewKeyring: %w", err)
+		}
+	}
+
+	dataStore := storage.NewDataStore(pool, cfg.StorePath, cfg.StoreInterval, sealer, cfg.TenantsLimit)
+
+	// Prepare partitions for incoming samples before accepting any requests.
+	if database, ok := dataStore.(storage.DatabaseStorage); ok {
+		if err = database.MaintainPartitions(context.Background(), time.Now(), cfg.Retention); err != nil {
+			log.Error().Err(err).Msg("Server - New - database.MaintainPartitions")
+		}
+	}
+
+	var upstream exporter.Exporter
+
+	if len(cfg.UpstreamAddress) != 0 {
+		upstream, err = newUpstreamExporter(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	var signer *security.Signer
 	if len(cfg.Secret) > 0 {
 		signer = security.NewSigner(cfg.Secret)
 	}
 
+	forwarder := relay.New(services.NewMetricsRecorder(dataStore), upstream, cfg.UpstreamInterval, cfg.UpstreamBuffer)
+
+	var tokens *security.Tokens
+
+	if len(cfg.TokensPath) != 0 {
+		tokens, err = security.NewTokens(cfg.TokensPath)
+		if err != nil {
+			return nil, fmt.Errorf("Server - New - security.NewTokens: %w", err)
+		}
+	}
+
+	identity, err := clusterIdentity(cfg, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	shards, err := cluster.New(cfg.ClusterSelf, cfg.ClusterNodes, forwarder, signer, cfg.ClusterToken, identity)
+	if err != nil {
+		return nil, fmt.Errorf("Server - New - cluster.New: %w", err)
+	}
+
+	var exempt []string
+	if len(identity) != 0 {
+		exempt = append(exempt, identity)
+	}
+
+	var policies *security.Policies
+
+	if len(cfg.PoliciesPath) != 0 {
+		policies, err = security.NewPolicies(cfg.PoliciesPath)
+		if err != nil {
+			return nil, fmt.Errorf("Server - New - security.NewPolicies: %w", err)
+		}
+	}
+
+	maintenance := services.NewMaintenanceRecorder(shards)
+	limits := services.SeriesLimits{
+		Tenant: cfg.SeriesQuota,
+		Global: cfg.SeriesLimit,
+		Client: cfg.ClientSeriesLimit,
+	}
+	quotas := services.NewQuotaRecorder(maintenance, dataStore, limits, exempt...)
+	recorder := services.NewAccessRecorder(quotas, policies)
+	healthcheck := services.NewHealthCheck(dataStore)
+
+	// History is collected from updates of series owned by this node only,
+	// thus in cluster mode range selectors would silently return incomplete results.
+	window := cfg.QueryWindow
+	if len(cfg.ClusterNodes) != 0 && window > 0 {
+		log.Warn().Msg("Range selectors are disabled in cluster mode")
+
+		window = 0
+	}
+
+	// History serves all clients, access to its series is checked by the engine.
+	history := query.NewHistory(quotas, window)
+	engine := query.NewEngine(recorder, history)
+
+	rules, err := query.ParseRules(cfg.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("Server - New - query.ParseRules: %w", err)
+	}
+
+	alertRules, err := alerting.ParseRules(cfg.Alerts)
+	if err != nil {
+		return nil, fmt.Errorf("Server - New - alerting.ParseRules: %w", err)
+	}
+
+	webhook, err := alerting.NewWebhook(cfg.AlertWebhooks)
+	if err != nil {
+		return nil, fmt.Errorf("Server - New - alerting.NewWebhook: %w", err)
+	}
+
+	inhibitions, err := alerting.ParseInhibitRules(cfg.InhibitRules)
+	if err != nil {
+		return nil, fmt.Errorf("Server - New - alerting.ParseInhibitRules: %w", err)
+	}
+
+	silences := alerting.NewSilences(dataStore)
+	alerts := alerting.NewManager(engine, alertRules, inhibitions, silences, webhook)
+
 	var key security.PrivateKey
 	if len(cfg.PrivateKeyPath) != 0 {
 		key, err = security.NewPrivateKey(cfg.PrivateKeyPath)
@@ -78,29 +228,122 @@ func New(cfg *config.Server) (*Server, error) {
 		}
 	}
 
+	var limiter *security.RateLimiter
+	if cfg.RateLimit > 0 {
+		limiter = security.NewRateLimiter(cfg.RateLimit, cfg.RateBurst, exempt...)
+	}
+
 	view, err := template.ParseFiles("./web/views/metrics.html")
 	if err != nil {
 		return nil, fmt.Errorf("Server - New - template.ParseFiles: %w", err)
 	}
 
-	router := httpbackend.Router(cfg.Address, view, recorder, healthcheck, signer, key, cfg.TrustedSubnet)
+	router := httpbackend.Router(httpbackend.Deps{
+		Address:        cfg.Address,
+		View:           view,
+		Recorder:       recorder,
+		Engine:         engine,
+		Alerts:         alerts,
+		Silences:       silences,
+		HealthCheck:    healthcheck,
+		Maintenance:    maintenance,
+		Cardinality:    quotas,
+		Signer:         signer,
+		PrivateKey:     key,
+		TrustedSubnet:  cfg.TrustedSubnet,
+		TrustedProxies: cfg.TrustedProxies,
+		Tokens:         tokens,
+		Limiter:        limiter,
+	})
 	httpSrv := httpserver.New(router, cfg.Address)
 
-	grpcSrv := grpcbackend.New(cfg.GRPCAddress, recorder, healthcheck, signer, cfg.TrustedSubnet)
+	grpcSrv := grpcbackend.New(grpcbackend.Deps{
+		Address:        cfg.GRPCAddress,
+		Recorder:       recorder,
+		Engine:         engine,
+		Alerts:         alerts,
+		HealthCheck:    healthcheck,
+		Signer:         signer,
+		TrustedSubnet:  cfg.TrustedSubnet,
+		TrustedProxies: cfg.TrustedProxies,
+		Tokens:         tokens,
+		Limiter:        limiter,
+	})
+
+	statsdSrv := statsd.New(cfg.StatsdAddress, cfg.StatsdFlush, recorder)
+
+	mapping, err := graphite.NewMapping(cfg.GraphiteMapping)
+	if err != nil {
+		return nil, fmt.Errorf("Server - New - graphite.NewMapping: %w", err)
+	}
+
+	graphiteSrv := graphite.New(cfg.GraphiteAddress, mapping, recorder)
 
 	profiler := prof.New(cfg.PprofAddress)
 
 	return &Server{
-		config:     cfg,
-		storage:    dataStore,
-		httpServer: httpSrv,
-		grpcServer: grpcSrv,
-		profiler:   profiler,
+		config:         cfg,
+		storage:        dataStore,
+		maintenance:    maintenance,
+		httpServer:     httpSrv,
+		grpcServer:     grpcSrv,
+		statsdServer:   statsdSrv,
+		graphiteServer: graphiteSrv,
+		relay:          forwarder,
+		cluster:        shards,
+		history:        history,
+		engine:         engine,
+		rules:          rules,
+		recorder:       recorder,
+		alerts:         alerts,
+		silences:       silences,
+		webhook:        webhook,
+		profiler:       profiler,
 	}, nil
 }
 
+// newUpstreamExporter creates exporter forwarding metrics to upstream collector.
+// The upstream hop uses own signing and encryption keys.
+func newUpstreamExporter(cfg *config.Server) (exporter.Exporter, error) {
+	if err := validators.ValidateTransport(cfg.UpstreamTransport); err != nil {
+		return nil, fmt.Errorf("Server - newUpstreamExporter - validators.ValidateTransport: %w", err)
+	}
+
+	var (
+		key security.PublicKey
+		err error
+	)
+
+	if len(cfg.UpstreamKeyPath) != 0 {
+		key, err = security.NewPublicKey(cfg.UpstreamKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("Server - newUpstreamExporter - security.NewPublicKey: %w", err)
+		}
+	}
+
+	return exporter.New(cfg.UpstreamTransport, cfg.UpstreamAddress, cfg.UpstreamSecret, cfg.UpstreamToken, key), nil
+}
+
+// clusterIdentity returns name of the identity authorized by the cluster token,
+// empty if authorization of requests is disabled.
+// Requests of this identity are recognized as proxied by other nodes of the cluster
+// and are not limited by rate limits and series limits as they were already checked
+// by the node which received them.
+func clusterIdentity(cfg *config.Server, tokens *security.Tokens) (string, error) {
+	if tokens == nil || len(cfg.ClusterToken) == 0 {
+		return "", nil
+	}
+
+	identity, err := tokens.Authorize(string(cfg.ClusterToken), security.ScopeRead)
+	if err != nil {
+		return "", fmt.Errorf("Server - clusterIdentity - tokens.Authorize: %w", err)
+	}
+
+	return identity.Name, nil
+}
+
 func (app *Server) restoreStorage() {
-	fileStore, ok := app.storage.(*storage.FileBackedStorage)
+	fileStore, ok := app.storage.(*storage.TenantStorage)
 	if !ok {
 		log.Warn().Msg("Metrics storage backend doesn't support restoring from disk!")
 		return
@@ -112,7 +355,7 @@ func (app *Server) restoreStorage() {
 }
 
 func (app *Server) dumpStorage(ctx context.Context) {
-	if _, ok := app.storage.(*storage.FileBackedStorage); !ok {
+	if _, ok := app.storage.(*storage.TenantStorage); !ok {
 		log.Warn().Msg("Metrics storage backend doesn't support saving to disk!")
 		return
 	}
@@ -126,7 +369,7 @@ func (app *Server) dumpStorage(ctx context.Context) {
 			func() {
 				defer recovery.TryRecover()
 
-				if err := app.storage.(*storage.FileBackedStorage).Dump(ctx); err != nil {
+				if err := app.storage.(*storage.TenantStorage).Dump(ctx); err != nil {
 					log.Error().Err(err).Msg("")
 				}
 			}()
@@ -138,6 +381,117 @@ func (app *Server) dumpStorage(ctx context.Context) {
 	}
 }
 
+func (app *Server) maintainPartitions(ctx context.Context) {
+	database, ok := app.storage.(storage.DatabaseStorage)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(_partitionsMaintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			func() {
+				defer recovery.TryRecover()
+
+				if err := database.MaintainPartitions(ctx, time.Now(), app.config.Retention); err != nil {
+					log.Error().Err(err).Msg("")
+				}
+			}()
+
+		case <-ctx.Done():
+			log.Info().Msg("Shutdown partitions maintenance")
+			return
+		}
+	}
+}
+
+// evaluateRules periodically calculates gauges defined by rules and records them.
+func (app *Server) evaluateRules(ctx context.Context) {
+	if len(app.rules) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(app.config.RulesInterval)
+	defer ticker.Stop()
+
+	ctx = security.WithIdentity(ctx, security.RulesIdentity)
+
+	for {
+		select {
+		case <-ticker.C:
+			func() {
+				defer recovery.TryRecover()
+
+				app.recordRules(ctx)
+			}()
+
+		case <-ctx.Done():
+			log.Info().Msg("Shutdown rules evaluation")
+			return
+		}
+	}
+}
+
+func (app *Server) recordRules(ctx context.Context) {
+	// Updates are rejected in maintenance mode anyway.
+	if app.maintenance.Enabled() {
+		return
+	}
+
+	records := make([]storage.Record, 0, len(app.rules))
+
+	for _, rule := range app.rules {
+		record, err := app.engine.EvaluateRule(ctx, rule)
+		if err != nil {
+			log.Warn().Err(err).Str("rule", rule.Name).Msg("Failed to evaluate rule")
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return
+	}
+
+	if _, err := app.recorder.PushList(ctx, records); err != nil {
+		log.Error().Err(err).Msg("")
+	}
+}
+
+// evaluateAlerts periodically checks conditions of alert rules.
+func (app *Server) evaluateAlerts(ctx context.Context) {
+	if len(app.config.Alerts) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(app.config.AlertsInterval)
+	defer ticker.Stop()
+
+	ctx = security.WithIdentity(ctx, security.AlertsIdentity)
+
+	for {
+		select {
+		case <-ticker.C:
+			func() {
+				defer recovery.TryRecover()
+
+				now := time.Now()
+
+				app.silences.Cleanup(ctx, now)
+				app.alerts.Evaluate(ctx, now)
+			}()
+
+		case <-ctx.Done():
+			log.Info().Msg("Shutdown alerts evaluation")
+			return
+		}
+	}
+}
+
 // Run starts the main app and waits till compeletion or termination signal.
 func (app *Server) Run() {
 	ctx, cancelBackgroundTasks := context.WithCancel(context.Background())
@@ -160,8 +514,29 @@ func (app *Server) Run() {
 		}
 	}
 
+	if len(app.config.DatabaseURL) > 0 {
+		go app.maintainPartitions(ctx)
+	}
+
+	go app.history.Collect(ctx)
+	go app.evaluateRules(ctx)
+	// Silences are loaded after restoration of the file-backed storage.
+	if err := app.silences.Load(ctx); err != nil {
+		log.Error().Err(err).Msg("app - Run - app.silences.Load")
+	}
+
+	go app.evaluateAlerts(ctx)
+
+	if app.config.ReadOnly {
+		app.maintenance.Enable(ctx)
+	}
+
 	app.httpServer.Start()
 	app.grpcServer.Start()
+	app.statsdServer.Start()
+	app.graphiteServer.Start()
+	app.relay.Start()
+	app.webhook.Start()
 
 	select {
 	case s := <-interrupt:
@@ -170,6 +545,10 @@ func (app *Server) Run() {
 		log.Error().Err(err).Msg("app - Run - app.httpServer.Notify")
 	case err := <-app.grpcServer.Notify():
 		log.Error().Err(err).Msg("app - Run - app.grpcServer.Notify")
+	case err := <-app.statsdServer.Notify():
+		log.Error().Err(err).Msg("app - Run - app.statsdServer.Notify")
+	case err := <-app.graphiteServer.Notify():
+		log.Error().Err(err).Msg("app - Run - app.graphiteServer.Notify")
 	case err := <-app.profiler.Notify():
 		log.Error().Err(err).Msg("app - Run - app.profiler.Notify")
 	}
@@ -207,6 +586,33 @@ func (app *Server) shutdown(ctx context.Context) {
 	log.Info().Msg("Shutting down gRPC API...")
 	app.grpcServer.Shutdown()
 
+	log.Info().Msg("Shutting down StatsD listener...")
+
+	if err := app.statsdServer.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("")
+	}
+
+	log.Info().Msg("Shutting down Graphite listener...")
+
+	if err := app.graphiteServer.Shutdown(); err != nil {
+		log.Error().Err(err).Msg("")
+	}
+
+	log.Info().Msg("Stopping alert notifications...")
+	app.webhook.Shutdown()
+
+	log.Info().Msg("Closing connections to cluster nodes...")
+
+	if err := app.cluster.Close(); err != nil {
+		log.Error().Err(err).Msg("")
+	}
+
+	log.Info().Msg("Forwarding remaining metrics to upstream...")
+
+	if err := app.relay.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("")
+	}
+
 	log.Info().Msg("Shutting down storage backend...")
 
 	if err := app.storage.Close(ctx); err != nil {
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+)
+
+var _ Recorder = (*AccessRecorder)(nil)
+
+// AccessRecorder enforces access policies of authenticated clients.
+// Built-in sources of metrics (e.g. rules evaluation or StatsD listener) are identified
+// by security.StatsdIdentity and alike, requests without identity are denied.
+type AccessRecorder struct {
+	Recorder
+
+	policies *security.Policies
+}
+
+// NewAccessRecorder creates new instance of AccessRecorder wrapping the recorder.
+// If policies are not set, AccessRecorder just passes requests to the recorder.
+func NewAccessRecorder(recorder Recorder, policies *security.Policies) *AccessRecorder {
+	return &AccessRecorder{Recorder: recorder, policies: policies}
+}
+
+// Readable reports whether client making the request may read the metric.
+func (r *AccessRecorder) Readable(ctx context.Context, name string) bool {
+	return r.allowed(ctx, security.VerbRead, name)
+}
+
+// allowed reports whether client making the request may perform the verb over the metric.
+func (r *AccessRecorder) allowed(ctx context.Context, verb security.Verb, name string) bool {
+	if r.policies == nil {
+		return true
+	}
+
+	identity, ok := security.IdentityFromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	return r.policies.Allowed(identity, verb, name)
+}
+
+func (r *AccessRecorder) authorize(ctx context.Context, verb security.Verb, name string) error {
+	if !r.allowed(ctx, verb, name) {
+		return entity.AccessDeniedError(string(verb), name)
+	}
+
+	return nil
+}
+
+// Push records metric data, if the client may write the metric.
+func (r *AccessRecorder) Push(ctx context.Context, record storage.Record) (storage.Record, error) {
+	if err := r.authorize(ctx, security.VerbWrite, record.Name); err != nil {
+		return storage.Record{}, pushError(err)
+	}
+
+	return r.Recorder.Push(ctx, record)
+}
+
+// PushList records list of metrics data, if the client may write all of the metrics.
+func (r *AccessRecorder) PushList(ctx context.Context, records []storage.Record) ([]storage.Record, error) {
+	for _, record := range records {
+		if err := r.authorize(ctx, security.VerbWrite, record.Name); err != nil {
+			return nil, fmt.Errorf("AccessRecorder - PushList - r.authorize: %w", err)
+		}
+	}
+
+	return r.Recorder.PushList(ctx, records)
+}
+
+// Get returns stored metrics record, if the client may read the metric.
+func (r *AccessRecorder) Get(ctx context.Context, kind, name string) (storage.Record, error) {
+	if err := r.authorize(ctx, security.VerbRead, name); err != nil {
+		return storage.Record{}, fmt.Errorf("AccessRecorder - Get - r.authorize: %w", err)
+	}
+
+	return r.Recorder.Get(ctx, kind, name)
+}
+
+// Delete removes stored metrics record, if the client may delete the metric.
+func (r *AccessRecorder) Delete(ctx context.Context, kind, name string) error {
+	if err := r.authorize(ctx, security.VerbDelete, name); err != nil {
+		return fmt.Errorf("AccessRecorder - Delete - r.authorize: %w", err)
+	}
+
+	return r.Recorder.Delete(ctx, kind, name)
+}
+
+// List retrieves single page of stored metrics, metrics the client may not read are skipped.
+// Thus the page could contain less records than requested.
+func (r *AccessRecorder) List(ctx context.Context, opts storage.ListOptions) (storage.Page, error) {
+	page, err := r.Recorder.List(ctx, opts)
+	if err != nil {
+		return storage.Page{}, err
+	}
+
+	records := make([]storage.Record, 0, len(page.Records))
+
+	for _, record := range page.Records {
+		if r.allowed(ctx, security.VerbRead, record.Name) {
+			records = append(records, record)
+		}
+	}
+
+	page.Records = records
+
+	return page, nil
+}
+
+// Watch returns channel receiving recorded metrics which match the filter
+// and the client may read.
+func (r *AccessRecorder) Watch(ctx context.Context, filter storage.Filter) <-chan storage.Record {
+	updates := r.Recorder.Watch(ctx, filter)
+
+	if r.policies == nil {
+		return updates
+	}
+
+	rv := make(chan storage.Record)
+
+	go func() {
+		defer close(rv)
+
+		for record := range updates {
+			if !r.allowed(ctx, security.VerbRead, record.Name) {
+				continue
+			}
+
+			select {
+			case rv <- record:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return rv
+}
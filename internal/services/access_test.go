@@ -0,0 +1,150 @@
+package services_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func newAccessRecorder(t *testing.T) *services.AccessRecorder {
+	t.Helper()
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "policies.json")
+	err := os.WriteFile(path, []byte(`[
+{"identity": "team-a", "verbs": ["read", "write"], "prefixes": ["TeamA"]},
+{"identity": "team-b", "verbs": ["read", "write", "delete"], "prefixes": ["TeamB"]},
+{"identity": "statsd", "verbs": ["write"], "prefixes": ["Statsd"]}
+]`), 0600)
+	require.NoError(err)
+
+	policies, err := security.NewPolicies(entity.FilePath(path))
+	require.NoError(err)
+
+	r := services.NewMetricsRecorder(storage.NewMemStorage())
+	ctx := context.Background()
+
+	for _, name := range []string{"TeamAAlloc", "TeamBAlloc"} {
+		_, err := r.Push(ctx, storage.Record{Name: name, Value: metrics.Gauge(11.23)})
+		require.NoError(err)
+	}
+
+	return services.NewAccessRecorder(r, policies)
+}
+
+func withIdentity(name string) context.Context {
+	identity := security.Identity{Name: name, Scopes: []security.Scope{security.ScopeWrite}}
+
+	return security.WithIdentity(context.Background(), identity)
+}
+
+func TestAccessRecorderEnforcesPolicies(t *testing.T) {
+	require := require.New(t)
+	r := newAccessRecorder(t)
+	ctx := withIdentity("team-a")
+
+	_, err := r.Push(ctx, storage.Record{Name: "TeamAFree", Value: metrics.Gauge(1)})
+	require.NoError(err)
+
+	_, err = r.Push(ctx, storage.Record{Name: "TeamBFree", Value: metrics.Gauge(1)})
+	require.ErrorIs(err, entity.ErrAccessDenied)
+
+	_, err = r.PushList(ctx, []storage.Record{
+		{Name: "TeamAFree", Value: metrics.Gauge(1)},
+		{Name: "TeamBFree", Value: metrics.Gauge(1)},
+	})
+	require.ErrorIs(err, entity.ErrAccessDenied)
+
+	_, err = r.Get(ctx, metrics.KindGauge, "TeamAAlloc")
+	require.NoError(err)
+
+	_, err = r.Get(ctx, metrics.KindGauge, "TeamBAlloc")
+	require.ErrorIs(err, entity.ErrAccessDenied)
+
+	err = r.Delete(ctx, metrics.KindGauge, "TeamAAlloc")
+	require.ErrorIs(err, entity.ErrAccessDenied)
+
+	err = r.Delete(withIdentity("team-b"), metrics.KindGauge, "TeamBAlloc")
+	require.NoError(err)
+}
+
+func TestAccessRecorderFiltersList(t *testing.T) {
+	require := require.New(t)
+	r := newAccessRecorder(t)
+
+	page, err := r.List(withIdentity("team-a"), storage.ListOptions{})
+	require.NoError(err)
+	require.Equal([]storage.Record{{Name: "TeamAAlloc", Value: metrics.Gauge(11.23)}}, page.Records)
+
+	page, err = r.List(context.Background(), storage.ListOptions{})
+	require.NoError(err)
+	require.Empty(page.Records)
+}
+
+func TestAccessRecorderFiltersWatch(t *testing.T) {
+	require := require.New(t)
+	r := newAccessRecorder(t)
+
+	ctx, cancel := context.WithCancel(withIdentity("team-a"))
+	defer cancel()
+
+	updates := r.Watch(ctx, storage.Filter{})
+
+	_, err := r.Push(withIdentity("team-b"), storage.Record{Name: "TeamBAlloc", Value: metrics.Gauge(1)})
+	require.NoError(err)
+
+	_, err = r.Push(withIdentity("team-a"), storage.Record{Name: "TeamAAlloc", Value: metrics.Gauge(2)})
+	require.NoError(err)
+
+	require.Equal(storage.Record{Name: "TeamAAlloc", Value: metrics.Gauge(2)}, <-updates)
+}
+
+func TestAccessRecorderPassesRequestsWithoutPolicies(t *testing.T) {
+	require := require.New(t)
+	ctx := withIdentity("team-a")
+
+	r := services.NewAccessRecorder(services.NewMetricsRecorder(storage.NewMemStorage()), nil)
+
+	_, err := r.Push(ctx, storage.Record{Name: "TeamBAlloc", Value: metrics.Gauge(1)})
+	require.NoError(err)
+	require.True(r.Readable(ctx, "TeamBAlloc"))
+}
+
+func TestAccessRecorderDeniesRequestsWithoutIdentity(t *testing.T) {
+	require := require.New(t)
+	r := newAccessRecorder(t)
+	ctx := context.Background()
+
+	_, err := r.Push(ctx, storage.Record{Name: "TeamAFree", Value: metrics.Gauge(1)})
+	require.ErrorIs(err, entity.ErrAccessDenied)
+
+	_, err = r.Get(ctx, metrics.KindGauge, "TeamAAlloc")
+	require.ErrorIs(err, entity.ErrAccessDenied)
+
+	require.False(r.Readable(ctx, "TeamAAlloc"))
+}
+
+func TestAccessRecorderEnforcesPoliciesOfBuiltinSources(t *testing.T) {
+	require := require.New(t)
+	r := newAccessRecorder(t)
+
+	_, err := r.PushList(
+		security.WithIdentity(context.Background(), security.StatsdIdentity),
+		[]storage.Record{{Name: "StatsdRequests", Value: metrics.Counter(1)}},
+	)
+	require.NoError(err)
+
+	_, err = r.PushList(
+		security.WithIdentity(context.Background(), security.GraphiteIdentity),
+		[]storage.Record{{Name: "StatsdRequests", Value: metrics.Counter(1)}},
+	)
+	require.ErrorIs(err, entity.ErrAccessDenied)
+}
@@ -0,0 +1,18 @@
+package services
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+var _ Cardinality = (*CardinalityMock)(nil)
+
+type CardinalityMock struct {
+	mock.Mock
+}
+
+func (m *CardinalityMock) Report(ctx context.Context, count int) (CardinalityReport, error) {
+	args := m.Called(ctx, count)
+	return args.Get(0).(CardinalityReport), args.Error(1)
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	_ Recorder    = (*MaintenanceRecorder)(nil)
+	_ Maintenance = (*MaintenanceRecorder)(nil)
+)
+
+// MaintenanceRecorder switches the service to read-only mode during maintenance.
+// While the mode is enabled all updates are rejected and reads are served
+// from the snapshot of stored metrics taken on entering the mode.
+// Snapshot of the default tenant is taken on entering the mode, snapshots of other tenants
+// are taken on the first read of the tenant, as updates are rejected they are the same.
+// Snapshots are taken without holding the lock, so updates are rejected meanwhile.
+type MaintenanceRecorder struct {
+	Recorder
+	sync.RWMutex
+
+	enabled bool
+
+	// Snapshots of tenants taken in current maintenance.
+	// If the snapshot of a tenant is not available, its reads are served by the storage.
+	snapshots   map[string]*tenantSnapshot
+	snapshotsMu sync.Mutex
+}
+
+// tenantSnapshot is a snapshot of metrics of single tenant.
+type tenantSnapshot struct {
+	// Closed when the snapshot is taken or failed.
+	ready chan struct{}
+
+	// Recorder serving reads from the snapshot, nil if the snapshot failed.
+	recorder Recorder
+}
+
+// NewMaintenanceRecorder creates new instance of MaintenanceRecorder wrapping the recorder.
+func NewMaintenanceRecorder(recorder Recorder) *MaintenanceRecorder {
+	return &MaintenanceRecorder{Recorder: recorder}
+}
+
+// takeSnapshot copies all stored metrics of the tenant to memory.
+func (r *MaintenanceRecorder) takeSnapshot(ctx context.Context) (Recorder, error) {
+	page, err := r.Recorder.List(ctx, storage.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("MaintenanceRecorder - takeSnapshot - r.Recorder.List: %w", err)
+	}
+
+	data := make(map[string]storage.Record, len(page.Records))
+	for _, record := range page.Records {
+		data[CalculateID(record.Name, record.Value.Kind())] = record
+	}
+
+	snapshot := storage.NewMemStorage()
+	if err := snapshot.PushBatch(ctx, data); err != nil {
+		return nil, fmt.Errorf("MaintenanceRecorder - takeSnapshot - snapshot.PushBatch: %w", err)
+	}
+
+	return NewMetricsRecorder(snapshot), nil
+}
+
+// Enable switches the service to read-only mode taking the snapshot of the default tenant.
+// If the snapshot of stored metrics cannot be taken, reads are served by the storage.
+func (r *MaintenanceRecorder) Enable(ctx context.Context) {
+	r.Lock()
+
+	if r.enabled {
+		r.Unlock()
+		return
+	}
+
+	r.enabled = true
+	snapshots := make(map[string]*tenantSnapshot)
+	r.snapshots = snapshots
+
+	r.Unlock()
+
+	log.Ctx(ctx).Warn().Msg("Maintenance mode enabled, updates are rejected")
+
+	// Updates are rejected already, thus the snapshot is consistent.
+	r.snapshotOf(entity.WithTenant(ctx, entity.DefaultTenant), snapshots)
+}
+
+// Disable switches the service back to normal mode.
+func (r *MaintenanceRecorder) Disable() {
+	r.Lock()
+	defer r.Unlock()
+
+	if !r.enabled {
+		return
+	}
+
+	r.enabled = false
+	r.snapshots = nil
+
+	log.Info().Msg("Maintenance mode disabled")
+}
+
+// Enabled reports whether the service is in read-only mode.
+func (r *MaintenanceRecorder) Enabled() bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.enabled
+}
+
+// snapshotOf returns recorder serving reads of the tenant from the snapshot, taking it if needed.
+// Concurrent reads of the tenant wait for the same snapshot.
+// If the snapshot cannot be taken, reads of the tenant are served by the storage
+// and the snapshot is taken again on the next read.
+func (r *MaintenanceRecorder) snapshotOf(ctx context.Context, snapshots map[string]*tenantSnapshot) Recorder {
+	tenant := entity.TenantFromContext(ctx)
+
+	r.snapshotsMu.Lock()
+	snapshot, ok := snapshots[tenant]
+
+	if !ok {
+		snapshot = &tenantSnapshot{ready: make(chan struct{})}
+		snapshots[tenant] = snapshot
+	}
+	r.snapshotsMu.Unlock()
+
+	if ok {
+		select {
+		case <-snapshot.ready:
+		case <-ctx.Done():
+			return r.Recorder
+		}
+
+		if snapshot.recorder == nil {
+			return r.Recorder
+		}
+
+		return snapshot.recorder
+	}
+
+	defer close(snapshot.ready)
+
+	recorder, err := r.takeSnapshot(ctx)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("tenant", tenant).
+			Msg("Failed to take snapshot, reads will be served by the storage")
+
+		r.snapshotsMu.Lock()
+		delete(snapshots, tenant)
+		r.snapshotsMu.Unlock()
+
+		return r.Recorder
+	}
+
+	snapshot.recorder = recorder
+
+	return recorder
+}
+
+// reader returns recorder which should serve reads of the tenant in current mode.
+// The snapshot is taken without holding the lock.
+func (r *MaintenanceRecorder) reader(ctx context.Context) Recorder {
+	r.RLock()
+	enabled, snapshots := r.enabled, r.snapshots
+	r.RUnlock()
+
+	if !enabled {
+		return r.Recorder
+	}
+
+	return r.snapshotOf(ctx, snapshots)
+}
+
+// Push records metric data, if the service is not in read-only mode.
+func (r *MaintenanceRecorder) Push(ctx context.Context, record storage.Record) (storage.Record, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.enabled {
+		return storage.Record{}, pushError(entity.ErrMaintenance)
+	}
+
+	return r.Recorder.Push(ctx, record)
+}
+
+// PushList records list of metrics data, if the service is not in read-only mode.
+func (r *MaintenanceRecorder) PushList(ctx context.Context, records []storage.Record) ([]storage.Record, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.enabled {
+		return nil, fmt.Errorf("MaintenanceRecorder - PushList: %w", entity.ErrMaintenance)
+	}
+
+	return r.Recorder.PushList(ctx, records)
+}
+
+// Get returns stored metrics record.
+func (r *MaintenanceRecorder) Get(ctx context.Context, kind, name string) (storage.Record, error) {
+	return r.reader(ctx).Get(ctx, kind, name)
+}
+
+// Delete removes stored metrics record, if the service is not in read-only mode.
+func (r *MaintenanceRecorder) Delete(ctx context.Context, kind, name string) error {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.enabled {
+		return fmt.Errorf("MaintenanceRecorder - Delete: %w", entity.ErrMaintenance)
+	}
+
+	return r.Recorder.Delete(ctx, kind, name)
+}
+
+// List retrieves single page of stored metrics ordered by name and kind.
+func (r *MaintenanceRecorder) List(ctx context.Context, opts storage.ListOptions) (storage.Page, error) {
+	return r.reader(ctx).List(ctx, opts)
+}
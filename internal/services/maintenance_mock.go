@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+var _ Maintenance = (*MaintenanceMock)(nil)
+
+type MaintenanceMock struct {
+	mock.Mock
+}
+
+func (m *MaintenanceMock) Enable(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func (m *MaintenanceMock) Disable() {
+	m.Called()
+}
+
+func (m *MaintenanceMock) Enabled() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
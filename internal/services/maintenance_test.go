@@ -0,0 +1,161 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceRejectsUpdates(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	r := services.NewMaintenanceRecorder(services.NewMetricsRecorder(storage.NewMemStorage()))
+	r.Enable(ctx)
+	require.True(r.Enabled())
+
+	_, err := r.Push(ctx, storage.Record{Name: "PollCount", Value: metrics.Counter(1)})
+	require.ErrorIs(err, entity.ErrMaintenance)
+
+	_, err = r.PushList(ctx, []storage.Record{{Name: "PollCount", Value: metrics.Counter(1)}})
+	require.ErrorIs(err, entity.ErrMaintenance)
+
+	r.Disable()
+	require.False(r.Enabled())
+
+	_, err = r.Push(ctx, storage.Record{Name: "PollCount", Value: metrics.Counter(1)})
+	require.NoError(err)
+}
+
+func TestMaintenanceServesReadsFromSnapshot(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	record := storage.Record{Name: "Alloc", Value: metrics.Gauge(11.23)}
+
+	m := new(storage.Mock)
+	m.On("List", mock.Anything, storage.ListOptions{}).Return(storage.Page{Records: []storage.Record{record}}, nil)
+
+	r := services.NewMaintenanceRecorder(services.NewMetricsRecorder(m))
+	r.Enable(ctx)
+
+	rv, err := r.Get(ctx, metrics.KindGauge, "Alloc")
+	require.NoError(err)
+	require.Equal(record, rv)
+
+	page, err := r.List(ctx, storage.ListOptions{})
+	require.NoError(err)
+	require.Equal([]storage.Record{record}, page.Records)
+
+	m.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	m.AssertNumberOfCalls(t, "List", 1)
+}
+
+func TestMaintenanceServesReadsOfTenantsFromSnapshots(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	acme := entity.WithTenant(ctx, "acme")
+	record := storage.Record{Name: "Alloc", Value: metrics.Gauge(11.23)}
+
+	m := new(storage.Mock)
+	m.On("List", mock.Anything, storage.ListOptions{}).Return(storage.Page{Records: []storage.Record{record}}, nil)
+
+	r := services.NewMaintenanceRecorder(services.NewMetricsRecorder(m))
+	r.Enable(ctx)
+
+	rv, err := r.Get(acme, metrics.KindGauge, "Alloc")
+	require.NoError(err)
+	require.Equal(record, rv)
+
+	page, err := r.List(acme, storage.ListOptions{})
+	require.NoError(err)
+	require.Equal([]storage.Record{record}, page.Records)
+
+	m.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	m.AssertNumberOfCalls(t, "List", 2)
+
+	r.Disable()
+
+	_, err = r.List(acme, storage.ListOptions{})
+	require.NoError(err)
+	m.AssertNumberOfCalls(t, "List", 3)
+}
+
+func TestMaintenanceFallsBackToStorageWithoutSnapshot(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	record := storage.Record{Name: "Alloc", Value: metrics.Gauge(11.23)}
+
+	m := new(storage.Mock)
+	m.On("List", mock.Anything, storage.ListOptions{}).Return(storage.Page{}, entity.ErrUnexpected)
+	m.On("Get", mock.Anything, "Alloc_gauge").Return(record, nil)
+
+	r := services.NewMaintenanceRecorder(services.NewMetricsRecorder(m))
+	r.Enable(ctx)
+	require.True(r.Enabled())
+
+	rv, err := r.Get(ctx, metrics.KindGauge, "Alloc")
+	require.NoError(err)
+	require.Equal(record, rv)
+}
+
+func TestMaintenanceRetriesFailedSnapshot(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	record := storage.Record{Name: "Alloc", Value: metrics.Gauge(11.23)}
+
+	m := new(storage.Mock)
+	m.On("List", mock.Anything, storage.ListOptions{}).Return(storage.Page{}, entity.ErrUnexpected).Once()
+	m.On("List", mock.Anything, storage.ListOptions{}).Return(storage.Page{Records: []storage.Record{record}}, nil)
+
+	r := services.NewMaintenanceRecorder(services.NewMetricsRecorder(m))
+	r.Enable(ctx)
+
+	for i := 0; i < 2; i++ {
+		rv, err := r.Get(ctx, metrics.KindGauge, "Alloc")
+		require.NoError(err)
+		require.Equal(record, rv)
+	}
+
+	m.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	m.AssertNumberOfCalls(t, "List", 2)
+}
+
+func TestMaintenanceTakesSnapshotWithoutLock(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	listed := make(chan struct{})
+	release := make(chan struct{})
+
+	m := new(storage.Mock)
+	m.On("List", mock.Anything, storage.ListOptions{}).Return(storage.Page{}, nil).Run(func(mock.Arguments) {
+		close(listed)
+		<-release
+	})
+
+	r := services.NewMaintenanceRecorder(services.NewMetricsRecorder(m))
+
+	enabled := make(chan struct{})
+
+	go func() {
+		r.Enable(ctx)
+		close(enabled)
+	}()
+
+	<-listed
+
+	// Updates are rejected while the snapshot is taken.
+	require.True(r.Enabled())
+
+	_, err := r.Push(ctx, storage.Record{Name: "PollCount", Value: metrics.Counter(1)})
+	require.ErrorIs(err, entity.ErrMaintenance)
+
+	close(release)
+	<-enabled
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+)
+
+var (
+	_ Recorder    = (*QuotaRecorder)(nil)
+	_ Cardinality = (*QuotaRecorder)(nil)
+)
+
+// SeriesLimits restrict count of distinct series, zero values disable the limits.
+type SeriesLimits struct {
+	// Maximal count of series of single tenant.
+	Tenant int
+
+	// Maximal count of series of all tenants.
+	Global int
+
+	// Maximal count of series created by single client, see security.ClientOf.
+	Client int
+}
+
+// SeriesCreator is a client which created series.
+type SeriesCreator struct {
+	Client string
+	Series int
+}
+
+// SeriesCounter counts series kept in the storage, see storage.Storage.
+type SeriesCounter interface {
+	// Count returns count of stored series of the tenant.
+	Count(ctx context.Context) (int, error)
+
+	// CountAll returns count of stored series of all tenants.
+	CountAll(ctx context.Context) (int, error)
+}
+
+// CardinalityReport describes count of series stored by the server.
+type CardinalityReport struct {
+	// Count of series of all tenants.
+	Series int
+
+	Limits SeriesLimits
+
+	// Clients created most series since start of the server, ordered by count of series.
+	Creators []SeriesCreator
+}
+
+// QuotaRecorder limits count of series stored for each tenant, for all tenants
+// and created by each client. Updates of existing series are always accepted.
+// Series of tenants are counted by the storage of this node, so in cluster mode
+// the limits of tenants and of all tenants restrict series stored on each node.
+type QuotaRecorder struct {
+	Recorder
+
+	// Counts series kept in the storage.
+	counter SeriesCounter
+
+	limits SeriesLimits
+
+	// Clients which are not limited and not reported, e.g. other nodes of the cluster.
+	exempt map[string]struct{}
+
+	// Creation of new series is serialized to avoid exceeding the limits
+	// by concurrent requests. Series are created rarely, so this doesn't affect throughput.
+	mu sync.Mutex
+
+	// Count of series created by each client.
+	creators map[string]int
+
+	// Clients created the series, keyed by tenant and ID of the series.
+	owners map[string]string
+
+	// Series known to be stored, keyed by tenant and ID of the series.
+	// Updates of known series don't require lookups in the storage.
+	// Series removed bypassing the recorder, e.g. by another node of the cluster, are looked up again
+	// only after restart of the server.
+	known   map[string]struct{}
+	knownMu sync.RWMutex
+}
+
+// NewQuotaRecorder creates new instance of QuotaRecorder wrapping the recorder.
+// Stored series are counted by the counter, usually the storage behind the recorder.
+// Series created by the exempt identities are not limited by client limit.
+func NewQuotaRecorder(
+	recorder Recorder,
+	counter SeriesCounter,
+	limits SeriesLimits,
+	exempt ...string,
+) *QuotaRecorder {
+	r := &QuotaRecorder{
+		Recorder: recorder,
+		counter:  counter,
+		limits:   limits,
+		exempt:   make(map[string]struct{}, len(exempt)),
+		creators: make(map[string]int),
+		owners:   make(map[string]string),
+		known:    make(map[string]struct{}),
+	}
+
+	for _, name := range exempt {
+		r.exempt[security.IdentityClient(name)] = struct{}{}
+	}
+
+	return r
+}
+
+func seriesKey(ctx context.Context, kind, name string) string {
+	return entity.TenantFromContext(ctx) + "/" + CalculateID(name, kind)
+}
+
+// exists reports whether the series is already stored.
+func (r *QuotaRecorder) exists(ctx context.Context, record storage.Record) (bool, error) {
+	_, err := r.Recorder.Get(ctx, record.Value.Kind(), record.Name)
+	if errors.Is(err, entity.ErrMetricNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// isKnown reports whether the series is known to be stored.
+func (r *QuotaRecorder) isKnown(key string) bool {
+	r.knownMu.RLock()
+	defer r.knownMu.RUnlock()
+
+	_, ok := r.known[key]
+
+	return ok
+}
+
+// remember marks the series as stored.
+func (r *QuotaRecorder) remember(key string) {
+	r.knownMu.Lock()
+	defer r.knownMu.Unlock()
+
+	r.known[key] = struct{}{}
+}
+
+// forget marks the series as removed.
+func (r *QuotaRecorder) forget(key string) {
+	r.knownMu.Lock()
+	defer r.knownMu.Unlock()
+
+	delete(r.known, key)
+}
+
+// newSeries returns records creating series which are not stored yet.
+// Only series which are not known yet are looked up in the storage.
+func (r *QuotaRecorder) newSeries(ctx context.Context, records []storage.Record) ([]storage.Record, error) {
+	rv := make([]storage.Record, 0)
+	seen := make(map[string]struct{}, len(records))
+
+	for _, record := range records {
+		key := seriesKey(ctx, record.Value.Kind(), record.Name)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+
+		if r.isKnown(key) {
+			continue
+		}
+
+		ok, err := r.exists(ctx, record)
+		if err != nil {
+			return nil, fmt.Errorf("QuotaRecorder - newSeries - r.exists: %w", err)
+		}
+
+		if ok {
+			r.remember(key)
+			continue
+		}
+
+		rv = append(rv, record)
+	}
+
+	return rv, nil
+}
+
+// creator returns client making the request, if the client should be limited.
+func (r *QuotaRecorder) creator(ctx context.Context) (string, bool) {
+	client, ok := security.ClientFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	if _, exempt := r.exempt[client]; exempt {
+		return "", false
+	}
+
+	return client, true
+}
+
+// reserve checks that additional count of series doesn't exceed the limits.
+// Must be called under lock.
+func (r *QuotaRecorder) reserve(ctx context.Context, count int) error {
+	if client, ok := r.creator(ctx); ok && r.limits.Client > 0 {
+		if r.creators[client]+count > r.limits.Client {
+			return entity.SeriesLimitExceededError("client "+client, r.limits.Client)
+		}
+	}
+
+	if r.limits.Tenant > 0 {
+		stored, err := r.counter.Count(ctx)
+		if err != nil {
+			return fmt.Errorf("QuotaRecorder - reserve - r.counter.Count: %w", err)
+		}
+
+		if stored+count > r.limits.Tenant {
+			return entity.SeriesQuotaExceededError(entity.TenantFromContext(ctx), r.limits.Tenant)
+		}
+	}
+
+	if r.limits.Global > 0 {
+		stored, err := r.counter.CountAll(ctx)
+		if err != nil {
+			return fmt.Errorf("QuotaRecorder - reserve - r.counter.CountAll: %w", err)
+		}
+
+		if stored+count > r.limits.Global {
+			return entity.SeriesLimitExceededError("global", r.limits.Global)
+		}
+	}
+
+	return nil
+}
+
+// commit accounts series created by the client making the request.
+// Must be called under lock.
+func (r *QuotaRecorder) commit(ctx context.Context, created []storage.Record) {
+	client, tracked := r.creator(ctx)
+
+	for _, record := range created {
+		key := seriesKey(ctx, record.Value.Kind(), record.Name)
+		r.remember(key)
+
+		if tracked {
+			r.owners[key] = client
+		}
+	}
+
+	if tracked {
+		r.creators[client] += len(created)
+	}
+}
+
+// admit checks that the records don't create series exceeding the limits and calls push.
+// Pushes creating new series are serialized. Creators of series are tracked
+// even if the limits are disabled, as they are shown in the cardinality report.
+// Errors returned by push are passed to caller as is.
+func (r *QuotaRecorder) admit(ctx context.Context, records []storage.Record, push func() error) error {
+	created, err := r.newSeries(ctx, records)
+	if err != nil {
+		return pushError(err)
+	}
+
+	if len(created) == 0 {
+		return push()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Some of the series could be created by concurrent request
+	// while we were waiting for the lock.
+	created, err = r.newSeries(ctx, created)
+	if err != nil {
+		return pushError(err)
+	}
+
+	if len(created) == 0 {
+		return push()
+	}
+
+	if err := r.reserve(ctx, len(created)); err != nil {
+		return pushError(err)
+	}
+
+	if err := push(); err != nil {
+		return err
+	}
+
+	r.commit(ctx, created)
+
+	return nil
+}
+
+// Push records metric data, if the record doesn't create series exceeding the limits.
+func (r *QuotaRecorder) Push(ctx context.Context, record storage.Record) (storage.Record, error) {
+	var rv storage.Record
+
+	err := r.admit(ctx, []storage.Record{record}, func() error {
+		var err error
+
+		rv, err = r.Recorder.Push(ctx, record)
+
+		return err
+	})
+
+	return rv, err
+}
+
+// PushList records list of metrics data, if the records don't create series exceeding the limits.
+// The list is rejected as a whole.
+func (r *QuotaRecorder) PushList(ctx context.Context, records []storage.Record) ([]storage.Record, error) {
+	var rv []storage.Record
+
+	err := r.admit(ctx, records, func() error {
+		var err error
+
+		rv, err = r.Recorder.PushList(ctx, records)
+
+		return err
+	})
+
+	return rv, err
+}
+
+// Delete removes stored metrics record releasing the series.
+func (r *QuotaRecorder) Delete(ctx context.Context, kind, name string) error {
+	if err := r.Recorder.Delete(ctx, kind, name); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := seriesKey(ctx, kind, name)
+	r.forget(key)
+
+	client, ok := r.owners[key]
+	if !ok {
+		return nil
+	}
+
+	delete(r.owners, key)
+
+	r.creators[client]--
+	if r.creators[client] == 0 {
+		delete(r.creators, client)
+	}
+
+	return nil
+}
+
+// Report returns count of stored series and up to count clients created most series.
+// Zero count means all clients.
+func (r *QuotaRecorder) Report(ctx context.Context, count int) (CardinalityReport, error) {
+	series, err := r.counter.CountAll(ctx)
+	if err != nil {
+		return CardinalityReport{}, fmt.Errorf("QuotaRecorder - Report - r.counter.CountAll: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	creators := make([]SeriesCreator, 0, len(r.creators))
+	for client, series := range r.creators {
+		creators = append(creators, SeriesCreator{Client: client, Series: series})
+	}
+
+	sort.Slice(creators, func(i, j int) bool {
+		if creators[i].Series != creators[j].Series {
+			return creators[i].Series > creators[j].Series
+		}
+
+		return creators[i].Client < creators[j].Client
+	})
+
+	if count > 0 && len(creators) > count {
+		creators = creators[:count]
+	}
+
+	return CardinalityReport{Series: series, Limits: r.limits, Creators: creators}, nil
+}
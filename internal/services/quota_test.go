@@ -0,0 +1,310 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newQuotaRecorder(t *testing.T, quota int) *services.QuotaRecorder {
+	t.Helper()
+
+	dataStore := storage.NewDataStore(nil, "", 0, nil, 0)
+
+	r := services.NewQuotaRecorder(
+		services.NewMetricsRecorder(dataStore),
+		dataStore,
+		services.SeriesLimits{Tenant: quota},
+	)
+
+	_, err := r.PushList(context.Background(), []storage.Record{
+		{Name: "Alloc", Value: metrics.Gauge(11.23)},
+		{Name: "PollCount", Value: metrics.Counter(1)},
+	})
+	require.NoError(t, err)
+
+	return r
+}
+
+func TestQuotaRecorderRejectsNewSeriesAboveQuota(t *testing.T) {
+	require := require.New(t)
+	r := newQuotaRecorder(t, 3)
+	ctx := context.Background()
+
+	_, err := r.Push(ctx, storage.Record{Name: "Frees", Value: metrics.Gauge(1)})
+	require.NoError(err)
+
+	_, err = r.Push(ctx, storage.Record{Name: "HeapAlloc", Value: metrics.Gauge(1)})
+	require.ErrorIs(err, entity.ErrSeriesQuotaExceeded)
+
+	_, err = r.Push(ctx, storage.Record{Name: "Alloc", Value: metrics.Gauge(2)})
+	require.NoError(err)
+
+	_, err = r.Push(ctx, storage.Record{Name: "Alloc", Value: metrics.Counter(2)})
+	require.ErrorIs(err, entity.ErrSeriesQuotaExceeded)
+}
+
+func TestQuotaRecorderRejectsWholeList(t *testing.T) {
+	require := require.New(t)
+	r := newQuotaRecorder(t, 3)
+	ctx := context.Background()
+
+	_, err := r.PushList(ctx, []storage.Record{
+		{Name: "Alloc", Value: metrics.Gauge(2)},
+		{Name: "Frees", Value: metrics.Gauge(1)},
+		{Name: "HeapAlloc", Value: metrics.Gauge(1)},
+	})
+	require.ErrorIs(err, entity.ErrSeriesQuotaExceeded)
+
+	_, err = r.Get(ctx, metrics.KindGauge, "Frees")
+	require.ErrorIs(err, entity.ErrMetricNotFound)
+
+	_, err = r.PushList(ctx, []storage.Record{
+		{Name: "Alloc", Value: metrics.Gauge(2)},
+		{Name: "Frees", Value: metrics.Gauge(1)},
+		{Name: "Frees", Value: metrics.Gauge(2)},
+	})
+	require.NoError(err)
+}
+
+func TestQuotaRecorderCountsSeriesOfEachTenant(t *testing.T) {
+	require := require.New(t)
+	r := newQuotaRecorder(t, 2)
+
+	_, err := r.Push(context.Background(), storage.Record{Name: "Frees", Value: metrics.Gauge(1)})
+	require.ErrorIs(err, entity.ErrSeriesQuotaExceeded)
+
+	_, err = r.PushList(entity.WithTenant(context.Background(), "acme"), []storage.Record{
+		{Name: "Alloc", Value: metrics.Gauge(1)},
+		{Name: "Frees", Value: metrics.Gauge(1)},
+	})
+	require.NoError(err)
+}
+
+func TestQuotaRecorderWithoutQuota(t *testing.T) {
+	r := newQuotaRecorder(t, 0)
+
+	_, err := r.Push(context.Background(), storage.Record{Name: "Frees", Value: metrics.Gauge(1)})
+	require.NoError(t, err)
+}
+
+func TestQuotaRecorderRejectsNewSeriesAboveGlobalLimit(t *testing.T) {
+	require := require.New(t)
+
+	dataStore := storage.NewDataStore(nil, "", 0, nil, 0)
+	r := services.NewQuotaRecorder(services.NewMetricsRecorder(dataStore), dataStore, services.SeriesLimits{Global: 3})
+
+	_, err := r.PushList(entity.WithTenant(context.Background(), "acme"), []storage.Record{
+		{Name: "Alloc", Value: metrics.Gauge(1)},
+		{Name: "Frees", Value: metrics.Gauge(1)},
+	})
+	require.NoError(err)
+
+	ctx := entity.WithTenant(context.Background(), "beta")
+
+	_, err = r.Push(ctx, storage.Record{Name: "Alloc", Value: metrics.Gauge(1)})
+	require.NoError(err)
+
+	_, err = r.Push(ctx, storage.Record{Name: "Frees", Value: metrics.Gauge(1)})
+	require.ErrorIs(err, entity.ErrSeriesLimitExceeded)
+
+	_, err = r.Push(ctx, storage.Record{Name: "Alloc", Value: metrics.Gauge(2)})
+	require.NoError(err)
+
+	require.NoError(r.Delete(ctx, metrics.KindGauge, "Alloc"))
+
+	_, err = r.Push(ctx, storage.Record{Name: "Frees", Value: metrics.Gauge(1)})
+	require.NoError(err)
+}
+
+func TestQuotaRecorderCountsSeriesStoredBeforeStart(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	dataStore := storage.NewDataStore(nil, "", 0, nil, 0)
+
+	err := dataStore.PushBatch(entity.WithTenant(ctx, "acme"), map[string]storage.Record{
+		"Alloc_gauge": {Name: "Alloc", Value: metrics.Gauge(1)},
+		"Frees_gauge": {Name: "Frees", Value: metrics.Gauge(1)},
+	})
+	require.NoError(err)
+
+	r := services.NewQuotaRecorder(
+		services.NewMetricsRecorder(dataStore),
+		dataStore,
+		services.SeriesLimits{Tenant: 2, Global: 3},
+	)
+
+	_, err = r.Push(entity.WithTenant(ctx, "acme"), storage.Record{Name: "HeapAlloc", Value: metrics.Gauge(1)})
+	require.ErrorIs(err, entity.ErrSeriesQuotaExceeded)
+
+	_, err = r.Push(ctx, storage.Record{Name: "Alloc", Value: metrics.Gauge(1)})
+	require.NoError(err)
+
+	_, err = r.Push(ctx, storage.Record{Name: "Frees", Value: metrics.Gauge(1)})
+	require.ErrorIs(err, entity.ErrSeriesLimitExceeded)
+
+	report, err := r.Report(ctx, 0)
+	require.NoError(err)
+	require.Equal(3, report.Series)
+}
+
+func TestQuotaRecorderRejectsNewSeriesAboveClientLimit(t *testing.T) {
+	require := require.New(t)
+
+	dataStore := storage.NewDataStore(nil, "", 0, nil, 0)
+	r := services.NewQuotaRecorder(
+		services.NewMetricsRecorder(dataStore),
+		dataStore,
+		services.SeriesLimits{Client: 2},
+		"cluster",
+	)
+
+	agent := security.WithClient(context.Background(), "ip:10.0.0.1")
+
+	_, err := r.PushList(agent, []storage.Record{
+		{Name: "Alloc", Value: metrics.Gauge(1)},
+		{Name: "Frees", Value: metrics.Gauge(1)},
+	})
+	require.NoError(err)
+
+	_, err = r.Push(agent, storage.Record{Name: "HeapAlloc", Value: metrics.Gauge(1)})
+	require.ErrorIs(err, entity.ErrSeriesLimitExceeded)
+	require.ErrorContains(err, "client ip:10.0.0.1")
+
+	_, err = r.Push(agent, storage.Record{Name: "Alloc", Value: metrics.Gauge(2)})
+	require.NoError(err)
+
+	another := security.WithClient(context.Background(), "ip:10.0.0.2")
+
+	_, err = r.Push(another, storage.Record{Name: "HeapAlloc", Value: metrics.Gauge(1)})
+	require.NoError(err)
+
+	cluster := security.WithClient(context.Background(), security.IdentityClient("cluster"))
+
+	_, err = r.PushList(cluster, []storage.Record{
+		{Name: "Lookups", Value: metrics.Gauge(1)},
+		{Name: "Mallocs", Value: metrics.Gauge(1)},
+		{Name: "NumGC", Value: metrics.Gauge(1)},
+	})
+	require.NoError(err)
+
+	require.NoError(r.Delete(agent, metrics.KindGauge, "Frees"))
+
+	_, err = r.Push(agent, storage.Record{Name: "HeapAlloc", Value: metrics.Counter(1)})
+	require.NoError(err)
+}
+
+func TestQuotaRecorderReport(t *testing.T) {
+	require := require.New(t)
+
+	dataStore := storage.NewDataStore(nil, "", 0, nil, 0)
+	limits := services.SeriesLimits{Global: 100, Client: 10}
+	r := services.NewQuotaRecorder(services.NewMetricsRecorder(dataStore), dataStore, limits, "cluster")
+
+	for client, names := range map[string][]string{
+		"ip:10.0.0.1":                      {"Alloc"},
+		"ip:10.0.0.2":                      {"Frees", "HeapAlloc"},
+		"identity:agent":                   {"Lookups", "Mallocs"},
+		security.IdentityClient("cluster"): {"NumGC"},
+	} {
+		ctx := security.WithClient(context.Background(), client)
+
+		for _, name := range names {
+			_, err := r.Push(ctx, storage.Record{Name: name, Value: metrics.Gauge(1)})
+			require.NoError(err)
+		}
+	}
+
+	_, err := r.Push(context.Background(), storage.Record{Name: "PollCount", Value: metrics.Counter(1)})
+	require.NoError(err)
+
+	report, err := r.Report(context.Background(), 2)
+	require.NoError(err)
+	require.Equal(7, report.Series)
+	require.Equal(limits, report.Limits)
+	require.Equal([]services.SeriesCreator{
+		{Client: "identity:agent", Series: 2},
+		{Client: "ip:10.0.0.2", Series: 2},
+	}, report.Creators)
+
+	report, err = r.Report(context.Background(), 0)
+	require.NoError(err)
+	require.Len(report.Creators, 3)
+}
+
+func TestQuotaRecorderReportsCreatorsWithoutLimits(t *testing.T) {
+	require := require.New(t)
+
+	dataStore := storage.NewDataStore(nil, "", 0, nil, 0)
+	r := services.NewQuotaRecorder(services.NewMetricsRecorder(dataStore), dataStore, services.SeriesLimits{})
+
+	agent := security.WithClient(context.Background(), "ip:10.0.0.1")
+
+	_, err := r.PushList(agent, []storage.Record{
+		{Name: "Alloc", Value: metrics.Gauge(1)},
+		{Name: "Frees", Value: metrics.Gauge(1)},
+	})
+	require.NoError(err)
+
+	_, err = r.Push(agent, storage.Record{Name: "Alloc", Value: metrics.Gauge(2)})
+	require.NoError(err)
+
+	report, err := r.Report(context.Background(), 0)
+	require.NoError(err)
+	require.Equal(2, report.Series)
+	require.Equal([]services.SeriesCreator{{Client: "ip:10.0.0.1", Series: 2}}, report.Creators)
+
+	require.NoError(r.Delete(agent, metrics.KindGauge, "Frees"))
+
+	report, err = r.Report(context.Background(), 0)
+	require.NoError(err)
+	require.Equal([]services.SeriesCreator{{Client: "ip:10.0.0.1", Series: 1}}, report.Creators)
+}
+
+func TestQuotaRecorderLooksUpEachSeriesOnce(t *testing.T) {
+	require := require.New(t)
+
+	m := new(services.RecorderMock)
+	m.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(storage.Record{}, entity.ErrMetricNotFound)
+	m.On("PushList", mock.Anything, mock.Anything).Return([]storage.Record{}, nil)
+	m.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	dataStore := storage.NewDataStore(nil, "", 0, nil, 0)
+	r := services.NewQuotaRecorder(m, dataStore, services.SeriesLimits{Tenant: 10})
+
+	records := []storage.Record{
+		{Name: "Alloc", Value: metrics.Gauge(1)},
+		{Name: "PollCount", Value: metrics.Counter(1)},
+	}
+
+	// New series are looked up before and after serialization of the request.
+	_, err := r.PushList(context.Background(), records)
+	require.NoError(err)
+	m.AssertNumberOfCalls(t, "Get", 4)
+
+	for i := 0; i < 3; i++ {
+		_, err = r.PushList(context.Background(), records)
+		require.NoError(err)
+	}
+
+	m.AssertNumberOfCalls(t, "Get", 4)
+
+	// Series of other tenants and removed series are looked up again.
+	_, err = r.PushList(entity.WithTenant(context.Background(), "acme"), records[:1])
+	require.NoError(err)
+	m.AssertNumberOfCalls(t, "Get", 6)
+
+	require.NoError(r.Delete(context.Background(), metrics.KindGauge, "Alloc"))
+
+	_, err = r.PushList(context.Background(), records)
+	require.NoError(err)
+	m.AssertNumberOfCalls(t, "Get", 8)
+}
@@ -125,16 +125,27 @@ func (r MetricsRecorder) Get(ctx context.Context, kind, name string) (storage.Re
 	return record, nil
 }
 
-// List retrieves all stored metrics.
-func (r MetricsRecorder) List(ctx context.Context) ([]storage.Record, error) {
-	rv, err := r.storage.GetAll(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list records: %w", err)
+// Delete removes stored metrics record.
+func (r MetricsRecorder) Delete(ctx context.Context, kind, name string) error {
+	if err := r.storage.Delete(ctx, CalculateID(name, kind)); err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
 	}
 
-	sort.Slice(rv, func(i, j int) bool {
-		return rv[i].Name < rv[j].Name
-	})
+	return nil
+}
+
+// List retrieves single page of stored metrics ordered by name and kind.
+func (r MetricsRecorder) List(ctx context.Context, opts storage.ListOptions) (storage.Page, error) {
+	rv, err := r.storage.List(ctx, opts)
+	if err != nil {
+		return storage.Page{}, fmt.Errorf("failed to list records: %w", err)
+	}
 
 	return rv, nil
 }
+
+// Watch returns channel receiving recorded metrics which match the filter.
+// The channel is closed as soon as provided context is done.
+func (r MetricsRecorder) Watch(ctx context.Context, filter storage.Filter) <-chan storage.Record {
+	return r.storage.Subscribe(ctx, filter)
+}
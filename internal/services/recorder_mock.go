@@ -28,12 +28,17 @@ func (m *RecorderMock) Get(ctx context.Context, kind, name string) (storage.Reco
 	return args.Get(0).(storage.Record), args.Error(1)
 }
 
-func (m *RecorderMock) List(ctx context.Context) ([]storage.Record, error) {
-	args := m.Called(ctx)
+func (m *RecorderMock) Delete(ctx context.Context, kind, name string) error {
+	args := m.Called(ctx, kind, name)
+	return args.Error(0)
+}
 
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
+func (m *RecorderMock) List(ctx context.Context, opts storage.ListOptions) (storage.Page, error) {
+	args := m.Called(ctx, opts)
+	return args.Get(0).(storage.Page), args.Error(1)
+}
 
-	return args.Get(0).([]storage.Record), args.Error(1)
+func (m *RecorderMock) Watch(ctx context.Context, filter storage.Filter) <-chan storage.Record {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(<-chan storage.Record)
 }
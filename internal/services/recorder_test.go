@@ -268,37 +268,51 @@ func TestGetUnknownMetric(t *testing.T) {
 }
 
 func TestListMetrics(t *testing.T) {
-	stored := []storage.Record{
-		{Name: "PollCount", Value: metrics.Counter(10)},
-		{Name: "Alloc", Value: metrics.Gauge(11.123)},
-	}
-
-	expected := []storage.Record{
-		{Name: "Alloc", Value: metrics.Gauge(11.123)},
-		{Name: "PollCount", Value: metrics.Counter(10)},
+	opts := storage.ListOptions{Prefix: "Poll", Limit: 10}
+	page := storage.Page{
+		Records: []storage.Record{
+			{Name: "PollCount", Value: metrics.Counter(10)},
+		},
 	}
 
 	m := new(storage.Mock)
-	m.On("GetAll", mock.Anything).Return(stored, nil)
+	m.On("List", mock.Anything, opts).Return(page, nil)
 
 	require := require.New(t)
 	r := services.NewMetricsRecorder(m)
 
-	data, err := r.List(context.Background())
+	data, err := r.List(context.Background(), opts)
 
 	require.NoError(err)
-	require.Equal(2, len(data))
-	require.Equal(expected, data)
+	require.Equal(page, data)
+	m.AssertExpectations(t)
 }
 
 func TestListMetricsOnBrokenStorage(t *testing.T) {
 	store := new(storage.Mock)
-	store.On("GetAll", mock.Anything).Return(nil, entity.ErrUnexpected)
+	store.On("List", mock.Anything, mock.Anything).Return(storage.Page{}, entity.ErrUnexpected)
 
 	r := services.NewMetricsRecorder(store)
 
-	_, err := r.List(context.Background())
+	_, err := r.List(context.Background(), storage.ListOptions{})
 
 	require.Error(t, err)
 	store.AssertExpectations(t)
 }
+
+func TestWatchMetrics(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := services.NewMetricsRecorder(storage.NewMemStorage())
+	updates := r.Watch(ctx, storage.Filter{Kinds: []string{metrics.KindCounter}})
+
+	pushMetric(t, r, "Alloc", metrics.Gauge(11.23), metrics.Gauge(11.23))
+	pushMetric(t, r, "PollCount", metrics.Counter(3), metrics.Counter(3))
+	pushMetric(t, r, "PollCount", metrics.Counter(5), metrics.Counter(8))
+
+	require.Equal(storage.Record{Name: "PollCount", Value: metrics.Counter(3)}, <-updates)
+	require.Equal(storage.Record{Name: "PollCount", Value: metrics.Counter(8)}, <-updates)
+}
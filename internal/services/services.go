@@ -11,9 +11,21 @@ type Recorder interface {
 	Push(ctx context.Context, record storage.Record) (storage.Record, error)
 	PushList(ctx context.Context, records []storage.Record) ([]storage.Record, error)
 	Get(ctx context.Context, kind, name string) (storage.Record, error)
-	List(ctx context.Context) ([]storage.Record, error)
+	Delete(ctx context.Context, kind, name string) error
+	List(ctx context.Context, opts storage.ListOptions) (storage.Page, error)
+	Watch(ctx context.Context, filter storage.Filter) <-chan storage.Record
 }
 
 type HealthCheck interface {
 	CheckStorage(ctx context.Context) error
 }
+
+type Maintenance interface {
+	Enable(ctx context.Context)
+	Disable()
+	Enabled() bool
+}
+
+type Cardinality interface {
+	Report(ctx context.Context, count int) (CardinalityReport, error)
+}
@@ -0,0 +1,138 @@
+package statsd
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+// Name of the counter accounting lines which couldn't be parsed.
+const _malformedLinesMetric = "StatsdMalformedLines"
+
+// A timer accumulates durations received during flush interval.
+type timer struct {
+	sum   float64
+	count float64
+}
+
+// An aggregator accumulates StatsD samples received during flush interval.
+type aggregator struct {
+	sync.Mutex
+
+	// Sums of counters adjusted according to sample rates.
+	counters map[string]float64
+
+	// Last values of gauges, kept between flushes to support relative updates.
+	gauges map[string]float64
+
+	// Names of gauges updated during the current flush interval.
+	updated map[string]struct{}
+
+	timers map[string]*timer
+
+	// Count of lines which couldn't be parsed.
+	malformed int64
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		counters: make(map[string]float64),
+		gauges:   make(map[string]float64),
+		updated:  make(map[string]struct{}),
+		timers:   make(map[string]*timer),
+	}
+}
+
+// AddPacket parses and accumulates all lines of single packet.
+func (a *aggregator) AddPacket(packet []byte) {
+	lines := strings.Split(string(packet), "\n")
+
+	a.Lock()
+	defer a.Unlock()
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		s, err := parseLine(line)
+		if err != nil {
+			log.Debug().Err(err).Str("line", line).Msg("Skipping StatsD line")
+
+			a.malformed++
+
+			continue
+		}
+
+		a.add(s)
+	}
+}
+
+func (a *aggregator) add(s sample) {
+	switch s.kind {
+	case _typeCounter:
+		a.counters[s.name] += s.value / s.rate
+
+	case _typeGauge:
+		if s.relative {
+			a.gauges[s.name] += s.value
+		} else {
+			a.gauges[s.name] = s.value
+		}
+
+		a.updated[s.name] = struct{}{}
+
+	case _typeTimer, _typeHistogram:
+		t, ok := a.timers[s.name]
+		if !ok {
+			t = new(timer)
+			a.timers[s.name] = t
+		}
+
+		t.sum += s.value / s.rate
+		t.count += 1 / s.rate
+	}
+}
+
+// Flush returns records accumulated since previous flush and resets the state.
+// Counters are recorded as counters, gauges as gauges,
+// each timer produces gauge with mean duration and counter "<name>Count" of events.
+func (a *aggregator) Flush() []storage.Record {
+	a.Lock()
+	defer a.Unlock()
+
+	rv := make([]storage.Record, 0, len(a.counters)+len(a.updated)+2*len(a.timers)+1)
+
+	for name, value := range a.counters {
+		rv = append(rv, storage.Record{Name: name, Value: metrics.Counter(math.Round(value))})
+	}
+
+	for name := range a.updated {
+		rv = append(rv, storage.Record{Name: name, Value: metrics.Gauge(a.gauges[name])})
+	}
+
+	for name, t := range a.timers {
+		rv = append(
+			rv,
+			storage.Record{Name: name, Value: metrics.Gauge(t.sum / t.count)},
+			storage.Record{Name: name + "Count", Value: metrics.Counter(math.Round(t.count))},
+		)
+	}
+
+	if a.malformed > 0 {
+		log.Warn().Int64("count", a.malformed).Msg("Malformed StatsD lines received")
+		rv = append(rv, storage.Record{Name: _malformedLinesMetric, Value: metrics.Counter(a.malformed)})
+	}
+
+	a.counters = make(map[string]float64)
+	a.updated = make(map[string]struct{})
+	a.timers = make(map[string]*timer)
+	a.malformed = 0
+
+	return rv
+}
@@ -0,0 +1,89 @@
+package statsd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/validators"
+)
+
+// Supported types of StatsD metrics.
+const (
+	_typeCounter   = "c"
+	_typeGauge     = "g"
+	_typeTimer     = "ms"
+	_typeHistogram = "h"
+)
+
+// A sample represents single StatsD line, e.g. "api.requests:1|c|@0.5".
+type sample struct {
+	// Name of the metric converted to the form acceptable by the service.
+	name string
+
+	// One of supported StatsD types.
+	kind string
+
+	value float64
+
+	// Fraction of events actually sent by the client, in range (0, 1].
+	rate float64
+
+	// Set for gauges which value starts with a sign, such gauges are adjusted
+	// by the value instead of being replaced.
+	relative bool
+}
+
+// parseLine parses single line in format "<name>:<value>|<type>[|@<rate>][|#<tags>]".
+// Tags are accepted for compatibility with DogStatsD, but ignored.
+func parseLine(line string) (sample, error) {
+	rawName, rest, ok := strings.Cut(line, ":")
+	if !ok {
+		return sample{}, fmt.Errorf("%w: value not found", entity.ErrMalformedStatsdLine)
+	}
+
+	name := validators.SanitizeMetricName(rawName)
+	if err := validators.ValidateMetricName(name, ""); err != nil {
+		return sample{}, fmt.Errorf("%w: %s", entity.ErrMalformedStatsdLine, err)
+	}
+
+	fields := strings.Split(rest, "|")
+	if len(fields) < 2 {
+		return sample{}, fmt.Errorf("%w: type not found", entity.ErrMalformedStatsdLine)
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample{}, fmt.Errorf("%w: %s", entity.ErrMalformedStatsdLine, err)
+	}
+
+	rv := sample{name: name, kind: fields[1], value: value, rate: 1}
+
+	switch rv.kind {
+	case _typeCounter, _typeTimer, _typeHistogram:
+
+	case _typeGauge:
+		rv.relative = strings.HasPrefix(fields[0], "+") || strings.HasPrefix(fields[0], "-")
+
+	default:
+		return sample{}, fmt.Errorf("%w: type %s not supported", entity.ErrMalformedStatsdLine, rv.kind)
+	}
+
+	for _, field := range fields[2:] {
+		switch {
+		case strings.HasPrefix(field, "@"):
+			rv.rate, err = strconv.ParseFloat(field[1:], 64)
+			if err != nil || rv.rate <= 0 || rv.rate > 1 {
+				return sample{}, fmt.Errorf("%w: invalid sample rate %s", entity.ErrMalformedStatsdLine, field)
+			}
+
+		case strings.HasPrefix(field, "#"):
+
+		default:
+			return sample{}, fmt.Errorf("%w: unexpected field %s", entity.ErrMalformedStatsdLine, field)
+		}
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,151 @@
+// Package statsd implements UDP listener accepting metrics in StatsD format.
+package statsd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/recovery"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// Maximal size of single UDP packet.
+const _maxPacketSize = 65535
+
+// Server receives StatsD metrics over UDP, aggregates them
+// and periodically records them via the recorder.
+type Server struct {
+	address       entity.NetAddress
+	flushInterval time.Duration
+	recorder      services.Recorder
+
+	conn   net.PacketConn
+	agg    *aggregator
+	notify chan error
+
+	// Closed on shutdown to stop background tasks.
+	quit chan struct{}
+
+	// Tracks background tasks.
+	wg sync.WaitGroup
+}
+
+// New creates new instance of StatsD server.
+// If empty address string used, empty Server (without initialization)
+// is created. Such server is no-op.
+func New(address entity.NetAddress, flushInterval time.Duration, recorder services.Recorder) *Server {
+	if len(address) == 0 {
+		return &Server{}
+	}
+
+	return &Server{
+		address:       address,
+		flushInterval: flushInterval,
+		recorder:      recorder,
+		agg:           newAggregator(),
+		notify:        make(chan error, 1),
+		quit:          make(chan struct{}),
+	}
+}
+
+// Start launches the StatsD server.
+func (s *Server) Start() {
+	if len(s.address) == 0 {
+		return
+	}
+
+	conn, err := net.ListenPacket("udp", s.address.String())
+	if err != nil {
+		s.notify <- err
+		return
+	}
+
+	s.conn = conn
+
+	s.wg.Add(2)
+
+	go s.serve()
+	go s.flushPeriodically()
+}
+
+// serve reads incoming packets until the connection is closed.
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	buf := make([]byte, _maxPacketSize)
+
+	for {
+		n, _, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.notify <- err
+			}
+
+			return
+		}
+
+		s.agg.AddPacket(buf[:n])
+	}
+}
+
+func (s *Server) flushPeriodically() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			func() {
+				defer recovery.TryRecover()
+
+				s.flush(context.Background())
+			}()
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// flush records metrics aggregated since previous flush.
+// The metrics are dropped, if recording fails.
+func (s *Server) flush(ctx context.Context) {
+	records := s.agg.Flush()
+	if len(records) == 0 {
+		return
+	}
+
+	ctx = security.WithIdentity(ctx, security.StatsdIdentity)
+	if _, err := s.recorder.PushList(ctx, records); err != nil {
+		log.Error().Err(err).Int("count", len(records)).Msg("StatsD metrics dropped")
+	}
+}
+
+// Notify reports errors received during start and work of the server.
+// Usually such errors are not recoverable.
+func (s *Server) Notify() <-chan error {
+	return s.notify
+}
+
+// Shutdown stops the server and records metrics aggregated so far.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.conn == nil {
+		return nil
+	}
+
+	close(s.quit)
+	err := s.conn.Close()
+
+	s.wg.Wait()
+	s.flush(ctx)
+
+	return err
+}
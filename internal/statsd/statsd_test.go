@@ -0,0 +1,167 @@
+package statsd_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/services"
+	"github.com/alkurbatov/metrics-collector/internal/statsd"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func freeUDPAddress(t *testing.T) entity.NetAddress {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+
+	return entity.NetAddress(conn.LocalAddr().String())
+}
+
+// sendPacket sends lines to the StatsD server in single packet
+// and returns records pushed by the first flush.
+func sendPacket(t *testing.T, packet string) []storage.Record {
+	t.Helper()
+	require := require.New(t)
+
+	flushed := make(chan []storage.Record, 1)
+
+	m := new(services.RecorderMock)
+	m.On("PushList", mock.Anything, mock.Anything).
+		Return([]storage.Record{}, nil).
+		Run(func(args mock.Arguments) {
+			select {
+			case flushed <- args.Get(1).([]storage.Record):
+			default:
+			}
+		})
+
+	address := freeUDPAddress(t)
+
+	srv := statsd.New(address, 50*time.Millisecond, m)
+	srv.Start()
+
+	defer func() {
+		require.NoError(srv.Shutdown(context.Background()))
+	}()
+
+	conn, err := net.Dial("udp", address.String())
+	require.NoError(err)
+
+	defer func() {
+		require.NoError(conn.Close())
+	}()
+
+	_, err = conn.Write([]byte(packet))
+	require.NoError(err)
+
+	select {
+	case records := <-flushed:
+		return records
+
+	case <-time.After(2 * time.Second):
+		require.FailNow("StatsD metrics were not flushed")
+		return nil
+	}
+}
+
+func TestStatsdServer(t *testing.T) {
+	tt := []struct {
+		name     string
+		packet   string
+		expected []storage.Record
+	}{
+		{
+			name:   "Should sum counters",
+			packet: "api.requests:1|c\napi.requests:2|c",
+			expected: []storage.Record{
+				{Name: "ApiRequests", Value: metrics.Counter(3)},
+			},
+		},
+		{
+			name:   "Should scale counters according to sample rate",
+			packet: "api.requests:1|c|@0.1",
+			expected: []storage.Record{
+				{Name: "ApiRequests", Value: metrics.Counter(10)},
+			},
+		},
+		{
+			name:   "Should keep last value of gauge",
+			packet: "cpu.load:3.2|g\ncpu.load:1.5|g",
+			expected: []storage.Record{
+				{Name: "CpuLoad", Value: metrics.Gauge(1.5)},
+			},
+		},
+		{
+			name:   "Should adjust gauge by relative value",
+			packet: "cpu.load:3.2|g\ncpu.load:+1|g\ncpu.load:-0.2|g",
+			expected: []storage.Record{
+				{Name: "CpuLoad", Value: metrics.Gauge(4)},
+			},
+		},
+		{
+			name:   "Should record mean duration and count of timers",
+			packet: "db.query:10|ms\ndb.query:20|ms|@0.5\ndb.query:30|h",
+			expected: []storage.Record{
+				{Name: "DbQuery", Value: metrics.Gauge(20)},
+				{Name: "DbQueryCount", Value: metrics.Counter(4)},
+			},
+		},
+		{
+			name:   "Should ignore tags",
+			packet: "api.requests:1|c|#env:prod",
+			expected: []storage.Record{
+				{Name: "ApiRequests", Value: metrics.Counter(1)},
+			},
+		},
+		{
+			name:   "Should account malformed lines",
+			packet: "api.requests:1|c\nxxx\napi.requests|c\napi.requests:x|c\napi.users:1|s\napi.requests:1|c|@2\n:1|c",
+			expected: []storage.Record{
+				{Name: "ApiRequests", Value: metrics.Counter(1)},
+				{Name: "StatsdMalformedLines", Value: metrics.Counter(6)},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			records := sendPacket(t, tc.packet)
+
+			require.ElementsMatch(t, tc.expected, records)
+		})
+	}
+}
+
+func TestStatsdServerIsNoopWithoutAddress(t *testing.T) {
+	srv := statsd.New("", time.Second, new(services.RecorderMock))
+	srv.Start()
+
+	require.Nil(t, srv.Notify())
+	require.NoError(t, srv.Shutdown(context.Background()))
+}
+
+func TestStatsdServerNotifiesOnListenFailure(t *testing.T) {
+	srv := statsd.New("256.0.0.1:8125", time.Second, new(services.RecorderMock))
+	srv.Start()
+
+	select {
+	case err := <-srv.Notify():
+		require.Error(t, err)
+
+	case <-time.After(time.Second):
+		require.FailNow(t, "StatsD server didn't report failure")
+	}
+
+	require.NoError(t, srv.Shutdown(context.Background()))
+}
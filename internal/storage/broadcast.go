@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Size of subscription's buffer, records published to a full buffer are dropped.
+const _subscriptionBufferSize = 256
+
+// A Filter selects records delivered to a subscriber.
+// Empty list matches any value.
+type Filter struct {
+	// Names of metrics to watch for.
+	Names []string
+
+	// Kinds of metrics to watch for, e.g. "counter".
+	Kinds []string
+}
+
+func matchAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Match verifies that the record satisfies the filter.
+func (f Filter) Match(record Record) bool {
+	return matchAny(f.Names, record.Name) && matchAny(f.Kinds, record.Value.Kind())
+}
+
+// Broadcaster delivers published records to all interested subscribers.
+// Publishers are never blocked by slow subscribers: if subscriber's buffer
+// is full, the record is dropped for this subscriber.
+type Broadcaster struct {
+	sync.RWMutex
+
+	subscribers map[chan Record]Filter
+}
+
+// NewBroadcaster creates new instance of Broadcaster.
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[chan Record]Filter),
+	}
+}
+
+// Subscribe returns channel receiving published records which match the filter.
+// The channel is closed as soon as provided context is done.
+func (b *Broadcaster) Subscribe(ctx context.Context, filter Filter) <-chan Record {
+	ch := make(chan Record, _subscriptionBufferSize)
+
+	b.Lock()
+	b.subscribers[ch] = filter
+	b.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.Lock()
+		defer b.Unlock()
+
+		delete(b.subscribers, ch)
+		close(ch)
+	}()
+
+	return ch
+}
+
+// Publish sends records to all subscribers interested in them.
+func (b *Broadcaster) Publish(records ...Record) {
+	b.RLock()
+	defer b.RUnlock()
+
+	for ch, filter := range b.subscribers {
+		for _, record := range records {
+			if !filter.Match(record) {
+				continue
+			}
+
+			select {
+			case ch <- record:
+			default:
+				log.Warn().Str("metric", record.Name).Msg("Subscriber is too slow, update dropped")
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterMatch(t *testing.T) {
+	record := storage.Record{Name: "PollCount", Value: metrics.Counter(10)}
+
+	tt := []struct {
+		name     string
+		filter   storage.Filter
+		expected bool
+	}{
+		{
+			name:     "Empty filter matches everything",
+			expected: true,
+		},
+		{
+			name:     "Should match by name",
+			filter:   storage.Filter{Names: []string{"Alloc", "PollCount"}},
+			expected: true,
+		},
+		{
+			name:     "Should match by kind",
+			filter:   storage.Filter{Kinds: []string{metrics.KindCounter}},
+			expected: true,
+		},
+		{
+			name:     "Should match by name and kind",
+			filter:   storage.Filter{Names: []string{"PollCount"}, Kinds: []string{metrics.KindCounter}},
+			expected: true,
+		},
+		{
+			name:   "Should not match different name",
+			filter: storage.Filter{Names: []string{"Alloc"}},
+		},
+		{
+			name:   "Should not match different kind",
+			filter: storage.Filter{Names: []string{"PollCount"}, Kinds: []string{metrics.KindGauge}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.filter.Match(record))
+		})
+	}
+}
+
+func TestBroadcasterPublish(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := storage.NewBroadcaster()
+	all := b.Subscribe(ctx, storage.Filter{})
+	gauges := b.Subscribe(ctx, storage.Filter{Kinds: []string{metrics.KindGauge}})
+
+	counter := storage.Record{Name: "PollCount", Value: metrics.Counter(1)}
+	gauge := storage.Record{Name: "Alloc", Value: metrics.Gauge(2.5)}
+	b.Publish(counter, gauge)
+
+	require.Equal(counter, <-all)
+	require.Equal(gauge, <-all)
+	require.Equal(gauge, <-gauges)
+	require.Empty(gauges)
+}
+
+func TestBroadcasterClosesSubscriptionOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b := storage.NewBroadcaster()
+	ch := b.Subscribe(ctx, storage.Filter{})
+
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		require.False(t, ok)
+
+	case <-time.After(time.Second):
+		require.Fail(t, "subscription was not closed")
+	}
+}
+
+func TestBroadcasterDoesntBlockOnSlowSubscriber(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := storage.NewBroadcaster()
+	_ = b.Subscribe(ctx, storage.Filter{})
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 1000; i++ {
+			b.Publish(storage.Record{Name: "PollCount", Value: metrics.Counter(i)})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "publisher was blocked")
+	}
+}
@@ -2,8 +2,12 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"sync"
+	"time"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/alkurbatov/metrics-collector/pkg/metrics"
@@ -19,14 +23,75 @@ func rollback(ctx context.Context, tx pgx.Tx) {
 	}
 }
 
+// Name of Postgres channel used to notify about metrics updates.
+// The notifications are sent by trigger on the metrics table, see migrations.
+const _updatesChannel = "metrics_updates"
+
+// Delay before next attempt to listen for notifications if connection was lost.
+const _listenRetryInterval = 5 * time.Second
+
+// notificationsListener tracks state of the background task
+// listening for metrics updates.
+type notificationsListener struct {
+	sync.Mutex
+
+	// Stops listening, nil if listening wasn't started.
+	cancel context.CancelFunc
+}
+
+func (l *notificationsListener) stop() {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.cancel != nil {
+		l.cancel()
+	}
+}
+
+// tenantFeeds notifies subscribers about updates of metrics of their tenants.
+type tenantFeeds struct {
+	sync.Mutex
+
+	feeds map[string]*Broadcaster
+}
+
+// get returns feed of the tenant.
+func (f *tenantFeeds) get(tenant string) *Broadcaster {
+	f.Lock()
+	defer f.Unlock()
+
+	feed, ok := f.feeds[tenant]
+	if !ok {
+		feed = NewBroadcaster()
+		f.feeds[tenant] = feed
+	}
+
+	return feed
+}
+
+// A notification describes update of a metric received from the database.
+type notification struct {
+	Tenant string `json:"tenant"`
+}
+
 // DatabaseStorage implements database metrics storage.
+// Metrics of tenants are distinguished by the tenant column.
 type DatabaseStorage struct {
 	pool DBConnPool
+
+	// Notify subscribers about updates received from the database.
+	feeds *tenantFeeds
+
+	listener *notificationsListener
 }
 
 // NewDatabaseStorage creates new instance of DatabaseStorage.
 func NewDatabaseStorage(pool DBConnPool) DatabaseStorage {
-	return DatabaseStorage{pool: pool}
+	return DatabaseStorage{
+		pool:     pool,
+		feeds:    &tenantFeeds{feeds: make(map[string]*Broadcaster)},
+		listener: new(notificationsListener),
+	}
 }
 
 // Push records metric data.
@@ -47,7 +112,9 @@ func (d DatabaseStorage) Push(ctx context.Context, key string, record Record) er
 
 	if _, err = tx.Exec(
 		ctx,
-		"INSERT INTO metrics(id, name, kind, value) values ($1, $2, $3, $4) ON CONFLICT (id) DO UPDATE SET value = $4",
+		`INSERT INTO metrics(tenant, id, name, kind, value) values ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant, id) DO UPDATE SET value = $5`,
+		entity.TenantFromContext(ctx),
 		key,
 		record.Name,
 		record.Value.Kind(),
@@ -69,10 +136,14 @@ func (d DatabaseStorage) PushBatch(ctx context.Context, data map[string]Record)
 	// (unless explicit transaction control statements are executed)
 	// we don't need to handle transactions manually.
 	// See: https://www.postgresql.org/docs/current/protocol-flow.html#PROTOCOL-FLOW-EXT-QUERY
+	tenant := entity.TenantFromContext(ctx)
+
 	batch := new(pgx.Batch)
 	for id, record := range data {
 		batch.Queue(
-			"INSERT INTO metrics(id, name, kind, value) values ($1, $2, $3, $4) ON CONFLICT (id) DO UPDATE SET value = $4",
+			`INSERT INTO metrics(tenant, id, name, kind, value) values ($1, $2, $3, $4, $5)
+			ON CONFLICT (tenant, id) DO UPDATE SET value = $5`,
+			tenant,
 			id,
 			record.Name,
 			record.Value.Kind(),
@@ -105,7 +176,12 @@ func (d DatabaseStorage) Get(ctx context.Context, key string) (Record, error) {
 	)
 
 	err := d.pool.
-		QueryRow(ctx, "SELECT name, kind, value FROM metrics WHERE id=$1", key).
+		QueryRow(
+			ctx,
+			"SELECT name, kind, value FROM metrics WHERE tenant=$1 AND id=$2",
+			entity.TenantFromContext(ctx),
+			key,
+		).
 		Scan(&name, &kind, &value)
 
 	if err != nil {
@@ -128,14 +204,23 @@ func (d DatabaseStorage) Get(ctx context.Context, key string) (Record, error) {
 	}
 }
 
-// GetAll returns all stored metrics.
-func (d DatabaseStorage) GetAll(ctx context.Context) ([]Record, error) {
-	rows, err := d.pool.Query(ctx, "SELECT name, kind, value FROM metrics")
+// Delete removes stored metrics record, returns entity.ErrMetricNotFound if there is no such record.
+// Samples of the metrics are kept until removed according to history retention.
+func (d DatabaseStorage) Delete(ctx context.Context, key string) error {
+	tag, err := d.pool.Exec(ctx, "DELETE FROM metrics WHERE tenant=$1 AND id=$2", entity.TenantFromContext(ctx), key)
 	if err != nil {
-		return nil, fmt.Errorf("DatabaseStorage - GetAll - d.pool.Query: %w", err)
+		return fmt.Errorf("DatabaseStorage - Delete - d.pool.Exec: %w", err)
 	}
-	defer rows.Close()
 
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("DatabaseStorage - Delete - tag.RowsAffected: %w", entity.ErrMetricNotFound)
+	}
+
+	return nil
+}
+
+// scanRecords reads records from rows returned by the database.
+func scanRecords(rows pgx.Rows) ([]Record, error) {
 	var (
 		name  string
 		kind  string
@@ -143,28 +228,241 @@ func (d DatabaseStorage) GetAll(ctx context.Context) ([]Record, error) {
 	)
 
 	rv := make([]Record, 0)
-	_, err = pgx.ForEachRow(rows, []any{&name, &kind, &value}, func() error {
-		switch kind {
-		case metrics.KindCounter:
-			rv = append(rv, Record{Name: name, Value: metrics.Counter(value)})
-			return nil
+	_, err := pgx.ForEachRow(rows, []any{&name, &kind, &value}, func() error {
+		record, err := toRecord(name, kind, value)
+		if err != nil {
+			return err
+		}
 
-		case metrics.KindGauge:
-			rv = append(rv, Record{Name: name, Value: metrics.Gauge(value)})
-			return nil
+		rv = append(rv, record)
 
-		default:
-			return entity.MetricNotImplementedError(kind)
-		}
+		return nil
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("DatabaseStorage - GetAll - pgx.ForEachRow: %w", err)
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// scanMatchingRecords reads records which names match the pattern
+// until one record more than the limit is found, zero limit means no limit.
+func scanMatchingRecords(rows pgx.Rows, pattern *regexp.Regexp, limit int) ([]Record, error) {
+	var (
+		name  string
+		kind  string
+		value float64
+	)
+
+	rv := make([]Record, 0)
+
+	for rows.Next() {
+		if err := rows.Scan(&name, &kind, &value); err != nil {
+			return nil, err
+		}
+
+		if !pattern.MatchString(name) {
+			continue
+		}
+
+		record, err := toRecord(name, kind, value)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, record)
+
+		if limit != 0 && len(rv) > limit {
+			break
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// toRecord converts row of the metrics table into record.
+func toRecord(name, kind string, value float64) (Record, error) {
+	switch kind {
+	case metrics.KindCounter:
+		return Record{Name: name, Value: metrics.Counter(value)}, nil
+
+	case metrics.KindGauge:
+		return Record{Name: name, Value: metrics.Gauge(value)}, nil
+
+	default:
+		return Record{}, entity.MetricNotImplementedError(kind)
+	}
+}
+
+// GetAll returns all stored metrics.
+func (d DatabaseStorage) GetAll(ctx context.Context) ([]Record, error) {
+	tenant := entity.TenantFromContext(ctx)
+
+	rows, err := d.pool.Query(ctx, "SELECT name, kind, value FROM metrics WHERE tenant=$1", tenant)
+	if err != nil {
+		return nil, fmt.Errorf("DatabaseStorage - GetAll - d.pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	rv, err := scanRecords(rows)
+	if err != nil {
+		return nil, fmt.Errorf("DatabaseStorage - GetAll - scanRecords: %w", err)
+	}
+
+	return rv, nil
+}
+
+// Count returns count of stored series of the tenant.
+func (d DatabaseStorage) Count(ctx context.Context) (int, error) {
+	var rv int
+
+	err := d.pool.
+		QueryRow(ctx, "SELECT count(*) FROM metrics WHERE tenant=$1", entity.TenantFromContext(ctx)).
+		Scan(&rv)
+	if err != nil {
+		return 0, fmt.Errorf("DatabaseStorage - Count - d.pool.QueryRow: %w", err)
+	}
+
+	return rv, nil
+}
+
+// CountAll returns count of stored series of all tenants.
+func (d DatabaseStorage) CountAll(ctx context.Context) (int, error) {
+	var rv int
+
+	if err := d.pool.QueryRow(ctx, "SELECT count(*) FROM metrics").Scan(&rv); err != nil {
+		return 0, fmt.Errorf("DatabaseStorage - CountAll - d.pool.QueryRow: %w", err)
+	}
+
+	return rv, nil
+}
+
+// List returns single page of stored metrics selected according to the options.
+func (d DatabaseStorage) List(ctx context.Context, opts ListOptions) (Page, error) {
+	name, kind, err := decodeCursor(opts.Cursor)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// Postgres regular expressions differ from RE2 syntax used by other storage types,
+	// e.g. in meaning of \b, so names are matched on our side.
+	pattern, err := regexp.Compile(opts.Match)
+	if err != nil {
+		return Page{}, fmt.Errorf("DatabaseStorage - List - regexp.Compile: %w", entity.ErrInvalidPattern)
+	}
+
+	// Use "C" collation to keep the same bytewise order
+	// as other storage types have.
+	query := "SELECT name, kind, value FROM metrics WHERE tenant = $1 AND starts_with(name, $2)"
+	args := []any{entity.TenantFromContext(ctx), opts.Prefix}
+
+	if len(opts.Kind) != 0 {
+		args = append(args, opts.Kind)
+		query += fmt.Sprintf(" AND kind = $%d", len(args))
+	}
+
+	if len(name) != 0 {
+		args = append(args, name, kind)
+		query += fmt.Sprintf(` AND (name COLLATE "C", kind) > ($%d, $%d)`, len(args)-1, len(args))
+	}
+
+	query += ` ORDER BY name COLLATE "C", kind`
+
+	// Request one extra record to find out whether next page exists.
+	// Records not matching the pattern are skipped while reading, so the limit can't be applied to the query.
+	if opts.Limit != 0 && len(opts.Match) == 0 {
+		args = append(args, opts.Limit+1)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := d.pool.Query(ctx, query, args...)
+	if err != nil {
+		return Page{}, fmt.Errorf("DatabaseStorage - List - d.pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	records, err := scanMatchingRecords(rows, pattern, opts.Limit)
+	if err != nil {
+		return Page{}, fmt.Errorf("DatabaseStorage - List - scanMatchingRecords: %w", err)
+	}
+
+	return newPage(records, opts.Limit), nil
+}
+
+// PushSilence creates new silence or replaces existing one with the same ID.
+func (d DatabaseStorage) PushSilence(ctx context.Context, silence Silence) error {
+	_, err := d.pool.Exec(
+		ctx,
+		`INSERT INTO silences(id, matchers, starts_at, ends_at, created_by, comment)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			matchers = EXCLUDED.matchers,
+			starts_at = EXCLUDED.starts_at,
+			ends_at = EXCLUDED.ends_at,
+			created_by = EXCLUDED.created_by,
+			comment = EXCLUDED.comment`,
+		silence.ID,
+		silence.Matchers,
+		silence.StartsAt,
+		silence.EndsAt,
+		silence.CreatedBy,
+		silence.Comment,
+	)
+	if err != nil {
+		return fmt.Errorf("DatabaseStorage - PushSilence - d.pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// GetSilences returns all stored silences including expired ones.
+func (d DatabaseStorage) GetSilences(ctx context.Context) ([]Silence, error) {
+	rows, err := d.pool.Query(ctx, "SELECT id, matchers, starts_at, ends_at, created_by, comment FROM silences")
+	if err != nil {
+		return nil, fmt.Errorf("DatabaseStorage - GetSilences - d.pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var silence Silence
+
+	rv := make([]Silence, 0)
+	if _, err := pgx.ForEachRow(
+		rows,
+		[]any{&silence.ID, &silence.Matchers, &silence.StartsAt, &silence.EndsAt, &silence.CreatedBy, &silence.Comment},
+		func() error {
+			rv = append(rv, silence)
+
+			// Don't share matchers between silences.
+			silence.Matchers = nil
+
+			return nil
+		},
+	); err != nil {
+		return nil, fmt.Errorf("DatabaseStorage - GetSilences - pgx.ForEachRow: %w", err)
 	}
 
 	return rv, nil
 }
 
+// DeleteSilence removes silence, returns entity.ErrSilenceNotFound if there is no such silence.
+func (d DatabaseStorage) DeleteSilence(ctx context.Context, id string) error {
+	tag, err := d.pool.Exec(ctx, "DELETE FROM silences WHERE id=$1", id)
+	if err != nil {
+		return fmt.Errorf("DatabaseStorage - DeleteSilence - d.pool.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("DatabaseStorage - DeleteSilence - tag.RowsAffected: %w", entity.ErrSilenceNotFound)
+	}
+
+	return nil
+}
+
 // Ping verifies that connection to the database can be established.
 func (d DatabaseStorage) Ping(ctx context.Context) error {
 	if err := d.pool.Ping(ctx); err != nil {
@@ -174,8 +472,92 @@ func (d DatabaseStorage) Ping(ctx context.Context) error {
 	return nil
 }
 
-// Close closes all open connection to the database.
+// Subscribe returns channel receiving all records of the tenant pushed to the storage
+// which match the filter. The channel is closed as soon as provided context is done.
+// The updates are delivered by Postgres LISTEN/NOTIFY, thus changes
+// made by other instances sharing the same database are received as well.
+func (d DatabaseStorage) Subscribe(ctx context.Context, filter Filter) <-chan Record {
+	d.listener.Lock()
+	defer d.listener.Unlock()
+
+	if d.listener.cancel == nil {
+		listenCtx, cancel := context.WithCancel(context.Background())
+		d.listener.cancel = cancel
+
+		go d.listen(listenCtx)
+	}
+
+	return d.feeds.get(entity.TenantFromContext(ctx)).Subscribe(ctx, filter)
+}
+
+// listen receives notifications about metrics updates and publishes them
+// to subscribers. Lost connections are reestablished until the context is done.
+func (d DatabaseStorage) listen(ctx context.Context) {
+	for {
+		err := d.waitForNotifications(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Error().Err(err).Msg("DatabaseStorage - listen - d.waitForNotifications")
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-time.After(_listenRetryInterval):
+		}
+	}
+}
+
+func (d DatabaseStorage) waitForNotifications(ctx context.Context) error {
+	pooledConn, err := d.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("DatabaseStorage - waitForNotifications - d.pool.Acquire: %w", err)
+	}
+
+	// The connection is kept in listening state, so it must
+	// not be returned back to the pool.
+	conn := pooledConn.Hijack()
+	defer func() {
+		if err := conn.Close(context.Background()); err != nil {
+			log.Error().Err(err).Msg("DatabaseStorage - waitForNotifications - conn.Close")
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+_updatesChannel); err != nil {
+		return fmt.Errorf("DatabaseStorage - waitForNotifications - conn.Exec: %w", err)
+	}
+
+	for {
+		msg, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("DatabaseStorage - waitForNotifications - conn.WaitForNotification: %w", err)
+		}
+
+		var (
+			record Record
+			update notification
+		)
+
+		if err := json.Unmarshal([]byte(msg.Payload), &record); err != nil {
+			log.Error().Err(err).Msg("DatabaseStorage - waitForNotifications - json.Unmarshal")
+			continue
+		}
+
+		if err := json.Unmarshal([]byte(msg.Payload), &update); err != nil {
+			log.Error().Err(err).Msg("DatabaseStorage - waitForNotifications - json.Unmarshal")
+			continue
+		}
+
+		d.feeds.get(update.Tenant).Publish(record)
+	}
+}
+
+// Close stops listening for updates and closes all open connection to the database.
 func (d DatabaseStorage) Close(_ context.Context) error {
+	d.listener.stop()
 	d.pool.Close()
+
 	return nil
 }
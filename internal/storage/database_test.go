@@ -2,12 +2,16 @@ package storage_test
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPing(t *testing.T) {
@@ -43,3 +47,200 @@ func TestCloseNeverFails(t *testing.T) {
 	s := storage.NewDatabaseStorage(m)
 	assert.NoError(t, s.Close(context.Background()))
 }
+
+func TestMaintainPartitions(t *testing.T) {
+	now := time.Date(2023, 3, 10, 15, 4, 5, 0, time.UTC)
+
+	tt := []struct {
+		name       string
+		retention  time.Duration
+		partitions []string
+		dropped    []string
+	}{
+		{
+			name:       "Should create upcoming partitions and keep history without retention",
+			partitions: []string{"samples_default", "samples_20230101"},
+		},
+		{
+			name:      "Should drop expired partitions",
+			retention: 48 * time.Hour,
+			partitions: []string{
+				"samples_default",
+				"samples_20230307",
+				"samples_20230308",
+				"samples_20230309",
+				"samples_20230310",
+			},
+			dropped: []string{"samples_20230307"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			rows := make([][]any, 0, len(tc.partitions))
+			for _, name := range tc.partitions {
+				rows = append(rows, []any{name})
+			}
+
+			m := storage.NewDBConnPoolMock()
+			m.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, nil)
+			m.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(storage.NewRowsMock(rows...), nil)
+
+			s := storage.NewDatabaseStorage(m)
+			err := s.MaintainPartitions(context.Background(), now, tc.retention)
+			require.NoError(err)
+
+			for _, day := range []string{"20230310", "20230311", "20230312", "20230313"} {
+				m.AssertCalled(t, "Exec", mock.Anything, mock.MatchedBy(func(sql string) bool {
+					return strings.Contains(sql, "CREATE TABLE samples_"+day+" (LIKE samples") &&
+						strings.Contains(sql, "DELETE FROM samples_default") &&
+						strings.Contains(sql, "ATTACH PARTITION samples_"+day)
+				}), mock.Anything)
+			}
+
+			purges := 0
+			if tc.retention != 0 {
+				purges = 1
+
+				m.AssertCalled(
+					t,
+					"Exec",
+					mock.Anything,
+					"DELETE FROM samples_default WHERE recorded_at < $1",
+					[]any{now.Add(-tc.retention)},
+				)
+			}
+
+			for _, name := range tc.dropped {
+				m.AssertCalled(t, "Exec", mock.Anything, `DROP TABLE IF EXISTS "`+name+`"`, mock.Anything)
+			}
+
+			m.AssertNumberOfCalls(t, "Exec", 4+purges+len(tc.dropped))
+		})
+	}
+}
+
+func TestMaintainPartitionsContinuesOnFailure(t *testing.T) {
+	m := storage.NewDBConnPoolMock()
+	m.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, entity.ErrUnexpected)
+	m.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(storage.NewRowsMock(), nil)
+
+	s := storage.NewDatabaseStorage(m)
+	err := s.MaintainPartitions(context.Background(), time.Now(), 24*time.Hour)
+	require.ErrorIs(t, err, entity.ErrUnexpected)
+
+	m.AssertNumberOfCalls(t, "Exec", 5)
+}
+
+func TestGetSilences(t *testing.T) {
+	require := require.New(t)
+
+	startsAt := time.Date(2023, 3, 10, 15, 0, 0, 0, time.UTC)
+	endsAt := startsAt.Add(time.Hour)
+
+	m := storage.NewDBConnPoolMock()
+	m.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(storage.NewRowsMock(
+		[]any{"1", []string{"alertname=HighCpu*"}, startsAt, endsAt, "admin", "deploy"},
+		[]any{"2", []string{"metric=HeapInuse", "severity=info"}, startsAt, endsAt, "", ""},
+	), nil)
+
+	s := storage.NewDatabaseStorage(m)
+	silences, err := s.GetSilences(context.Background())
+	require.NoError(err)
+
+	require.Equal([]storage.Silence{
+		{
+			ID:        "1",
+			Matchers:  []string{"alertname=HighCpu*"},
+			StartsAt:  startsAt,
+			EndsAt:    endsAt,
+			CreatedBy: "admin",
+			Comment:   "deploy",
+		},
+		{
+			ID:       "2",
+			Matchers: []string{"metric=HeapInuse", "severity=info"},
+			StartsAt: startsAt,
+			EndsAt:   endsAt,
+		},
+	}, silences)
+}
+
+func TestDeleteSilence(t *testing.T) {
+	tt := []struct {
+		name     string
+		tag      string
+		expected error
+	}{
+		{
+			name: "Should delete existing silence",
+			tag:  "DELETE 1",
+		},
+		{
+			name:     "Should fail if silence not found",
+			tag:      "DELETE 0",
+			expected: entity.ErrSilenceNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := storage.NewDBConnPoolMock()
+			m.On("Exec", mock.Anything, "DELETE FROM silences WHERE id=$1", mock.Anything).
+				Return(pgconn.NewCommandTag(tc.tag), nil)
+
+			s := storage.NewDatabaseStorage(m)
+			err := s.DeleteSilence(context.Background(), "1")
+
+			if tc.expected == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestDeleteMetric(t *testing.T) {
+	tt := []struct {
+		name     string
+		tag      string
+		expected error
+	}{
+		{
+			name: "Should delete existing metric",
+			tag:  "DELETE 1",
+		},
+		{
+			name:     "Should fail if metric not found",
+			tag:      "DELETE 0",
+			expected: entity.ErrMetricNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := storage.NewDBConnPoolMock()
+			m.On(
+				"Exec",
+				mock.Anything,
+				"DELETE FROM metrics WHERE tenant=$1 AND id=$2",
+				[]any{"acme", "PollCount_counter"},
+			).
+				Return(pgconn.NewCommandTag(tc.tag), nil)
+
+			s := storage.NewDatabaseStorage(m)
+			err := s.Delete(entity.WithTenant(context.Background(), "acme"), "PollCount_counter")
+
+			if tc.expected == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,6 +13,13 @@ import (
 
 var _ Storage = (*FileBackedStorage)(nil)
 
+// A Sealer encrypts storage snapshots before writing them to disk
+// and decrypts them on restore.
+type Sealer interface {
+	Seal(data []byte) ([]byte, error)
+	Open(data []byte) ([]byte, error)
+}
+
 // FileBackedStorage implements in-memory metrics storage with ability to
 // dump/restore metrics data to/from disk.
 type FileBackedStorage struct {
@@ -23,14 +31,18 @@ type FileBackedStorage struct {
 
 	// Dump recorded data on each push of a record.
 	syncMode bool
+
+	// Encrypts snapshots, nil value means that snapshots are stored in plain text.
+	sealer Sealer
 }
 
 // NewFileBackedStorage creates new instance of FileBackedStorage.
-func NewFileBackedStorage(storePath string, syncMode bool) *FileBackedStorage {
+func NewFileBackedStorage(storePath string, syncMode bool, sealer Sealer) *FileBackedStorage {
 	return &FileBackedStorage{
 		MemStorage: NewMemStorage(),
 		storePath:  storePath,
 		syncMode:   syncMode,
+		sealer:     sealer,
 	}
 }
 
@@ -60,37 +72,76 @@ func (f *FileBackedStorage) PushBatch(ctx context.Context, data map[string]Recor
 	return nil
 }
 
+// Delete removes stored metrics record, returns entity.ErrMetricNotFound if there is no such record.
+func (f *FileBackedStorage) Delete(ctx context.Context, key string) error {
+	if err := f.MemStorage.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	if f.syncMode {
+		return f.Dump(ctx)
+	}
+
+	return nil
+}
+
+// PushSilence creates new silence or replaces existing one with the same ID.
+func (f *FileBackedStorage) PushSilence(ctx context.Context, silence Silence) error {
+	if err := f.MemStorage.PushSilence(ctx, silence); err != nil {
+		return err
+	}
+
+	if f.syncMode {
+		return f.Dump(ctx)
+	}
+
+	return nil
+}
+
+// DeleteSilence removes silence, returns entity.ErrSilenceNotFound if there is no such silence.
+func (f *FileBackedStorage) DeleteSilence(ctx context.Context, id string) error {
+	if err := f.MemStorage.DeleteSilence(ctx, id); err != nil {
+		return err
+	}
+
+	if f.syncMode {
+		return f.Dump(ctx)
+	}
+
+	return nil
+}
+
 // Close dumps all stored data to disk. The storage can be restored from this dump later.
 func (f *FileBackedStorage) Close(ctx context.Context) error {
 	return f.Dump(ctx)
 }
 
 // Restore reads previously stored data from disk and populates the storage.
-func (f *FileBackedStorage) Restore() (err error) {
+func (f *FileBackedStorage) Restore() error {
 	f.Lock()
 	defer f.Unlock()
 
 	log.Info().Msg("Restoring storage data from " + f.storePath)
 
-	file, err := os.Open(f.storePath)
+	data, err := os.ReadFile(f.storePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			log.Warn().Msg("No storage dump found, data restoration is not possible")
 			return nil
 		}
 
-		return fmt.Errorf("FileBackedStorage - Restore - os.Open: %w", err)
+		return fmt.Errorf("FileBackedStorage - Restore - os.ReadFile: %w", err)
 	}
 
-	defer func() {
-		if dErr := file.Close(); err == nil && dErr != nil {
-			err = fmt.Errorf("FileBackedStorage - Restore - file.Close: %w", dErr)
+	if f.sealer != nil {
+		data, err = f.sealer.Open(data)
+		if err != nil {
+			return fmt.Errorf("FileBackedStorage - Restore - f.sealer.Open: %w", err)
 		}
-	}()
+	}
 
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(f.MemStorage); err != nil {
-		return fmt.Errorf("FileBackedStorage - Restore - decoder.Decode: %w", err)
+	if err := json.Unmarshal(data, f.MemStorage); err != nil {
+		return fmt.Errorf("FileBackedStorage - Restore - json.Unmarshal: %w", err)
 	}
 
 	log.Info().Msg("Storage data was successfully restored")
@@ -105,23 +156,26 @@ func (f *FileBackedStorage) Dump(ctx context.Context) (err error) {
 
 	log.Ctx(ctx).Info().Msg("Pushing storage data to " + f.storePath)
 
-	file, err := os.OpenFile(f.storePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("FileBackedStorage - Dump - os.OpenFile: %w", err)
-	}
-
-	defer func() {
-		if dErr := file.Close(); err == nil && dErr != nil {
-			err = fmt.Errorf("FileBackedStorage - Dump - file.Close: %w", dErr)
-		}
-	}()
-
-	encoder := json.NewEncoder(file)
+	data := new(bytes.Buffer)
+	encoder := json.NewEncoder(data)
 	snapshot := f.Snapshot()
 
 	if err := encoder.Encode(snapshot); err != nil {
 		return fmt.Errorf("FileBackedStorage - Dump - encoder.Encode: %w", err)
 	}
 
+	payload := data.Bytes()
+
+	if f.sealer != nil {
+		payload, err = f.sealer.Seal(payload)
+		if err != nil {
+			return fmt.Errorf("FileBackedStorage - Dump - f.sealer.Seal: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(f.storePath, payload, 0600); err != nil {
+		return fmt.Errorf("FileBackedStorage - Dump - os.WriteFile: %w", err)
+	}
+
 	return nil
 }
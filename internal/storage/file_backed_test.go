@@ -3,8 +3,13 @@ package storage_test
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/security"
+	"github.com/alkurbatov/metrics-collector/internal/security/securitytest"
 	"github.com/alkurbatov/metrics-collector/internal/storage"
 	"github.com/alkurbatov/metrics-collector/pkg/metrics"
 	"github.com/stretchr/testify/require"
@@ -14,11 +19,12 @@ func createStoreWithData(
 	t *testing.T,
 	storePath string,
 	syncMode bool,
+	sealer storage.Sealer,
 ) *storage.FileBackedStorage {
 	t.Helper()
 
 	ctx := context.Background()
-	store := storage.NewFileBackedStorage(storePath, syncMode)
+	store := storage.NewFileBackedStorage(storePath, syncMode, sealer)
 
 	batch := map[string]storage.Record{
 		"PollCount_counter": {Name: "PollCount", Value: metrics.Counter(10)},
@@ -52,10 +58,10 @@ func TestSyncDumpRestoreStorage(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	store := createStoreWithData(t, storePath, true)
+	store := createStoreWithData(t, storePath, true, nil)
 	storedData := store.Snapshot()
 
-	store = storage.NewFileBackedStorage(storePath, true)
+	store = storage.NewFileBackedStorage(storePath, true, nil)
 	err := store.Restore()
 	require.NoError(t, err)
 
@@ -71,13 +77,13 @@ func TestAsyncDumpRestoreStorage(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	store := createStoreWithData(t, storePath, false)
+	store := createStoreWithData(t, storePath, false, nil)
 	storedData := store.Snapshot()
 
 	err := store.Close(context.Background())
 	require.NoError(t, err)
 
-	store = storage.NewFileBackedStorage(storePath, false)
+	store = storage.NewFileBackedStorage(storePath, false, nil)
 	err = store.Restore()
 	require.NoError(t, err)
 
@@ -86,8 +92,106 @@ func TestAsyncDumpRestoreStorage(t *testing.T) {
 }
 
 func TestRestoreDoesntFailIfNoSourceFile(t *testing.T) {
-	store := storage.NewFileBackedStorage("xxx", false)
+	store := storage.NewFileBackedStorage("xxx", false, nil)
 
 	err := store.Restore()
 	require.NoError(t, err)
 }
+
+func createKeyring(t *testing.T, oldKeys ...entity.FilePath) (*security.Keyring, entity.FilePath) {
+	t.Helper()
+
+	path := securitytest.StoreKey(t, 32)
+
+	keyring, err := security.NewKeyring(path, oldKeys...)
+	require.NoError(t, err)
+
+	return keyring, path
+}
+
+func TestEncryptedDumpRestoreStorage(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "encrypted-dump-restore.json")
+	keyring, _ := createKeyring(t)
+
+	store := createStoreWithData(t, storePath, true, keyring)
+	storedData := store.Snapshot()
+
+	raw, err := os.ReadFile(storePath)
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), "PollCount")
+
+	store = storage.NewFileBackedStorage(storePath, true, keyring)
+	err = store.Restore()
+	require.NoError(t, err)
+
+	restoredData := store.Snapshot()
+	require.Equal(t, storedData, restoredData)
+}
+
+func TestRestoreEncryptedWithOldKey(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "rotated-dump-restore.json")
+	oldKeyring, oldKeyPath := createKeyring(t)
+
+	store := createStoreWithData(t, storePath, true, oldKeyring)
+	storedData := store.Snapshot()
+
+	keyring, _ := createKeyring(t, oldKeyPath)
+	store = storage.NewFileBackedStorage(storePath, true, keyring)
+	err := store.Restore()
+	require.NoError(t, err)
+
+	restoredData := store.Snapshot()
+	require.Equal(t, storedData, restoredData)
+}
+
+func TestRestorePlainSnapshotWithEncryptionEnabled(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "plain-dump-restore.json")
+
+	store := createStoreWithData(t, storePath, true, nil)
+	storedData := store.Snapshot()
+
+	keyring, _ := createKeyring(t)
+	store = storage.NewFileBackedStorage(storePath, true, keyring)
+	err := store.Restore()
+	require.NoError(t, err)
+
+	restoredData := store.Snapshot()
+	require.Equal(t, storedData, restoredData)
+}
+
+func TestRestoreFailsWithUnknownKey(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "unknown-key-restore.json")
+	oldKeyring, _ := createKeyring(t)
+
+	createStoreWithData(t, storePath, true, oldKeyring)
+
+	keyring, _ := createKeyring(t)
+	store := storage.NewFileBackedStorage(storePath, true, keyring)
+	err := store.Restore()
+	require.ErrorIs(t, err, entity.ErrUnknownStoreKey)
+}
+
+func TestSyncDumpRestoreSilences(t *testing.T) {
+	require := require.New(t)
+	storePath := filepath.Join(t.TempDir(), "silences.json")
+	ctx := context.Background()
+
+	silence := storage.Silence{
+		ID:       "1",
+		Matchers: []string{"alertname=HighCpu*"},
+		StartsAt: time.Unix(1000, 0).UTC(),
+		EndsAt:   time.Unix(2000, 0).UTC(),
+	}
+
+	store := storage.NewFileBackedStorage(storePath, true, nil)
+	require.NoError(store.PushSilence(ctx, silence))
+	require.NoError(store.PushSilence(ctx, storage.Silence{ID: "2", Matchers: []string{"metric=Alloc"}}))
+	require.NoError(store.DeleteSilence(ctx, "2"))
+
+	store = storage.NewFileBackedStorage(storePath, true, nil)
+	require.NoError(store.Restore())
+
+	silences, err := store.GetSilences(ctx)
+	require.NoError(err)
+	require.Equal([]storage.Silence{silence}, silences)
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+)
+
+// Separates name and kind of a record inside a cursor.
+const _cursorSeparator = ":"
+
+// ListOptions narrows down and paginates list of stored records.
+// The records are always ordered by name and kind.
+type ListOptions struct {
+	// Select only metrics which names start with the prefix.
+	Prefix string
+
+	// Select only metrics which names match regular expression, see NamePattern.
+	Match string
+
+	// Select only metrics of specified kind, e.g. "counter".
+	Kind string
+
+	// Maximal count of records on single page, zero value means no limit.
+	Limit int
+
+	// Opaque position in the list returned by previous call,
+	// empty value means the first page.
+	Cursor string
+}
+
+// A Page represents part of the list of stored records.
+type Page struct {
+	Records []Record
+
+	// Cursor pointing to the next page, empty if there are no more records.
+	NextCursor string
+}
+
+// globToRegexp converts glob into regular expression matching whole name.
+// Wildcard '*' matches any sequence of characters, '?' matches single character.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+
+	sb.WriteString("^")
+
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+
+		case '?':
+			sb.WriteString(".")
+
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	sb.WriteString("$")
+
+	return sb.String()
+}
+
+// NamePattern creates regular expression selecting metrics by name
+// either from glob or from regular expression, but not from both of them.
+// Unlike glob, regular expression matches any part of the name unless anchored.
+func NamePattern(glob, expr string) (string, error) {
+	if len(glob) != 0 && len(expr) != 0 {
+		return "", fmt.Errorf("%w: glob and regular expression are mutually exclusive", entity.ErrInvalidPattern)
+	}
+
+	if len(glob) != 0 {
+		return globToRegexp(glob), nil
+	}
+
+	if _, err := regexp.Compile(expr); err != nil {
+		return "", fmt.Errorf("%w: %s", entity.ErrInvalidPattern, err)
+	}
+
+	return expr, nil
+}
+
+// encodeCursor creates cursor pointing to the records following the provided one.
+func encodeCursor(record Record) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(record.Name + _cursorSeparator + record.Value.Kind()))
+}
+
+// decodeCursor extracts name and kind of the last record from previous page.
+func decodeCursor(cursor string) (name, kind string, err error) {
+	if len(cursor) == 0 {
+		return "", "", nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", fmt.Errorf("storage - decodeCursor - base64.DecodeString: %w", entity.ErrInvalidCursor)
+	}
+
+	pos := strings.LastIndex(string(raw), _cursorSeparator)
+	if pos == -1 {
+		return "", "", fmt.Errorf("storage - decodeCursor - strings.LastIndex: %w", entity.ErrInvalidCursor)
+	}
+
+	return string(raw[:pos]), string(raw[pos+1:]), nil
+}
+
+// less defines order of records in the list.
+func less(name, kind, otherName, otherKind string) bool {
+	if name != otherName {
+		return name < otherName
+	}
+
+	return kind < otherKind
+}
+
+// paginate selects single page from the list of records according to the options.
+// The records must be already filtered.
+func paginate(records []Record, opts ListOptions) (Page, error) {
+	name, kind, err := decodeCursor(opts.Cursor)
+	if err != nil {
+		return Page{}, err
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return less(records[i].Name, records[i].Value.Kind(), records[j].Name, records[j].Value.Kind())
+	})
+
+	start := sort.Search(len(records), func(i int) bool {
+		return less(name, kind, records[i].Name, records[i].Value.Kind())
+	})
+
+	return newPage(records[start:], opts.Limit), nil
+}
+
+// newPage cuts off records exceeding the limit and creates cursor pointing to them.
+func newPage(records []Record, limit int) Page {
+	if limit == 0 || len(records) <= limit {
+		return Page{Records: records}
+	}
+
+	records = records[:limit]
+
+	return Page{Records: records, NextCursor: encodeCursor(records[len(records)-1])}
+}
+
+// MergePages combines pages of records retrieved with the same options from several sources,
+// e.g. from nodes of a cluster, into single page ordered by name and kind.
+func MergePages(pages []Page, limit int) Page {
+	records := make([]Record, 0)
+	hasMore := false
+
+	for _, page := range pages {
+		records = append(records, page.Records...)
+		hasMore = hasMore || len(page.NextCursor) != 0
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return less(records[i].Name, records[i].Value.Kind(), records[j].Name, records[j].Value.Kind())
+	})
+
+	// A source might have more records even if the merged page isn't full.
+	if hasMore && len(records) == limit {
+		return Page{Records: records, NextCursor: encodeCursor(records[len(records)-1])}
+	}
+
+	return newPage(records, limit)
+}
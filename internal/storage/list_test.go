@@ -0,0 +1,252 @@
+package storage_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamePattern(t *testing.T) {
+	tt := []struct {
+		name     string
+		glob     string
+		expr     string
+		expected string
+		err      error
+	}{
+		{
+			name:     "Should accept empty pattern",
+			expected: "",
+		},
+		{
+			name:     "Should convert glob to anchored regular expression",
+			glob:     "Heap*",
+			expected: "^Heap.*$",
+		},
+		{
+			name:     "Should convert single character wildcard",
+			glob:     "Gc?PU",
+			expected: "^Gc.PU$",
+		},
+		{
+			name:     "Should escape special characters of glob",
+			glob:     "X.Y+",
+			expected: `^X\.Y\+$`,
+		},
+		{
+			name:     "Should pass regular expression as is",
+			expr:     "^(Heap|Stack)",
+			expected: "^(Heap|Stack)",
+		},
+		{
+			name: "Should fail on invalid regular expression",
+			expr: "Heap(",
+			err:  entity.ErrInvalidPattern,
+		},
+		{
+			name: "Should fail if both glob and regular expression provided",
+			glob: "Heap*",
+			expr: "Heap",
+			err:  entity.ErrInvalidPattern,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			pattern, err := storage.NamePattern(tc.glob, tc.expr)
+
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, pattern)
+		})
+	}
+}
+
+// listStorages returns storages of each type filled with the same records.
+// Database returns the records, which satisfy kind and cursor of the options, in the storage order,
+// as the query does.
+func listStorages(t *testing.T, records []storage.Record, opts storage.ListOptions) map[string]storage.Storage {
+	t.Helper()
+
+	mem := storage.NewMemStorage()
+	data := make(map[string]storage.Record, len(records))
+
+	rows := make([][]any, 0, len(records))
+
+	for _, record := range records {
+		data[record.Name+"_"+record.Value.Kind()] = record
+
+		if len(opts.Kind) != 0 && record.Value.Kind() != opts.Kind {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(record.Value.String(), 64)
+		require.NoError(t, err)
+
+		rows = append(rows, []any{record.Name, record.Value.Kind(), value})
+	}
+
+	require.NoError(t, mem.PushBatch(context.Background(), data))
+
+	pool := storage.NewDBConnPoolMock()
+	pool.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(storage.NewRowsMock(rows...), nil)
+
+	return map[string]storage.Storage{
+		"memory":   mem,
+		"database": storage.NewDatabaseStorage(pool),
+	}
+}
+
+func TestListMatchesNames(t *testing.T) {
+	// Ordered as returned by the database.
+	records := []storage.Record{
+		{Name: "HeapAlloc", Value: metrics.Gauge(1)},
+		{Name: "HeapIdle", Value: metrics.Gauge(2)},
+		{Name: "PollCount", Value: metrics.Counter(4)},
+		{Name: "StackInuse", Value: metrics.Gauge(3)},
+	}
+
+	tt := []struct {
+		name     string
+		opts     storage.ListOptions
+		expected []string
+		next     bool
+	}{
+		{
+			name:     "Should select metrics matching glob",
+			opts:     storage.ListOptions{Match: "^Heap.*$"},
+			expected: []string{"HeapAlloc", "HeapIdle"},
+		},
+		{
+			name:     "Should select metrics matching part of the name",
+			opts:     storage.ListOptions{Match: "Inuse|Count"},
+			expected: []string{"PollCount", "StackInuse"},
+		},
+		{
+			name:     "Should combine pattern with kind",
+			opts:     storage.ListOptions{Match: "o", Kind: metrics.KindCounter},
+			expected: []string{"PollCount"},
+		},
+		{
+			name:     "Should match word boundary as RE2 does",
+			opts:     storage.ListOptions{Match: `Count\b`},
+			expected: []string{"PollCount"},
+		},
+		{
+			name:     "Should match Perl character classes as RE2 does",
+			opts:     storage.ListOptions{Match: `^\PLHeap`},
+			expected: []string{},
+		},
+		{
+			name:     "Should apply limit to matching metrics only",
+			opts:     storage.ListOptions{Match: "l", Limit: 1},
+			expected: []string{"HeapAlloc"},
+			next:     true,
+		},
+		{
+			name:     "Should not report next page if all matching metrics fit the limit",
+			opts:     storage.ListOptions{Match: "Stack", Limit: 1},
+			expected: []string{"StackInuse"},
+		},
+	}
+
+	for _, tc := range tt {
+		for kind, s := range listStorages(t, records, tc.opts) {
+			t.Run(kind+"/"+tc.name, func(t *testing.T) {
+				page, err := s.List(context.Background(), tc.opts)
+				require.NoError(t, err)
+
+				names := make([]string, 0, len(page.Records))
+				for _, record := range page.Records {
+					names = append(names, record.Name)
+				}
+
+				require.Equal(t, tc.expected, names)
+				require.Equal(t, tc.next, len(page.NextCursor) != 0)
+			})
+		}
+	}
+}
+
+func TestListRejectsInvalidPattern(t *testing.T) {
+	opts := storage.ListOptions{Match: "Heap("}
+
+	for kind, s := range listStorages(t, nil, opts) {
+		t.Run(kind, func(t *testing.T) {
+			_, err := s.List(context.Background(), opts)
+			require.ErrorIs(t, err, entity.ErrInvalidPattern)
+		})
+	}
+}
+
+func TestMergePages(t *testing.T) {
+	tt := []struct {
+		name     string
+		pages    []storage.Page
+		limit    int
+		expected []string
+		hasMore  bool
+	}{
+		{
+			name: "Should merge pages in order of names",
+			pages: []storage.Page{
+				{Records: []storage.Record{{Name: "Alloc", Value: metrics.Gauge(1)}, {Name: "Frees", Value: metrics.Gauge(3)}}},
+				{Records: []storage.Record{{Name: "Count", Value: metrics.Counter(2)}}},
+			},
+			expected: []string{"Alloc", "Count", "Frees"},
+		},
+		{
+			name: "Should cut off records exceeding the limit",
+			pages: []storage.Page{
+				{Records: []storage.Record{{Name: "Alloc", Value: metrics.Gauge(1)}, {Name: "Frees", Value: metrics.Gauge(3)}}},
+				{Records: []storage.Record{{Name: "Count", Value: metrics.Counter(2)}}},
+			},
+			limit:    2,
+			expected: []string{"Alloc", "Count"},
+			hasMore:  true,
+		},
+		{
+			name: "Should keep cursor if source has more records",
+			pages: []storage.Page{
+				{Records: []storage.Record{{Name: "Alloc", Value: metrics.Gauge(1)}}, NextCursor: "cursor"},
+				{Records: []storage.Record{}},
+			},
+			limit:    1,
+			expected: []string{"Alloc"},
+			hasMore:  true,
+		},
+		{
+			name: "Should not set cursor if all records fit the limit",
+			pages: []storage.Page{
+				{Records: []storage.Record{{Name: "Alloc", Value: metrics.Gauge(1)}}},
+				{Records: []storage.Record{{Name: "Count", Value: metrics.Counter(2)}}},
+			},
+			limit:    2,
+			expected: []string{"Alloc", "Count"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			page := storage.MergePages(tc.pages, tc.limit)
+
+			names := make([]string, 0, len(page.Records))
+			for _, record := range page.Records {
+				names = append(names, record.Name)
+			}
+
+			require.Equal(t, tc.expected, names)
+			require.Equal(t, tc.hasMore, len(page.NextCursor) != 0)
+		})
+	}
+}
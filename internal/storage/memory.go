@@ -2,6 +2,9 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
@@ -11,14 +14,20 @@ var _ Storage = (*MemStorage)(nil)
 
 // MemStorage implements in-memory metrics storage.
 type MemStorage struct {
-	Data map[string]Record `json:"records"`
+	Data     map[string]Record  `json:"records"`
+	Silences map[string]Silence `json:"silences"`
 	sync.RWMutex
+
+	// Notifies subscribers about pushed records.
+	feed *Broadcaster
 }
 
 // NewMemStorage creates new instance of MemStorage.
 func NewMemStorage() *MemStorage {
 	return &MemStorage{
-		Data: make(map[string]Record),
+		Data:     make(map[string]Record),
+		Silences: make(map[string]Silence),
+		feed:     NewBroadcaster(),
 	}
 }
 
@@ -28,6 +37,7 @@ func (m *MemStorage) Push(_ context.Context, key string, record Record) error {
 	defer m.Unlock()
 
 	m.Data[key] = record
+	m.feed.Publish(record)
 
 	return nil
 }
@@ -39,6 +49,7 @@ func (m *MemStorage) PushBatch(_ context.Context, data map[string]Record) error
 
 	for id, record := range data {
 		m.Data[id] = record
+		m.feed.Publish(record)
 	}
 
 	return nil
@@ -57,6 +68,20 @@ func (m *MemStorage) Get(_ context.Context, key string) (Record, error) {
 	return record, nil
 }
 
+// Delete removes stored metrics record, returns entity.ErrMetricNotFound if there is no such record.
+func (m *MemStorage) Delete(_ context.Context, key string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.Data[key]; !ok {
+		return entity.ErrMetricNotFound
+	}
+
+	delete(m.Data, key)
+
+	return nil
+}
+
 // GetAll returns all stored metrics.
 func (m *MemStorage) GetAll(_ context.Context) ([]Record, error) {
 	m.RLock()
@@ -73,6 +98,108 @@ func (m *MemStorage) GetAll(_ context.Context) ([]Record, error) {
 	return rv, nil
 }
 
+// Count returns count of stored series.
+func (m *MemStorage) Count(_ context.Context) (int, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	return len(m.Data), nil
+}
+
+// CountAll returns count of stored series, as MemStorage keeps metrics of single tenant.
+func (m *MemStorage) CountAll(ctx context.Context) (int, error) {
+	return m.Count(ctx)
+}
+
+// List returns single page of stored metrics selected according to the options.
+func (m *MemStorage) List(_ context.Context, opts ListOptions) (Page, error) {
+	records, err := m.filter(opts)
+	if err != nil {
+		return Page{}, err
+	}
+
+	return paginate(records, opts)
+}
+
+// filter returns all stored metrics matching prefix, pattern and kind from the options.
+func (m *MemStorage) filter(opts ListOptions) ([]Record, error) {
+	pattern, err := regexp.Compile(opts.Match)
+	if err != nil {
+		return nil, fmt.Errorf("MemStorage - filter - regexp.Compile: %w", entity.ErrInvalidPattern)
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+
+	rv := make([]Record, 0)
+
+	for _, v := range m.Data {
+		if !strings.HasPrefix(v.Name, opts.Prefix) {
+			continue
+		}
+
+		if !pattern.MatchString(v.Name) {
+			continue
+		}
+
+		if len(opts.Kind) != 0 && v.Value.Kind() != opts.Kind {
+			continue
+		}
+
+		rv = append(rv, v)
+	}
+
+	return rv, nil
+}
+
+// PushSilence creates new silence or replaces existing one with the same ID.
+func (m *MemStorage) PushSilence(_ context.Context, silence Silence) error {
+	m.Lock()
+	defer m.Unlock()
+
+	// Snapshots created before silences were introduced don't contain them.
+	if m.Silences == nil {
+		m.Silences = make(map[string]Silence)
+	}
+
+	m.Silences[silence.ID] = silence
+
+	return nil
+}
+
+// GetSilences returns all stored silences including expired ones.
+func (m *MemStorage) GetSilences(_ context.Context) ([]Silence, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	rv := make([]Silence, 0, len(m.Silences))
+	for _, v := range m.Silences {
+		rv = append(rv, v)
+	}
+
+	return rv, nil
+}
+
+// DeleteSilence removes silence, returns entity.ErrSilenceNotFound if there is no such silence.
+func (m *MemStorage) DeleteSilence(_ context.Context, id string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.Silences[id]; !ok {
+		return entity.ErrSilenceNotFound
+	}
+
+	delete(m.Silences, id)
+
+	return nil
+}
+
+// Subscribe returns channel receiving all records pushed to the storage
+// which match the filter. The channel is closed as soon as provided context is done.
+func (m *MemStorage) Subscribe(ctx context.Context, filter Filter) <-chan Record {
+	return m.feed.Subscribe(ctx, filter)
+}
+
 // Close has no effect on in-memory storage.
 func (m *MemStorage) Close(_ context.Context) error {
 	return nil // noop
@@ -89,5 +216,11 @@ func (m *MemStorage) Snapshot() *MemStorage {
 		snapshot[k] = v
 	}
 
-	return &MemStorage{Data: snapshot}
+	silences := make(map[string]Silence, len(m.Silences))
+
+	for k, v := range m.Silences {
+		silences[k] = v
+	}
+
+	return &MemStorage{Data: snapshot, Silences: silences}
 }
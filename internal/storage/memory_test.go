@@ -3,6 +3,7 @@ package storage_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/alkurbatov/metrics-collector/internal/storage"
@@ -144,3 +145,71 @@ func TestCloseIsNoop(t *testing.T) {
 	m := storage.NewMemStorage()
 	assert.NoError(t, m.Close(context.Background()))
 }
+
+func TestSubscribe(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := storage.NewMemStorage()
+	updates := m.Subscribe(ctx, storage.Filter{Names: []string{metricName}})
+
+	record := storage.Record{Name: metricName, Value: metrics.Counter(10)}
+	err := m.Push(ctx, metricID, record)
+	require.NoError(err)
+
+	err = m.PushBatch(ctx, map[string]storage.Record{
+		"Alloc_gauge": {Name: "Alloc", Value: metrics.Gauge(13.123)},
+		metricID:      {Name: metricName, Value: metrics.Counter(12)},
+	})
+	require.NoError(err)
+
+	require.Equal(record, <-updates)
+	require.Equal(storage.Record{Name: metricName, Value: metrics.Counter(12)}, <-updates)
+	require.Empty(updates)
+}
+
+func TestSilences(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	m := storage.NewMemStorage()
+
+	silence := storage.Silence{
+		ID:       "1",
+		Matchers: []string{"alertname=HighCpu*"},
+		StartsAt: time.Unix(1000, 0),
+		EndsAt:   time.Unix(2000, 0),
+	}
+
+	require.NoError(m.PushSilence(ctx, silence))
+
+	silence.Comment = "deploy"
+	require.NoError(m.PushSilence(ctx, silence))
+
+	silences, err := m.GetSilences(ctx)
+	require.NoError(err)
+	require.Equal([]storage.Silence{silence}, silences)
+
+	require.NoError(m.DeleteSilence(ctx, "1"))
+	require.ErrorIs(m.DeleteSilence(ctx, "1"), entity.ErrSilenceNotFound)
+
+	silences, err = m.GetSilences(ctx)
+	require.NoError(err)
+	require.Empty(silences)
+}
+
+func TestDelete(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	m := storage.NewMemStorage()
+
+	err := m.Push(ctx, metricID, storage.Record{Name: metricName, Value: metrics.Counter(10)})
+	require.NoError(err)
+
+	require.NoError(m.Delete(ctx, metricID))
+	require.ErrorIs(m.Delete(ctx, metricID), entity.ErrMetricNotFound)
+
+	_, err = m.Get(ctx, metricID)
+	require.ErrorIs(err, entity.ErrMetricNotFound)
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Prefix of names of daily partitions of the samples table.
+	_samplesPartitionPrefix = "samples_"
+
+	// Layout of date in names of daily partitions.
+	_samplesPartitionLayout = "20060102"
+
+	// Count of upcoming days for which partitions are created in advance.
+	_samplesPartitionsAhead = 3
+)
+
+// samplesPartitionName returns name of partition storing samples recorded on the day.
+func samplesPartitionName(day time.Time) string {
+	return _samplesPartitionPrefix + day.Format(_samplesPartitionLayout)
+}
+
+// listSamplesPartitions returns names of all partitions of the samples table.
+func (d DatabaseStorage) listSamplesPartitions(ctx context.Context) ([]string, error) {
+	rows, err := d.pool.Query(
+		ctx,
+		`SELECT child.relname FROM pg_inherits
+			JOIN pg_class parent ON pg_inherits.inhparent = parent.oid
+			JOIN pg_class child ON pg_inherits.inhrelid = child.oid
+		WHERE parent.relname = 'samples'`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("DatabaseStorage - listSamplesPartitions - d.pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var name string
+
+	rv := make([]string, 0)
+	if _, err := pgx.ForEachRow(rows, []any{&name}, func() error {
+		rv = append(rv, name)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("DatabaseStorage - listSamplesPartitions - pgx.ForEachRow: %w", err)
+	}
+
+	return rv, nil
+}
+
+// createSamplesPartition creates partition storing samples recorded on the day, if it doesn't exist.
+// Samples of the day caught by the default partition are moved to the new partition,
+// otherwise Postgres refuses to attach it.
+func (d DatabaseStorage) createSamplesPartition(ctx context.Context, day time.Time) error {
+	name := samplesPartitionName(day)
+	from := day.Format(time.RFC3339)
+	to := day.AddDate(0, 0, 1).Format(time.RFC3339)
+
+	// Identifiers and bounds can't be passed as query parameters,
+	// but both are generated from the date, thus it is safe to format them.
+	// The DO block is executed in single transaction, the lock of the default partition
+	// prevents concurrent writes of samples of the day to it until the partition is attached.
+	query := fmt.Sprintf(
+		`DO $$
+		BEGIN
+			IF to_regclass('%[1]s') IS NULL THEN
+				LOCK TABLE samples_default IN SHARE ROW EXCLUSIVE MODE;
+				CREATE TABLE %[1]s (LIKE samples INCLUDING DEFAULTS);
+				WITH moved AS (
+					DELETE FROM samples_default WHERE recorded_at >= '%[2]s' AND recorded_at < '%[3]s'
+					RETURNING tenant, name, kind, value, recorded_at
+				)
+				INSERT INTO %[1]s (tenant, name, kind, value, recorded_at) SELECT * FROM moved;
+				ALTER TABLE samples ATTACH PARTITION %[1]s FOR VALUES FROM ('%[2]s') TO ('%[3]s');
+			END IF;
+		END $$`,
+		name, from, to,
+	)
+
+	if _, err := d.pool.Exec(ctx, query); err != nil {
+		return fmt.Errorf("DatabaseStorage - createSamplesPartition - d.pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// purgeDefaultPartition removes samples older than the threshold from the default partition.
+func (d DatabaseStorage) purgeDefaultPartition(ctx context.Context, threshold time.Time) error {
+	if _, err := d.pool.Exec(ctx, "DELETE FROM samples_default WHERE recorded_at < $1", threshold); err != nil {
+		return fmt.Errorf("DatabaseStorage - purgeDefaultPartition - d.pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// dropSamplesPartition removes partition together with all samples stored in it.
+func (d DatabaseStorage) dropSamplesPartition(ctx context.Context, name string) error {
+	if _, err := d.pool.Exec(ctx, "DROP TABLE IF EXISTS "+pgx.Identifier{name}.Sanitize()); err != nil {
+		return fmt.Errorf("DatabaseStorage - dropSamplesPartition - d.pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// MaintainPartitions creates daily partitions of the samples table for the current
+// and several upcoming days and drops partitions containing only samples older than
+// the retention period. Expired samples caught by the default partition are removed as well.
+// Zero retention means that history is kept forever.
+// Failure to process single partition doesn't stop processing of others,
+// the last error is returned.
+func (d DatabaseStorage) MaintainPartitions(ctx context.Context, now time.Time, retention time.Duration) error {
+	var rv error
+
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	for i := 0; i <= _samplesPartitionsAhead; i++ {
+		if err := d.createSamplesPartition(ctx, today.AddDate(0, 0, i)); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("")
+			rv = err
+		}
+	}
+
+	if retention == 0 {
+		return rv
+	}
+
+	partitions, err := d.listSamplesPartitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	threshold := now.UTC().Add(-retention)
+
+	if err := d.purgeDefaultPartition(ctx, threshold); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("")
+		rv = err
+	}
+
+	for _, name := range partitions {
+		day, err := time.Parse(_samplesPartitionLayout, strings.TrimPrefix(name, _samplesPartitionPrefix))
+		if err != nil {
+			// Skip the default partition and partitions created manually.
+			continue
+		}
+
+		if day.AddDate(0, 0, 1).After(threshold) {
+			continue
+		}
+
+		log.Ctx(ctx).Info().Str("partition", name).Msg("Dropping expired samples partition")
+
+		if err := d.dropSamplesPartition(ctx, name); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("")
+			rv = err
+		}
+	}
+
+	return rv
+}
@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/mock"
 )
@@ -28,6 +30,11 @@ func (m *DBConnPoolMock) Ping(ctx context.Context) error {
 	return args.Error(0)
 }
 
+func (m *DBConnPoolMock) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	mArgs := m.Called(ctx, sql, args)
+	return mArgs.Get(0).(pgconn.CommandTag), mArgs.Error(1)
+}
+
 func (m *DBConnPoolMock) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
 	mArgs := m.Called(ctx, sql, args)
 	return mArgs.Get(0).(pgx.Rows), mArgs.Error(1)
@@ -46,3 +53,54 @@ func (m *DBConnPoolMock) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchR
 func (m *DBConnPoolMock) Close() {
 	_ = m.Called()
 }
+
+var _ pgx.Rows = (*RowsMock)(nil)
+
+// RowsMock emulates result of a query returning the provided rows.
+type RowsMock struct {
+	rows [][]any
+	pos  int
+}
+
+func NewRowsMock(rows ...[]any) *RowsMock {
+	return &RowsMock{rows: rows}
+}
+
+func (m *RowsMock) Close() {}
+
+func (m *RowsMock) Err() error {
+	return nil
+}
+
+func (m *RowsMock) CommandTag() pgconn.CommandTag {
+	return pgconn.NewCommandTag("SELECT")
+}
+
+func (m *RowsMock) FieldDescriptions() []pgconn.FieldDescription {
+	return nil
+}
+
+func (m *RowsMock) Next() bool {
+	m.pos++
+	return m.pos <= len(m.rows)
+}
+
+func (m *RowsMock) Scan(dest ...any) error {
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(m.rows[m.pos-1][i]))
+	}
+
+	return nil
+}
+
+func (m *RowsMock) Values() ([]any, error) {
+	return m.rows[m.pos-1], nil
+}
+
+func (m *RowsMock) RawValues() [][]byte {
+	return nil
+}
+
+func (m *RowsMock) Conn() *pgx.Conn {
+	return nil
+}
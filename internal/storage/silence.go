@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// A Silence mutes notifications about alerts matching all matchers during the time window.
+type Silence struct {
+	ID string `json:"id"`
+
+	// Matchers in the <label>=<glob> form, e.g. "alertname=HighCpu*".
+	Matchers []string `json:"matchers"`
+
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedBy string    `json:"created_by"`
+	Comment   string    `json:"comment"`
+}
+
+// SilenceStorage keeps silences of alerts.
+type SilenceStorage interface {
+	// PushSilence creates new silence or replaces existing one with the same ID.
+	PushSilence(ctx context.Context, silence Silence) error
+
+	// GetSilences returns all stored silences including expired ones.
+	GetSilences(ctx context.Context) ([]Silence, error)
+
+	// DeleteSilence removes silence, returns entity.ErrSilenceNotFound if there is no such silence.
+	DeleteSilence(ctx context.Context, id string) error
+}
@@ -6,16 +6,31 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
 )
 
 type Storage interface {
+	SilenceStorage
+
 	Push(ctx context.Context, key string, record Record) error
 	PushBatch(ctx context.Context, data map[string]Record) error
 	Get(ctx context.Context, key string) (Record, error)
+	Delete(ctx context.Context, key string) error
 	GetAll(ctx context.Context) ([]Record, error)
+	List(ctx context.Context, opts ListOptions) (Page, error)
 	Close(ctx context.Context) error
+
+	// Count returns count of stored series of the tenant.
+	Count(ctx context.Context) (int, error)
+
+	// CountAll returns count of stored series of all tenants.
+	CountAll(ctx context.Context) (int, error)
+
+	// Subscribe returns channel receiving all records pushed to the storage
+	// which match the filter. The channel is closed as soon as provided context is done.
+	Subscribe(ctx context.Context, filter Filter) <-chan Record
 }
 
 // NewDataStore create new DataStore object encapsulating particular storage type.
@@ -23,7 +38,16 @@ type Storage interface {
 // - if DB connection was initialized, use database storage;
 // - if filePath is set, use file backed storage;
 // - otherwise store data in memory.
-func NewDataStore(pool *pgxpool.Pool, filePath string, storeInterval time.Duration) Storage {
+// Metrics of tenants are kept in the tenant column of the database,
+// in separate files or in separate in-memory maps. Count of tenants kept in separate files
+// or maps is restricted by tenantsLimit, zero value disables limit.
+func NewDataStore(
+	pool *pgxpool.Pool,
+	filePath string,
+	storeInterval time.Duration,
+	sealer Sealer,
+	tenantsLimit int,
+) Storage {
 	if pool != nil {
 		log.Info().Msg("Attached database storage")
 		return NewDatabaseStorage(pool)
@@ -31,17 +55,31 @@ func NewDataStore(pool *pgxpool.Pool, filePath string, storeInterval time.Durati
 
 	if len(filePath) == 0 {
 		log.Info().Msg("Attached in-memory storage")
-		return NewMemStorage()
+
+		return NewTenantStorage(
+			func(string) Storage {
+				return NewMemStorage()
+			},
+			nil,
+			tenantsLimit,
+		)
 	}
 
 	log.Info().Msg("Attached file-backed storage")
 
-	return NewFileBackedStorage(filePath, storeInterval == 0)
+	return NewTenantStorage(
+		func(tenant string) Storage {
+			return NewFileBackedStorage(TenantStorePath(filePath, tenant), storeInterval == 0, sealer)
+		},
+		PersistedTenants(filePath),
+		tenantsLimit,
+	)
 }
 
 type DBConnPool interface {
 	Acquire(ctx context.Context) (*pgxpool.Conn, error)
 	Ping(ctx context.Context) error
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
@@ -28,6 +28,11 @@ func (m *Mock) Get(ctx context.Context, key string) (Record, error) {
 	return args.Get(0).(Record), args.Error(1)
 }
 
+func (m *Mock) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
 func (m *Mock) GetAll(ctx context.Context) ([]Record, error) {
 	args := m.Called(ctx)
 
@@ -38,7 +43,52 @@ func (m *Mock) GetAll(ctx context.Context) ([]Record, error) {
 	return args.Get(0).([]Record), args.Error(1)
 }
 
+func (m *Mock) List(ctx context.Context, opts ListOptions) (Page, error) {
+	args := m.Called(ctx, opts)
+	return args.Get(0).(Page), args.Error(1)
+}
+
+func (m *Mock) Count(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *Mock) CountAll(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *Mock) Close(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
 }
+
+func (m *Mock) Subscribe(ctx context.Context, filter Filter) <-chan Record {
+	args := m.Called(ctx, filter)
+
+	if args.Get(0) == nil {
+		return nil
+	}
+
+	return args.Get(0).(<-chan Record)
+}
+
+func (m *Mock) PushSilence(ctx context.Context, silence Silence) error {
+	args := m.Called(ctx, silence)
+	return args.Error(0)
+}
+
+func (m *Mock) GetSilences(ctx context.Context) ([]Silence, error) {
+	args := m.Called(ctx)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Silence), args.Error(1)
+}
+
+func (m *Mock) DeleteSilence(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
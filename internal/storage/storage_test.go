@@ -25,34 +25,34 @@ func TestNewDataStore(t *testing.T) {
 			expected: storage.DatabaseStorage{},
 		},
 		{
-			name:     "Should create file backed storage, if path and interval are set",
+			name:     "Should create file backed storage of tenants, if path and interval are set",
 			path:     "some/path",
 			interval: 10 * time.Second,
-			expected: &storage.FileBackedStorage{},
+			expected: &storage.TenantStorage{},
 		},
 		{
-			name:     "Should create file backed storage, if path set without interval",
+			name:     "Should create file backed storage of tenants, if path set without interval",
 			path:     "some/path",
 			interval: 0,
-			expected: &storage.FileBackedStorage{},
+			expected: &storage.TenantStorage{},
 		},
 		{
-			name:     "Should create memory storage, if path not set",
+			name:     "Should create memory storage of tenants, if path not set",
 			path:     "",
 			interval: 10,
-			expected: &storage.MemStorage{},
+			expected: &storage.TenantStorage{},
 		},
 		{
-			name:     "Should create memory storage, if path not set and interval is zero",
+			name:     "Should create memory storage of tenants, if path not set and interval is zero",
 			path:     "",
 			interval: 0,
-			expected: &storage.MemStorage{},
+			expected: &storage.TenantStorage{},
 		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			store := storage.NewDataStore(tc.db, tc.path, tc.interval)
+			store := storage.NewDataStore(tc.db, tc.path, tc.interval, nil, 0)
 			assert.IsType(t, tc.expected, store)
 		})
 	}
@@ -0,0 +1,330 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+)
+
+var _ Storage = (*TenantStorage)(nil)
+
+// restorer is implemented by storages able to restore data from disk.
+type restorer interface {
+	Restore() error
+}
+
+// dumper is implemented by storages able to dump data to disk.
+type dumper interface {
+	Dump(ctx context.Context) error
+}
+
+// TenantStorePath returns path to dump file of the tenant,
+// e.g. "/tmp/metrics-db.json" becomes "/tmp/metrics-db.acme.json" for tenant "acme".
+// Data of the default tenant is kept in the original file.
+func TenantStorePath(path, tenant string) string {
+	if tenant == entity.DefaultTenant {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+
+	return strings.TrimSuffix(path, ext) + "." + tenant + ext
+}
+
+// PersistedTenants returns function listing tenants which have dump files
+// next to the dump file of the default tenant, see TenantStorePath.
+func PersistedTenants(path string) func() ([]string, error) {
+	return func() ([]string, error) {
+		ext := filepath.Ext(path)
+		prefix := filepath.Base(strings.TrimSuffix(path, ext)) + "."
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("storage - PersistedTenants - os.ReadDir: %w", err)
+		}
+
+		rv := make([]string, 0)
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || len(name) <= len(prefix)+len(ext) ||
+				!strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+				continue
+			}
+
+			// Names of tenants never contain dots,
+			// so files like "metrics-db.json.bak" are not dumps of tenants.
+			tenant := name[len(prefix) : len(name)-len(ext)]
+			if strings.Contains(tenant, ".") {
+				continue
+			}
+
+			rv = append(rv, tenant)
+		}
+
+		return rv, nil
+	}
+}
+
+// TenantStorage isolates metrics of tenants keeping them in separate storages
+// (e.g. separate in-memory maps or separate dump files).
+// Storage of a tenant is created on the first write, reads of tenants without storages
+// behave as reads of empty storage, e.g. subscriptions to such tenants receive nothing.
+// Silences are shared by all tenants and kept in the storage of the default tenant.
+type TenantStorage struct {
+	sync.Mutex
+
+	// Creates storage of new tenant.
+	create func(tenant string) Storage
+
+	// Lists tenants having data on disk, nil if data is not kept on disk.
+	persisted func() ([]string, error)
+
+	stores map[string]Storage
+
+	// Serves reads of tenants without storages.
+	empty Storage
+
+	// Maximal count of tenants besides the default one, zero value disables limit.
+	limit int
+
+	// Restore data of tenants from disk on creation of their storages.
+	restore bool
+}
+
+// NewTenantStorage creates new instance of TenantStorage
+// creating storages of tenants with provided function.
+// The persisted function lists tenants which data should be restored from disk, it may be nil.
+// The limit restricts count of tenants besides the default one, zero value disables limit.
+func NewTenantStorage(
+	create func(tenant string) Storage,
+	persisted func() ([]string, error),
+	limit int,
+) *TenantStorage {
+	return &TenantStorage{
+		create:    create,
+		persisted: persisted,
+		stores:    map[string]Storage{entity.DefaultTenant: create(entity.DefaultTenant)},
+		empty:     NewMemStorage(),
+		limit:     limit,
+	}
+}
+
+// lookup returns storage of tenant selected by the request to read data from.
+// Reads of tenants without storages are served by empty storage.
+func (t *TenantStorage) lookup(ctx context.Context) Storage {
+	t.Lock()
+	defer t.Unlock()
+
+	if s, ok := t.stores[entity.TenantFromContext(ctx)]; ok {
+		return s
+	}
+
+	return t.empty
+}
+
+// store returns storage of tenant selected by the request to write data to,
+// the storage is created if it doesn't exist.
+func (t *TenantStorage) store(ctx context.Context) (Storage, error) {
+	tenant := entity.TenantFromContext(ctx)
+
+	t.Lock()
+	defer t.Unlock()
+
+	if s, ok := t.stores[tenant]; ok {
+		return s, nil
+	}
+
+	// The default tenant is not counted.
+	if t.limit > 0 && len(t.stores)-1 >= t.limit {
+		return nil, entity.TenantsLimitExceededError(t.limit)
+	}
+
+	s := t.create(tenant)
+
+	if r, ok := s.(restorer); ok && t.restore {
+		if err := r.Restore(); err != nil {
+			return nil, fmt.Errorf("TenantStorage - store - r.Restore: %w", err)
+		}
+	}
+
+	t.stores[tenant] = s
+
+	return s, nil
+}
+
+// defaultStore returns storage of the default tenant.
+func (t *TenantStorage) defaultStore() Storage {
+	t.Lock()
+	defer t.Unlock()
+
+	return t.stores[entity.DefaultTenant]
+}
+
+// snapshot returns copy of storages of all tenants,
+// so long operations with them don't block access to other tenants.
+func (t *TenantStorage) snapshot() map[string]Storage {
+	t.Lock()
+	defer t.Unlock()
+
+	rv := make(map[string]Storage, len(t.stores))
+	for tenant, s := range t.stores {
+		rv[tenant] = s
+	}
+
+	return rv
+}
+
+// Push records metric data of the tenant.
+func (t *TenantStorage) Push(ctx context.Context, key string, record Record) error {
+	s, err := t.store(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.Push(ctx, key, record)
+}
+
+// PushBatch records list of metrics data of the tenant.
+func (t *TenantStorage) PushBatch(ctx context.Context, data map[string]Record) error {
+	s, err := t.store(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.PushBatch(ctx, data)
+}
+
+// Get returns stored metrics record of the tenant.
+func (t *TenantStorage) Get(ctx context.Context, key string) (Record, error) {
+	return t.lookup(ctx).Get(ctx, key)
+}
+
+// Delete removes stored metrics record of the tenant.
+func (t *TenantStorage) Delete(ctx context.Context, key string) error {
+	return t.lookup(ctx).Delete(ctx, key)
+}
+
+// GetAll returns all stored metrics of the tenant.
+func (t *TenantStorage) GetAll(ctx context.Context) ([]Record, error) {
+	return t.lookup(ctx).GetAll(ctx)
+}
+
+// List returns single page of stored metrics of the tenant.
+func (t *TenantStorage) List(ctx context.Context, opts ListOptions) (Page, error) {
+	return t.lookup(ctx).List(ctx, opts)
+}
+
+// Count returns count of stored series of the tenant.
+func (t *TenantStorage) Count(ctx context.Context) (int, error) {
+	return t.lookup(ctx).Count(ctx)
+}
+
+// CountAll returns count of stored series of all tenants.
+func (t *TenantStorage) CountAll(ctx context.Context) (int, error) {
+	rv := 0
+
+	for tenant, s := range t.snapshot() {
+		count, err := s.Count(entity.WithTenant(ctx, tenant))
+		if err != nil {
+			return 0, fmt.Errorf("TenantStorage - CountAll - tenant %q: %w", tenant, err)
+		}
+
+		rv += count
+	}
+
+	return rv, nil
+}
+
+// Subscribe returns channel receiving records of the tenant pushed to the storage
+// which match the filter. The channel is closed as soon as provided context is done.
+// Subscriptions to tenants without storages receive nothing.
+func (t *TenantStorage) Subscribe(ctx context.Context, filter Filter) <-chan Record {
+	return t.lookup(ctx).Subscribe(ctx, filter)
+}
+
+// PushSilence creates new silence or replaces existing one with the same ID.
+func (t *TenantStorage) PushSilence(ctx context.Context, silence Silence) error {
+	return t.defaultStore().PushSilence(ctx, silence)
+}
+
+// GetSilences returns all stored silences including expired ones.
+func (t *TenantStorage) GetSilences(ctx context.Context) ([]Silence, error) {
+	return t.defaultStore().GetSilences(ctx)
+}
+
+// DeleteSilence removes silence, returns entity.ErrSilenceNotFound if there is no such silence.
+func (t *TenantStorage) DeleteSilence(ctx context.Context, id string) error {
+	return t.defaultStore().DeleteSilence(ctx, id)
+}
+
+// Restore reads previously stored data of tenants from disk.
+// Storages of tenants written for the first time later are restored on creation.
+func (t *TenantStorage) Restore() error {
+	t.Lock()
+	defer t.Unlock()
+
+	t.restore = true
+
+	if t.persisted != nil {
+		tenants, err := t.persisted()
+		if err != nil {
+			return fmt.Errorf("TenantStorage - Restore - t.persisted: %w", err)
+		}
+
+		for _, tenant := range tenants {
+			if _, ok := t.stores[tenant]; !ok {
+				t.stores[tenant] = t.create(tenant)
+			}
+		}
+	}
+
+	for tenant, s := range t.stores {
+		r, ok := s.(restorer)
+		if !ok {
+			continue
+		}
+
+		if err := r.Restore(); err != nil {
+			return fmt.Errorf("TenantStorage - Restore - tenant %q: %w", tenant, err)
+		}
+	}
+
+	return nil
+}
+
+// Dump writes stored data of all tenants to disk.
+func (t *TenantStorage) Dump(ctx context.Context) error {
+	for tenant, s := range t.snapshot() {
+		d, ok := s.(dumper)
+		if !ok {
+			continue
+		}
+
+		if err := d.Dump(ctx); err != nil {
+			return fmt.Errorf("TenantStorage - Dump - tenant %q: %w", tenant, err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes storages of all tenants, e.g. dumps their data to disk.
+func (t *TenantStorage) Close(ctx context.Context) error {
+	for tenant, s := range t.snapshot() {
+		if err := s.Close(ctx); err != nil {
+			return fmt.Errorf("TenantStorage - Close - tenant %q: %w", tenant, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alkurbatov/metrics-collector/internal/entity"
+	"github.com/alkurbatov/metrics-collector/internal/storage"
+	"github.com/alkurbatov/metrics-collector/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantStorePath(t *testing.T) {
+	tt := []struct {
+		name     string
+		path     string
+		tenant   string
+		expected string
+	}{
+		{
+			name:     "Should keep path of default tenant",
+			path:     "/tmp/metrics-db.json",
+			tenant:   entity.DefaultTenant,
+			expected: "/tmp/metrics-db.json",
+		},
+		{
+			name:     "Should insert tenant before extension",
+			path:     "/tmp/metrics-db.json",
+			tenant:   "acme",
+			expected: "/tmp/metrics-db.acme.json",
+		},
+		{
+			name:     "Should append tenant to path without extension",
+			path:     "/tmp/metrics-db",
+			tenant:   "acme",
+			expected: "/tmp/metrics-db.acme",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, storage.TenantStorePath(tc.path, tc.tenant))
+		})
+	}
+}
+
+func TestTenantStorageIsolatesTenants(t *testing.T) {
+	require := require.New(t)
+
+	s := storage.NewDataStore(nil, "", 0, nil, 0)
+	acme := entity.WithTenant(context.Background(), "acme")
+
+	err := s.Push(acme, metricID, storage.Record{Name: metricName, Value: metrics.Counter(10)})
+	require.NoError(err)
+
+	_, err = s.Get(context.Background(), metricID)
+	require.ErrorIs(err, entity.ErrMetricNotFound)
+
+	page, err := s.List(context.Background(), storage.ListOptions{})
+	require.NoError(err)
+	require.Empty(page.Records)
+
+	page, err = s.List(acme, storage.ListOptions{})
+	require.NoError(err)
+	require.Equal([]storage.Record{{Name: metricName, Value: metrics.Counter(10)}}, page.Records)
+}
+
+func TestTenantStorageSharesSilences(t *testing.T) {
+	require := require.New(t)
+
+	s := storage.NewDataStore(nil, "", 0, nil, 0)
+
+	err := s.PushSilence(entity.WithTenant(context.Background(), "acme"), storage.Silence{ID: "1"})
+	require.NoError(err)
+
+	silences, err := s.GetSilences(context.Background())
+	require.NoError(err)
+	require.Len(silences, 1)
+}
+
+func TestTenantStorageDumpsTenantsToSeparateFiles(t *testing.T) {
+	require := require.New(t)
+
+	storePath := filepath.Join(t.TempDir(), "metrics-db.json")
+	ctx := context.Background()
+	acme := entity.WithTenant(ctx, "acme")
+
+	s := storage.NewDataStore(nil, storePath, 0, nil, 0)
+
+	err := s.Push(ctx, "Alloc_gauge", storage.Record{Name: "Alloc", Value: metrics.Gauge(11.23)})
+	require.NoError(err)
+
+	err = s.Push(acme, metricID, storage.Record{Name: metricName, Value: metrics.Counter(10)})
+	require.NoError(err)
+
+	require.FileExists(storePath)
+	require.FileExists(storage.TenantStorePath(storePath, "acme"))
+
+	restored, ok := storage.NewDataStore(nil, storePath, 0, nil, 0).(*storage.TenantStorage)
+	require.True(ok)
+	require.NoError(restored.Restore())
+
+	_, err = restored.Get(ctx, metricID)
+	require.ErrorIs(err, entity.ErrMetricNotFound)
+
+	record, err := restored.Get(acme, metricID)
+	require.NoError(err)
+	require.Equal(metrics.Counter(10), record.Value)
+}
+
+func TestTenantStorageReadsDoNotCreateTenants(t *testing.T) {
+	require := require.New(t)
+
+	s := storage.NewTenantStorage(func(string) storage.Storage { return storage.NewMemStorage() }, nil, 1)
+	ctx := context.Background()
+
+	for _, tenant := range []string{"acme", "globex", "initech"} {
+		_, err := s.Get(entity.WithTenant(ctx, tenant), metricID)
+		require.ErrorIs(err, entity.ErrMetricNotFound)
+
+		page, err := s.List(entity.WithTenant(ctx, tenant), storage.ListOptions{})
+		require.NoError(err)
+		require.Empty(page.Records)
+	}
+
+	record := storage.Record{Name: metricName, Value: metrics.Counter(10)}
+	require.NoError(s.Push(entity.WithTenant(ctx, "acme"), metricID, record))
+}
+
+func TestTenantStorageCountsSeries(t *testing.T) {
+	require := require.New(t)
+
+	s := storage.NewDataStore(nil, "", 0, nil, 0)
+	ctx := context.Background()
+	acme := entity.WithTenant(ctx, "acme")
+
+	require.NoError(s.Push(ctx, metricID, storage.Record{Name: metricName, Value: metrics.Counter(10)}))
+	require.NoError(s.PushBatch(acme, map[string]storage.Record{
+		metricID:      {Name: metricName, Value: metrics.Counter(10)},
+		"Alloc_gauge": {Name: "Alloc", Value: metrics.Gauge(11.23)},
+	}))
+
+	count, err := s.Count(acme)
+	require.NoError(err)
+	require.Equal(2, count)
+
+	count, err = s.Count(entity.WithTenant(ctx, "globex"))
+	require.NoError(err)
+	require.Zero(count)
+
+	count, err = s.CountAll(ctx)
+	require.NoError(err)
+	require.Equal(3, count)
+}
+
+func TestTenantStorageLimitsTenants(t *testing.T) {
+	require := require.New(t)
+
+	s := storage.NewTenantStorage(func(string) storage.Storage { return storage.NewMemStorage() }, nil, 1)
+	ctx := context.Background()
+	record := storage.Record{Name: metricName, Value: metrics.Counter(10)}
+
+	require.NoError(s.Push(ctx, metricID, record))
+	require.NoError(s.Push(entity.WithTenant(ctx, "acme"), metricID, record))
+	require.NoError(s.Push(entity.WithTenant(ctx, "acme"), metricID, record))
+
+	err := s.Push(entity.WithTenant(ctx, "globex"), metricID, record)
+	require.ErrorIs(err, entity.ErrTenantsLimitExceeded)
+
+	err = s.PushBatch(entity.WithTenant(ctx, "globex"), map[string]storage.Record{metricID: record})
+	require.ErrorIs(err, entity.ErrTenantsLimitExceeded)
+}
+
+type blockingDumpStorage struct {
+	storage.Storage
+
+	dumping chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingDumpStorage) Dump(ctx context.Context) error {
+	close(s.dumping)
+	<-s.release
+
+	return nil
+}
+
+func TestTenantStorageDumpDoesNotBlockOtherTenants(t *testing.T) {
+	require := require.New(t)
+
+	slow := &blockingDumpStorage{
+		Storage: storage.NewMemStorage(),
+		dumping: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	s := storage.NewTenantStorage(func(tenant string) storage.Storage {
+		if tenant == entity.DefaultTenant {
+			return slow
+		}
+
+		return storage.NewMemStorage()
+	}, nil, 0)
+
+	ctx := context.Background()
+	dumped := make(chan error)
+
+	go func() { dumped <- s.Dump(ctx) }()
+
+	<-slow.dumping
+
+	record := storage.Record{Name: metricName, Value: metrics.Counter(10)}
+	pushed := make(chan error)
+
+	go func() { pushed <- s.Push(entity.WithTenant(ctx, "acme"), metricID, record) }()
+
+	select {
+	case err := <-pushed:
+		require.NoError(err)
+	case <-time.After(time.Second):
+		require.Fail("push blocked by dump of another tenant")
+	}
+
+	close(slow.release)
+	require.NoError(<-dumped)
+}
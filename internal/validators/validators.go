@@ -2,7 +2,12 @@
 package validators
 
 import (
+	"fmt"
+	"hash/fnv"
 	"regexp"
+	"sort"
+	"strings"
+	"unicode"
 
 	"github.com/alkurbatov/metrics-collector/internal/entity"
 	"github.com/alkurbatov/metrics-collector/internal/services"
@@ -24,6 +29,65 @@ func ValidateMetricName(name, kind string) error {
 	return nil
 }
 
+// SanitizeMetricName converts name used by third-party systems into acceptable one
+// dropping all unsupported characters, e.g. "go_memstats.heap_alloc" becomes "GoMemstatsHeapAlloc".
+func SanitizeMetricName(name string) string {
+	var sb strings.Builder
+
+	upper := true
+
+	for _, r := range name {
+		if r > unicode.MaxASCII || (!unicode.IsLetter(r) && !unicode.IsDigit(r)) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// SanitizeLabels converts labels distinguishing series of third-party systems (e.g. Prometheus labels)
+// into suffix of acceptable metric name. Each label is represented by its key followed by its value,
+// labels are ordered by keys. As unsupported characters are dropped and different labels could
+// produce the same string, hash of the original labels is appended,
+// e.g. {job="api"} becomes "JobApi" followed by 8 hex digits. No labels produce empty suffix.
+func SanitizeLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var sb strings.Builder
+
+	h := fnv.New32a()
+
+	for _, key := range keys {
+		sb.WriteString(SanitizeMetricName(key))
+		sb.WriteString(SanitizeMetricName(labels[key]))
+
+		// Zero bytes separate keys and values,
+		// thus {a="bc"} and {ab="c"} produce different hashes.
+		_, _ = h.Write([]byte(key + "\x00" + labels[key] + "\x00"))
+	}
+
+	fmt.Fprintf(&sb, "%08x", h.Sum32())
+
+	return sb.String()
+}
+
 // ValidateMetricKind verifies that provided metric kind is known.
 func ValidateMetricKind(kind string) error {
 	switch kind {
@@ -101,6 +101,100 @@ func TestValidateMetricsName(t *testing.T) {
 	}
 }
 
+func TestSanitizeMetricName(t *testing.T) {
+	tt := []struct {
+		name     string
+		metric   string
+		expected string
+	}{
+		{
+			name:     "Should keep acceptable name",
+			metric:   "HeapAlloc",
+			expected: "HeapAlloc",
+		},
+		{
+			name:     "Should convert snake case",
+			metric:   "go_memstats_heap_alloc_bytes",
+			expected: "GoMemstatsHeapAllocBytes",
+		},
+		{
+			name:     "Should drop dots and colons",
+			metric:   "api.requests:total",
+			expected: "ApiRequestsTotal",
+		},
+		{
+			name:     "Should drop non-ASCII characters",
+			metric:   "запросы_api",
+			expected: "Api",
+		},
+		{
+			name:     "Should return empty name if nothing left",
+			metric:   "__",
+			expected: "",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, validators.SanitizeMetricName(tc.metric))
+		})
+	}
+}
+
+func TestSanitizeLabels(t *testing.T) {
+	tt := []struct {
+		name     string
+		labels   map[string]string
+		expected string
+	}{
+		{
+			name:     "Should return empty suffix without labels",
+			expected: "",
+		},
+		{
+			name:     "Should append keys and values of labels ordered by keys followed by hash",
+			labels:   map[string]string{"job": "api", "instance": "localhost:9090"},
+			expected: "InstanceLocalhost9090JobApi619ef7cc",
+		},
+		{
+			name:     "Should distinguish labels with the same concatenated values",
+			labels:   map[string]string{"job": "a", "instance": "bc"},
+			expected: "InstanceBcJobAdc8d5903",
+		},
+		{
+			name:     "Should distinguish labels differing in dropped characters only",
+			labels:   map[string]string{"job": "api_1"},
+			expected: "JobApi146f4a1f8",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, validators.SanitizeLabels(tc.labels))
+		})
+	}
+}
+
+func TestSanitizeLabelsAvoidsCollisions(t *testing.T) {
+	labels := []map[string]string{
+		{"job": "ab", "instance": "c"},
+		{"job": "a", "instance": "bc"},
+		{"a": "bc"},
+		{"ab": "c"},
+		{"job": "api-1"},
+		{"job": "api_1"},
+	}
+
+	seen := make(map[string]struct{}, len(labels))
+
+	for _, l := range labels {
+		suffix := validators.SanitizeLabels(l)
+		assert.NotContains(t, seen, suffix)
+
+		seen[suffix] = struct{}{}
+	}
+}
+
 func TestValidateMetricKind(t *testing.T) {
 	tt := []struct {
 		name string
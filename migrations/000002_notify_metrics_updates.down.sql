@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS metrics__notify_update ON metrics;
+DROP FUNCTION IF EXISTS notify_metrics_update;
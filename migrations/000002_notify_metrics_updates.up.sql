@@ -0,0 +1,21 @@
+CREATE OR REPLACE FUNCTION notify_metrics_update() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify(
+        'metrics_updates',
+        json_build_object(
+            'name', NEW.name,
+            'kind', NEW.kind::text,
+            'value', CASE
+                WHEN NEW.kind = 'counter' THEN NEW.value::bigint::text
+                ELSE NEW.value::text
+            END
+        )::text
+    );
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER metrics__notify_update
+    AFTER INSERT OR UPDATE ON metrics
+    FOR EACH ROW EXECUTE FUNCTION notify_metrics_update();
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS metrics__name_kind_idx;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS metrics__name_kind_idx ON metrics (name COLLATE "C", kind);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS metrics__record_sample ON metrics;
+DROP FUNCTION IF EXISTS record_metrics_sample;
+DROP TABLE IF EXISTS samples;
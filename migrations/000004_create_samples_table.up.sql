@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS samples(
+    name        varchar(255) not null,
+    kind        mkind not null,
+    value       double precision not null,
+    recorded_at timestamptz not null default now()
+) PARTITION BY RANGE (recorded_at);
+
+-- Daily partitions are created and dropped by the server, see DatabaseStorage.MaintainPartitions.
+-- The default partition only catches samples written before the server created
+-- partition for the current day, thus writes never fail. Such samples are moved to the daily
+-- partition when it is created or removed after the retention period.
+CREATE TABLE IF NOT EXISTS samples_default PARTITION OF samples DEFAULT;
+
+CREATE INDEX IF NOT EXISTS samples__name_kind_recorded_at_idx ON samples (name, kind, recorded_at);
+
+CREATE OR REPLACE FUNCTION record_metrics_sample() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO samples(name, kind, value) VALUES (NEW.name, NEW.kind, NEW.value);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER metrics__record_sample
+    AFTER INSERT OR UPDATE ON metrics
+    FOR EACH ROW EXECUTE FUNCTION record_metrics_sample();
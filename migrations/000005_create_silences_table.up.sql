@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS silences(
+    id         varchar(36) primary key,
+    matchers   text[] not null,
+    starts_at  timestamptz not null,
+    ends_at    timestamptz not null,
+    created_by varchar(255) not null default '',
+    comment    text not null default ''
+);
@@ -0,0 +1,37 @@
+CREATE OR REPLACE FUNCTION record_metrics_sample() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO samples(name, kind, value) VALUES (NEW.name, NEW.kind, NEW.value);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notify_metrics_update() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify(
+        'metrics_updates',
+        json_build_object(
+            'name', NEW.name,
+            'kind', NEW.kind::text,
+            'value', CASE
+                WHEN NEW.kind = 'counter' THEN NEW.value::bigint::text
+                ELSE NEW.value::text
+            END
+        )::text
+    );
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS samples__tenant_name_kind_recorded_at_idx;
+DELETE FROM samples WHERE tenant <> '';
+ALTER TABLE samples DROP COLUMN IF EXISTS tenant;
+CREATE INDEX IF NOT EXISTS samples__name_kind_recorded_at_idx ON samples (name, kind, recorded_at);
+
+DROP INDEX IF EXISTS metrics__tenant_name_kind_idx;
+DELETE FROM metrics WHERE tenant <> '';
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS metrics_pkey;
+ALTER TABLE metrics DROP COLUMN IF EXISTS tenant;
+ALTER TABLE metrics ADD PRIMARY KEY (id);
+CREATE INDEX IF NOT EXISTS metrics__name_kind_idx ON metrics (name COLLATE "C", kind);
@@ -0,0 +1,38 @@
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS tenant varchar(64) not null default '';
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS metrics_pkey;
+ALTER TABLE metrics ADD PRIMARY KEY (tenant, id);
+
+DROP INDEX IF EXISTS metrics__name_kind_idx;
+CREATE INDEX IF NOT EXISTS metrics__tenant_name_kind_idx ON metrics (tenant, name COLLATE "C", kind);
+
+ALTER TABLE samples ADD COLUMN IF NOT EXISTS tenant varchar(64) not null default '';
+
+DROP INDEX IF EXISTS samples__name_kind_recorded_at_idx;
+CREATE INDEX IF NOT EXISTS samples__tenant_name_kind_recorded_at_idx ON samples (tenant, name, kind, recorded_at);
+
+CREATE OR REPLACE FUNCTION notify_metrics_update() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify(
+        'metrics_updates',
+        json_build_object(
+            'tenant', NEW.tenant,
+            'name', NEW.name,
+            'kind', NEW.kind::text,
+            'value', CASE
+                WHEN NEW.kind = 'counter' THEN NEW.value::bigint::text
+                ELSE NEW.value::text
+            END
+        )::text
+    );
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_metrics_sample() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO samples(tenant, name, kind, value) VALUES (NEW.tenant, NEW.name, NEW.kind, NEW.value);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
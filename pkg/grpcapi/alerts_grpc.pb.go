@@ -0,0 +1,103 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AlertsClient is the client API for Alerts service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AlertsClient interface {
+	// List returns pending and firing alerts.
+	List(ctx context.Context, in *ListAlertsRequest, opts ...grpc.CallOption) (*ListAlertsResponse, error)
+}
+
+type alertsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAlertsClient(cc grpc.ClientConnInterface) AlertsClient {
+	return &alertsClient{cc}
+}
+
+func (c *alertsClient) List(ctx context.Context, in *ListAlertsRequest, opts ...grpc.CallOption) (*ListAlertsResponse, error) {
+	out := new(ListAlertsResponse)
+	err := c.cc.Invoke(ctx, "/metrics.collector.v1.Alerts/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AlertsServer is the server API for Alerts service.
+// All implementations must embed UnimplementedAlertsServer
+// for forward compatibility
+type AlertsServer interface {
+	// List returns pending and firing alerts.
+	List(context.Context, *ListAlertsRequest) (*ListAlertsResponse, error)
+	mustEmbedUnimplementedAlertsServer()
+}
+
+// UnimplementedAlertsServer must be embedded to have forward compatible implementations.
+type UnimplementedAlertsServer struct {
+}
+
+func (UnimplementedAlertsServer) List(context.Context, *ListAlertsRequest) (*ListAlertsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedAlertsServer) mustEmbedUnimplementedAlertsServer() {}
+
+// UnsafeAlertsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AlertsServer will
+// result in compilation errors.
+type UnsafeAlertsServer interface {
+	mustEmbedUnimplementedAlertsServer()
+}
+
+func RegisterAlertsServer(s grpc.ServiceRegistrar, srv AlertsServer) {
+	s.RegisterService(&Alerts_ServiceDesc, srv)
+}
+
+func _Alerts_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAlertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlertsServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/metrics.collector.v1.Alerts/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlertsServer).List(ctx, req.(*ListAlertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Alerts_ServiceDesc is the grpc.ServiceDesc for Alerts service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Alerts_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "metrics.collector.v1.Alerts",
+	HandlerType: (*AlertsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "List",
+			Handler:    _Alerts_List_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "alerts.proto",
+}
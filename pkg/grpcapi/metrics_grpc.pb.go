@@ -20,7 +20,20 @@ const _ = grpc.SupportPackageIsVersion7
 type MetricsClient interface {
 	Update(ctx context.Context, in *MetricReq, opts ...grpc.CallOption) (*MetricReq, error)
 	BatchUpdate(ctx context.Context, in *BatchUpdateRequest, opts ...grpc.CallOption) (*BatchUpdateResponse, error)
+	// StreamUpdate pushes metrics one per message as soon as they are collected.
+	// The server records all metrics received over the stream after the client closed it
+	// and acknowledges them with single summary. If the metrics can't be recorded,
+	// the stream is closed with error, none of the metrics is recorded and all of them should be sent again.
+	StreamUpdate(ctx context.Context, opts ...grpc.CallOption) (Metrics_StreamUpdateClient, error)
 	Get(ctx context.Context, in *GetMetricRequest, opts ...grpc.CallOption) (*MetricReq, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	// Delete removes stored metric.
+	Delete(ctx context.Context, in *GetMetricRequest, opts ...grpc.CallOption) (*DeleteMetricResponse, error)
+	// Watch streams updates of selected metrics as soon as they are recorded.
+	// Updates could be dropped, if the client doesn't keep up with the stream.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Metrics_WatchClient, error)
+	// Query evaluates expression over stored metrics, e.g. "max(HeapInuse[10m])".
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
 }
 
 type metricsClient struct {
@@ -49,6 +62,40 @@ func (c *metricsClient) BatchUpdate(ctx context.Context, in *BatchUpdateRequest,
 	return out, nil
 }
 
+func (c *metricsClient) StreamUpdate(ctx context.Context, opts ...grpc.CallOption) (Metrics_StreamUpdateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Metrics_ServiceDesc.Streams[0], "/metrics.collector.v1.Metrics/StreamUpdate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &metricsStreamUpdateClient{stream}
+	return x, nil
+}
+
+type Metrics_StreamUpdateClient interface {
+	Send(*MetricReq) error
+	CloseAndRecv() (*StreamSummary, error)
+	grpc.ClientStream
+}
+
+type metricsStreamUpdateClient struct {
+	grpc.ClientStream
+}
+
+func (x *metricsStreamUpdateClient) Send(m *MetricReq) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *metricsStreamUpdateClient) CloseAndRecv() (*StreamSummary, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(StreamSummary)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *metricsClient) Get(ctx context.Context, in *GetMetricRequest, opts ...grpc.CallOption) (*MetricReq, error) {
 	out := new(MetricReq)
 	err := c.cc.Invoke(ctx, "/metrics.collector.v1.Metrics/Get", in, out, opts...)
@@ -58,13 +105,85 @@ func (c *metricsClient) Get(ctx context.Context, in *GetMetricRequest, opts ...g
 	return out, nil
 }
 
+func (c *metricsClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	out := new(ListResponse)
+	err := c.cc.Invoke(ctx, "/metrics.collector.v1.Metrics/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsClient) Delete(ctx context.Context, in *GetMetricRequest, opts ...grpc.CallOption) (*DeleteMetricResponse, error) {
+	out := new(DeleteMetricResponse)
+	err := c.cc.Invoke(ctx, "/metrics.collector.v1.Metrics/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Metrics_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Metrics_ServiceDesc.Streams[1], "/metrics.collector.v1.Metrics/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &metricsWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Metrics_WatchClient interface {
+	Recv() (*MetricReq, error)
+	grpc.ClientStream
+}
+
+type metricsWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *metricsWatchClient) Recv() (*MetricReq, error) {
+	m := new(MetricReq)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *metricsClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error) {
+	out := new(QueryResponse)
+	err := c.cc.Invoke(ctx, "/metrics.collector.v1.Metrics/Query", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetricsServer is the server API for Metrics service.
 // All implementations must embed UnimplementedMetricsServer
 // for forward compatibility
 type MetricsServer interface {
 	Update(context.Context, *MetricReq) (*MetricReq, error)
 	BatchUpdate(context.Context, *BatchUpdateRequest) (*BatchUpdateResponse, error)
+	// StreamUpdate pushes metrics one per message as soon as they are collected.
+	// The server records all metrics received over the stream after the client closed it
+	// and acknowledges them with single summary. If the metrics can't be recorded,
+	// the stream is closed with error, none of the metrics is recorded and all of them should be sent again.
+	StreamUpdate(Metrics_StreamUpdateServer) error
 	Get(context.Context, *GetMetricRequest) (*MetricReq, error)
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	// Delete removes stored metric.
+	Delete(context.Context, *GetMetricRequest) (*DeleteMetricResponse, error)
+	// Watch streams updates of selected metrics as soon as they are recorded.
+	// Updates could be dropped, if the client doesn't keep up with the stream.
+	Watch(*WatchRequest, Metrics_WatchServer) error
+	// Query evaluates expression over stored metrics, e.g. "max(HeapInuse[10m])".
+	Query(context.Context, *QueryRequest) (*QueryResponse, error)
 	mustEmbedUnimplementedMetricsServer()
 }
 
@@ -78,9 +197,24 @@ func (UnimplementedMetricsServer) Update(context.Context, *MetricReq) (*MetricRe
 func (UnimplementedMetricsServer) BatchUpdate(context.Context, *BatchUpdateRequest) (*BatchUpdateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdate not implemented")
 }
+func (UnimplementedMetricsServer) StreamUpdate(Metrics_StreamUpdateServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamUpdate not implemented")
+}
 func (UnimplementedMetricsServer) Get(context.Context, *GetMetricRequest) (*MetricReq, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
 }
+func (UnimplementedMetricsServer) List(context.Context, *ListRequest) (*ListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedMetricsServer) Delete(context.Context, *GetMetricRequest) (*DeleteMetricResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedMetricsServer) Watch(*WatchRequest, Metrics_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedMetricsServer) Query(context.Context, *QueryRequest) (*QueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
 func (UnimplementedMetricsServer) mustEmbedUnimplementedMetricsServer() {}
 
 // UnsafeMetricsServer may be embedded to opt out of forward compatibility for this service.
@@ -130,6 +264,32 @@ func _Metrics_BatchUpdate_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Metrics_StreamUpdate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MetricsServer).StreamUpdate(&metricsStreamUpdateServer{stream})
+}
+
+type Metrics_StreamUpdateServer interface {
+	SendAndClose(*StreamSummary) error
+	Recv() (*MetricReq, error)
+	grpc.ServerStream
+}
+
+type metricsStreamUpdateServer struct {
+	grpc.ServerStream
+}
+
+func (x *metricsStreamUpdateServer) SendAndClose(m *StreamSummary) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *metricsStreamUpdateServer) Recv() (*MetricReq, error) {
+	m := new(MetricReq)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _Metrics_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetMetricRequest)
 	if err := dec(in); err != nil {
@@ -148,6 +308,81 @@ func _Metrics_Get_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Metrics_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/metrics.collector.v1.Metrics/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Metrics_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMetricRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/metrics.collector.v1.Metrics/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServer).Delete(ctx, req.(*GetMetricRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Metrics_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MetricsServer).Watch(m, &metricsWatchServer{stream})
+}
+
+type Metrics_WatchServer interface {
+	Send(*MetricReq) error
+	grpc.ServerStream
+}
+
+type metricsWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *metricsWatchServer) Send(m *MetricReq) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Metrics_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/metrics.collector.v1.Metrics/Query",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Metrics_ServiceDesc is the grpc.ServiceDesc for Metrics service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -167,7 +402,30 @@ var Metrics_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Get",
 			Handler:    _Metrics_Get_Handler,
 		},
+		{
+			MethodName: "List",
+			Handler:    _Metrics_List_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Metrics_Delete_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _Metrics_Query_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamUpdate",
+			Handler:       _Metrics_StreamUpdate_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Metrics_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "metrics.proto",
 }
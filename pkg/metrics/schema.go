@@ -1,6 +1,8 @@
 // Package metrics provides client REST API for metrics collector (server).
 package metrics
 
+import "time"
+
 // MetricReq represents info regarding particular metric name, type and value.
 // Used in REST API requests/responses to/from metrics collector.
 type MetricReq struct {
@@ -21,6 +23,148 @@ type MetricReq struct {
 	Hash string `json:"hash,omitempty"`
 }
 
+// ListResponse represents single page of stored metrics.
+// Used in REST API responses from metrics collector.
+type ListResponse struct {
+	Data []*MetricReq `json:"data"`
+
+	// Cursor pointing to the next page, empty if there are no more metrics.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// QuerySample represents current value of single metric.
+type QuerySample struct {
+	ID    string  `json:"id"`
+	MType string  `json:"type"`
+	Value float64 `json:"value"`
+}
+
+// QueryPoint represents value of a metric recorded at the moment.
+type QueryPoint struct {
+	// Unix time in milliseconds.
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// QuerySeries represents history of values of single metric.
+type QuerySeries struct {
+	ID     string       `json:"id"`
+	MType  string       `json:"type"`
+	Points []QueryPoint `json:"points"`
+}
+
+// QueryResponse represents result of query evaluation.
+// Used in REST API responses from metrics collector.
+type QueryResponse struct {
+	// Type of the result: scalar, vector or matrix.
+	Type string `json:"type"`
+
+	// Set if type of the result is scalar.
+	Scalar *float64 `json:"scalar,omitempty"`
+
+	// Set if type of the result is vector.
+	Vector []QuerySample `json:"vector,omitempty"`
+
+	// Set if type of the result is matrix.
+	Matrix []QuerySeries `json:"matrix,omitempty"`
+}
+
+// Alert represents state of alert rule for single metric.
+// Used in REST API responses from metrics collector and in webhook notifications.
+type Alert struct {
+	// Name of the alert rule.
+	Name string `json:"name"`
+
+	// Name of the metric, omitted if condition of the rule evaluates to single number.
+	Metric string `json:"metric,omitempty"`
+
+	Severity string `json:"severity"`
+
+	// Condition of the rule, e.g. "CPUutilization* > 90".
+	Condition string `json:"condition"`
+
+	// One of pending, firing or resolved.
+	State string `json:"state"`
+
+	// Last value of the condition's expression.
+	Value float64 `json:"value"`
+
+	// Moment since which the condition holds.
+	ActiveSince time.Time `json:"active_since"`
+
+	// Moment when the alert started firing, omitted for pending alerts.
+	FiredAt *time.Time `json:"fired_at,omitempty"`
+
+	// Moment when the alert was resolved, omitted for active alerts.
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+
+	// Additional labels defined by the rule, e.g. {"host": "web1"}.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// IDs of silences muting the alert.
+	SilencedBy []string `json:"silenced_by,omitempty"`
+
+	// Names of firing alerts inhibiting the alert.
+	InhibitedBy []string `json:"inhibited_by,omitempty"`
+}
+
+// AlertsResponse represents list of pending and firing alerts.
+// Used in REST API responses from metrics collector.
+type AlertsResponse struct {
+	Data []Alert `json:"data"`
+}
+
+// Silence mutes notifications about alerts matching all matchers during the time window.
+// Used in REST API requests and responses of metrics collector.
+type Silence struct {
+	// Generated by the server, ignored in requests.
+	ID string `json:"id,omitempty"`
+
+	// Matchers in the <label>=<glob> form, e.g. "alertname=HighCpu*".
+	// Supported labels are alertname, metric, severity and labels defined by alert rules.
+	Matchers []string `json:"matchers"`
+
+	// Start of the silence, if omitted in request the silence starts immediately.
+	StartsAt time.Time `json:"starts_at"`
+
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+
+	// One of pending, active or expired, ignored in requests.
+	State string `json:"state,omitempty"`
+}
+
+// SilencesResponse represents list of silences.
+// Used in REST API responses from metrics collector.
+type SilencesResponse struct {
+	Data []Silence `json:"data"`
+}
+
+// SeriesCreator represents client which created series.
+type SeriesCreator struct {
+	// Name of the client, e.g. "identity:agent" for authorized clients or "ip:10.0.0.1" for others.
+	Client string `json:"client"`
+
+	// Count of series created by the client since start of the server.
+	Series int `json:"series"`
+}
+
+// CardinalityResponse represents count of stored series and clients created most of them.
+// Used in REST API responses from metrics collector.
+type CardinalityResponse struct {
+	// Count of series of all tenants.
+	Series int `json:"series"`
+
+	// Limits of count of series, zero value means no limit.
+	TenantLimit int `json:"tenant_limit"`
+	GlobalLimit int `json:"global_limit"`
+	ClientLimit int `json:"client_limit"`
+
+	// Clients created most series, ordered by count of series.
+	TopCreators []SeriesCreator `json:"top_creators"`
+}
+
 // NewUpdateCounterReq creates new MetricReq structure to be used for
 // updating counter metric.
 func NewUpdateCounterReq(name string, value Counter) MetricReq {